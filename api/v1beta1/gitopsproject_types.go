@@ -26,12 +26,18 @@ type GitOpsProjectSpec struct {
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName"`
 
+	// Impersonation defines the identity used to reconcile the project.
+	// Its service account name takes precedence over serviceAccountName.
+	// +optional
+	Impersonation *GitOpsProjectImpersonation `json:"impersonation,omitempty"`
+
 	//+kubebuilder:validation:MinLength=1
 	// The url to the gitops repository.
 	URL string `json:"url"`
 
 	//+kubebuilder:validation:MinLength=1
 	// The reference to the gitops repository containing navecd configuration.
+	// It is either a tag or a semver constraint like ">=1.0, <2.0", which resolves to the highest matching tag.
 	Ref string `json:"ref"`
 
 	//+kubebuilder:validation:MinLength=1
@@ -47,23 +53,152 @@ type GitOpsProjectSpec struct {
 	// This defines how often navecd will try to fetch changes from the gitops repository.
 	PullIntervalSeconds int `json:"pullIntervalSeconds"`
 
+	// This flag tells the controller to create namespaces targeted by components,
+	// which are not declared in the project. Created namespaces are garbage collected,
+	// once no component targets them anymore. Defaults to false.
+	// +optional
+	CreateNamespaces bool `json:"createNamespaces,omitempty"`
+
+	// This flag tells the controller to apply ResourceQuotas, LimitRanges and NetworkPolicies
+	// before all other components of their namespace, even without declared dependencies. Defaults to false.
+	// +optional
+	OrderPolicies bool `json:"orderPolicies,omitempty"`
+
+	// This flag tells the controller to refuse loading components with fields,
+	// which are not defined by the schema, like misspelled ones. Defaults to false.
+	// +optional
+	StrictComponents bool `json:"strictComponents,omitempty"`
+
+	// This flag tells the controller to reconcile the project, whenever a CRD of an API group
+	// used or required by its components is created or changed. Defaults to false.
+	// +optional
+	ReconcileOnAPIChange bool `json:"reconcileOnAPIChange,omitempty"`
+
+	// This flag tells the controller to evaluate workloads against the PodSecurity level enforced by their namespace
+	// and to refuse applying violating workloads with a description of every violation. Defaults to false.
+	// +optional
+	CheckPodSecurity bool `json:"checkPodSecurity,omitempty"`
+
+	// The names of pull secrets, which are added to the imagePullSecrets of all pod-spec-bearing manifests.
+	// The secrets have to exist in the namespaces of the workloads. Declared pull secrets are kept.
+	// +optional
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+
+	// The registry, which replaces the registry host of all container images in pod-spec-bearing manifests.
+	// It may contain a path, e.g. mirror.example.com/dockerhub. Images without a registry host are prefixed.
+	// The inventory keeps the declared images.
+	// +optional
+	ImageRegistryPrefix string `json:"imageRegistryPrefix,omitempty"`
+
+	// This flag tells the controller to set the GitOpsProject as owner of all applied manifests, so that Kubernetes
+	// deletes them together with the GitOpsProject. Cluster-scoped objects and objects of other namespaces cannot reference it
+	// and are labeled with navecd.io/owner-name and navecd.io/owner-namespace instead, which the controller deletes
+	// before it removes its finalizer from the deleted GitOpsProject. Defaults to false.
+	// +optional
+	OwnerReferences bool `json:"ownerReferences,omitempty"`
+
+	// This flag tells the controller to annotate applied manifests with navecd.io/source,
+	// pointing to the file and line declaring their component. Defaults to false.
+	// +optional
+	SourceAnnotations bool `json:"sourceAnnotations,omitempty"`
+
 	// This flag tells the controller to suspend subsequent executions, it does
 	// not apply to already started executions.  Defaults to false.
 	// +optional
 	Suspend *bool `json:"suspend,omitempty"`
+
+	// This flag tells the controller to refuse reconciling an artifact, whose tag points to
+	// a different digest than at the last reconciliation. Defaults to false.
+	// +optional
+	RequireImmutableTags bool `json:"requireImmutableTags,omitempty"`
+}
+
+// GitOpsProjectImpersonation defines the identity used to reconcile a GitOpsProject.
+type GitOpsProjectImpersonation struct {
+	// The service account in the namespace of the GitOpsProject to impersonate.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// The groups to impersonate. Requires a service account name.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// Extra attributes to impersonate, like scopes. Requires a service account name.
+	// +optional
+	Extra map[string][]string `json:"extra,omitempty"`
 }
 
 type GitOpsProjectRevision struct {
 	Digest        string      `json:"digest,omitempty"`
 	ReconcileTime metav1.Time `json:"reconcileTime,omitempty"`
+	// Tag the reference resolved to. It differs from the reference only for semver constraints.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// Registry is the repository the artifact was loaded from, after mirrors were applied.
+	// +optional
+	Registry string `json:"registry,omitempty"`
+	// SourceRevision is the source control revision the artifact was built from, e.g. a commit sha.
+	// +optional
+	SourceRevision string `json:"sourceRevision,omitempty"`
+	// BuildTime is the time the artifact was built in RFC 3339 format.
+	// +optional
+	BuildTime string `json:"buildTime,omitempty"`
+}
+
+// GitOpsProjectLastReconcile summarizes the changes of the last reconciliation.
+type GitOpsProjectLastReconcile struct {
+	// ChangedObjects is the number of components, which were changed by the last reconciliation.
+	// Components are compared to the state applied by the previous reconciliation.
+	// +optional
+	ChangedObjects int `json:"changedObjects"`
 }
 
+// GitOpsProjectHealth summarizes the state of all components of a GitOpsProject.
+// +kubebuilder:validation:Enum=Healthy;Degraded;Progressing
+type GitOpsProjectHealth string
+
+const (
+	// HealthHealthy means that all components were applied and are ready.
+	HealthHealthy GitOpsProjectHealth = "Healthy"
+
+	// HealthDegraded means that the reconciliation or at least one component failed.
+	HealthDegraded GitOpsProjectHealth = "Degraded"
+
+	// HealthProgressing means that all components were applied, but some are not ready yet.
+	HealthProgressing GitOpsProjectHealth = "Progressing"
+)
+
 // GitOpsProjectStatus defines the observed state of GitOpsProject
 type GitOpsProjectStatus struct {
 	// +optional
 	Revision GitOpsProjectRevision `json:"revision,omitempty"`
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// SuspendedComponents lists the ids of components, which are not applied because of the suspend attribute.
+	// +optional
+	SuspendedComponents []string `json:"suspendedComponents,omitempty"`
+	// Health is computed from the reconcile errors and the readiness of all components after each reconciliation.
+	// +optional
+	Health GitOpsProjectHealth `json:"health,omitempty"`
+	// LastReconcile summarizes the changes of the last reconciliation.
+	// +optional
+	LastReconcile GitOpsProjectLastReconcile `json:"lastReconcile,omitempty"`
+	// Releases lists the state of all Helm releases reconciled by the last reconciliation.
+	// +optional
+	Releases []GitOpsProjectReleaseStatus `json:"releases,omitempty"`
+}
+
+// GitOpsProjectReleaseStatus is the state of a reconciled Helm release.
+type GitOpsProjectReleaseStatus struct {
+	// ID of the Helm release component.
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Revision of the release. It is incremented by every upgrade.
+	Revision int `json:"revision"`
+	// Notes are the rendered NOTES.txt of the chart, truncated to 1024 bytes.
+	// +optional
+	Notes string `json:"notes,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -50,6 +50,42 @@ func (in *GitOpsProject) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsProjectImpersonation) DeepCopyInto(out *GitOpsProjectImpersonation) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Extra != nil {
+		in, out := &in.Extra, &out.Extra
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectImpersonation.
+func (in *GitOpsProjectImpersonation) DeepCopy() *GitOpsProjectImpersonation {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsProjectImpersonation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsProjectList) DeepCopyInto(out *GitOpsProjectList) {
 	*out = *in
@@ -85,6 +121,16 @@ func (in *GitOpsProjectList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsProjectSpec) DeepCopyInto(out *GitOpsProjectSpec) {
 	*out = *in
+	if in.Impersonation != nil {
+		in, out := &in.Impersonation, &out.Impersonation
+		*out = new(GitOpsProjectImpersonation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Suspend != nil {
 		in, out := &in.Suspend, &out.Suspend
 		*out = new(bool)
@@ -112,6 +158,16 @@ func (in *GitOpsProjectStatus) DeepCopyInto(out *GitOpsProjectStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SuspendedComponents != nil {
+		in, out := &in.SuspendedComponents, &out.SuspendedComponents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Releases != nil {
+		in, out := &in.Releases, &out.Releases
+		*out = make([]GitOpsProjectReleaseStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsProjectStatus.
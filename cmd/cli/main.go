@@ -16,16 +16,27 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/garbage"
+	"github.com/kharf/navecd/pkg/inventory"
 	"github.com/kharf/navecd/pkg/kube"
 	"github.com/kharf/navecd/pkg/oci"
+	"github.com/kharf/navecd/pkg/policy"
 	"github.com/kharf/navecd/pkg/project"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	ctrlZap "sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 var Version string
@@ -47,6 +58,12 @@ type RootCommandBuilder struct {
 	versionCommandBuilder      VersionCommandBuilder
 	installCommandBuilder      InstallCommandBuilder
 	pushArtifactCommandBuilder PushArtifactCommandBuilder
+	inventoryCommandBuilder    InventoryCommandBuilder
+	validatePolicyBuilder      ValidatePolicyCommandBuilder
+	applyCommandBuilder        ApplyCommandBuilder
+	bundleCommandBuilder       BundleCommandBuilder
+	migrateInventoryBuilder    MigrateInventoryCommandBuilder
+	planCommandBuilder         PlanCommandBuilder
 }
 
 func (builder RootCommandBuilder) Build() *cobra.Command {
@@ -54,14 +71,36 @@ func (builder RootCommandBuilder) Build() *cobra.Command {
 		Use:   "navecd",
 		Short: "A GitOps Declarative Continuous Delivery toolkit",
 	}
+	rootCmd.PersistentFlags().Int(logLevelFlag, 0, "The verbosity level of the log written to stderr. 1 enables debug output, like registry requests")
 	rootCmd.AddCommand(builder.initCommandBuilder.Build())
 	rootCmd.AddCommand(builder.verifyCommandBuilder.Build())
 	rootCmd.AddCommand(builder.versionCommandBuilder.Build())
 	rootCmd.AddCommand(builder.installCommandBuilder.Build())
 	rootCmd.AddCommand(builder.pushArtifactCommandBuilder.Build())
+	rootCmd.AddCommand(builder.inventoryCommandBuilder.Build())
+	rootCmd.AddCommand(builder.validatePolicyBuilder.Build())
+	rootCmd.AddCommand(builder.applyCommandBuilder.Build())
+	rootCmd.AddCommand(builder.bundleCommandBuilder.Build())
+	rootCmd.AddCommand(builder.migrateInventoryBuilder.Build())
+	rootCmd.AddCommand(builder.planCommandBuilder.Build())
 	return &rootCmd
 }
 
+const logLevelFlag = "log-level"
+
+// newLogger returns a logger writing to stderr of the command with the verbosity of the log-level flag.
+func newLogger(cmd *cobra.Command) (logr.Logger, error) {
+	logLevel, err := cmd.Flags().GetInt(logLevelFlag)
+	if err != nil {
+		return logr.Logger{}, err
+	}
+
+	return ctrlZap.New(
+		ctrlZap.WriteTo(cmd.ErrOrStderr()),
+		ctrlZap.Level(zapcore.Level(-logLevel)),
+	), nil
+}
+
 type InitCommandBuilder struct{}
 
 func (builder InitCommandBuilder) Build() *cobra.Command {
@@ -100,6 +139,9 @@ type VerifyCommandBuilder struct{}
 
 func (builder VerifyCommandBuilder) Build() *cobra.Command {
 	var dir string
+	var checkArtifacts bool
+	var strictRefs bool
+	var strict bool
 	cmd := &cobra.Command{
 		Use:   "verify",
 		Short: "Validate Navecd Configuration in specified directory",
@@ -115,21 +157,345 @@ func (builder VerifyCommandBuilder) Build() *cobra.Command {
 				-1,
 			)
 
-			instance, err := projectManager.Load(context.Background(), cwd, dir)
+			instance, err := projectManager.Load(
+				context.Background(),
+				cwd,
+				dir,
+				project.WithStrictComponents(strict),
+			)
 			if err != nil {
 				return err
 			}
 
+			for _, warning := range instance.Warnings {
+				fmt.Fprintf(cobraCmd.ErrOrStderr(), "warning: %s\n", warning)
+			}
+
 			_, err = instance.Dag.TopologicalSort()
 			if err != nil {
 				return err
 			}
 
+			if checkArtifacts || strictRefs {
+				checker := project.ArtifactChecker{
+					OCIOptions: []oci.Option{oci.WithKeychain(authn.DefaultKeychain)},
+				}
+				if !checkArtifacts {
+					checker.Kinds = []project.ArtifactKind{project.ChartArtifact}
+				}
+
+				missing, err := checker.Check(context.Background(), instance)
+				if err != nil {
+					return err
+				}
+
+				for _, artifact := range missing {
+					fmt.Fprintf(
+						cobraCmd.ErrOrStderr(),
+						"missing %s %s referenced by %s\n",
+						artifact.Kind,
+						artifact.Reference,
+						artifact.ComponentID,
+					)
+				}
+
+				if len(missing) != 0 {
+					return fmt.Errorf("%w: %d not found", ErrMissingArtifacts, len(missing))
+				}
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().
+		StringVar(&dir, "dir", ".", "Dir of the GitOps Repository containing project configuration")
+	cmd.Flags().
+		BoolVar(&checkArtifacts, "check-artifacts", false, "Contact registries to confirm that all referenced image tags and chart versions exist")
+	cmd.Flags().
+		BoolVar(&strictRefs, "strict-refs", false, "Contact chart repositories to confirm that the chart and version of every HelmRelease exist")
+	cmd.Flags().
+		BoolVar(&strict, "strict", true, "Fail on component fields, which are not defined by the schema, like misspelled ones")
+	return cmd
+}
+
+type ValidatePolicyCommandBuilder struct{}
+
+func (builder ValidatePolicyCommandBuilder) Build() *cobra.Command {
+	var dir string
+	var policiesDir string
+	cmd := &cobra.Command{
+		Use:   "validate-policy",
+		Short: "Validate rendered manifests of the Navecd Configuration against Kyverno policies",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			policies, err := policy.Load(policiesDir)
+			if err != nil {
+				return err
+			}
+
+			projectManager := project.NewManager(
+				component.NewBuilder(),
+				-1,
+			)
+
+			instance, err := projectManager.Load(context.Background(), cwd, dir)
+			if err != nil {
+				return err
+			}
+
+			instances, err := instance.Dag.TopologicalSort()
+			if err != nil {
+				return err
+			}
+
+			violations := policy.Evaluate(policies, instances)
+			for _, violation := range violations {
+				fmt.Fprintln(cobraCmd.ErrOrStderr(), violation.String())
+			}
+
+			if len(violations) != 0 {
+				return fmt.Errorf("%d policy violations found", len(violations))
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().
+		StringVar(&dir, "dir", ".", "Dir of the GitOps Repository containing project configuration")
+	cmd.Flags().
+		StringVar(&policiesDir, "policies", "", "Dir containing Kyverno ClusterPolicies and Policies as YAML files")
+	_ = cmd.MarkFlagRequired("policies")
+	return cmd
+}
+
+type ApplyCommandBuilder struct{}
+
+func (builder ApplyCommandBuilder) Build() *cobra.Command {
+	var dir string
+	var inventoryDir string
+	var prune bool
+	var deletePropagation string
+	var dryRun bool
+	var imagePullSecrets []string
+	var imageRegistryPrefix string
+	var sourceAnnotations bool
+	var componentID string
+	var withDependencies bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Reconcile the local Navecd Project once against the Kubernetes Cluster of the current context",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			propagation, err := garbage.ParseDeletePropagation(deletePropagation)
+			if err != nil {
+				return err
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+
+			log, err := newLogger(cobraCmd)
+			if err != nil {
+				return err
+			}
+			action := project.NewApplyAction(log, kubeConfig, cwd)
+			result, err := action.Apply(ctx, project.ApplyOptions{
+				Dir:                 dir,
+				InventoryDir:        inventoryDir,
+				Prune:               prune,
+				DeletePropagation:   propagation,
+				DryRun:              dryRun,
+				ImagePullSecrets:    imagePullSecrets,
+				ImageRegistryPrefix: imageRegistryPrefix,
+				SourceAnnotations:   sourceAnnotations,
+				Component:           componentID,
+				WithDependencies:    withDependencies,
+				FieldManager:        "navecd-cli",
+			})
+			if err != nil {
+				return timeoutError(ctx, timeout, err)
+			}
+
+			suffix := ""
+			if dryRun {
+				suffix = " (dry run)"
+			}
+
+			for _, item := range result.Pruned {
+				fmt.Fprintf(cobraCmd.OutOrStdout(), "%s pruned%s\n", item.GetID(), suffix)
+			}
+
+			failures := 0
+			for _, outcome := range result.Outcomes {
+				if outcome.Err != nil {
+					failures++
+					fmt.Fprintf(cobraCmd.OutOrStdout(), "%s %s%s: %s\n", outcome.ID, outcome.Outcome, suffix, outcome.Err)
+					continue
+				}
+				fmt.Fprintf(cobraCmd.OutOrStdout(), "%s %s%s\n", outcome.ID, outcome.Outcome, suffix)
+			}
+
+			if failures != 0 {
+				return fmt.Errorf("%w: %d failed", ErrApplyFailed, failures)
+			}
+
 			return nil
 		},
 	}
 	cmd.Flags().
 		StringVar(&dir, "dir", ".", "Dir of the GitOps Repository containing project configuration")
+	cmd.Flags().
+		StringVar(&inventoryDir, "inventory-dir", ".navecd/inventory", "Dir of the inventory, which tracks applied components for pruning")
+	cmd.Flags().
+		BoolVar(&prune, "prune", false, "Delete previously applied components, which are no longer declared")
+	cmd.Flags().
+		StringVar(&deletePropagation, "delete-propagation", "Foreground", "How dependents of pruned objects are deleted: Foreground, Background or Orphan")
+	cmd.Flags().
+		BoolVar(&dryRun, "dry-run", false, "Validate manifests against the cluster without persisting them. Helm releases are skipped")
+	cmd.Flags().
+		StringArrayVar(&imagePullSecrets, "image-pull-secret", nil, "Name of a pull secret added to all workloads. Can be repeated")
+	cmd.Flags().
+		StringVar(&imageRegistryPrefix, "image-registry-prefix", "", "Registry replacing the registry host of all workload images, e.g. mirror.example.com")
+	cmd.Flags().
+		BoolVar(&sourceAnnotations, "source-annotations", false, "Annotate applied manifests with the file and line declaring their component")
+	cmd.Flags().
+		StringVar(&componentID, "component", "", "Id of the only component to apply. All other components are left untouched")
+	cmd.Flags().
+		BoolVar(&withDependencies, "with-deps", false, "Apply the transitive dependencies of the component as well")
+	cmd.Flags().DurationVar(&timeout, "timeout", DefaultCommandTimeout, "Maximum duration of the apply")
+	return cmd
+}
+
+type PlanCommandBuilder struct{}
+
+func (builder PlanCommandBuilder) Build() *cobra.Command {
+	var dir string
+	var url string
+	var ref string
+	var insecureRegistry bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Computes the changes of the local Navecd Project against the Kubernetes Cluster of the current context and optionally pushes them as OCI artifact",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			kubeConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+
+			log, err := newLogger(cobraCmd)
+			if err != nil {
+				return err
+			}
+
+			plan, err := project.NewPlanAction(log, kubeConfig, cwd).Plan(ctx, project.PlanOptions{
+				Dir:          dir,
+				FieldManager: "navecd-cli",
+			})
+			if err != nil {
+				return timeoutError(ctx, timeout, err)
+			}
+
+			planJSON, err := json.MarshalIndent(plan, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			if url == "" {
+				fmt.Fprintln(cobraCmd.OutOrStdout(), string(planJSON))
+				return nil
+			}
+
+			ociClient, err := oci.NewRepositoryClient(url, insecureRegistry)
+			if err != nil {
+				return err
+			}
+
+			digest, err := oci.NewProjectClient(ociClient).PushPlan(
+				ctx,
+				ref,
+				planJSON,
+				oci.WithRepositoryOption(oci.WithInsecure(insecureRegistry)),
+				oci.WithRepositoryOption(oci.WithLogger(log)),
+			)
+			if err != nil {
+				return timeoutError(ctx, timeout, err)
+			}
+			fmt.Fprintf(cobraCmd.OutOrStdout(), "pushed plan of %d objects to %s:%s with digest %s\n", len(plan.Objects), url, ref, digest)
+			return nil
+		},
+	}
+	cmd.Flags().
+		StringVar(&dir, "dir", ".", "Dir of the GitOps Repository containing project configuration")
+	cmd.Flags().StringVarP(&url, "url", "u", "", "Url to the OCI Repository the plan is pushed to. The plan is printed, if empty")
+	cmd.Flags().StringVarP(&ref, "ref", "r", "plan", "Ref of the pushed plan")
+	cmd.Flags().BoolVar(&insecureRegistry, "insecure", false, "Insecure allows communicating with OCI registries without TLS")
+	cmd.Flags().DurationVar(&timeout, "timeout", DefaultCommandTimeout, "Maximum duration of the plan")
+	cmd.AddCommand(builder.buildFetch())
+	return cmd
+}
+
+func (builder PlanCommandBuilder) buildFetch() *cobra.Command {
+	var url string
+	var ref string
+	var insecureRegistry bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "fetch",
+		Short: "Prints a plan pushed to the specified OCI Repository",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			ociClient, err := oci.NewRepositoryClient(url, insecureRegistry)
+			if err != nil {
+				return err
+			}
+
+			planJSON, err := oci.NewProjectClient(ociClient).LoadPlan(
+				ctx,
+				ref,
+				oci.WithRepositoryOption(oci.WithInsecure(insecureRegistry)),
+			)
+			if err != nil {
+				return timeoutError(ctx, timeout, err)
+			}
+			fmt.Fprintln(cobraCmd.OutOrStdout(), string(planJSON))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&url, "url", "u", "", "Url to the OCI Repository holding the plan")
+	cmd.Flags().StringVarP(&ref, "ref", "r", "plan", "Ref of the plan")
+	cmd.Flags().BoolVar(&insecureRegistry, "insecure", false, "Insecure allows communicating with OCI registries without TLS")
+	cmd.Flags().DurationVar(&timeout, "timeout", DefaultCommandTimeout, "Maximum duration of the fetch")
+
+	_ = cmd.MarkFlagRequired("url")
 	return cmd
 }
 
@@ -151,7 +517,6 @@ func (builder VersionCommandBuilder) Build() *cobra.Command {
 type InstallCommandBuilder struct{}
 
 func (builder InstallCommandBuilder) Build() *cobra.Command {
-	ctx := context.Background()
 	var ref string
 	var url string
 	var dir string
@@ -161,11 +526,18 @@ func (builder InstallCommandBuilder) Build() *cobra.Command {
 	var wip string
 	var secretRef string
 	var insecureRegistry bool
+	var bundle string
+	var namespace string
+	var registryAuthFile string
+	var timeout time.Duration
 	cmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install Navecd onto a Kubernetes Cluster",
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
 			kubeConfig, err := config.GetConfig()
 			if err != nil {
 				return err
@@ -182,6 +554,11 @@ func (builder InstallCommandBuilder) Build() *cobra.Command {
 			}
 			httpClient := http.DefaultClient
 
+			log, err := newLogger(cobraCmd)
+			if err != nil {
+				return err
+			}
+
 			action := project.NewInstallAction(client, httpClient, wd)
 			if _, err := action.Install(ctx,
 				project.InstallOptions{
@@ -194,9 +571,13 @@ func (builder InstallCommandBuilder) Build() *cobra.Command {
 					WIP:              wip,
 					SecretRef:        secretRef,
 					InsecureRegistry: insecureRegistry,
+					Namespace:        namespace,
+					Bundle:           bundle,
+					RegistryAuthFile: registryAuthFile,
+					Log:              log,
 				},
 			); err != nil {
-				return err
+				return timeoutError(ctx, timeout, err)
 			}
 			return nil
 		},
@@ -210,10 +591,86 @@ func (builder InstallCommandBuilder) Build() *cobra.Command {
 	cmd.Flags().StringVar(&wip, "wip", "", "Workload Identity Provider used for OCI registry access. Supported values are 'aws', 'azure' and 'gcp'")
 	cmd.Flags().StringVar(&secretRef, "secret", "", "Reference to the Kubernetes secret containing the OCI registry credentials in the Navecd controller namespace")
 	cmd.Flags().BoolVar(&insecureRegistry, "insecure", false, "Insecure allows communicating with OCI registries without TLS")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace the Navecd controller and the GitOps Project are installed into")
+	cmd.Flags().StringVar(&bundle, "bundle", "", "Path to a bundle created by 'navecd bundle' to install instead of the project in the current directory")
+	cmd.Flags().StringVar(&registryAuthFile, "registry-auth-file", "", "JSON file mapping registry hosts to their credentials. It is installed as secret for the controller and used to push the project")
+	cmd.Flags().DurationVar(&timeout, "timeout", DefaultCommandTimeout, "Maximum duration of the installation")
+
+	// name and url are part of the bundle.
+	cmd.MarkFlagsOneRequired("name", "bundle")
+	cmd.MarkFlagsOneRequired("url", "bundle")
+	cmd.MarkFlagsMutuallyExclusive("name", "bundle")
+	cmd.MarkFlagsMutuallyExclusive("url", "bundle")
+	// the namespace is part of the bundle.
+	cmd.MarkFlagsMutuallyExclusive("namespace", "bundle")
+	_ = cmd.MarkFlagRequired("ref")
+	return cmd
+}
+
+type BundleCommandBuilder struct{}
+
+func (builder BundleCommandBuilder) Build() *cobra.Command {
+	var output string
+	var ref string
+	var url string
+	var dir string
+	var name string
+	var interval int
+	var shard string
+	var wip string
+	var secretRef string
+	var namespace string
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Packs the Navecd controller manifests and the Project OCI artifact into a single archive for offline installations",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			wd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			log, err := newLogger(cobraCmd)
+			if err != nil {
+				return err
+			}
+
+			action := project.NewBundleAction(wd)
+			digest, err := action.Bundle(output,
+				project.InstallOptions{
+					Url:       url,
+					Ref:       ref,
+					Dir:       dir,
+					Name:      name,
+					Interval:  interval,
+					Shard:     shard,
+					WIP:       wip,
+					SecretRef: secretRef,
+					Namespace: namespace,
+					Log:       log,
+				},
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("bundled %s:%s with digest %s into %s\n", url, ref, digest, output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "navecd-bundle.tgz", "Path of the written bundle")
+	cmd.Flags().StringVarP(&url, "url", "u", "", "Url to the OCI GitOps Repository the bundle is installed into")
+	cmd.Flags().StringVarP(&ref, "ref", "r", "main", "Ref to the OCI GitOps Repository")
+	cmd.Flags().StringVar(&dir, "dir", ".", "Dir of the GitOps Project Configuration inside the OCI GitOps Repository")
+	cmd.Flags().StringVar(&name, "name", "", "Name of the GitOps Project")
+	cmd.Flags().IntVarP(&interval, "interval", "i", 30, "Definition of how often Navecd will reconcile its cluster state. Value is defined in seconds")
+	cmd.Flags().StringVar(&shard, "shard", "primary", "Navecd Instance/Shard responsible for reconciliation")
+	cmd.Flags().StringVar(&wip, "wip", "", "Workload Identity Provider used for OCI registry access. Supported values are 'aws', 'azure' and 'gcp'")
+	cmd.Flags().StringVar(&secretRef, "secret", "", "Reference to the Kubernetes secret containing the OCI registry credentials in the Navecd controller namespace")
+
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", project.ControllerNamespace, "Namespace the Navecd controller and the GitOps Project are installed into")
 
 	_ = cmd.MarkFlagRequired("name")
 	_ = cmd.MarkFlagRequired("url")
-	_ = cmd.MarkFlagRequired("ref")
 	return cmd
 }
 
@@ -223,11 +680,18 @@ func (builder PushArtifactCommandBuilder) Build() *cobra.Command {
 	var ref string
 	var url string
 	var insecureRegistry bool
+	var sbom bool
+	var provenance bool
+	var revision string
+	var timeout time.Duration
 	cmd := &cobra.Command{
 		Use:   "push",
 		Short: "Builds and pushes a Navecd Project OCI artifact to the specified OCI Repository",
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
 			cwd, err := os.Getwd()
 			if err != nil {
 				return err
@@ -238,15 +702,27 @@ func (builder PushArtifactCommandBuilder) Build() *cobra.Command {
 			}
 			projectClient := oci.NewProjectClient(ociClient)
 
+			log, err := newLogger(cobraCmd)
+			if err != nil {
+				return err
+			}
+
 			digest, err := projectClient.PushImageFromPath(
+				ctx,
 				ref,
 				cwd,
 				oci.WithRepositoryOption(
 					oci.WithInsecure(insecureRegistry),
 				),
+				oci.WithRepositoryOption(
+					oci.WithLogger(log),
+				),
+				oci.WithSBOM(sbom),
+				oci.WithProvenance(provenance),
+				oci.WithRevision(revision),
 			)
 			if err != nil {
-				return err
+				return timeoutError(ctx, timeout, err)
 			}
 			fmt.Printf("pushed %s:%s with digest %s\n", url, ref, digest)
 			return nil
@@ -255,8 +731,161 @@ func (builder PushArtifactCommandBuilder) Build() *cobra.Command {
 	cmd.Flags().StringVarP(&url, "url", "u", "", "Url to the OCI GitOps Repository")
 	cmd.Flags().StringVarP(&ref, "ref", "r", "main", "Ref to the OCI GitOps Repository")
 	cmd.Flags().BoolVar(&insecureRegistry, "insecure", false, "Insecure allows communicating with OCI registries without TLS")
+	cmd.Flags().BoolVar(&sbom, "sbom", false, "Attach an SPDX SBOM of the project files to the pushed artifact using the OCI referrers API")
+	cmd.Flags().BoolVar(&provenance, "provenance", false, "Attach a SLSA provenance attestation to the pushed artifact using the OCI referrers API")
+	cmd.Flags().StringVar(&revision, "revision", "", "Source control revision the project is built from, e.g. a commit sha. It is annotated on the pushed artifact")
+	cmd.Flags().DurationVar(&timeout, "timeout", DefaultCommandTimeout, "Maximum duration of the push")
 
 	_ = cmd.MarkFlagRequired("url")
 	_ = cmd.MarkFlagRequired("ref")
 	return cmd
 }
+
+// DefaultCommandTimeout is the default maximum duration of commands communicating with a registry or cluster.
+const DefaultCommandTimeout = 2 * time.Minute
+
+var (
+	ErrCommandTimeout   = errors.New("Command timed out")
+	ErrMissingArtifacts = errors.New("Referenced artifacts are missing")
+	ErrApplyFailed      = errors.New("Components failed to apply")
+)
+
+// timeoutError replaces err with a descriptive error, if it was caused by exceeding the command timeout.
+func timeoutError(ctx context.Context, timeout time.Duration, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %w", ErrCommandTimeout, timeout, err)
+	}
+	return err
+}
+
+type InventoryCommandBuilder struct{}
+
+func (builder InventoryCommandBuilder) Build() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "Export or import the inventory of a Navecd Project",
+	}
+	cmd.AddCommand(builder.buildExport())
+	cmd.AddCommand(builder.buildImport())
+	return cmd
+}
+
+func (builder InventoryCommandBuilder) buildExport() *cobra.Command {
+	var projectUID string
+	var shard string
+	var inventoryDir string
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Serializes all inventory items of a Navecd Project into a single JSON document",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			inventoryInstance := &inventory.Instance{
+				Path: filepath.Join(inventoryDir, shard, projectUID),
+			}
+
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			return inventoryInstance.Export(file)
+		},
+	}
+	cmd.Flags().StringVar(&projectUID, "project", "", "UID of the GitOps Project")
+	cmd.Flags().StringVar(&shard, "shard", "primary", "Instance of the Navecd Project managing the GitOps Project")
+	cmd.Flags().StringVar(&inventoryDir, "inventory-dir", "/inventory", "Dir which holds the inventory of all GitOps Projects")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File the JSON document is written to")
+
+	_ = cmd.MarkFlagRequired("project")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func (builder InventoryCommandBuilder) buildImport() *cobra.Command {
+	var projectUID string
+	var shard string
+	var inventoryDir string
+	var input string
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Restores inventory items of a Navecd Project from a JSON document created by export",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			inventoryInstance := &inventory.Instance{
+				Path: filepath.Join(inventoryDir, shard, projectUID),
+			}
+
+			file, err := os.Open(input)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			return inventoryInstance.Import(file)
+		},
+	}
+	cmd.Flags().StringVar(&projectUID, "project", "", "UID of the GitOps Project")
+	cmd.Flags().StringVar(&shard, "shard", "primary", "Instance of the Navecd Project managing the GitOps Project")
+	cmd.Flags().StringVar(&inventoryDir, "inventory-dir", "/inventory", "Dir which holds the inventory of all GitOps Projects")
+	cmd.Flags().StringVarP(&input, "input", "i", "", "File containing the JSON document created by export")
+
+	_ = cmd.MarkFlagRequired("project")
+	_ = cmd.MarkFlagRequired("input")
+	return cmd
+}
+
+type MigrateInventoryCommandBuilder struct{}
+
+func (builder MigrateInventoryCommandBuilder) Build() *cobra.Command {
+	var projectUID string
+	var shard string
+	var inventoryDir string
+	var dryRun bool
+	format := inventory.FormatJSON
+	cmd := &cobra.Command{
+		Use:   "migrate-inventory",
+		Short: "Rewrites the inventory of a Navecd Project into the current layout and keeps the previous one as backup",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			inventoryInstance := &inventory.Instance{
+				Path:              filepath.Join(inventoryDir, shard, projectUID),
+				HelmReleaseFormat: format,
+			}
+
+			result, err := inventoryInstance.MigrateLayout(filepath.Join(inventoryDir, projectUID), dryRun)
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				fmt.Printf("verified migration of %d items from %s to %s\n", result.Items, result.SourcePath, inventoryInstance.Path)
+				return nil
+			}
+			fmt.Printf(
+				"migrated %d items from %s to %s, backup is stored in %s\n",
+				result.Items,
+				result.SourcePath,
+				inventoryInstance.Path,
+				result.BackupPath,
+			)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&projectUID, "project", "", "UID of the GitOps Project")
+	cmd.Flags().StringVar(&shard, "shard", "primary", "Instance of the Navecd Project managing the GitOps Project")
+	cmd.Flags().StringVar(&inventoryDir, "inventory-dir", "/inventory", "Dir which holds the inventory of all GitOps Projects")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only verify the migration without replacing the inventory")
+	cmd.Flags().Func("format", "The format HelmRelease content is stored with, either json or gzip. Defaults to json", func(name string) error {
+		parsed, err := inventory.ParseFormat(name)
+		if err != nil {
+			return err
+		}
+		format = parsed
+		return nil
+	})
+
+	_ = cmd.MarkFlagRequired("project")
+	return cmd
+}
@@ -21,6 +21,9 @@ import (
 	"fmt"
 	_ "net/http/pprof"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "go.uber.org/automaxprocs"
 
@@ -33,6 +36,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/kharf/navecd/internal/controller"
+	"github.com/kharf/navecd/pkg/inventory"
+	"github.com/kharf/navecd/pkg/oci"
 )
 
 var (
@@ -49,6 +54,34 @@ func main() {
 	var inventoryPath string
 	var insecureSkipTLSverify bool
 	var plainHTTP bool
+	var shutdownTimeout time.Duration
+	var discoveryCacheTTL time.Duration
+	var credentialCacheTTL time.Duration
+	var cacheRetention time.Duration
+	var chartCacheMaxBytes int64
+	var chartCacheTTL time.Duration
+	var loadRetries int
+	var loadRetryBackoff time.Duration
+	var loadRetryInterval time.Duration
+	var waitRetryInterval time.Duration
+	var applyTimeout time.Duration
+	var applyMaxTimeout time.Duration
+	var maxArtifactBytes int64
+	var deletePropagation string
+	var concurrency int
+	var maxConcurrentReconciles int
+	var fieldManager string
+	var proxy string
+	var clusterName string
+	var notificationWebhook string
+	var provisionRBAC bool
+	var projectLeases bool
+	var registryAuthFile string
+	inventoryFormat := inventory.FormatJSON
+	registryMirrors := oci.Mirrors{}
+	clusterLabels := map[string]string{}
+	var allowedKinds []string
+	var deniedKinds []string
 	flag.StringVar(
 		&metricsAddr,
 		"metrics-bind-address",
@@ -98,6 +131,205 @@ func main() {
 		false,
 		"Force http for Helm registries.",
 	)
+	flag.DurationVar(
+		&shutdownTimeout,
+		"shutdown-timeout",
+		30*time.Second,
+		"The maximum duration to wait for in-flight reconciliations on shutdown.",
+	)
+	flag.DurationVar(
+		&discoveryCacheTTL,
+		"discovery-cache-ttl",
+		5*time.Minute,
+		"How long discovered APIs are shared across reconciliations. Changed CRDs invalidate them immediately. 0 disables the cache.",
+	)
+	flag.DurationVar(
+		&credentialCacheTTL,
+		"credential-cache-ttl",
+		10*time.Minute,
+		"How long credentials fetched through workload identity are shared across reconciliations. 0 disables the cache.",
+	)
+	flag.DurationVar(
+		&cacheRetention,
+		"cache-retention",
+		24*time.Hour,
+		"How long extracted project artifacts, which are not loaded anymore, are kept in the cache. 0 keeps them forever.",
+	)
+	flag.Int64Var(
+		&chartCacheMaxBytes,
+		"chart-cache-max-bytes",
+		0,
+		"The maximum size in bytes of cached helm charts. Least recently used charts are evicted first. 0 disables the limit.",
+	)
+	flag.DurationVar(
+		&chartCacheTTL,
+		"chart-cache-ttl",
+		0,
+		"How long cached helm charts, which are not used anymore, are kept in the cache. 0 keeps them forever.",
+	)
+	flag.IntVar(
+		&loadRetries,
+		"load-retries",
+		3,
+		"The number of additional attempts to download a project artifact on recoverable errors, before falling back to the previously downloaded artifact. 0 disables retries.",
+	)
+	flag.DurationVar(
+		&loadRetryBackoff,
+		"load-retry-backoff",
+		time.Second,
+		"The wait duration before the first download retry. It doubles with every subsequent retry.",
+	)
+	flag.DurationVar(
+		&loadRetryInterval,
+		"load-retry-interval",
+		15*time.Second,
+		"How soon projects are reconciled again, whose artifact could not be loaded because of a recoverable error, like an unreachable registry. 0 retries them with their pull interval.",
+	)
+	flag.DurationVar(
+		&waitRetryInterval,
+		"wait-retry-interval",
+		10*time.Second,
+		"How soon projects are reconciled again, whose components wait for their waitFor condition. 0 reconciles them again with their pull interval.",
+	)
+	flag.DurationVar(
+		&applyTimeout,
+		"apply-timeout",
+		30*time.Second,
+		"How long an apply waits for its object to become ready, e.g. a CRD to be established, without progress of its status.",
+	)
+	flag.DurationVar(
+		&applyMaxTimeout,
+		"apply-max-timeout",
+		5*time.Minute,
+		"How long an apply waits at most for its object to become ready, while its status makes progress.",
+	)
+	flag.Int64Var(
+		&maxArtifactBytes,
+		"max-artifact-bytes",
+		512<<20,
+		"The maximum size in bytes of downloaded and extracted project artifacts. 0 disables the limit.",
+	)
+	defaultConcurrency := -1
+	if concurrencyEnv := os.Getenv("CONCURRENCY"); concurrencyEnv != "" {
+		var err error
+		defaultConcurrency, err = strconv.Atoi(concurrencyEnv)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	flag.IntVar(
+		&concurrency,
+		"concurrency",
+		defaultConcurrency,
+		"The worker pool size for loading projects and reconciling components. -1 means no limit. Defaults to the CONCURRENCY environment variable.",
+	)
+	flag.IntVar(
+		&maxConcurrentReconciles,
+		"max-concurrent-reconciles",
+		1,
+		"The maximum number of projects reconciled at once, independent of the worker pool size of a single project.",
+	)
+	flag.StringVar(
+		&fieldManager,
+		"field-manager",
+		"",
+		"The field manager name owning applied objects. Defaults to the controller name.",
+	)
+	flag.StringVar(
+		&proxy,
+		"proxy",
+		"",
+		"The proxy url routing all registry and chart repository traffic. Overrides the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.",
+	)
+	flag.Func(
+		"registry-mirror",
+		"A rewrite rule in the format prefix=mirror routing registry traffic through a mirror, e.g. docker.io=mirror.internal/docker. Can be repeated.",
+		func(rule string) error {
+			prefix, mirror, err := oci.ParseMirror(rule)
+			if err != nil {
+				return err
+			}
+			registryMirrors[prefix] = mirror
+			return nil
+		},
+	)
+	flag.StringVar(
+		&clusterName,
+		"cluster-name",
+		"",
+		"The name of the cluster, which projects can reference with the clusterName tag variable.",
+	)
+	flag.Func(
+		"cluster-label",
+		"A label in the format key=value, which projects can reference with the clusterLabels tag variable. Can be repeated.",
+		func(label string) error {
+			key, value, found := strings.Cut(label, "=")
+			if !found || key == "" {
+				return fmt.Errorf("expected key=value, got %s", label)
+			}
+			clusterLabels[key] = value
+			return nil
+		},
+	)
+	flag.StringVar(
+		&deletePropagation,
+		"delete-propagation",
+		"Foreground",
+		"How dependents of garbage collected objects are deleted: Foreground, Background or Orphan.",
+	)
+	flag.Func(
+		"allowed-kind",
+		"A kind in the format group/Kind, which manifests and patches are restricted to. Core kinds omit the group and * matches all kinds of a group. Can be repeated.",
+		func(kind string) error {
+			allowedKinds = append(allowedKinds, kind)
+			return nil
+		},
+	)
+	flag.Func(
+		"denied-kind",
+		"A kind in the format group/Kind, which manifests and patches must not have. Takes precedence over allowed kinds. Can be repeated.",
+		func(kind string) error {
+			deniedKinds = append(deniedKinds, kind)
+			return nil
+		},
+	)
+	flag.StringVar(
+		&notificationWebhook,
+		"notification-webhook",
+		"",
+		"The url the outcome of every reconciliation is posted to as JSON.",
+	)
+	flag.BoolVar(
+		&provisionRBAC,
+		"provision-rbac",
+		false,
+		"Grant impersonated service accounts access to the resources declared by their project through provisioned Roles and ClusterRoles.",
+	)
+	flag.BoolVar(
+		&projectLeases,
+		"project-leases",
+		false,
+		"Acquire a Lease per project before reconciling it, so that shards do not reconcile the same project concurrently while projects are moved between them.",
+	)
+	flag.StringVar(
+		&registryAuthFile,
+		"registry-auth-file",
+		"",
+		"The JSON file mapping registry hosts to their credentials, which are used for projects and charts without declared auth. Defaults to /registry-auth/credentials.json, if it exists.",
+	)
+	flag.Func(
+		"inventory-format",
+		"The format HelmRelease content is stored with in the inventory, either json or gzip. Defaults to json.",
+		func(name string) error {
+			format, err := inventory.ParseFormat(name)
+			if err != nil {
+				return err
+			}
+			inventoryFormat = format
+			return nil
+		},
+	)
 	flag.Parse()
 
 	cfg := ctrl.GetConfigOrDie()
@@ -113,6 +345,34 @@ func main() {
 		controller.LogLevel(logLevel),
 		controller.PlainHTTP(plainHTTP),
 		controller.InsecureSkipTLSverify(insecureSkipTLSverify),
+		controller.ShutdownTimeout(shutdownTimeout),
+		controller.DiscoveryCacheTTL(discoveryCacheTTL),
+		controller.CredentialCacheTTL(credentialCacheTTL),
+		controller.CacheRetention(cacheRetention),
+		controller.ChartCacheMaxBytes(chartCacheMaxBytes),
+		controller.ChartCacheTTL(chartCacheTTL),
+		controller.LoadRetries(loadRetries),
+		controller.LoadRetryBackoff(loadRetryBackoff),
+		controller.LoadRetryInterval(loadRetryInterval),
+		controller.WaitRetryInterval(waitRetryInterval),
+		controller.ApplyTimeout(applyTimeout),
+		controller.ApplyMaxTimeout(applyMaxTimeout),
+		controller.MaxArtifactBytes(maxArtifactBytes),
+		controller.Concurrency(concurrency),
+		controller.MaxConcurrentReconciles(maxConcurrentReconciles),
+		controller.RegistryMirrors(registryMirrors),
+		controller.FieldManager(fieldManager),
+		controller.Proxy(proxy),
+		controller.ClusterName(clusterName),
+		controller.ClusterLabels(clusterLabels),
+		controller.InventoryFormat(inventoryFormat),
+		controller.NotificationWebhook(notificationWebhook),
+		controller.ProvisionRBAC(provisionRBAC),
+		controller.ProjectLeases(projectLeases),
+		controller.RegistryAuthFile(registryAuthFile),
+		controller.DeletePropagation(deletePropagation),
+		controller.AllowedKinds(allowedKinds),
+		controller.DeniedKinds(deniedKinds),
 	)
 	if err != nil {
 		os.Exit(1)
@@ -18,7 +18,11 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -31,21 +35,35 @@ import (
 	ctrlZap "sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/kubectl/pkg/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
 	gitops "github.com/kharf/navecd/api/v1beta1"
+	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/garbage"
+	"github.com/kharf/navecd/pkg/inventory"
+	"github.com/kharf/navecd/pkg/kube"
+	"github.com/kharf/navecd/pkg/oci"
 	"github.com/kharf/navecd/pkg/project"
 	"github.com/prometheus/client_golang/prometheus"
 	helmKube "helm.sh/helm/v4/pkg/kube"
@@ -54,9 +72,17 @@ import (
 	// +kubebuilder:scaffold:imports
 )
 
+var (
+	ErrInvalidConcurrency = errors.New("Concurrency has to be positive or -1 for no limit")
+	ErrInvalidProxy       = errors.New("Proxy has to be an absolute url")
+
+	ErrInvalidMaxConcurrentReconciles = errors.New("Max concurrent reconciles has to be positive")
+)
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme.Scheme))
 	utilruntime.Must(gitops.AddToScheme(scheme.Scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme.Scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -71,8 +97,36 @@ type GitOpsProjectController struct {
 	Reconciler project.Reconciler
 
 	ReconciliationHistogram *prometheus.HistogramVec
+
+	// MaxConcurrentReconciles limits how many projects are reconciled at once across the controller.
+	// It is independent of the worker pool size of a single project. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	// Recorder publishes events regarding the reconciled GitOpsProjects, if set.
+	Recorder events.EventRecorder
+
+	// LoadRetryInterval replaces the pull interval of projects, whose artifact could not be loaded because of a recoverable error,
+	// like an unreachable registry, if it is shorter. Zero means they are retried with their pull interval.
+	LoadRetryInterval time.Duration
+
+	// WaitRetryInterval replaces the pull interval of projects, whose components wait for their wait condition, if it is shorter,
+	// so that their dependents are applied soon after it is met. Zero means they are reconciled again with their pull interval.
+	WaitRetryInterval time.Duration
+
+	drainer *drainer
 }
 
+const (
+	// DriftCorrectedReason is the event reason for recreated manifests, which were deleted out of band.
+	DriftCorrectedReason = "DriftCorrected"
+
+	// TagMutatedReason is the event reason for tags pointing to a different digest than at the last reconciliation.
+	TagMutatedReason = "TagMutated"
+
+	// DirNotFoundReason is the condition reason for loaded projects, which do not contain the configured spec.dir.
+	DirNotFoundReason = "DirNotFound"
+)
+
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (controller *GitOpsProjectController) Reconcile(
@@ -82,6 +136,13 @@ func (controller *GitOpsProjectController) Reconcile(
 	triggerTime := v1.Now()
 	log := controller.Log
 
+	ctx, done, ok := controller.drainer.track(ctx)
+	if !ok {
+		log.Info("Shutting down. Skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+	defer done()
+
 	log.Info("Reconciling")
 
 	var gProject gitops.GitOpsProject
@@ -94,6 +155,32 @@ func (controller *GitOpsProjectController) Reconcile(
 		RequeueAfter: time.Duration(gProject.Spec.PullIntervalSeconds) * time.Second,
 	}
 
+	if !gProject.GetDeletionTimestamp().IsZero() {
+		return controller.finalize(ctx, &gProject, requeueResult), nil
+	}
+
+	// the shard holding the lease reports the state of the project, which is why it is acquired before any write.
+	if controller.Reconciler.LeaseIdentity != "" {
+		leaseCtx, release, err := controller.Reconciler.HoldLease(ctx, gProject.GetUID())
+		if errors.Is(err, project.ErrLeaseHeld) {
+			log.Info("Skipping reconciliation", "reason", err.Error())
+			return requeueResult, nil
+		}
+		if err != nil {
+			log.Error(err, "Unable to acquire project lease")
+			return requeueResult, nil
+		}
+		defer release()
+		ctx = leaseCtx
+	}
+
+	if needsFinalizer(controller.Reconciler, gProject) && controllerutil.AddFinalizer(&gProject, ProjectFinalizer) {
+		if err := controller.Client.Update(ctx, &gProject, client.FieldOwner(controller.Reconciler.FieldManager)); err != nil {
+			log.Error(err, "Unable to add GitOpsProject finalizer")
+			return requeueResult, nil
+		}
+	}
+
 	gProject.Status.Conditions = make([]v1.Condition, 0, 2)
 	if err := controller.updateCondition(ctx, &gProject, v1.Condition{
 		Type:               "Running",
@@ -107,9 +194,30 @@ func (controller *GitOpsProjectController) Reconcile(
 	}
 
 	result, err := controller.Reconciler.Reconcile(ctx, gProject)
+	if errors.Is(err, project.ErrLeaseLost) {
+		// another shard may hold the lease by now and report the state of the project.
+		log.Error(err, "Reconciling aborted")
+		return requeueResult, nil
+	}
 	if err != nil {
 		log.Error(err, "Reconciling failed")
-		return requeueResult, nil
+		if errors.Is(err, project.ErrTagMutated) && controller.Recorder != nil {
+			controller.Recorder.Eventf(&gProject, nil, corev1.EventTypeWarning, TagMutatedReason, "Refuse", "%s", err.Error())
+		}
+		if errors.Is(err, project.ErrDirNotFound) {
+			gProject.Status.Conditions = append(gProject.Status.Conditions, v1.Condition{
+				Type:               "Finished",
+				Reason:             DirNotFoundReason,
+				Message:            fmt.Sprintf("%s. Please fix spec.dir", err),
+				Status:             "False",
+				LastTransitionTime: v1.Now(),
+			})
+		}
+		gProject.Status.Health = gitops.HealthDegraded
+		if err := controller.Client.Status().Update(ctx, &gProject, client.FieldOwner(controller.Reconciler.FieldManager)); err != nil {
+			log.Error(err, "Unable to update GitOpsProject status health")
+		}
+		return controller.retryLoad(requeueResult, err), nil
 	}
 
 	// Refetch to get newest state.
@@ -120,8 +228,43 @@ func (controller *GitOpsProjectController) Reconcile(
 
 	reconciledTime := v1.Now()
 	gProject.Status.Revision = gitops.GitOpsProjectRevision{
-		Digest:        result.Digest,
-		ReconcileTime: reconciledTime,
+		Digest:         result.Digest,
+		ReconcileTime:  reconciledTime,
+		Tag:            result.Tag,
+		Registry:       result.Registry,
+		SourceRevision: result.SourceRevision,
+		BuildTime:      result.BuildTime,
+	}
+	gProject.Status.SuspendedComponents = result.SuspendedComponents
+	gProject.Status.LastReconcile = gitops.GitOpsProjectLastReconcile{
+		ChangedObjects: result.ChangedObjects,
+	}
+	gProject.Status.Releases = releaseStatuses(result.Releases)
+	if len(result.RecreatedComponents) != 0 && controller.Recorder != nil {
+		controller.Recorder.Eventf(
+			&gProject,
+			nil,
+			corev1.EventTypeNormal,
+			DriftCorrectedReason,
+			"Recreate",
+			"Recreated components deleted out of band: %s",
+			strings.Join(result.RecreatedComponents, ", "),
+		)
+	}
+	if result.TagMutated && controller.Recorder != nil {
+		controller.Recorder.Eventf(
+			&gProject,
+			nil,
+			corev1.EventTypeWarning,
+			TagMutatedReason,
+			"Reconcile",
+			"Tag %s points to a different digest than at the last reconciliation: %s",
+			result.Tag,
+			result.Digest,
+		)
+	}
+	if health := projectHealth(result); health != "" {
+		gProject.Status.Health = health
 	}
 
 	if err := controller.updateCondition(ctx, &gProject, v1.Condition{
@@ -141,7 +284,136 @@ func (controller *GitOpsProjectController) Reconcile(
 	}).Observe(time.Since(triggerTime.Time).Seconds())
 
 	log.Info("Reconciling finished")
-	return requeueResult, nil
+	return controller.retryWaiting(controller.retryLoad(requeueResult, result.DownloadError), result), nil
+}
+
+// ProjectFinalizer defers the deletion of GitOpsProjects, until the objects Kubernetes does not delete together with them are removed,
+// like their lease or owned objects, which cannot reference them with an owner reference.
+const ProjectFinalizer = "gitops.navecd.io/finalizer"
+
+// needsFinalizer reports whether the project manages objects, which have to be removed on deletion.
+func needsFinalizer(reconciler project.Reconciler, gProject gitops.GitOpsProject) bool {
+	return reconciler.LeaseIdentity != "" || gProject.Spec.OwnerReferences
+}
+
+// finalize removes the owned objects and the lease of a deleted project and then its finalizer.
+// Projects, whose lease is held by another shard, are finalized once it is released or expired.
+func (controller *GitOpsProjectController) finalize(
+	ctx context.Context,
+	gProject *gitops.GitOpsProject,
+	requeueResult ctrl.Result,
+) ctrl.Result {
+	log := controller.Log
+	if !controllerutil.ContainsFinalizer(gProject, ProjectFinalizer) {
+		return ctrl.Result{}
+	}
+
+	leaseCtx, release := ctx, func() {}
+	if controller.Reconciler.LeaseIdentity != "" {
+		// the lease keeps other shards from applying objects again, while they are deleted.
+		var err error
+		leaseCtx, release, err = controller.Reconciler.HoldLease(ctx, gProject.GetUID())
+		if err != nil {
+			log.Error(err, "Unable to acquire project lease")
+			return requeueResult
+		}
+	}
+
+	if gProject.Spec.OwnerReferences {
+		if err := controller.Reconciler.DeleteOwnedObjects(leaseCtx, *gProject); err != nil {
+			release()
+			log.Error(err, "Unable to delete owned objects")
+			return requeueResult
+		}
+	}
+
+	release()
+	if controller.Reconciler.LeaseIdentity != "" {
+		if err := controller.Reconciler.DeleteLease(ctx, gProject.GetUID()); err != nil {
+			log.Error(err, "Unable to delete project lease")
+			return requeueResult
+		}
+	}
+
+	controllerutil.RemoveFinalizer(gProject, ProjectFinalizer)
+	if err := controller.Client.Update(ctx, gProject, client.FieldOwner(controller.Reconciler.FieldManager)); err != nil {
+		log.Error(err, "Unable to remove GitOpsProject finalizer")
+		return requeueResult
+	}
+
+	log.Info("Finalized")
+	return ctrl.Result{}
+}
+
+// retryLoad requeues projects after the LoadRetryInterval, if their artifact could not be loaded because of a recoverable error,
+// so that they recover quickly once the registry is back instead of waiting for their next pull.
+func (controller *GitOpsProjectController) retryLoad(requeueResult ctrl.Result, err error) ctrl.Result {
+	var loadErr *project.RecoverableLoadError
+	if controller.LoadRetryInterval <= 0 || !errors.As(err, &loadErr) {
+		return requeueResult
+	}
+
+	if requeueResult.RequeueAfter <= 0 || controller.LoadRetryInterval < requeueResult.RequeueAfter {
+		requeueResult.RequeueAfter = controller.LoadRetryInterval
+	}
+	return requeueResult
+}
+
+// retryWaiting requeues projects after the WaitRetryInterval, if they have components, whose wait condition is not met yet.
+// Wait conditions are checked once per reconciliation instead of blocking a worker until they are met.
+func (controller *GitOpsProjectController) retryWaiting(requeueResult ctrl.Result, result *project.ReconcileResult) ctrl.Result {
+	if controller.WaitRetryInterval <= 0 || len(result.WaitingComponents) == 0 {
+		return requeueResult
+	}
+
+	if requeueResult.RequeueAfter <= 0 || controller.WaitRetryInterval < requeueResult.RequeueAfter {
+		requeueResult.RequeueAfter = controller.WaitRetryInterval
+	}
+	return requeueResult
+}
+
+// projectHealth summarizes the reconciliation result.
+// Suspended projects keep their previous health.
+func projectHealth(result *project.ReconcileResult) gitops.GitOpsProjectHealth {
+	switch {
+	case result.Suspended:
+		return ""
+	case result.ComponentError != nil || result.DownloadError != nil:
+		return gitops.HealthDegraded
+	case len(result.ProgressingComponents) != 0:
+		return gitops.HealthProgressing
+	}
+	return gitops.HealthHealthy
+}
+
+// maxReleaseNotesLength limits the notes stored per release to keep the status object small.
+const maxReleaseNotesLength = 1024
+
+// releaseStatuses converts the reconciled releases to their status representation with truncated notes.
+func releaseStatuses(releases []project.ReleaseStatus) []gitops.GitOpsProjectReleaseStatus {
+	if len(releases) == 0 {
+		return nil
+	}
+
+	statuses := make([]gitops.GitOpsProjectReleaseStatus, 0, len(releases))
+	for _, release := range releases {
+		statuses = append(statuses, gitops.GitOpsProjectReleaseStatus{
+			ID:        release.ID,
+			Name:      release.Name,
+			Namespace: release.Namespace,
+			Revision:  release.Revision,
+			Notes:     truncateNotes(release.Notes),
+		})
+	}
+	return statuses
+}
+
+func truncateNotes(notes string) string {
+	if len(notes) <= maxReleaseNotesLength {
+		return notes
+	}
+	const suffix = "..."
+	return strings.ToValidUTF8(notes[:maxReleaseNotesLength-len(suffix)], "") + suffix
 }
 
 func (reconciler *GitOpsProjectController) updateCondition(
@@ -162,22 +434,102 @@ func (reconciler *GitOpsProjectController) SetupWithManager(
 	controllerName string,
 ) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&gitops.GitOpsProject{}).
+		For(&gitops.GitOpsProject{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// CRD status changes are watched too, because APIs are only served once their CRD is established.
+		WatchesMetadata(
+			&apiextensionsv1.CustomResourceDefinition{},
+			handler.EnqueueRequestsFromMapFunc(reconciler.projectsUsingCRD),
+		).
 		Named(controllerName).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		WithOptions(reconciler.options()).
 		Complete(reconciler)
 }
 
+// options configures the workers of the controller, which take enqueued projects one at a time,
+// so that at most MaxConcurrentReconciles projects are reconciled simultaneously.
+func (reconciler *GitOpsProjectController) options() controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: reconciler.MaxConcurrentReconciles,
+	}
+}
+
+// projectsUsingCRD maps a changed CRD to the projects, which opted into reconciling on API changes
+// and used its API group at their last reconciliation.
+func (controller *GitOpsProjectController) projectsUsingCRD(ctx context.Context, crd client.Object) []reconcile.Request {
+	// the served APIs changed, so that cached discovery results are outdated.
+	controller.Reconciler.DiscoveryCache.Invalidate()
+
+	if controller.Reconciler.APIGroups == nil {
+		return nil
+	}
+
+	// CRDs are named <plural>.<group>
+	_, group, found := strings.Cut(crd.GetName(), ".")
+	if !found {
+		return nil
+	}
+
+	var projects gitops.GitOpsProjectList
+	if err := controller.Client.List(ctx, &projects); err != nil {
+		controller.Log.Error(err, "Unable to list GitOpsProjects affected by CRD change", "crd", crd.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, gProject := range projects.Items {
+		if !gProject.Spec.ReconcileOnAPIChange || !controller.Reconciler.APIGroups.Uses(gProject.GetUID(), group) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      gProject.GetName(),
+				Namespace: gProject.GetNamespace(),
+			},
+		})
+	}
+
+	return requests
+}
+
 type setupOptions struct {
-	NamePodinfoPath       string
-	NamespacePodinfoPath  string
-	ShardPodinfoPath      string
-	InventoryPath         string
-	MetricsAddr           string
-	ProbeAddr             string
-	LogLevel              int
-	InsecureSkipTLSverify bool
-	PlainHTTP             bool
+	NamePodinfoPath         string
+	NamespacePodinfoPath    string
+	ShardPodinfoPath        string
+	InventoryPath           string
+	MetricsAddr             string
+	ProbeAddr               string
+	LogLevel                int
+	InsecureSkipTLSverify   bool
+	PlainHTTP               bool
+	ShutdownTimeout         time.Duration
+	DiscoveryCacheTTL       time.Duration
+	CredentialCacheTTL      time.Duration
+	CacheRetention          time.Duration
+	ChartCacheMaxBytes      int64
+	ChartCacheTTL           time.Duration
+	LoadRetries             int
+	LoadRetryBackoff        time.Duration
+	LoadRetryInterval       time.Duration
+	WaitRetryInterval       time.Duration
+	ApplyTimeout            time.Duration
+	ApplyMaxTimeout         time.Duration
+	MaxArtifactBytes        int64
+	DeletePropagation       string
+	Concurrency             int
+	MaxConcurrentReconciles int
+	RegistryMirrors         oci.Mirrors
+	FieldManager            string
+	Proxy                   string
+	ClusterName             string
+	ClusterLabels           map[string]string
+	InventoryFormat         inventory.Format
+	NotificationWebhook     string
+	ProvisionRBAC           bool
+	ProjectLeases           bool
+	RegistryAuthFile        string
+	AllowedKinds            []string
+	DeniedKinds             []string
 }
 
 type option interface {
@@ -244,6 +596,241 @@ func (opt PlainHTTP) apply(options *setupOptions) {
 	options.PlainHTTP = bool(opt)
 }
 
+type ShutdownTimeout time.Duration
+
+func (opt ShutdownTimeout) apply(options *setupOptions) {
+	if opt > 0 {
+		options.ShutdownTimeout = time.Duration(opt)
+	}
+}
+
+// DiscoveryCacheTTL defines how long discovered APIs are shared across reconciliations, before they are discovered again.
+// Changed CRDs invalidate the cache immediately. Zero disables the cache.
+type DiscoveryCacheTTL time.Duration
+
+func (opt DiscoveryCacheTTL) apply(options *setupOptions) {
+	options.DiscoveryCacheTTL = time.Duration(opt)
+}
+
+// CredentialCacheTTL defines how long credentials fetched through workload identity are shared across reconciliations,
+// before they are fetched again. It has to be shorter than the validity of the cloud provider tokens. Zero disables the cache.
+type CredentialCacheTTL time.Duration
+
+func (opt CredentialCacheTTL) apply(options *setupOptions) {
+	options.CredentialCacheTTL = time.Duration(opt)
+}
+
+// CacheRetention defines how long extracted project artifacts, which are not loaded anymore, are kept in the cache.
+// Zero keeps them forever.
+type CacheRetention time.Duration
+
+func (opt CacheRetention) apply(options *setupOptions) {
+	options.CacheRetention = time.Duration(opt)
+}
+
+// ChartCacheMaxBytes limits the size of cached helm charts. Least recently used charts are evicted first, when exceeded.
+// Zero disables the limit.
+type ChartCacheMaxBytes int64
+
+func (opt ChartCacheMaxBytes) apply(options *setupOptions) {
+	options.ChartCacheMaxBytes = int64(opt)
+}
+
+// ChartCacheTTL defines how long cached helm charts, which are not used anymore, are kept in the cache.
+// Zero keeps them forever.
+type ChartCacheTTL time.Duration
+
+func (opt ChartCacheTTL) apply(options *setupOptions) {
+	options.ChartCacheTTL = time.Duration(opt)
+}
+
+// LoadRetries is the number of additional attempts to download a project artifact on recoverable errors,
+// like an unreachable registry, before the project falls back to its previously downloaded artifact.
+// Zero disables retries.
+type LoadRetries int
+
+func (opt LoadRetries) apply(options *setupOptions) {
+	if opt >= 0 {
+		options.LoadRetries = int(opt)
+	}
+}
+
+// LoadRetryBackoff defines the wait duration before the first download retry. It doubles with every subsequent retry.
+type LoadRetryBackoff time.Duration
+
+func (opt LoadRetryBackoff) apply(options *setupOptions) {
+	if opt > 0 {
+		options.LoadRetryBackoff = time.Duration(opt)
+	}
+}
+
+// LoadRetryInterval defines how soon projects are reconciled again,
+// whose artifact could not be loaded because of a recoverable error, like an unreachable registry.
+// Zero retries them with their pull interval.
+type LoadRetryInterval time.Duration
+
+func (opt LoadRetryInterval) apply(options *setupOptions) {
+	options.LoadRetryInterval = time.Duration(opt)
+}
+
+// WaitRetryInterval defines how soon projects are reconciled again,
+// whose components wait for their wait condition. Zero reconciles them again with their pull interval.
+type WaitRetryInterval time.Duration
+
+func (opt WaitRetryInterval) apply(options *setupOptions) {
+	options.WaitRetryInterval = time.Duration(opt)
+}
+
+// ApplyTimeout bounds how long an apply waits for its object to become ready, e.g. a CRD to be established,
+// while the status of the object makes no progress.
+type ApplyTimeout time.Duration
+
+func (opt ApplyTimeout) apply(options *setupOptions) {
+	if opt > 0 {
+		options.ApplyTimeout = time.Duration(opt)
+	}
+}
+
+// ApplyMaxTimeout is the hard cap for applies, whose object makes progress.
+// Zero means the apply timeout is never extended.
+type ApplyMaxTimeout time.Duration
+
+func (opt ApplyMaxTimeout) apply(options *setupOptions) {
+	options.ApplyMaxTimeout = time.Duration(opt)
+}
+
+// MaxArtifactBytes limits the size of downloaded and extracted project artifacts.
+// Larger artifacts fail the reconciliation without falling back to a previous version. Zero disables the limit.
+type MaxArtifactBytes int64
+
+func (opt MaxArtifactBytes) apply(options *setupOptions) {
+	options.MaxArtifactBytes = int64(opt)
+}
+
+// Concurrency defines the worker pool size of project loading and component reconciliation.
+// It has to be positive or -1 for no limit.
+type Concurrency int
+
+func (opt Concurrency) apply(options *setupOptions) {
+	options.Concurrency = int(opt)
+}
+
+// MaxConcurrentReconciles limits how many projects are reconciled at once.
+// It has to be positive.
+type MaxConcurrentReconciles int
+
+func (opt MaxConcurrentReconciles) apply(options *setupOptions) {
+	options.MaxConcurrentReconciles = int(opt)
+}
+
+// RegistryMirrors rewrite registry host prefixes to the hosts of their mirrors.
+type RegistryMirrors oci.Mirrors
+
+func (opt RegistryMirrors) apply(options *setupOptions) {
+	options.RegistryMirrors = oci.Mirrors(opt)
+}
+
+// FieldManager is the name of the manager owning the fields of applied objects.
+// Defaults to the controller name.
+// Multiple Navecd installations on one cluster should use distinct field managers.
+type FieldManager string
+
+func (opt FieldManager) apply(options *setupOptions) {
+	if opt != "" {
+		options.FieldManager = string(opt)
+	}
+}
+
+// Proxy is the url of a proxy routing all registry and chart repository traffic.
+// It overrides the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+type Proxy string
+
+func (opt Proxy) apply(options *setupOptions) {
+	if opt != "" {
+		options.Proxy = string(opt)
+	}
+}
+
+// ClusterName is exposed to projects as the clusterName tag variable.
+type ClusterName string
+
+func (opt ClusterName) apply(options *setupOptions) {
+	options.ClusterName = string(opt)
+}
+
+// ClusterLabels are exposed to projects as the clusterLabels tag variable.
+type ClusterLabels map[string]string
+
+func (opt ClusterLabels) apply(options *setupOptions) {
+	options.ClusterLabels = map[string]string(opt)
+}
+
+// DeletePropagation defines how dependents of garbage collected manifests are deleted: Foreground, Background or Orphan.
+type DeletePropagation string
+
+func (opt DeletePropagation) apply(options *setupOptions) {
+	if opt != "" {
+		options.DeletePropagation = string(opt)
+	}
+}
+
+// AllowedKinds restricts applied and collected manifests and patches to the given group/Kind patterns.
+// All kinds are allowed, if empty.
+type AllowedKinds []string
+
+func (opt AllowedKinds) apply(options *setupOptions) {
+	options.AllowedKinds = []string(opt)
+}
+
+// DeniedKinds refuses to apply and collect manifests and patches matching the given group/Kind patterns.
+// They take precedence over AllowedKinds.
+type DeniedKinds []string
+
+func (opt DeniedKinds) apply(options *setupOptions) {
+	options.DeniedKinds = []string(opt)
+}
+
+// InventoryFormat is the format HelmRelease content is stored with in the inventory.
+type InventoryFormat inventory.Format
+
+func (opt InventoryFormat) apply(options *setupOptions) {
+	if opt != "" {
+		options.InventoryFormat = inventory.Format(opt)
+	}
+}
+
+// NotificationWebhook is the url every reconciliation outcome is posted to.
+type NotificationWebhook string
+
+func (opt NotificationWebhook) apply(options *setupOptions) {
+	options.NotificationWebhook = string(opt)
+}
+
+// ProvisionRBAC grants impersonated service accounts access to the resources declared by their project.
+type ProvisionRBAC bool
+
+func (opt ProvisionRBAC) apply(options *setupOptions) {
+	options.ProvisionRBAC = bool(opt)
+}
+
+// ProjectLeases lets shards acquire a Lease per project before reconciling it,
+// so that projects moved between shards are not reconciled by both at the same time.
+type ProjectLeases bool
+
+func (opt ProjectLeases) apply(options *setupOptions) {
+	options.ProjectLeases = bool(opt)
+}
+
+// RegistryAuthFile is the path to a JSON file mapping registry hosts to their credentials, see [oci.LoadRegistryCredentials].
+// A missing file means that no static credentials are used.
+type RegistryAuthFile string
+
+func (opt RegistryAuthFile) apply(options *setupOptions) {
+	if opt != "" {
+		options.RegistryAuthFile = string(opt)
+	}
+}
+
 type LogLevel int
 
 func (opt LogLevel) apply(options *setupOptions) {
@@ -261,6 +848,23 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 		InsecureSkipTLSverify: false,
 		PlainHTTP:             false,
 		LogLevel:              0,
+		ShutdownTimeout:       30 * time.Second,
+		DiscoveryCacheTTL:     5 * time.Minute,
+		CredentialCacheTTL:    10 * time.Minute,
+		CacheRetention:        24 * time.Hour,
+		LoadRetries:           3,
+		LoadRetryBackoff:      time.Second,
+		LoadRetryInterval:     15 * time.Second,
+		WaitRetryInterval:     10 * time.Second,
+		ApplyTimeout:          kube.DefaultApplyTimeout,
+		ApplyMaxTimeout:       5 * time.Minute,
+		MaxArtifactBytes:      512 << 20,
+		DeletePropagation:     string(v1.DeletePropagationForeground),
+		// the optional registry auth secret is mounted to /registry-auth.
+		RegistryAuthFile: "/registry-auth/credentials.json",
+		// -1 means no limit. According to benchmarks this config had the best performance for all cpu quotas tested (1, 2, 4 cpus).
+		Concurrency:             -1,
+		MaxConcurrentReconciles: 1,
 	}
 
 	for _, opt := range options {
@@ -273,6 +877,38 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 	}))
 	ctrl.SetLogger(log)
 
+	if opts.Concurrency == 0 || opts.Concurrency < -1 {
+		err := fmt.Errorf("%w: got %d", ErrInvalidConcurrency, opts.Concurrency)
+		log.Error(err, "Invalid concurrency")
+		return nil, err
+	}
+
+	if opts.MaxConcurrentReconciles < 1 {
+		err := fmt.Errorf("%w: got %d", ErrInvalidMaxConcurrentReconciles, opts.MaxConcurrentReconciles)
+		log.Error(err, "Invalid max concurrent reconciles")
+		return nil, err
+	}
+
+	if _, err := parseProxy(opts.Proxy); err != nil {
+		log.Error(err, "Invalid proxy")
+		return nil, err
+	}
+
+	if _, err := loadRegistryCredentials(opts.RegistryAuthFile); err != nil {
+		log.Error(err, "Unable to load registry credentials")
+		return nil, err
+	}
+
+	if err := kindFilter(opts).Validate(); err != nil {
+		log.Error(err, "Invalid kinds")
+		return nil, err
+	}
+
+	if _, err := garbage.ParseDeletePropagation(opts.DeletePropagation); err != nil {
+		log.Error(err, "Invalid delete propagation")
+		return nil, err
+	}
+
 	nameBytes, err := os.ReadFile(opts.NamePodinfoPath)
 	if err != nil {
 		log.Error(err, "Unable to read controller name")
@@ -280,6 +916,9 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 	}
 
 	controllerName := strings.TrimSpace(string(nameBytes))
+	if opts.FieldManager == "" {
+		opts.FieldManager = controllerName
+	}
 
 	namespaceBytes, err := os.ReadFile(opts.NamespacePodinfoPath)
 	if err != nil {
@@ -303,8 +942,11 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 		return nil, err
 	}
 
+	// leave the manager some headroom to stop the remaining runnables after draining.
+	gracefulShutdownTimeout := opts.ShutdownTimeout + 10*time.Second
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
-		Scheme: scheme.Scheme,
+		Scheme:                  scheme.Scheme,
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
 		Metrics: server.Options{
 			BindAddress: opts.MetricsAddr,
 			ExtraHandlers: map[string]http.Handler{
@@ -329,14 +971,7 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 		return nil, err
 	}
 
-	componentBuilder := component.NewBuilder()
-
-	// -1 means no limit. According to benchmarks this config had the best performance for all cpu quotas tested (1, 2, 4 cpus).
-	workerSize := -1
-
-	projectManager := project.NewManager(componentBuilder, workerSize)
-
-	helmKube.ManagedFieldsManager = controllerName
+	helmKube.ManagedFieldsManager = opts.FieldManager
 
 	reconciliationHisto := prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "navecd",
@@ -355,24 +990,28 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 		<-signalChan
 	}()
 
+	drainer := newDrainer(opts.ShutdownTimeout)
+	if err := mgr.Add(drainer); err != nil {
+		log.Error(err, "Unable to set up graceful shutdown")
+		return nil, err
+	}
+
+	reconciler := newReconciler(log, cfg, opts, controllerName, namespace, shard)
+	if err := registerCredentialCacheMetrics(reconciler.CredentialCache); err != nil {
+		log.Error(err, "Unable to register Prometheus Collector")
+		return nil, err
+	}
+
 	if err := (&GitOpsProjectController{
+		drainer:                 drainer,
 		Log:                     log,
 		ReconciliationHistogram: reconciliationHisto,
+		MaxConcurrentReconciles: opts.MaxConcurrentReconciles,
 		Client:                  mgr.GetClient(),
-		Reconciler: project.Reconciler{
-			Log:                   log,
-			KubeConfig:            cfg,
-			ComponentBuilder:      componentBuilder,
-			ProjectManager:        projectManager,
-			FieldManager:          controllerName,
-			WorkerPoolSize:        workerSize,
-			InsecureSkipTLSverify: opts.InsecureSkipTLSverify,
-			PlainHTTP:             opts.PlainHTTP,
-			CacheDir:              os.TempDir(),
-			// /inventory is mounted as volume.
-			InventoryRootDir: opts.InventoryPath,
-			Namespace:        namespace,
-		},
+		Recorder:                mgr.GetEventRecorder(controllerName),
+		LoadRetryInterval:       opts.LoadRetryInterval,
+		WaitRetryInterval:       opts.WaitRetryInterval,
+		Reconciler:              reconciler,
 	}).SetupWithManager(mgr, controllerName); err != nil {
 		log.Error(err, "Unable to create controller")
 		return nil, err
@@ -390,3 +1029,141 @@ func Setup(cfg *rest.Config, options ...option) (manager.Manager, error) {
 
 	return mgr, nil
 }
+
+// registerCredentialCacheMetrics exposes the hits and misses of the credential cache, if set.
+func registerCredentialCacheMetrics(cache *cloud.CredentialCache) error {
+	if cache == nil {
+		return nil
+	}
+
+	hits := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "navecd",
+		Name:      "credential_cache_hits_total",
+		Help:      "Number of cloud credential resolutions answered by the cache",
+	}, func() float64 { return float64(cache.Hits()) })
+	misses := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "navecd",
+		Name:      "credential_cache_misses_total",
+		Help:      "Number of cloud credential resolutions fetching credentials from the cloud provider",
+	}, func() float64 { return float64(cache.Misses()) })
+
+	for _, collector := range []prometheus.Collector{hits, misses} {
+		if err := metrics.Registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadRegistryCredentials returns nil for an empty path or a missing file.
+func loadRegistryCredentials(path string) (oci.RegistryCredentials, error) {
+	if path == "" {
+		return nil, nil
+	}
+	credentials, err := oci.LoadRegistryCredentials(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return credentials, err
+}
+
+func kindFilter(opts *setupOptions) component.KindFilter {
+	return component.KindFilter{
+		Allowed: opts.AllowedKinds,
+		Denied:  opts.DeniedKinds,
+	}
+}
+
+// parseProxy returns nil for an empty proxy, which means the proxy environment variables are used.
+func parseProxy(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProxy, err)
+	}
+	if proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("%w: got %s", ErrInvalidProxy, proxy)
+	}
+	return proxyURL, nil
+}
+
+// credentialCacheSize bounds the number of cached credentials, which are keyed by provider, registry host and namespace.
+const credentialCacheSize = 128
+
+func newReconciler(
+	log logr.Logger,
+	cfg *rest.Config,
+	opts *setupOptions,
+	controllerName string,
+	namespace string,
+	shard string,
+) project.Reconciler {
+	componentBuilder := component.NewBuilder()
+	fieldManager := opts.FieldManager
+	if fieldManager == "" {
+		fieldManager = controllerName
+	}
+	// validated by Setup
+	proxy, _ := parseProxy(opts.Proxy)
+	deletePropagation, _ := garbage.ParseDeletePropagation(opts.DeletePropagation)
+	registryCredentials, _ := loadRegistryCredentials(opts.RegistryAuthFile)
+	var discoveryCache *kube.DiscoveryCache
+	if opts.DiscoveryCacheTTL > 0 {
+		discoveryCache = kube.NewDiscoveryCache(cfg, opts.DiscoveryCacheTTL)
+	}
+	var credentialCache *cloud.CredentialCache
+	if opts.CredentialCacheTTL > 0 {
+		credentialCache = cloud.NewCredentialCache(credentialCacheSize, opts.CredentialCacheTTL)
+	}
+	var leaseIdentity string
+	if opts.ProjectLeases {
+		leaseIdentity = shard
+	}
+	var postReconcile project.PostReconcileHook
+	if opts.NotificationWebhook != "" {
+		notifier := &project.WebhookNotifier{
+			URL: opts.NotificationWebhook,
+		}
+		postReconcile = notifier.Notify
+	}
+	return project.Reconciler{
+		Log:                   log,
+		KubeConfig:            cfg,
+		ComponentBuilder:      componentBuilder,
+		ProjectManager:        project.NewManager(componentBuilder, opts.Concurrency),
+		FieldManager:          fieldManager,
+		WorkerPoolSize:        opts.Concurrency,
+		InsecureSkipTLSverify: opts.InsecureSkipTLSverify,
+		PlainHTTP:             opts.PlainHTTP,
+		CacheDir:              os.TempDir(),
+		// /inventory is mounted as volume.
+		InventoryRootDir:           opts.InventoryPath,
+		Shard:                      shard,
+		Namespace:                  namespace,
+		RegistryMirrors:            opts.RegistryMirrors,
+		Proxy:                      proxy,
+		RegistryCredentials:        registryCredentials,
+		ClusterName:                opts.ClusterName,
+		ClusterLabels:              opts.ClusterLabels,
+		InventoryHelmReleaseFormat: opts.InventoryFormat,
+		PostReconcile:              postReconcile,
+		ProvisionRBAC:              opts.ProvisionRBAC,
+		LeaseIdentity:              leaseIdentity,
+		APIGroups:                  project.NewAPIGroups(),
+		DiscoveryCache:             discoveryCache,
+		CredentialCache:            credentialCache,
+		MaxArtifactBytes:           opts.MaxArtifactBytes,
+		CacheRetention:             opts.CacheRetention,
+		LoadRetries:                opts.LoadRetries,
+		LoadRetryBackoff:           opts.LoadRetryBackoff,
+		ChartCacheMaxBytes:         opts.ChartCacheMaxBytes,
+		ChartCacheTTL:              opts.ChartCacheTTL,
+		ApplyTimeout:               opts.ApplyTimeout,
+		ApplyMaxTimeout:            opts.ApplyMaxTimeout,
+		Waits:                      &component.WaitTracker{},
+		KindFilter:                 kindFilter(opts),
+		DeletePropagation:          deletePropagation,
+	}
+}
@@ -35,6 +35,9 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -47,7 +50,7 @@ module: "github.com/kharf/navecd/internal/controller/projectone@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -77,7 +80,7 @@ module: "github.com/kharf/navecd/internal/controller/projecttwo@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -154,7 +157,7 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 					"image",
 					false,
 					projectPath,
-					"0.0.99",
+					"0.26.99",
 				)
 				Expect(err).NotTo(HaveOccurred())
 
@@ -200,7 +203,7 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 						"image",
 						false,
 						projectPath,
-						"0.0.99",
+						"0.26.99",
 					)
 					Expect(err).NotTo(HaveOccurred())
 
@@ -287,6 +290,373 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 					}, duration, assertionInterval).Should(Succeed())
 				},
 			)
+
+			It(
+				"Should summarize the component health in the project status",
+				func() {
+					gitOpsProjectName := "test"
+					setupPodInfo(gitOpsProjectName)
+
+					ctx := context.Background()
+
+					err := project.Init(
+						"github.com/kharf/navecd/controller",
+						"primary",
+						"image",
+						false,
+						projectPath,
+						"0.26.99",
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					// the namespace of the config map is not declared, so the component fails
+					err = os.WriteFile(
+						filepath.Join(projectPath, "infra", "toola", "configmap.cue"),
+						[]byte(`package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+cm: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "health"
+			namespace: "toolc"
+		}
+	}
+}
+`),
+						0600,
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					installAction := project.NewInstallAction(
+						kubernetes.DynamicTestKubeClient.DynamicClient(),
+						http.DefaultClient,
+						projectPath,
+					)
+
+					_, err = installAction.Install(
+						ctx,
+						project.InstallOptions{
+							Url:      repository.Name,
+							Ref:      repository.Ref,
+							Dir:      ".",
+							Name:     gitOpsProjectName,
+							Shard:    "primary",
+							Interval: intervalInSeconds,
+						},
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					mgr, err := Setup(
+						kubernetes.ControlPlane.Config,
+						InsecureSkipTLSverify(true),
+						MetricsAddr("0"),
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					go func() {
+						defer GinkgoRecover()
+						_ = mgr.Start(ctx)
+					}()
+
+					projectHealth := func() (gitops.GitOpsProjectHealth, error) {
+						var gitOpsProject gitops.GitOpsProject
+						if err := k8sClient.Get(
+							ctx,
+							types.NamespacedName{
+								Name:      gitOpsProjectName,
+								Namespace: gitOpsProjectNamespace,
+							},
+							&gitOpsProject,
+						); err != nil {
+							return "", err
+						}
+						return gitOpsProject.Status.Health, nil
+					}
+
+					Eventually(projectHealth, duration, assertionInterval).
+						Should(Equal(gitops.HealthDegraded))
+
+					err = os.WriteFile(
+						filepath.Join(projectPath, "infra", "toola", "toolc.cue"),
+						[]byte(`package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+toolc: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "toolc"
+	}
+}
+
+cm: dependencies: [toolc.id]
+`),
+						0600,
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					ociClient, err := oci.NewRepositoryClient(repository.Name, false)
+					Expect(err).NotTo(HaveOccurred())
+					_, err = oci.NewProjectClient(ociClient).
+						PushImageFromPath(ctx, repository.Ref, projectPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(projectHealth, duration, assertionInterval).
+						Should(Equal(gitops.HealthHealthy))
+				},
+			)
+
+			It(
+				"Should record the number of changed objects in the project status",
+				func() {
+					gitOpsProjectName := "test"
+					setupPodInfo(gitOpsProjectName)
+
+					ctx := context.Background()
+
+					err := project.Init(
+						"github.com/kharf/navecd/controller",
+						"primary",
+						"image",
+						false,
+						projectPath,
+						"0.26.99",
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					installAction := project.NewInstallAction(
+						kubernetes.DynamicTestKubeClient.DynamicClient(),
+						http.DefaultClient,
+						projectPath,
+					)
+
+					_, err = installAction.Install(
+						ctx,
+						project.InstallOptions{
+							Url:      repository.Name,
+							Ref:      repository.Ref,
+							Dir:      ".",
+							Name:     gitOpsProjectName,
+							Shard:    "primary",
+							Interval: intervalInSeconds,
+						},
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					mgr, err := Setup(
+						kubernetes.ControlPlane.Config,
+						InsecureSkipTLSverify(true),
+						MetricsAddr("0"),
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					go func() {
+						defer GinkgoRecover()
+						_ = mgr.Start(ctx)
+					}()
+
+					changedObjects := func() (int, error) {
+						var gitOpsProject gitops.GitOpsProject
+						if err := k8sClient.Get(
+							ctx,
+							types.NamespacedName{
+								Name:      gitOpsProjectName,
+								Namespace: gitOpsProjectNamespace,
+							},
+							&gitOpsProject,
+						); err != nil {
+							return 0, err
+						}
+						if gitOpsProject.Status.Revision.ReconcileTime.IsZero() {
+							return -1, nil
+						}
+						return gitOpsProject.Status.LastReconcile.ChangedObjects, nil
+					}
+
+					// the second reconciliation of an unchanged project does not change anything
+					Eventually(changedObjects, duration, assertionInterval).
+						Should(Equal(0))
+
+					err = os.WriteFile(
+						filepath.Join(projectPath, "infra", "toola", "configmap.cue"),
+						[]byte(`package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+cm: component.#Manifest & {
+	dependencies: [ns.id]
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "changed"
+			namespace: "toola"
+		}
+	}
+}
+`),
+						0600,
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					ociClient, err := oci.NewRepositoryClient(repository.Name, false)
+					Expect(err).NotTo(HaveOccurred())
+					_, err = oci.NewProjectClient(ociClient).
+						PushImageFromPath(ctx, repository.Ref, projectPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(changedObjects, duration, assertionInterval).
+						Should(BeNumerically(">", 0))
+				},
+			)
+
+			It(
+				"Should reconcile projects requiring an API, once its CRD is installed",
+				func() {
+					gitOpsProjectName := "test"
+					setupPodInfo(gitOpsProjectName)
+
+					ctx := context.Background()
+
+					err := project.Init(
+						"github.com/kharf/navecd/controller",
+						"primary",
+						"image",
+						false,
+						projectPath,
+						"0.26.99",
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.WriteFile(
+						filepath.Join(projectPath, "infra", "toola", "widget.cue"),
+						[]byte(`package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+widget: component.#Manifest & {
+	dependencies: [ns.id]
+	content: {
+		apiVersion: "apichange.navecd.io/v1"
+		kind:       "Widget"
+		metadata: {
+			name:      "widget"
+			namespace: "toola"
+		}
+	}
+} @requires("apichange.navecd.io/v1/Widget")
+`),
+						0600,
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					installAction := project.NewInstallAction(
+						kubernetes.DynamicTestKubeClient.DynamicClient(),
+						http.DefaultClient,
+						projectPath,
+					)
+
+					// only the CRD watch can trigger a reconciliation within the test duration
+					_, err = installAction.Install(
+						ctx,
+						project.InstallOptions{
+							Url:      repository.Name,
+							Ref:      repository.Ref,
+							Dir:      ".",
+							Name:     gitOpsProjectName,
+							Shard:    "primary",
+							Interval: 3600,
+						},
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					projectKey := types.NamespacedName{
+						Name:      gitOpsProjectName,
+						Namespace: gitOpsProjectNamespace,
+					}
+					var gitOpsProject gitops.GitOpsProject
+					err = k8sClient.Get(ctx, projectKey, &gitOpsProject)
+					Expect(err).NotTo(HaveOccurred())
+					gitOpsProject.Spec.ReconcileOnAPIChange = true
+					err = k8sClient.Update(ctx, &gitOpsProject)
+					Expect(err).NotTo(HaveOccurred())
+
+					mgr, err := Setup(
+						kubernetes.ControlPlane.Config,
+						InsecureSkipTLSverify(true),
+						MetricsAddr("0"),
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					go func() {
+						defer GinkgoRecover()
+						_ = mgr.Start(ctx)
+					}()
+
+					Eventually(func(g Gomega) {
+						var gitOpsProject gitops.GitOpsProject
+						err := k8sClient.Get(ctx, projectKey, &gitOpsProject)
+						g.Expect(err).ToNot(HaveOccurred())
+						g.Expect(gitOpsProject.Status.Revision.ReconcileTime.IsZero()).To(BeFalse())
+					}, duration, assertionInterval).Should(Succeed())
+
+					preserveUnknownFields := true
+					crd := &apiextensionsv1.CustomResourceDefinition{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "widgets.apichange.navecd.io",
+						},
+						Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+							Group: "apichange.navecd.io",
+							Names: apiextensionsv1.CustomResourceDefinitionNames{
+								Plural:   "widgets",
+								Singular: "widget",
+								Kind:     "Widget",
+								ListKind: "WidgetList",
+							},
+							Scope: apiextensionsv1.NamespaceScoped,
+							Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+								{
+									Name:    "v1",
+									Served:  true,
+									Storage: true,
+									Schema: &apiextensionsv1.CustomResourceValidation{
+										OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+											Type:                   "object",
+											XPreserveUnknownFields: &preserveUnknownFields,
+										},
+									},
+								},
+							},
+						},
+					}
+					err = k8sClient.Create(ctx, crd)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(func() error {
+						widget := &unstructured.Unstructured{}
+						widget.SetAPIVersion("apichange.navecd.io/v1")
+						widget.SetKind("Widget")
+						return k8sClient.Get(
+							ctx,
+							types.NamespacedName{Name: "widget", Namespace: "toola"},
+							widget,
+						)
+					}, duration, assertionInterval).Should(Succeed())
+				},
+			)
 		})
 	})
 
@@ -336,7 +706,7 @@ var _ = Describe("GitOpsProject controller", Ordered, func() {
 					"image",
 					false,
 					projectPath,
-					"0.0.99",
+					"0.26.99",
 				)
 				Expect(err).NotTo(HaveOccurred())
 
@@ -0,0 +1,238 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	gitops "github.com/kharf/navecd/api/v1beta1"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/garbage"
+	"github.com/kharf/navecd/pkg/oci"
+	"github.com/kharf/navecd/pkg/project"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestNewReconciler_Concurrency(t *testing.T) {
+	opts := &setupOptions{}
+	Concurrency(4).apply(opts)
+
+	reconciler := newReconciler(logr.Discard(), &rest.Config{}, opts, "navecd", "navecd-system", "primary")
+	assert.Equal(t, reconciler.WorkerPoolSize, 4)
+	assert.Equal(t, reconciler.ProjectManager.WorkerPoolSize(), 4)
+}
+
+func TestNewReconciler_FieldManager(t *testing.T) {
+	opts := &setupOptions{}
+	reconciler := newReconciler(logr.Discard(), &rest.Config{}, opts, "navecd", "navecd-system", "primary")
+	assert.Equal(t, reconciler.FieldManager, "navecd")
+
+	FieldManager("navecd-secondary").apply(opts)
+	reconciler = newReconciler(logr.Discard(), &rest.Config{}, opts, "navecd", "navecd-system", "primary")
+	assert.Equal(t, reconciler.FieldManager, "navecd-secondary")
+}
+
+func TestNewReconciler_LoadRetries(t *testing.T) {
+	opts := &setupOptions{}
+	LoadRetries(5).apply(opts)
+	LoadRetryBackoff(2 * time.Second).apply(opts)
+
+	reconciler := newReconciler(logr.Discard(), &rest.Config{}, opts, "navecd", "navecd-system", "primary")
+	assert.Equal(t, reconciler.LoadRetries, 5)
+	assert.Equal(t, reconciler.LoadRetryBackoff, 2*time.Second)
+}
+
+func TestNewReconciler_RegistryCredentials(t *testing.T) {
+	opts := &setupOptions{}
+	RegistryAuthFile(filepath.Join(t.TempDir(), "missing.json")).apply(opts)
+	reconciler := newReconciler(logr.Discard(), &rest.Config{}, opts, "navecd", "navecd-system", "primary")
+	assert.Assert(t, reconciler.RegistryCredentials == nil)
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	err := os.WriteFile(path, []byte(`{"ghcr.io": {"username": "navecd", "password": "abcd"}}`), 0600)
+	assert.NilError(t, err)
+
+	RegistryAuthFile(path).apply(opts)
+	reconciler = newReconciler(logr.Discard(), &rest.Config{}, opts, "navecd", "navecd-system", "primary")
+	assert.DeepEqual(t, reconciler.RegistryCredentials, oci.RegistryCredentials{
+		"ghcr.io": {Username: "navecd", Password: "abcd"},
+	})
+}
+
+func TestSetup_InvalidRegistryAuthFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	err := os.WriteFile(path, []byte(`{"ghcr.io": {"username": "navecd"}}`), 0600)
+	assert.NilError(t, err)
+
+	_, err = Setup(&rest.Config{}, RegistryAuthFile(path))
+	assert.ErrorIs(t, err, oci.ErrInvalidRegistryCredentials)
+}
+
+func TestSetup_InvalidConcurrency(t *testing.T) {
+	for _, concurrency := range []int{0, -2} {
+		_, err := Setup(&rest.Config{}, Concurrency(concurrency))
+		assert.ErrorIs(t, err, ErrInvalidConcurrency)
+	}
+}
+
+func TestSetup_InvalidProxy(t *testing.T) {
+	for _, proxy := range []string{"proxy:3128", "://proxy"} {
+		_, err := Setup(&rest.Config{}, Proxy(proxy))
+		assert.ErrorIs(t, err, ErrInvalidProxy)
+	}
+}
+
+func TestSetup_InvalidMaxConcurrentReconciles(t *testing.T) {
+	for _, maxConcurrentReconciles := range []int{0, -1} {
+		_, err := Setup(&rest.Config{}, MaxConcurrentReconciles(maxConcurrentReconciles))
+		assert.ErrorIs(t, err, ErrInvalidMaxConcurrentReconciles)
+	}
+}
+
+func TestSetup_InvalidKinds(t *testing.T) {
+	for _, opt := range []option{AllowedKinds{"/Secret"}, DeniedKinds{"rbac.authorization.k8s.io/"}, DeniedKinds{"*/ClusterRole"}} {
+		_, err := Setup(&rest.Config{}, opt)
+		assert.ErrorIs(t, err, component.ErrInvalidKindPattern)
+	}
+}
+
+func TestSetup_InvalidDeletePropagation(t *testing.T) {
+	_, err := Setup(&rest.Config{}, DeletePropagation("foreground"))
+	assert.ErrorIs(t, err, garbage.ErrInvalidDeletePropagation)
+}
+
+func TestGitOpsProjectController_MaxConcurrentReconciles(t *testing.T) {
+	const projects = 6
+	const limit = 2
+
+	var inFlight, maxInFlight, reconciled atomic.Int32
+	options := (&GitOpsProjectController{MaxConcurrentReconciles: limit}).options()
+	skipNameValidation := true
+	options.SkipNameValidation = &skipNameValidation
+	options.Reconciler = reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		current := inFlight.Add(1)
+		for {
+			previous := maxInFlight.Load()
+			if current <= previous || maxInFlight.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		inFlight.Add(-1)
+		reconciled.Add(1)
+		return reconcile.Result{}, nil
+	})
+
+	projectController, err := controller.NewUnmanaged("gitopsproject", options)
+	assert.NilError(t, err)
+
+	events := make(chan event.GenericEvent, projects)
+	err = projectController.Watch(source.Channel(events, &handler.EnqueueRequestForObject{}))
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = projectController.Start(ctx)
+	}()
+
+	for i := range projects {
+		events <- event.GenericEvent{
+			Object: &gitops.GitOpsProject{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      fmt.Sprintf("project-%d", i),
+					Namespace: "navecd-system",
+				},
+			},
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for reconciled.Load() != projects && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, reconciled.Load(), int32(projects))
+	assert.Equal(t, maxInFlight.Load(), int32(limit))
+}
+
+func TestGitOpsProjectController_RetryLoad(t *testing.T) {
+	projectController := &GitOpsProjectController{LoadRetryInterval: 15 * time.Second}
+	requeueResult := ctrl.Result{RequeueAfter: 5 * time.Minute}
+	loadErr := fmt.Errorf("%w: %w", project.ErrLoadProject, &project.RecoverableLoadError{
+		Err:        errors.New("registry unavailable"),
+		BackupPath: "/cache/project-bkp",
+	})
+
+	// projects reconciled from the backup are retried sooner than their pull interval.
+	result := projectController.retryLoad(requeueResult, loadErr)
+	assert.Equal(t, result.RequeueAfter, 15*time.Second)
+
+	result = projectController.retryLoad(requeueResult, nil)
+	assert.Equal(t, result.RequeueAfter, 5*time.Minute)
+
+	result = projectController.retryLoad(requeueResult, errors.New("unrecoverable"))
+	assert.Equal(t, result.RequeueAfter, 5*time.Minute)
+
+	// shorter pull intervals are kept.
+	result = projectController.retryLoad(ctrl.Result{RequeueAfter: 5 * time.Second}, loadErr)
+	assert.Equal(t, result.RequeueAfter, 5*time.Second)
+
+	projectController.LoadRetryInterval = 0
+	result = projectController.retryLoad(requeueResult, loadErr)
+	assert.Equal(t, result.RequeueAfter, 5*time.Minute)
+}
+
+func TestGitOpsProjectController_RetryWaiting(t *testing.T) {
+	projectController := &GitOpsProjectController{WaitRetryInterval: 10 * time.Second}
+	requeueResult := ctrl.Result{RequeueAfter: 5 * time.Minute}
+	waiting := &project.ReconcileResult{
+		ProgressingComponents: []string{"db_default_apps_StatefulSet"},
+		WaitingComponents:     []string{"db_default_apps_StatefulSet"},
+	}
+
+	// projects with waiting components are reconciled again sooner than their pull interval.
+	result := projectController.retryWaiting(requeueResult, waiting)
+	assert.Equal(t, result.RequeueAfter, 10*time.Second)
+
+	result = projectController.retryWaiting(requeueResult, &project.ReconcileResult{
+		ProgressingComponents: []string{"web_default_apps_Deployment"},
+	})
+	assert.Equal(t, result.RequeueAfter, 5*time.Minute)
+
+	// shorter pull intervals are kept.
+	result = projectController.retryWaiting(ctrl.Result{RequeueAfter: 5 * time.Second}, waiting)
+	assert.Equal(t, result.RequeueAfter, 5*time.Second)
+
+	projectController.WaitRetryInterval = 0
+	result = projectController.retryWaiting(requeueResult, waiting)
+	assert.Equal(t, result.RequeueAfter, 5*time.Minute)
+}
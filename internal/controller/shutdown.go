@@ -0,0 +1,111 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var (
+	ErrShutdownTimeout = errors.New("Timed out waiting for in-flight reconciliations")
+)
+
+// drainer coordinates the graceful shutdown of the controller.
+// Once the manager context is cancelled, it stops accepting new reconciliations
+// and waits up to timeout for in-flight reconciliations to finish.
+// In-flight reconciliations are only cancelled after the timeout, so that applies and inventory writes are not cut off mid-write.
+type drainer struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+
+	abortCtx context.Context
+	abort    context.CancelFunc
+}
+
+var _ manager.Runnable = (*drainer)(nil)
+var _ manager.LeaderElectionRunnable = (*drainer)(nil)
+
+func newDrainer(timeout time.Duration) *drainer {
+	abortCtx, abort := context.WithCancel(context.Background())
+	return &drainer{
+		timeout:  timeout,
+		abortCtx: abortCtx,
+		abort:    abort,
+	}
+}
+
+// track registers a reconciliation and returns its context, which is detached from the cancellation of ctx
+// and only cancelled once draining timed out.
+// It returns false, if the controller is shutting down and the reconciliation must not be started.
+func (d *drainer) track(ctx context.Context) (context.Context, func(), bool) {
+	if d == nil {
+		return ctx, func() {}, true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return nil, nil, false
+	}
+	d.inFlight.Add(1)
+
+	reconcileCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(d.abortCtx, cancel)
+
+	return reconcileCtx, func() {
+		stop()
+		cancel()
+		d.inFlight.Done()
+	}, true
+}
+
+// Start blocks until ctx is cancelled and then drains in-flight reconciliations.
+func (d *drainer) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return d.drain()
+}
+
+func (d *drainer) NeedLeaderElection() bool {
+	return false
+}
+
+func (d *drainer) drain() error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(d.timeout):
+		d.abort()
+		<-done
+		return ErrShutdownTimeout
+	}
+}
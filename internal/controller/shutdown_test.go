@@ -0,0 +1,71 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDrainer(t *testing.T) {
+	drainer := newDrainer(5 * time.Second)
+
+	mgrCtx, cancel := context.WithCancel(context.Background())
+	reconcileCtx, done, ok := drainer.track(mgrCtx)
+	assert.Assert(t, ok)
+
+	var finished atomic.Bool
+	go func() {
+		defer done()
+		time.Sleep(200 * time.Millisecond)
+		// the in-flight reconciliation is not cut off by the shutdown.
+		if reconcileCtx.Err() == nil {
+			finished.Store(true)
+		}
+	}()
+
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- drainer.Start(mgrCtx)
+	}()
+
+	cancel()
+	assert.NilError(t, <-startErr)
+	assert.Assert(t, finished.Load())
+
+	_, _, ok = drainer.track(mgrCtx)
+	assert.Assert(t, !ok)
+}
+
+func TestDrainer_Timeout(t *testing.T) {
+	drainer := newDrainer(100 * time.Millisecond)
+
+	mgrCtx, cancel := context.WithCancel(context.Background())
+	reconcileCtx, done, ok := drainer.track(mgrCtx)
+	assert.Assert(t, ok)
+
+	go func() {
+		defer done()
+		<-reconcileCtx.Done()
+	}()
+
+	cancel()
+	assert.ErrorIs(t, drainer.Start(mgrCtx), ErrShutdownTimeout)
+	assert.ErrorIs(t, reconcileCtx.Err(), context.Canceled)
+}
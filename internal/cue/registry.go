@@ -0,0 +1,133 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cue
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"cuelabs.dev/go/oci/ociregistry"
+	"cuelabs.dev/go/oci/ociregistry/ociauth"
+	"cuelabs.dev/go/oci/ociregistry/ociclient"
+	"cuelang.org/go/mod/modcache"
+	"cuelang.org/go/mod/modconfig"
+	"cuelang.org/go/mod/modregistry"
+)
+
+// RegistryCredentials authenticate at a CUE module registry host.
+type RegistryCredentials struct {
+	Username string
+	Password string
+}
+
+// NewRegistry returns a module registry resolving modules with cueRegistry, which has the format of the CUE_REGISTRY environment variable.
+// Credentials are keyed by registry host and take precedence over the CUE and Docker logins of the environment.
+// Fetched modules are cached in the CUE cache directory.
+func NewRegistry(
+	cueRegistry string,
+	credentials map[string]RegistryCredentials,
+	transport http.RoundTripper,
+) (modconfig.Registry, error) {
+	resolver, err := modconfig.NewResolver(&modconfig.Config{
+		CUERegistry: cueRegistry,
+		Transport:   transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cacheDir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return modcache.New(modregistry.NewClientWithResolver(&authResolver{
+		resolver:    resolver,
+		credentials: credentials,
+		transport:   transport,
+		registries:  make(map[string]ociregistry.Interface),
+	}), cacheDir)
+}
+
+// authResolver resolves modules like the CUE resolver, but authenticates with the configured credentials.
+type authResolver struct {
+	resolver    *modconfig.Resolver
+	credentials map[string]RegistryCredentials
+	transport   http.RoundTripper
+
+	mu         sync.Mutex
+	registries map[string]ociregistry.Interface
+}
+
+var _ modregistry.Resolver = (*authResolver)(nil)
+
+func (r *authResolver) ResolveToRegistry(mpath string, version string) (modregistry.RegistryLocation, error) {
+	loc, ok := r.resolver.ResolveToLocation(mpath, version)
+	credentials, found := r.credentials[loc.Host]
+	if !ok || !found {
+		return r.resolver.ResolveToRegistry(mpath, version)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	registry := r.registries[loc.Host]
+	if registry == nil {
+		var err error
+		registry, err = ociclient.New(loc.Host, &ociclient.Options{
+			Insecure: loc.Insecure,
+			Transport: ociauth.NewStdTransport(ociauth.StdTransportParams{
+				Config:    staticAuthConfig(credentials),
+				Transport: r.transport,
+			}),
+		})
+		if err != nil {
+			return modregistry.RegistryLocation{}, fmt.Errorf("cannot make client: %w", err)
+		}
+		r.registries[loc.Host] = registry
+	}
+
+	return modregistry.RegistryLocation{
+		Registry:   registry,
+		Repository: loc.Repository,
+		Tag:        loc.Tag,
+	}, nil
+}
+
+type staticAuthConfig RegistryCredentials
+
+var _ ociauth.Config = staticAuthConfig{}
+
+func (config staticAuthConfig) EntryForRegistry(host string) (ociauth.ConfigEntry, error) {
+	return ociauth.ConfigEntry{
+		Username: config.Username,
+		Password: config.Password,
+	}, nil
+}
+
+// cacheDir mirrors the cache directory resolution of the cue command.
+func cacheDir() (string, error) {
+	if dir := os.Getenv("CUE_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, "cue"), nil
+}
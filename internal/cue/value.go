@@ -20,14 +20,28 @@ import (
 	"strings"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/load"
+	"cuelang.org/go/mod/modconfig"
 )
 
+// Package is a compiled cue package.
+type Package struct {
+	Value cue.Value
+
+	// Warnings are non-fatal findings, like files which were silently excluded from the package.
+	Warnings []string
+}
+
+// BuildPackage compiles the package at packagePath.
+// Module dependencies are fetched from registry, which defaults to the registries configured by the CUE_REGISTRY environment variable.
 func BuildPackage(
 	packagePath string,
 	projectRoot string,
-) (*cue.Value, error) {
+	tagVars map[string]load.TagVar,
+	registry modconfig.Registry,
+) (*Package, error) {
 	harmonizedPackagePath := packagePath
 	currentDirectoryPrefix := "./"
 	if !strings.HasPrefix(packagePath, currentDirectoryPrefix) {
@@ -38,6 +52,8 @@ func BuildPackage(
 		Package:    filepath.Base(harmonizedPackagePath),
 		ModuleRoot: projectRoot,
 		Dir:        projectRoot,
+		TagVars:    tagVars,
+		Registry:   registry,
 	}
 
 	instances := load.Instances([]string{harmonizedPackagePath}, cfg)
@@ -62,5 +78,31 @@ func BuildPackage(
 	if err := value.Validate(); err != nil {
 		return nil, err
 	}
-	return &value, nil
+
+	return &Package{
+		Value:    value,
+		Warnings: ignoredFileWarnings(instance.IgnoredFiles),
+	}, nil
+}
+
+// ignoredFileWarnings reports files, which are part of the package directory but were not built.
+// Hidden, test and tool files are excluded on purpose and therefore not reported.
+func ignoredFileWarnings(files []*build.File) []string {
+	var warnings []string
+	for _, file := range files {
+		base := filepath.Base(file.Filename)
+		if strings.HasPrefix(base, ".") ||
+			strings.HasPrefix(base, "_") ||
+			strings.HasSuffix(base, "_test.cue") ||
+			strings.HasSuffix(base, "_tool.cue") {
+			continue
+		}
+
+		reason := "excluded from build"
+		if file.ExcludeReason != nil {
+			reason = file.ExcludeReason.Error()
+		}
+		warnings = append(warnings, fmt.Sprintf("file %s ignored: %s", file.Filename, reason))
+	}
+	return warnings
 }
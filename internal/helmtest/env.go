@@ -292,6 +292,9 @@ metadata:
   {{- end }}
 {{- end }}
 
+-- test/templates/NOTES.txt --
+Release {{ .Release.Name }} runs chart version {{ .Chart.Version }}.
+
 -- test/templates/tests/test-connection.yaml --
 apiVersion: v1
 kind: Pod
@@ -696,6 +699,9 @@ spec:
         {{- toYaml . | nindent 8 }}
       {{- end }}
 
+-- test/templates/NOTES.txt --
+Release {{ .Release.Name }} runs chart version {{ .Chart.Version }}.
+
 -- test/templates/tests/test-connection.yaml --
 apiVersion: v1
 kind: Pod
@@ -162,7 +162,7 @@ func (client *FakeDynamicClient) Update(
 	return client.Err
 }
 
-func (client *FakeDynamicClient) Delete(ctx context.Context, obj *unstructured.Unstructured) error {
+func (client *FakeDynamicClient) Delete(ctx context.Context, obj *unstructured.Unstructured, opts ...kube.DeleteOption) error {
 	return client.Err
 }
 
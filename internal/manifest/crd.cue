@@ -67,6 +67,21 @@ _crd: {
 								]
 								type: "object"
 							}
+							checkPodSecurity: {
+								description: """
+	This flag tells the controller to evaluate workloads against the PodSecurity level enforced by their namespace
+	and to refuse applying violating workloads with a description of every violation. Defaults to false.
+	"""
+								type: "boolean"
+							}
+							createNamespaces: {
+								description: """
+	This flag tells the controller to create namespaces targeted by components,
+	which are not declared in the project. Created namespaces are garbage collected,
+	once no component targets them anymore. Defaults to false.
+	"""
+								type: "boolean"
+							}
 							dir: {
 								default: "."
 								description: """
@@ -76,17 +91,106 @@ _crd: {
 								minLength: 1
 								type:      "string"
 							}
+							impersonation: {
+								description: """
+	Impersonation defines the identity used to reconcile the project.
+	Its service account name takes precedence over serviceAccountName.
+	"""
+								properties: {
+									extra: {
+										additionalProperties: {
+											items: type: "string"
+											type: "array"
+										}
+										description: "Extra attributes to impersonate, like scopes. Requires a service account name."
+										type:        "object"
+									}
+									groups: {
+										description: "The groups to impersonate. Requires a service account name."
+										items: type: "string"
+										type: "array"
+									}
+									serviceAccountName: {
+										description: "The service account in the namespace of the GitOpsProject to impersonate."
+										type:        "string"
+									}
+								}
+								type: "object"
+							}
+							imagePullSecrets: {
+								description: """
+	The names of pull secrets, which are added to the imagePullSecrets of all pod-spec-bearing manifests.
+	The secrets have to exist in the namespaces of the workloads. Declared pull secrets are kept.
+	"""
+								items: type: "string"
+								type: "array"
+							}
+							imageRegistryPrefix: {
+								description: """
+	The registry, which replaces the registry host of all container images in pod-spec-bearing manifests.
+	It may contain a path, e.g. mirror.example.com/dockerhub. Images without a registry host are prefixed.
+	The inventory keeps the declared images.
+	"""
+								type: "string"
+							}
+							orderPolicies: {
+								description: """
+	This flag tells the controller to apply ResourceQuotas, LimitRanges and NetworkPolicies
+	before all other components of their namespace, even without declared dependencies. Defaults to false.
+	"""
+								type: "boolean"
+							}
+							ownerReferences: {
+								description: """
+	This flag tells the controller to set the GitOpsProject as owner of all applied manifests, so that Kubernetes
+	deletes them together with the GitOpsProject. Cluster-scoped objects and objects of other namespaces cannot reference it
+	and are labeled with navecd.io/owner-name and navecd.io/owner-namespace instead, which the controller deletes
+	before it removes its finalizer from the deleted GitOpsProject. Defaults to false.
+	"""
+								type: "boolean"
+							}
 							pullIntervalSeconds: {
 								description: "This defines how often navecd will try to fetch changes from the gitops repository."
 								minimum:     5
 								type:        "integer"
 							}
+							reconcileOnAPIChange: {
+								description: """
+	This flag tells the controller to reconcile the project, whenever a CRD of an API group
+	used or required by its components is created or changed. Defaults to false.
+	"""
+								type: "boolean"
+							}
 							ref: {
-								description: "The reference to the gitops repository containing navecd configuration."
-								minLength:   1
-								type:        "string"
+								description: """
+	The reference to the gitops repository containing navecd configuration.
+	It is either a tag or a semver constraint like ">=1.0, <2.0", which resolves to the highest matching tag.
+	"""
+								minLength: 1
+								type:      "string"
+							}
+							requireImmutableTags: {
+								description: """
+	This flag tells the controller to refuse reconciling an artifact, whose tag points to
+	a different digest than at the last reconciliation. Defaults to false.
+	"""
+								type: "boolean"
 							}
 							serviceAccountName: type: "string"
+							sourceAnnotations: {
+								description: """
+	This flag tells the controller to annotate applied manifests with navecd.io/source,
+	pointing to the file and line declaring their component. Defaults to false.
+	"""
+								type: "boolean"
+							}
+							strictComponents: {
+								description: """
+	This flag tells the controller to refuse loading components with fields,
+	which are not defined by the schema, like misspelled ones. Defaults to false.
+	"""
+								type: "boolean"
+							}
 							suspend: {
 								description: """
 	This flag tells the controller to suspend subsequent executions, it does
@@ -181,16 +285,87 @@ _crd: {
 								}
 								type: "array"
 							}
+							health: {
+								description: "Health is computed from the reconcile errors and the readiness of all components after each reconciliation."
+								enum: [
+									"Healthy",
+									"Degraded",
+									"Progressing",
+								]
+								type: "string"
+							}
+							lastReconcile: {
+								description: "LastReconcile summarizes the changes of the last reconciliation."
+								properties: changedObjects: {
+									description: """
+	ChangedObjects is the number of components, which were changed by the last reconciliation.
+	Components are compared to the state applied by the previous reconciliation.
+	"""
+									type: "integer"
+								}
+								type: "object"
+							}
+							releases: {
+								description: "Releases lists the state of all Helm releases reconciled by the last reconciliation."
+								items: {
+									description: "GitOpsProjectReleaseStatus is the state of a reconciled Helm release."
+									properties: {
+										id: {
+											description: "ID of the Helm release component."
+											type:        "string"
+										}
+										name: type:      "string"
+										namespace: type: "string"
+										notes: {
+											description: "Notes are the rendered NOTES.txt of the chart, truncated to 1024 bytes."
+											type:        "string"
+										}
+										revision: {
+											description: "Revision of the release. It is incremented by every upgrade."
+											type:        "integer"
+										}
+									}
+									required: [
+										"id",
+										"name",
+										"namespace",
+										"revision",
+									]
+									type: "object"
+								}
+								type: "array"
+							}
 							revision: {
 								properties: {
+									buildTime: {
+										description: "BuildTime is the time the artifact was built in RFC 3339 format."
+										type:        "string"
+									}
 									digest: type: "string"
 									reconcileTime: {
 										format: "date-time"
 										type:   "string"
 									}
+									registry: {
+										description: "Registry is the repository the artifact was loaded from, after mirrors were applied."
+										type:        "string"
+									}
+									sourceRevision: {
+										description: "SourceRevision is the source control revision the artifact was built from, e.g. a commit sha."
+										type:        "string"
+									}
+									tag: {
+										description: "Tag the reference resolved to. It differs from the reference only for semver constraints."
+										type:        "string"
+									}
 								}
 								type: "object"
 							}
+							suspendedComponents: {
+								description: "SuspendedComponents lists the ids of components, which are not applied because of the suspend attribute."
+								items: type: "string"
+								type: "array"
+							}
 						}
 						type: "object"
 					}
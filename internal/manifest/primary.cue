@@ -20,7 +20,7 @@ ns: component.#Manifest & {
 		apiVersion: "v1"
 		kind:       "Namespace"
 		metadata: {
-			name:   "navecd-system"
+			name:   *"navecd-system" | string @tag(controllerNamespace, var=controllerNamespace)
 			labels: _{{.Shard}}Labels
 		}
 	}
@@ -191,6 +191,13 @@ _{{.Shard}}LeaderRoleName: "{{.Shard}}-leader-election"
 							name: "cache"
 							emptyDir: {}
 						},
+						{
+							name: "registry-auth"
+							secret: {
+								secretName: "{{.Name}}-registry-auth"
+								optional:   true
+							}
+						},
 					]
 					containers: [
 						{
@@ -246,6 +253,11 @@ _{{.Shard}}LeaderRoleName: "{{.Shard}}-leader-election"
 									name:      "cache"
 									mountPath: "/.cache"
 								},
+								{
+									name:      "registry-auth"
+									mountPath: "/registry-auth"
+									readOnly:  true
+								},
 							]
 						},
 					]
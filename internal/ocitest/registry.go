@@ -361,6 +361,7 @@ func NewTLSRegistry(private bool, cloudProviderID cloud.ProviderID) (*Registry,
 type options struct {
 	private         bool
 	cloudProviderID cloud.ProviderID
+	schemaVersions  []string
 }
 
 type private bool
@@ -391,10 +392,24 @@ func WithProvider(providerID cloud.ProviderID) provider {
 	return provider(providerID)
 }
 
+type schemaVersions []string
+
+var _ Option = (*schemaVersions)(nil)
+
+func (opt schemaVersions) Apply(opts *options) {
+	opts.schemaVersions = opt
+}
+
+// WithSchemaVersions pushes the navecd schema with each of the given versions instead of v0.26.99.
+func WithSchemaVersions(versions ...string) schemaVersions {
+	return schemaVersions(versions)
+}
+
 func NewTLSRegistryWithSchema(opts ...Option) (*Registry, error) {
 	options := &options{
 		private:         false,
 		cloudProviderID: "",
+		schemaVersions:  []string{"v0.26.99"},
 	}
 	for _, o := range opts {
 		o.Apply(options)
@@ -415,14 +430,16 @@ func NewTLSRegistryWithSchema(opts ...Option) (*Registry, error) {
 	schemaSrc := "schema"
 
 	ctx := context.Background()
-	m, err := module.NewVersion("github.com/kharf/navecd/schema", "v0.0.99")
-	if err != nil {
-		return nil, err
-	}
+	for _, version := range options.schemaVersions {
+		m, err := module.NewVersion("github.com/kharf/navecd/schema", version)
+		if err != nil {
+			return nil, err
+		}
 
-	err = registry.PushModuleFromPath(ctx, m, schemaSrc)
-	if err != nil {
-		return nil, err
+		err = registry.PushModuleFromPath(ctx, m, schemaSrc)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return registry, nil
@@ -15,8 +15,27 @@ var _ project.RemoteLoader = (*FakeRemoteLoader)(nil)
 
 func (f *FakeRemoteLoader) Load(ctx context.Context, targetDir string, auth *cloud.Auth) (project.Digest, error) {
 	if f.Err != nil {
-		return "", f.Err
+		return project.Digest{}, f.Err
 	}
 
-	return project.Digest(f.Digest), nil
+	return project.Digest{Artifact: f.Digest}, nil
+}
+
+// FlakyRemoteLoader fails with Err for the first Failures calls and succeeds afterwards.
+type FlakyRemoteLoader struct {
+	Err      error
+	Failures int
+	Digest   string
+	Calls    int
+}
+
+var _ project.RemoteLoader = (*FlakyRemoteLoader)(nil)
+
+func (f *FlakyRemoteLoader) Load(ctx context.Context, targetDir string, auth *cloud.Auth) (project.Digest, error) {
+	f.Calls++
+	if f.Calls <= f.Failures {
+		return project.Digest{}, f.Err
+	}
+
+	return project.Digest{Artifact: f.Digest}, nil
 }
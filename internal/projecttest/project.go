@@ -16,6 +16,7 @@ package projecttest
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -112,6 +113,7 @@ func (env *Environment) PushProject(t testing.TB, projectName string, tag string
 	}
 
 	_, err = projectClient.PushImageFromPath(
+		context.Background(),
 		tag,
 		tmpDir,
 		oci.WithRepositoryOption(
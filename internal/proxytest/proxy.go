@@ -0,0 +1,100 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxytest
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+)
+
+// Proxy is a stub forward proxy, which tunnels https traffic and records the requested hosts.
+type Proxy struct {
+	server *httptest.Server
+
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (proxy *Proxy) Close() {
+	proxy.server.Close()
+}
+
+// URL of the proxy in the format http://ipaddr:port.
+func (proxy *Proxy) URL() *url.URL {
+	proxyURL, _ := url.Parse(proxy.server.URL)
+	return proxyURL
+}
+
+// Hosts returns all hosts requested through the proxy.
+func (proxy *Proxy) Hosts() []string {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	hosts := make([]string, len(proxy.hosts))
+	copy(hosts, proxy.hosts)
+	return hosts
+}
+
+func NewProxy() *Proxy {
+	proxy := &Proxy{}
+	proxy.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy.mu.Lock()
+		proxy.hosts = append(proxy.hosts, r.Host)
+		proxy.mu.Unlock()
+
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			target.Close()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		client, _, err := hijacker.Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+
+		if _, err := client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+			target.Close()
+			client.Close()
+			return
+		}
+
+		go tunnel(target, client)
+		go tunnel(client, target)
+	}))
+	return proxy
+}
+
+func tunnel(dst io.WriteCloser, src io.ReadCloser) {
+	defer dst.Close()
+	defer src.Close()
+	_, _ = io.Copy(dst, src)
+}
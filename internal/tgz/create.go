@@ -3,22 +3,49 @@ package tgz
 import (
 	"archive/tar"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm used to compress a tar archive.
+type Compression string
+
+const (
+	Gzip Compression = "gzip"
+	Zstd Compression = "zstd"
 )
 
 func Create(sourceDir string, targetArchiveFilePath string) error {
+	return CreateCompressed(sourceDir, targetArchiveFilePath, Gzip)
+}
+
+// CreateCompressed archives sourceDir into a tar archive compressed with the given algorithm.
+func CreateCompressed(sourceDir string, targetArchiveFilePath string, compression Compression) error {
 	archive, err := os.Create(targetArchiveFilePath)
 	if err != nil {
 		return err
 	}
 	defer archive.Close()
 
-	gzipWriter := gzip.NewWriter(archive)
-	defer gzipWriter.Close()
+	var compressedWriter io.WriteCloser
+	switch compression {
+	case Gzip:
+		compressedWriter = gzip.NewWriter(archive)
+	case Zstd:
+		compressedWriter, err = zstd.NewWriter(archive)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported compression %s", compression)
+	}
+	defer compressedWriter.Close()
 
-	tarWriter := tar.NewWriter(gzipWriter)
+	tarWriter := tar.NewWriter(compressedWriter)
 	defer tarWriter.Close()
 
 	return filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
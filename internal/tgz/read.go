@@ -3,25 +3,85 @@ package tgz
 import (
 	"archive/tar"
 	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	ErrSizeLimitExceeded = errors.New("Archive exceeds the maximum size")
+	ErrIllegalPath       = errors.New("Archive entry escapes the target directory")
 )
 
+type readOptions struct {
+	maxBytes int64
+}
+
+type ReadOption func(opts *readOptions)
+
+// WithMaxBytes aborts the extraction, once more than maxBytes have been extracted.
+// Zero or less means no limit.
+func WithMaxBytes(maxBytes int64) ReadOption {
+	return func(opts *readOptions) {
+		opts.maxBytes = maxBytes
+	}
+}
+
 func Read(archiveFilePath string, targetDir string) error {
+	return ReadCompressed(archiveFilePath, targetDir, Gzip)
+}
+
+// ReadCompressed extracts a tar archive compressed with the given algorithm into targetDir.
+func ReadCompressed(archiveFilePath string, targetDir string, compression Compression) error {
+	return ReadSubpath(archiveFilePath, targetDir, compression, "")
+}
+
+// ReadSubpath extracts only the files below subpath of a tar archive compressed with the given algorithm into targetDir.
+// The subpath prefix is stripped, so that its content ends up directly in targetDir.
+// An empty subpath extracts the whole archive.
+// Entries, which would be extracted outside of targetDir, are rejected with ErrIllegalPath.
+func ReadSubpath(archiveFilePath string, targetDir string, compression Compression, subpath string, opts ...ReadOption) error {
+	options := &readOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	subpath = strings.Trim(path.Clean("/"+filepath.ToSlash(subpath)), "/")
+
 	archiveFile, err := os.Open(archiveFilePath)
 	if err != nil {
 		return err
 	}
 	defer archiveFile.Close()
 
-	zipReader, err := gzip.NewReader(archiveFile)
-	if err != nil {
-		return err
+	var decompressedReader io.Reader
+	switch compression {
+	case Gzip:
+		zipReader, err := gzip.NewReader(archiveFile)
+		if err != nil {
+			return err
+		}
+		defer zipReader.Close()
+		decompressedReader = zipReader
+	case Zstd:
+		zstdReader, err := zstd.NewReader(archiveFile)
+		if err != nil {
+			return err
+		}
+		defer zstdReader.Close()
+		decompressedReader = zstdReader
+	default:
+		return fmt.Errorf("unsupported compression %s", compression)
 	}
-	defer zipReader.Close()
-	tarReader := tar.NewReader(zipReader)
+	tarReader := tar.NewReader(decompressedReader)
 
+	var extracted int64
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
@@ -33,20 +93,42 @@ func Read(archiveFilePath string, targetDir string) error {
 		}
 
 		if header.Typeflag == tar.TypeReg {
-			if err := os.MkdirAll(filepath.Dir(filepath.Join(targetDir, header.Name)), 0700); err != nil {
+			name := strings.TrimPrefix(path.Clean(header.Name), "./")
+			if subpath != "" {
+				if !strings.HasPrefix(name, subpath+"/") {
+					continue
+				}
+				name = strings.TrimPrefix(name, subpath+"/")
+			}
+			if !filepath.IsLocal(filepath.FromSlash(name)) {
+				return fmt.Errorf("%w: %s", ErrIllegalPath, header.Name)
+			}
+
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(targetDir, name)), 0700); err != nil {
 				return err
 			}
 
-			dst, err := os.Create(filepath.Join(targetDir, header.Name))
+			dst, err := os.Create(filepath.Join(targetDir, name))
 			if err != nil {
 				return err
 			}
 			defer dst.Close()
 
-			_, err = io.Copy(dst, tarReader)
+			var reader io.Reader = tarReader
+			if options.maxBytes > 0 {
+				// one more byte than allowed reveals an exceeded limit, regardless of the declared header size.
+				reader = io.LimitReader(tarReader, options.maxBytes-extracted+1)
+			}
+
+			written, err := io.Copy(dst, reader)
 			if err != nil {
 				return err
 			}
+
+			extracted += written
+			if options.maxBytes > 0 && extracted > options.maxBytes {
+				return fmt.Errorf("%w: more than %d bytes", ErrSizeLimitExceeded, options.maxBytes)
+			}
 		}
 	}
 
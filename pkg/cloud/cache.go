@@ -0,0 +1,159 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// credentialKey identifies credentials fetched from a cloud provider.
+type credentialKey struct {
+	provider  ProviderID
+	host      string
+	namespace string
+}
+
+func (key credentialKey) String() string {
+	return string(key.provider) + "|" + key.host + "|" + key.namespace
+}
+
+type credentialEntry struct {
+	key         credentialKey
+	credentials Credentials
+	fetchedAt   time.Time
+}
+
+// CredentialCache shares credentials fetched through workload identity across reconciliations and chart pulls,
+// so that cloud providers are not asked for a new token on every resolution.
+// It holds at most size entries and evicts the least recently used one, when full.
+// Entries are fetched again after the TTL, which has to be shorter than the validity of the provider tokens.
+// Concurrent resolutions of the same key share a single fetch.
+// It is safe for concurrent use.
+type CredentialCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[credentialKey]*list.Element
+	lru     *list.List
+
+	fetches singleflight.Group
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCredentialCache constructs an empty [CredentialCache] holding at most size entries for the given TTL.
+func NewCredentialCache(size int, ttl time.Duration) *CredentialCache {
+	return &CredentialCache{
+		size:    max(size, 1),
+		ttl:     ttl,
+		entries: make(map[credentialKey]*list.Element, size),
+		lru:     list.New(),
+	}
+}
+
+// Hits returns the number of resolutions answered by the cache.
+func (cache *CredentialCache) Hits() uint64 {
+	return cache.hits.Load()
+}
+
+// Misses returns the number of resolutions, which had to fetch credentials or wait for a concurrent fetch.
+func (cache *CredentialCache) Misses() uint64 {
+	return cache.misses.Load()
+}
+
+// get returns the cached credentials for key or fetches and stores them.
+// Failed fetches are not cached.
+func (cache *CredentialCache) get(
+	ctx context.Context,
+	key credentialKey,
+	fetch func(context.Context) (*Credentials, error),
+) (*Credentials, error) {
+	if credentials, found := cache.lookup(key); found {
+		cache.hits.Add(1)
+		return credentials, nil
+	}
+	cache.misses.Add(1)
+
+	result, err, _ := cache.fetches.Do(key.String(), func() (any, error) {
+		// a concurrent fetch may have finished in the meantime.
+		if credentials, found := cache.lookup(key); found {
+			return credentials, nil
+		}
+
+		credentials, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cache.store(key, *credentials)
+		return credentials, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := *result.(*Credentials)
+	return &credentials, nil
+}
+
+func (cache *CredentialCache) lookup(key credentialKey) (*Credentials, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, found := cache.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	entry := element.Value.(*credentialEntry)
+	if time.Since(entry.fetchedAt) >= cache.ttl {
+		cache.lru.Remove(element)
+		delete(cache.entries, key)
+		return nil, false
+	}
+
+	cache.lru.MoveToFront(element)
+	credentials := entry.credentials
+	return &credentials, true
+}
+
+func (cache *CredentialCache) store(key credentialKey, credentials Credentials) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, found := cache.entries[key]; found {
+		cache.lru.Remove(element)
+		delete(cache.entries, key)
+	}
+
+	for cache.lru.Len() >= cache.size {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*credentialEntry).key)
+	}
+
+	cache.entries[key] = cache.lru.PushFront(&credentialEntry{
+		key:         key,
+		credentials: credentials,
+		fetchedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kharf/navecd/pkg/cloud"
+	"gotest.tools/v3/assert"
+)
+
+// metadataServer issues a new GCP access token on every request and counts the requests.
+type metadataServer struct {
+	*httptest.Server
+	requests atomic.Int64
+}
+
+func newMetadataServer() *metadataServer {
+	server := &metadataServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := server.requests.Add(1)
+		// slow responses let concurrent resolutions overlap.
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600,"token_type":"Bearer"}`, request)
+	}))
+	return server
+}
+
+func readGCPCredentials(
+	server *metadataServer,
+	cache *cloud.CredentialCache,
+	host string,
+	namespace string,
+) (*cloud.Credentials, error) {
+	return cloud.ReadCredentials(
+		context.Background(),
+		host,
+		cloud.Auth{WorkloadIdentity: &cloud.WorkloadIdentity{Provider: cloud.GCP}},
+		nil,
+		cloud.WithNamespace(namespace),
+		cloud.WithCustomGCPMetadataServerURL(server.URL),
+		cloud.WithCredentialCache(cache),
+	)
+}
+
+func TestCredentialCache_Concurrent(t *testing.T) {
+	server := newMetadataServer()
+	defer server.Close()
+
+	cache := cloud.NewCredentialCache(8, time.Hour)
+
+	var wg sync.WaitGroup
+	passwords := make([]string, 20)
+	errs := make([]error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// project and chart pulls address the same registry with and without scheme.
+			host := "europe-west3-docker.pkg.dev/project/repo"
+			if i%2 == 0 {
+				host = "oci://europe-west3-docker.pkg.dev/project/charts"
+			}
+			credentials, err := readGCPCredentials(server, cache, host, "navecd-system")
+			errs[i] = err
+			if err == nil {
+				passwords[i] = credentials.Password
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 20 {
+		assert.NilError(t, errs[i])
+		assert.Equal(t, passwords[i], "token-1")
+	}
+	assert.Equal(t, server.requests.Load(), int64(1))
+	assert.Equal(t, cache.Hits()+cache.Misses(), uint64(20))
+
+	credentials, err := readGCPCredentials(server, cache, "europe-west3-docker.pkg.dev", "navecd-system")
+	assert.NilError(t, err)
+	assert.Equal(t, credentials.Password, "token-1")
+	assert.Equal(t, server.requests.Load(), int64(1))
+
+	// credentials are scoped to the namespace.
+	credentials, err = readGCPCredentials(server, cache, "europe-west3-docker.pkg.dev", "other")
+	assert.NilError(t, err)
+	assert.Equal(t, credentials.Password, "token-2")
+	assert.Equal(t, server.requests.Load(), int64(2))
+}
+
+func TestCredentialCache_TTL(t *testing.T) {
+	server := newMetadataServer()
+	defer server.Close()
+
+	cache := cloud.NewCredentialCache(8, 100*time.Millisecond)
+
+	credentials, err := readGCPCredentials(server, cache, "europe-west3-docker.pkg.dev", "navecd-system")
+	assert.NilError(t, err)
+	assert.Equal(t, credentials.Password, "token-1")
+
+	time.Sleep(150 * time.Millisecond)
+	credentials, err = readGCPCredentials(server, cache, "europe-west3-docker.pkg.dev", "navecd-system")
+	assert.NilError(t, err)
+	assert.Equal(t, credentials.Password, "token-2")
+	assert.Equal(t, cache.Hits(), uint64(0))
+	assert.Equal(t, cache.Misses(), uint64(2))
+}
+
+func TestCredentialCache_Eviction(t *testing.T) {
+	server := newMetadataServer()
+	defer server.Close()
+
+	cache := cloud.NewCredentialCache(2, time.Hour)
+
+	for _, host := range []string{"a.pkg.dev", "b.pkg.dev", "a.pkg.dev", "c.pkg.dev", "a.pkg.dev", "b.pkg.dev"} {
+		_, err := readGCPCredentials(server, cache, host, "navecd-system")
+		assert.NilError(t, err)
+	}
+
+	// b is the least recently used entry, when c is stored.
+	assert.Equal(t, server.requests.Load(), int64(4))
+	assert.Equal(t, cache.Hits(), uint64(2))
+	assert.Equal(t, cache.Misses(), uint64(4))
+}
@@ -108,6 +108,7 @@ type options struct {
 	namespace            string
 	azureLoginURL        string
 	gcpMetadataServerURL string
+	credentialCache      *CredentialCache
 }
 
 type option func(*options)
@@ -136,6 +137,14 @@ func WithCustomGCPMetadataServerURL(url string) option {
 	}
 }
 
+// WithCredentialCache shares credentials fetched through workload identity via the given cache.
+// Credentials read from secrets are never cached.
+func WithCredentialCache(cache *CredentialCache) option {
+	return func(o *options) {
+		o.credentialCache = cache
+	}
+}
+
 func ReadCredentials(
 	ctx context.Context,
 	host string,
@@ -175,7 +184,19 @@ func ReadCredentials(
 			options.gcpMetadataServerURL,
 		)
 
-		return provider.FetchCredentials(ctx)
+		if options.credentialCache == nil {
+			return provider.FetchCredentials(ctx)
+		}
+
+		return options.credentialCache.get(
+			ctx,
+			credentialKey{
+				provider:  auth.WorkloadIdentity.Provider,
+				host:      providerURL.Host,
+				namespace: options.namespace,
+			},
+			provider.FetchCredentials,
+		)
 	}
 
 	if auth.SecretRef == nil {
@@ -17,32 +17,77 @@ package component
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	cueErrors "cuelang.org/go/cue/errors"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/mod/modconfig"
 	internalCue "github.com/kharf/navecd/internal/cue"
 	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/helm"
 	"github.com/kharf/navecd/pkg/kube"
+	componentschema "github.com/kharf/navecd/schema/component"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type Manifest = kube.Manifest
+type Patch = kube.Patch
 type ExtendedUnstructured = kube.ExtendedUnstructured
 type FieldMetadata = kube.ManifestFieldMetadata
 
 var (
-	ErrMissingField    = errors.New("Missing content field")
-	ErrEmptyFieldLabel = errors.New("Unexpected empty field label")
-	ErrCUEBuildError   = errors.New("CUE Build Error")
+	ErrMissingField       = errors.New("Missing content field")
+	ErrEmptyFieldLabel    = errors.New("Unexpected empty field label")
+	ErrCUEBuildError      = errors.New("CUE Build Error")
+	ErrInvalidRequiredAPI = errors.New("Invalid required API")
+	ErrUnknownAttribute   = errors.New("Unknown build attribute")
+	ErrGenerateName       = errors.New("Unsupported generateName")
+	ErrUnknownField       = errors.New("Unknown component field")
+	ErrInvalidSuspend     = errors.New("Invalid suspend attribute")
 )
 
 const (
 	// ignoreAttr is a CUE build attribute a user can define on a field or declaration
 	// to tell Navecd to ignore fields or structs when applying Kubernetes Manifests.
 	ignoreAttr = "ignore"
+
+	// preserveAttr is a CUE build attribute a user can define on a field or declaration
+	// to tell Navecd to keep the live value of fields owned by other controllers, like replicas managed by HPAs.
+	preserveAttr = "preserve"
+
+	// waveAttr is a CUE build attribute a user can define on a component declaration
+	// to group components into sync waves, which are applied in ascending order.
+	// Components without this attribute belong to wave 0.
+	waveAttr = "wave"
+
+	// suspendAttr is a CUE build attribute a user can define on a component declaration
+	// to tell Navecd to stop applying the component, while keeping it in the inventory.
+	suspendAttr = "suspend"
+
+	// requiresAttr is a CUE build attribute a user can define on a component declaration
+	// to only apply the component, if all given APIs in the format group/version/Kind are served by the cluster.
+	// Core APIs omit the group, e.g. v1/ConfigMap.
+	requiresAttr = "requires"
+
+	// keepAttr is a CUE build attribute a user can define on a manifest component declaration
+	// to tell Navecd to keep the object in the cluster, when the component is removed from the project.
+	keepAttr = "keep"
+
+	// waitForAttr is a CUE build attribute a user can define on a manifest or patch component declaration
+	// to tell Navecd to wait until a field of the live object has the given value, before applying dependents.
+	waitForAttr = "waitFor"
+
+	// hookAttr is a CUE build attribute a user can define on a Job manifest component declaration
+	// to run the Job before or after another component. See [JobHook].
+	hookAttr = "hook"
 )
 
 // Builder compiles and decodes CUE kubernetes manifest definitions of a component to the corresponding Go struct.
@@ -56,8 +101,12 @@ func NewBuilder() Builder {
 
 // buildOptions defining which package is compiled and how it is done.
 type buildOptions struct {
-	packagePath string
-	projectRoot string
+	packagePath   string
+	projectRoot   string
+	componentName string
+	tagVars       map[string]load.TagVar
+	registry      modconfig.Registry
+	strict        bool
 }
 
 type buildOption = func(opts *buildOptions)
@@ -76,12 +125,104 @@ func WithProjectRoot(projectRootPath string) buildOption {
 	}
 }
 
+// WithComponentName restricts the build result to the component with the given id.
+// Building errors if the package does not contain the component.
+func WithComponentName(id string) buildOption {
+	return func(opts *buildOptions) {
+		opts.componentName = id
+	}
+}
+
+// WithTagVars provides values for fields declaring a tag variable, like @tag(name, var=clusterName).
+// Fields referencing unknown variables fail the build.
+func WithTagVars(tagVars map[string]load.TagVar) buildOption {
+	return func(opts *buildOptions) {
+		opts.tagVars = tagVars
+	}
+}
+
+// WithRegistry fetches CUE module dependencies from the given registry.
+// Defaults to the registries configured by the CUE_REGISTRY environment variable.
+func WithRegistry(registry modconfig.Registry) buildOption {
+	return func(opts *buildOptions) {
+		opts.registry = registry
+	}
+}
+
+// WithStrict fails the build on component fields, which are not defined by the schema of their type,
+// like a misspelled dependancies field, which would be ignored otherwise.
+func WithStrict(enabled bool) buildOption {
+	return func(opts *buildOptions) {
+		opts.strict = enabled
+	}
+}
+
 const (
 	ProjectRootPath = "."
 )
 
+// componentDefinitions are the schema definitions of the component types.
+// The fields of all definitions of a type are allowed on a component of that type.
+var componentDefinitions = []string{"#Manifest", "#RawManifest", "#Patch", "#HelmRelease"}
+
+// componentFields returns the fields defined by the schema for each component type.
+var componentFields = sync.OnceValues(func() (map[string][]string, error) {
+	ctx := cuecontext.New()
+	schemaValue := ctx.CompileString(componentschema.Schema)
+	if err := schemaValue.Err(); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string][]string, len(componentDefinitions))
+	for _, definition := range componentDefinitions {
+		definitionValue := schemaValue.LookupPath(cue.ParsePath(definition))
+		instanceType, err := definitionValue.LookupPath(cue.ParsePath("type")).String()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", definition, err)
+		}
+
+		iter, err := definitionValue.Fields(cue.Optional(true))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", definition, err)
+		}
+		for iter.Next() {
+			field := iter.Selector().Unquoted()
+			if !slices.Contains(fields[instanceType], field) {
+				fields[instanceType] = append(fields[instanceType], field)
+			}
+		}
+	}
+
+	return fields, nil
+})
+
 type BuildResult struct {
 	Instances []Instance
+
+	// Warnings are non-fatal findings of the build, which do not prevent the package from being compiled.
+	Warnings []string
+
+	// Waves maps ids of components declaring a sync wave to their wave.
+	Waves map[string]int
+
+	// Suspended holds the ids of components, which are not applied until the suspend attribute is removed.
+	Suspended []string
+
+	// Keep holds the ids of manifests, whose objects are kept in the cluster, when they are removed from the project.
+	Keep []string
+
+	// Requires maps ids of components to the APIs they require.
+	Requires map[string][]string
+
+	// WaitFor maps ids of components to the condition their dependents wait for.
+	WaitFor map[string]WaitCondition
+
+	// Hooks maps ids of Job manifests to the component they run for.
+	Hooks map[string]JobHook
+
+	// Sources maps ids of components to the position of their declaration in the format file:line.
+	// Files are relative to the project root.
+	Sources map[string]string
 }
 
 // Build accepts options defining which cue package to compile
@@ -95,20 +236,29 @@ func (b Builder) Build(opts ...buildOption) (*BuildResult, error) {
 		opt(options)
 	}
 
-	value, err := internalCue.BuildPackage(
+	pkg, err := internalCue.BuildPackage(
 		options.packagePath,
 		options.projectRoot,
+		options.tagVars,
+		options.registry,
 	)
 	if err != nil {
 		return nil, buildError(err)
 	}
 
-	iter, err := value.Fields()
+	iter, err := pkg.Value.Fields()
 	if err != nil {
 		return nil, buildError(err)
 	}
 
 	var instances []Instance
+	waves := make(map[string]int)
+	var suspended []string
+	var keep []string
+	requires := make(map[string][]string)
+	waitFor := make(map[string]WaitCondition)
+	hooks := make(map[string]JobHook)
+	sources := make(map[string]string)
 
 	for iter.Next() {
 		componentValue := iter.Value()
@@ -123,11 +273,58 @@ func (b Builder) Build(opts ...buildOption) (*BuildResult, error) {
 			return nil, buildError(err)
 		}
 
+		// the embedded objects of raw manifests are filtered by their own ids.
+		raw := instanceType == "Manifest" && componentValue.LookupPath(cue.ParsePath("content")).Kind() == cue.StringKind
+		if options.componentName != "" && id != options.componentName && !raw {
+			continue
+		}
+
+		if options.strict {
+			if err := validateFields(componentValue, instanceType); err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrCUEBuildError, id, err)
+			}
+		}
+
 		dependencies, err := getStringSliceValue(componentValue, "dependencies")
 		if err != nil {
 			return nil, buildError(err)
 		}
 
+		attributes, err := decodeComponentAttributes(componentValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+
+		if attributes.wave != nil {
+			waves[id] = *attributes.wave
+		}
+
+		if attributes.suspended {
+			suspended = append(suspended, id)
+		}
+
+		if len(attributes.requires) != 0 {
+			requires[id] = attributes.requires
+		}
+
+		if attributes.waitFor != nil {
+			if instanceType == "HelmRelease" {
+				return nil, fmt.Errorf("%s: %w: Helm releases cannot wait for a field", id, ErrInvalidWaitCondition)
+			}
+			waitFor[id] = *attributes.waitFor
+		}
+
+		if attributes.hook != nil {
+			if instanceType != "Manifest" {
+				return nil, fmt.Errorf("%s: %w: only Job manifests can be hooks", id, ErrInvalidHook)
+			}
+			hooks[id] = *attributes.hook
+		}
+
+		if source := sourcePosition(componentValue, options.projectRoot); source != "" {
+			sources[id] = source
+		}
+
 		switch instanceType {
 		case "Manifest":
 			contentValue, err := getValue(componentValue, "content")
@@ -135,6 +332,43 @@ func (b Builder) Build(opts ...buildOption) (*BuildResult, error) {
 				return nil, buildError(err)
 			}
 
+			if raw {
+				content, err := contentValue.String()
+				if err != nil {
+					return nil, buildError(err)
+				}
+
+				manifests, err := decodeRawManifests(id, content, dependencies, options.componentName)
+				if err != nil {
+					return nil, err
+				}
+
+				// attributes of a raw manifest apply to all of its objects.
+				ids := make([]string, 0, len(manifests))
+				for _, manifest := range manifests {
+					ids = append(ids, manifest.ID)
+				}
+				rekey(waves, id, ids)
+				rekey(requires, id, ids)
+				rekey(waitFor, id, ids)
+				rekey(hooks, id, ids)
+				rekey(sources, id, ids)
+				if index := slices.Index(suspended, id); index != -1 {
+					suspended = slices.Replace(suspended, index, index+1, ids...)
+				}
+
+				for _, manifest := range manifests {
+					if _, isHook := hooks[manifest.ID]; isHook && !isJob(manifest.Content.Unstructured) {
+						return nil, fmt.Errorf("%s: %w: only Job manifests can be hooks", manifest.ID, ErrInvalidHook)
+					}
+					if attributes.keep {
+						keep = append(keep, manifest.ID)
+					}
+					instances = append(instances, manifest)
+				}
+				continue
+			}
+
 			content, metadata, err := decodeValue(
 				*contentValue,
 				nil,
@@ -167,8 +401,57 @@ func (b Builder) Build(opts ...buildOption) (*BuildResult, error) {
 			if err := validateManifest(manifest); err != nil {
 				return nil, fmt.Errorf("%w: %w", ErrCUEBuildError, err)
 			}
+
+			if _, isHook := hooks[id]; isHook && !isJob(manifest.Content.Unstructured) {
+				return nil, fmt.Errorf("%s: %w: only Job manifests can be hooks", id, ErrInvalidHook)
+			}
+
+			if attributes.keep {
+				keep = append(keep, id)
+			}
+
 			instances = append(instances, &manifest)
 
+		case "Patch":
+			contentValue, err := getValue(componentValue, "content")
+			if err != nil {
+				return nil, buildError(err)
+			}
+
+			content, metadata, err := decodeValue(
+				*contentValue,
+				nil,
+				nil,
+				options.projectRoot,
+			)
+			if err != nil {
+				return nil, buildError(err)
+			}
+
+			contentNode, ok := content.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf(
+					"%w: expected patch content to be of type struct",
+					ErrCUEBuildError,
+				)
+			}
+
+			patch := Patch{
+				ID:           id,
+				Dependencies: dependencies,
+				Content: ExtendedUnstructured{
+					Unstructured: &unstructured.Unstructured{
+						Object: contentNode,
+					},
+					Metadata: metadata,
+				},
+			}
+
+			if err := validateManifest(Manifest(patch)); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrCUEBuildError, err)
+			}
+			instances = append(instances, &patch)
+
 		case "HelmRelease":
 			name, err := getStringValue(componentValue, "name")
 			if err != nil {
@@ -241,6 +524,12 @@ func (b Builder) Build(opts ...buildOption) (*BuildResult, error) {
 				return nil, buildError(err)
 			}
 
+			// released schemas do not define createNamespace yet.
+			createNamespace, err := getOptionalBoolValue(componentValue, "createNamespace")
+			if err != nil {
+				return nil, buildError(err)
+			}
+
 			hr := &helm.ReleaseComponent{
 				ID:           id,
 				Dependencies: dependencies,
@@ -252,6 +541,7 @@ func (b Builder) Build(opts ...buildOption) (*BuildResult, error) {
 					CRDs: helm.CRDs{
 						AllowUpgrade: allowUpgrade,
 					},
+					CreateNamespace: createNamespace,
 				},
 			}
 
@@ -263,11 +553,198 @@ func (b Builder) Build(opts ...buildOption) (*BuildResult, error) {
 		}
 	}
 
+	if options.componentName != "" && len(instances) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownComponentID, options.componentName)
+	}
+
 	return &BuildResult{
 		Instances: instances,
+		Warnings:  pkg.Warnings,
+		Waves:     waves,
+		Suspended: suspended,
+		Keep:      keep,
+		Requires:  requires,
+		WaitFor:   waitFor,
+		Hooks:     hooks,
+		Sources:   sources,
 	}, nil
 }
 
+// sourcePosition returns the file and line declaring the component in the format file:line.
+// The file is relative to the project root, if it is located inside of it.
+func sourcePosition(componentValue cue.Value, projectRoot string) string {
+	pos := componentValue.Pos()
+	if !pos.IsValid() || pos.Filename() == "" {
+		return ""
+	}
+
+	filename := pos.Filename()
+	if rel, err := filepath.Rel(projectRoot, filename); err == nil && filepath.IsLocal(rel) {
+		filename = rel
+	}
+
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(filename), pos.Line())
+}
+
+func decodeRequires(componentValue cue.Value) ([]string, error) {
+	attr := componentValue.Attribute(requiresAttr)
+	if attr.Err() != nil {
+		return nil, nil
+	}
+
+	apis := make([]string, 0, attr.NumArgs())
+	for i := 0; i < attr.NumArgs(); i++ {
+		api, err := attr.String(i)
+		if err != nil {
+			return nil, buildError(err)
+		}
+
+		if _, err := ParseRequiredAPI(api); err != nil {
+			return nil, err
+		}
+
+		apis = append(apis, api)
+	}
+
+	return apis, nil
+}
+
+// ParseRequiredAPI parses an API in the format group/version/Kind or version/Kind for core APIs.
+func ParseRequiredAPI(api string) (schema.GroupVersionKind, error) {
+	parts := strings.Split(api, "/")
+	for _, part := range parts {
+		if part == "" {
+			return schema.GroupVersionKind{}, fmt.Errorf("%w: %s", ErrInvalidRequiredAPI, api)
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionKind{Version: parts[0], Kind: parts[1]}, nil
+	case 3:
+		return schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]}, nil
+	}
+
+	return schema.GroupVersionKind{}, fmt.Errorf("%w: %s", ErrInvalidRequiredAPI, api)
+}
+
+func decodeWave(componentValue cue.Value) (int, bool, error) {
+	attr := componentValue.Attribute(waveAttr)
+	if attr.Err() != nil {
+		return 0, false, nil
+	}
+
+	wave, err := attr.Int(0)
+	if err != nil {
+		return 0, false, buildError(err)
+	}
+
+	return int(wave), true, nil
+}
+
+// decodeSuspend reports whether the component is suspended through @suspend() or @suspend(true).
+// @suspend(false) does not suspend the component.
+func decodeSuspend(componentValue cue.Value) (bool, error) {
+	attr := componentValue.Attribute(suspendAttr)
+	if attr.Err() != nil {
+		return false, nil
+	}
+
+	if attr.NumArgs() != 1 {
+		return false, fmt.Errorf("%w: expected at most one bool argument", ErrInvalidSuspend)
+	}
+
+	arg, err := attr.String(0)
+	if err != nil {
+		return false, buildError(err)
+	}
+	if arg == "" {
+		return true, nil
+	}
+
+	suspended, err := strconv.ParseBool(arg)
+	if err != nil {
+		return false, fmt.Errorf("%w: expected a bool, got %s", ErrInvalidSuspend, arg)
+	}
+
+	return suspended, nil
+}
+
+// componentAttributes holds the build attributes defined on a component declaration.
+type componentAttributes struct {
+	wave      *int
+	suspended bool
+	requires  []string
+	keep      bool
+	waitFor   *WaitCondition
+	hook      *JobHook
+}
+
+// componentAttributeHandler decodes a build attribute defined on a component declaration into its attributes.
+type componentAttributeHandler func(componentValue cue.Value, attributes *componentAttributes) error
+
+// componentAttributeHandlers holds all build attributes Navecd understands on component declarations.
+// Adding an attribute only requires registering its handler here.
+var componentAttributeHandlers = map[string]componentAttributeHandler{
+	waveAttr: func(componentValue cue.Value, attributes *componentAttributes) error {
+		wave, found, err := decodeWave(componentValue)
+		if found {
+			attributes.wave = &wave
+		}
+		return err
+	},
+	suspendAttr: func(componentValue cue.Value, attributes *componentAttributes) error {
+		var err error
+		attributes.suspended, err = decodeSuspend(componentValue)
+		return err
+	},
+	requiresAttr: func(componentValue cue.Value, attributes *componentAttributes) error {
+		var err error
+		attributes.requires, err = decodeRequires(componentValue)
+		return err
+	},
+	keepAttr: func(_ cue.Value, attributes *componentAttributes) error {
+		attributes.keep = true
+		return nil
+	},
+	waitForAttr: func(componentValue cue.Value, attributes *componentAttributes) error {
+		var err error
+		attributes.waitFor, err = decodeWaitFor(componentValue)
+		return err
+	},
+	hookAttr: func(componentValue cue.Value, attributes *componentAttributes) error {
+		var err error
+		attributes.hook, err = decodeHook(componentValue)
+		return err
+	},
+}
+
+// decodeComponentAttributes decodes all build attributes defined on a component declaration
+// and rejects attributes, which are neither registered in [componentAttributeHandlers] nor foreign.
+func decodeComponentAttributes(componentValue cue.Value) (*componentAttributes, error) {
+	attributes := &componentAttributes{}
+	for _, attr := range componentValue.Attributes(cue.ValueAttr) {
+		handler, found := componentAttributeHandlers[attr.Name()]
+		if !found {
+			if slices.Contains(foreignAttributes, attr.Name()) {
+				continue
+			}
+			return nil, fmt.Errorf(
+				"%w: @%s on %s",
+				ErrUnknownAttribute,
+				attr.Name(),
+				componentValue.Path(),
+			)
+		}
+
+		if err := handler(componentValue, attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	return attributes, nil
+}
+
 func decodeValues(componentValue cue.Value) (helm.Values, error) {
 	valuesValue, err := getValue(componentValue, "values")
 	if err != nil {
@@ -452,7 +929,7 @@ func decodeField(
 
 	switch finalValue.Kind() {
 	case cue.StringKind:
-		fieldMeta, err := decodeStringBuildAttributes(value)
+		fieldMeta, err := decodeBuildAttributes(value)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -555,36 +1032,49 @@ func decodeStruct(
 	return content, nil, nil
 }
 
+// attributeHandler applies a build attribute defined on a field or declaration to its metadata.
+type attributeHandler func(attr cue.Attribute, meta *FieldMetadata) error
+
+// attributeHandlers holds all build attributes Navecd understands on fields or declarations of manifest content.
+// Adding an attribute only requires registering its handler here.
+var attributeHandlers = map[string]attributeHandler{
+	ignoreAttr: func(_ cue.Attribute, meta *FieldMetadata) error {
+		meta.IgnoreInstr = kube.OnConflict
+		return nil
+	},
+	preserveAttr: func(_ cue.Attribute, meta *FieldMetadata) error {
+		meta.Preserve = true
+		return nil
+	},
+}
+
+// foreignAttributes are attributes owned by CUE itself or other tooling, like generated Kubernetes schemas,
+// which are tolerated on manifest content.
+var foreignAttributes = []string{"go", "protobuf", "tag", "embed", "extern"}
+
 func decodeBuildAttributes(value cue.Value) (*FieldMetadata, error) {
 	attributes := value.Attributes(cue.ValueAttr)
 
 	var meta *FieldMetadata
 	for _, attr := range attributes {
-		switch attr.Name() {
-		case ignoreAttr:
-			if meta == nil {
-				meta = new(FieldMetadata)
+		handler, found := attributeHandlers[attr.Name()]
+		if !found {
+			if slices.Contains(foreignAttributes, attr.Name()) {
+				continue
 			}
-			meta.IgnoreInstr = kube.OnConflict
+			return nil, fmt.Errorf(
+				"%w: @%s on %s",
+				ErrUnknownAttribute,
+				attr.Name(),
+				value.Path(),
+			)
 		}
-	}
-
-	return meta, nil
-}
-
-func decodeStringBuildAttributes(
-	value cue.Value,
-) (*FieldMetadata, error) {
-	attributes := value.Attributes(cue.ValueAttr)
 
-	var meta *FieldMetadata
-	for _, attr := range attributes {
-		switch attr.Name() {
-		case ignoreAttr:
-			if meta == nil {
-				meta = new(FieldMetadata)
-			}
-			meta.IgnoreInstr = kube.OnConflict
+		if meta == nil {
+			meta = new(FieldMetadata)
+		}
+		if err := handler(attr, meta); err != nil {
+			return nil, fmt.Errorf("@%s on %s: %w", attr.Name(), value.Path(), err)
 		}
 	}
 
@@ -615,6 +1105,15 @@ func getBoolValue(value cue.Value, key string) (bool, error) {
 	return boolValue, nil
 }
 
+// getOptionalBoolValue returns false, if the field does not exist.
+func getOptionalBoolValue(value cue.Value, key string) (bool, error) {
+	parsedValue, err := getOptionalValue(value, key)
+	if err != nil || parsedValue == nil {
+		return false, err
+	}
+	return parsedValue.Bool()
+}
+
 func getStringSliceValue(value cue.Value, key string) ([]string, error) {
 	parsedValue := value.LookupPath(cue.ParsePath(key))
 	if parsedValue.Err() != nil {
@@ -678,6 +1177,16 @@ func validateManifest(instance Manifest) error {
 		)
 	}
 
+	// server-side apply and the inventory identify objects by their name,
+	// which is unknown until the api server generated it.
+	if generateName, found := metadata["generateName"]; found {
+		return fmt.Errorf(
+			"%w: metadata.generateName %v has no stable name, declare metadata.name instead",
+			ErrGenerateName,
+			generateName,
+		)
+	}
+
 	_, found = metadata["name"]
 	if !found {
 		return missingFieldError("metadata.name")
@@ -686,6 +1195,44 @@ func validateManifest(instance Manifest) error {
 	return nil
 }
 
+// validateFields returns an error naming all fields of the component, which are not defined for its type.
+// Hidden fields and definitions are not considered.
+func validateFields(componentValue cue.Value, instanceType string) error {
+	fields, err := componentFields()
+	if err != nil {
+		return err
+	}
+
+	knownFields, found := fields[instanceType]
+	if !found {
+		return nil
+	}
+
+	iter, err := componentValue.Fields(cue.Optional(true))
+	if err != nil {
+		return err
+	}
+
+	var unknownFields []string
+	for iter.Next() {
+		field := iter.Selector().Unquoted()
+		if !slices.Contains(knownFields, field) {
+			unknownFields = append(unknownFields, field)
+		}
+	}
+
+	if len(unknownFields) != 0 {
+		return fmt.Errorf(
+			"%w for type %s: %s",
+			ErrUnknownField,
+			instanceType,
+			strings.Join(unknownFields, ", "),
+		)
+	}
+
+	return nil
+}
+
 func missingFieldError(key string) error {
 	return fmt.Errorf("%w: %s field not found", ErrMissingField, key)
 }
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"testing"
 
+	"cuelang.org/go/cue"
 	"github.com/kharf/navecd/internal/dnstest"
 	"github.com/kharf/navecd/internal/ocitest"
 	"github.com/kharf/navecd/internal/testtemplates"
@@ -38,7 +39,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -453,7 +454,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -476,7 +477,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -511,7 +512,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -539,7 +540,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -568,7 +569,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -601,7 +602,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -625,6 +626,36 @@ secret: {
 `, testtemplates.ModuleVersion)
 }
 
+func useGenerateNameTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/generatename/component.cue --
+package generatename
+
+job: {
+	type: "Manifest"
+	id:   "unimportant"
+	dependencies: []
+	content: {
+		apiVersion: "batch/v1"
+		kind:       "Job"
+		metadata: {
+			generateName: "migration-"
+			namespace:    "test"
+		}
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
 func useMissingApiVersionTemplate() string {
 	return fmt.Sprintf(`
 -- cue.mod/module.cue --
@@ -632,7 +663,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -663,7 +694,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -694,7 +725,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -727,7 +758,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -760,7 +791,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -793,7 +824,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -826,7 +857,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -880,7 +911,7 @@ module: "github.com/kharf/navecd/internal/component/build@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -909,6 +940,164 @@ release: component.#HelmRelease & {
 `, testtemplates.ModuleVersion)
 }
 
+func useCreateNamespaceTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/createnamespace/component.cue --
+package createnamespace
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+release: component.#HelmRelease & {
+	name:      "test"
+	namespace: "test"
+	chart: {
+		name:    "test"
+		repoURL: "http://test"
+		version: "test"
+	}
+	createNamespace: true
+}
+`, testtemplates.ModuleVersion)
+}
+
+// useNoCreateNamespaceTemplate declares a HelmRelease like schemas without createNamespace, e.g. v0.26.0, do.
+func useNoCreateNamespaceTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+
+-- infra/nocreatenamespace/component.cue --
+package nocreatenamespace
+
+release: {
+	type: "HelmRelease"
+	id:   "test_test_HelmRelease"
+	dependencies: []
+	name:      "test"
+	namespace: "test"
+	chart: {
+		name:    "test"
+		repoURL: "http://test"
+		version: "test"
+	}
+	values: {}
+	patches: []
+	crds: allowUpgrade: false
+}
+`, testtemplates.ModuleVersion)
+}
+
+func useIgnoredFileTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/ignoredfile/component.cue --
+package ignoredfile
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+namespace: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "test"
+	}
+}
+
+-- infra/ignoredfile/typo.cue --
+package ignoredfil
+
+secret: {
+	type: "Manifest"
+	id:   "unimportant"
+	dependencies: []
+	content: {}
+}
+
+-- infra/ignoredfile/_scratch.cue --
+package ignoredfile
+`, testtemplates.ModuleVersion)
+}
+
+func usePreserveAttributeTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/preserveattribute/component.cue --
+package preserveattribute
+
+deployment: {
+	type: "Manifest"
+	id:   "test_test_apps_Deployment"
+	dependencies: []
+	content: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+		metadata: {
+			name:      "test"
+			namespace: "test"
+		}
+		spec: replicas: 1 @preserve()
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
+func useUnknownAttributeTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/unknownattribute/component.cue --
+package unknownattribute
+
+namespace: {
+	type: "Manifest"
+	id:   "test___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "test" @ignor(conflict)
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
 func TestBuilder_Build(t *testing.T) {
 	defer goleak.VerifyNone(
 		t,
@@ -932,6 +1121,7 @@ func TestBuilder_Build(t *testing.T) {
 		packagePath         string
 		template            string
 		expectedBuildResult *BuildResult
+		expectedWarnings    []string
 		expectedErr         string
 	}{
 		{
@@ -1527,7 +1717,7 @@ This field may not be empty.`,
 			name:        "Content-Wrong-Field-Type",
 			packagePath: "./infra/contentwrongtype",
 			template:    useContentWrongTypeTemplate(),
-			expectedErr: "CUE Build Error: expected content to be of type struct",
+			expectedErr: "Invalid raw manifest: unimportant: document 0: expected content to be of type struct",
 		},
 		{
 			name:        "Patches-Wrong-Field-Type",
@@ -1559,6 +1749,12 @@ This field may not be empty.`,
 			template:    useMissingMetadataNameTemplate(),
 			expectedErr: ErrMissingField.Error(),
 		},
+		{
+			name:        "Generate-Name",
+			packagePath: "./infra/generatename",
+			template:    useGenerateNameTemplate(),
+			expectedErr: ErrGenerateName.Error(),
+		},
 		{
 			name:        "Missing-ApiVersion",
 			packagePath: "./infra/apiversionmissing",
@@ -1632,6 +1828,131 @@ This field may not be empty.`,
 			},
 			expectedErr: "",
 		},
+		{
+			name:        "Create-Namespace",
+			packagePath: "./infra/createnamespace",
+			template:    useCreateNamespaceTemplate(),
+			expectedBuildResult: &BuildResult{
+				Instances: []Instance{
+					&helm.ReleaseComponent{
+						ID: "test_test_HelmRelease",
+						Content: helm.ReleaseDeclaration{
+							Name:      "test",
+							Namespace: "test",
+							Chart: &helm.Chart{
+								Name:    "test",
+								RepoURL: "http://test",
+								Version: "test",
+							},
+							Values:          helm.Values{},
+							CreateNamespace: true,
+						},
+						Dependencies: []string{},
+					},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "No-Create-Namespace",
+			packagePath: "./infra/nocreatenamespace",
+			template:    useNoCreateNamespaceTemplate(),
+			expectedBuildResult: &BuildResult{
+				Instances: []Instance{
+					&helm.ReleaseComponent{
+						ID: "test_test_HelmRelease",
+						Content: helm.ReleaseDeclaration{
+							Name:      "test",
+							Namespace: "test",
+							Chart: &helm.Chart{
+								Name:    "test",
+								RepoURL: "http://test",
+								Version: "test",
+							},
+							Values: helm.Values{},
+						},
+						Dependencies: []string{},
+					},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Preserve-Attribute",
+			packagePath: "./infra/preserveattribute",
+			template:    usePreserveAttributeTemplate(),
+			expectedBuildResult: &BuildResult{
+				Instances: []Instance{
+					&Manifest{
+						ID: "test_test_apps_Deployment",
+						Content: ExtendedUnstructured{
+							Unstructured: &unstructured.Unstructured{
+								Object: map[string]any{
+									"apiVersion": "apps/v1",
+									"kind":       "Deployment",
+									"metadata": map[string]any{
+										"name":      "test",
+										"namespace": "test",
+									},
+									"spec": map[string]any{
+										"replicas": int64(1),
+									},
+								},
+							},
+							Metadata: &kube.ManifestMetadata{
+								Node: map[string]kube.ManifestMetadata{
+									"spec": {
+										Node: map[string]kube.ManifestMetadata{
+											"replicas": {
+												Field: &kube.ManifestFieldMetadata{
+													Preserve: true,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						Dependencies: []string{},
+					},
+				},
+			},
+			expectedErr: "",
+		},
+		{
+			name:        "Unknown-Attribute",
+			packagePath: "./infra/unknownattribute",
+			template:    useUnknownAttributeTemplate(),
+			expectedErr: "Unknown build attribute: @ignor on namespace.content.metadata.name",
+		},
+		{
+			name:        "Ignored-File-Warning",
+			packagePath: "./infra/ignoredfile",
+			template:    useIgnoredFileTemplate(),
+			expectedBuildResult: &BuildResult{
+				Instances: []Instance{
+					&Manifest{
+						ID: "test___Namespace",
+						Content: ExtendedUnstructured{
+							Unstructured: &unstructured.Unstructured{
+								Object: map[string]any{
+									"apiVersion": "v1",
+									"kind":       "Namespace",
+									"metadata": map[string]any{
+										"name": "test",
+									},
+								},
+							},
+						},
+						Dependencies: []string{},
+					},
+				},
+			},
+			expectedWarnings: []string{
+				"infra/ignoredfile/typo.cue ignored: package is ignoredfil, want ignoredfile",
+			},
+			expectedErr: "",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1651,7 +1972,697 @@ This field may not be empty.`,
 				assert.Assert(t, buildResult != nil)
 
 				assert.DeepEqual(t, buildResult.Instances, tc.expectedBuildResult.Instances)
+				assert.Equal(t, len(buildResult.Warnings), len(tc.expectedWarnings))
+				for i, warning := range tc.expectedWarnings {
+					assert.Assert(t, strings.Contains(buildResult.Warnings[i], warning), buildResult.Warnings[i])
+				}
 			}
 		})
 	}
 }
+
+func useMultiComponentTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/multi/component.cue --
+package multi
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+namespace: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "test"
+	}
+}
+
+secret: component.#Manifest & {
+	dependencies: [namespace.id]
+	content: {
+		apiVersion: "v1"
+		kind:       "Secret"
+		metadata: {
+			name:      "secret"
+			namespace: "test"
+		}
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
+func TestBuilder_Build_ComponentName(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	rootDir := t.TempDir()
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	_, err = txtar.Create(rootDir, strings.NewReader(useMultiComponentTemplate()))
+	assert.NilError(t, err)
+
+	builder := NewBuilder()
+
+	buildResult, err := builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/multi"),
+		WithComponentName("secret_test__Secret"),
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, buildResult.Instances, []Instance{
+		&Manifest{
+			ID: "secret_test__Secret",
+			Content: ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "v1",
+						"kind":       "Secret",
+						"metadata": map[string]any{
+							"name":      "secret",
+							"namespace": "test",
+						},
+					},
+				},
+			},
+			Dependencies: []string{"test___Namespace"},
+		},
+	})
+
+	_, err = builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/multi"),
+		WithComponentName("unknown_test__Secret"),
+	)
+	assert.ErrorIs(t, err, ErrUnknownComponentID)
+}
+
+func TestBuilder_Build_Sources(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	rootDir := t.TempDir()
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	_, err = txtar.Create(rootDir, strings.NewReader(useMultiComponentTemplate()))
+	assert.NilError(t, err)
+
+	buildResult, err := NewBuilder().Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/multi"),
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, buildResult.Sources, map[string]string{
+		"test___Namespace":    "infra/multi/component.cue:7",
+		"secret_test__Secret": "infra/multi/component.cue:15",
+	})
+}
+
+func usePatchTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/patch/component.cue --
+package patch
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+deployment: component.#Patch & {
+	content: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+		metadata: {
+			name:      "operator-managed"
+			namespace: "test"
+		}
+		spec: replicas: 3 @ignore(conflict)
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
+func TestBuilder_Build_Patch(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	rootDir := t.TempDir()
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	_, err = txtar.Create(rootDir, strings.NewReader(usePatchTemplate()))
+	assert.NilError(t, err)
+
+	builder := NewBuilder()
+
+	buildResult, err := builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/patch"),
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, buildResult.Instances, []Instance{
+		&Patch{
+			ID: "operator-managed_test_apps_Deployment_Patch",
+			Content: ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata": map[string]any{
+							"name":      "operator-managed",
+							"namespace": "test",
+						},
+						"spec": map[string]any{
+							"replicas": int64(3),
+						},
+					},
+				},
+				Metadata: &kube.ManifestMetadata{
+					Node: map[string]kube.ManifestMetadata{
+						"spec": {
+							Node: map[string]kube.ManifestMetadata{
+								"replicas": {
+									Field: &kube.ManifestFieldMetadata{
+										IgnoreInstr: kube.OnConflict,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{},
+		},
+	})
+}
+
+func useRawManifestTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/raw/component.cue --
+package raw
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+legacy: component.#RawManifest & {
+	id: "legacy"
+	dependencies: ["test___Namespace"]
+	content: """
+		# exported from the previous deployment tooling
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: legacy
+		  namespace: test
+		data:
+		  key: value
+		---
+		apiVersion: apps/v1
+		kind: Deployment
+		metadata:
+		  name: legacy
+		  namespace: test
+		spec:
+		  replicas: 2
+		"""
+} @wave(1)
+`, testtemplates.ModuleVersion)
+}
+
+func TestBuilder_Build_RawManifest(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	rootDir := t.TempDir()
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	_, err = txtar.Create(rootDir, strings.NewReader(useRawManifestTemplate()))
+	assert.NilError(t, err)
+
+	builder := NewBuilder()
+
+	buildResult, err := builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/raw"),
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, buildResult.Instances, []Instance{
+		&Manifest{
+			ID: "legacy_test__ConfigMap",
+			Content: ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata": map[string]any{
+							"name":      "legacy",
+							"namespace": "test",
+						},
+						"data": map[string]any{
+							"key": "value",
+						},
+					},
+				},
+			},
+			Dependencies: []string{"test___Namespace"},
+		},
+		&Manifest{
+			ID: "legacy_test_apps_Deployment",
+			Content: ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata": map[string]any{
+							"name":      "legacy",
+							"namespace": "test",
+						},
+						"spec": map[string]any{
+							"replicas": int64(2),
+						},
+					},
+				},
+			},
+			Dependencies: []string{"test___Namespace"},
+		},
+	})
+	assert.DeepEqual(t, buildResult.Waves, map[string]int{
+		"legacy_test__ConfigMap":      1,
+		"legacy_test_apps_Deployment": 1,
+	})
+
+	buildResult, err = builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/raw"),
+		WithComponentName("legacy_test_apps_Deployment"),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(buildResult.Instances), 1)
+	assert.Equal(t, buildResult.Instances[0].GetID(), "legacy_test_apps_Deployment")
+}
+
+func useRegisteredAttributeTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/registeredattribute/component.cue --
+package registeredattribute
+
+deployment: {
+	type: "Manifest"
+	id:   "test_test_apps_Deployment"
+	dependencies: []
+	content: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+		metadata: {
+			name:      "test"
+			namespace: "test"
+		}
+		spec: replicas: 1 @go(Replicas) @custom(conflict)
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
+func TestBuilder_Build_UnknownComponentAttribute(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	testCases := []struct {
+		name      string
+		attribute string
+		err       error
+	}{
+		{
+			name:      "Misspelled",
+			attribute: `@wiatFor("status.phase", "Active")`,
+			err:       ErrUnknownAttribute,
+		},
+		{
+			name:      "Field-Attribute",
+			attribute: `@ignore()`,
+			err:       ErrUnknownAttribute,
+		},
+		{
+			name:      "Registered",
+			attribute: `@go(Namespace) @wave(1) @suspend() @keep() @requires("v1/Namespace") @waitFor("status.phase", "Active")`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			_, err := txtar.Create(rootDir, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+
+-- infra/componentattribute/component.cue --
+package componentattribute
+
+ns: {
+	type: "Manifest"
+	id:   "test___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "test"
+	}
+} %s
+`, testtemplates.ModuleVersion, tc.attribute)))
+			assert.NilError(t, err)
+
+			buildResult, err := NewBuilder().Build(
+				WithProjectRoot(rootDir),
+				WithPackagePath("./infra/componentattribute"),
+			)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, buildResult.Waves["test___Namespace"], 1)
+			assert.DeepEqual(t, buildResult.Suspended, []string{"test___Namespace"})
+			assert.DeepEqual(t, buildResult.Keep, []string{"test___Namespace"})
+			assert.DeepEqual(t, buildResult.Requires["test___Namespace"], []string{"v1/Namespace"})
+			assert.Equal(t, buildResult.WaitFor["test___Namespace"].Value, "Active")
+		})
+	}
+}
+
+func TestBuilder_Build_Suspend(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	testCases := []struct {
+		name      string
+		attribute string
+		suspended []string
+		err       error
+	}{
+		{
+			name:      "No-Argument",
+			attribute: `@suspend()`,
+			suspended: []string{"test___Namespace"},
+		},
+		{
+			name:      "True",
+			attribute: `@suspend(true)`,
+			suspended: []string{"test___Namespace"},
+		},
+		{
+			name:      "False",
+			attribute: `@suspend(false)`,
+		},
+		{
+			name:      "Invalid-Argument",
+			attribute: `@suspend(yes)`,
+			err:       ErrInvalidSuspend,
+		},
+		{
+			name:      "Too-Many-Arguments",
+			attribute: `@suspend(true, false)`,
+			err:       ErrInvalidSuspend,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			_, err := txtar.Create(rootDir, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+
+-- infra/suspend/component.cue --
+package suspend
+
+ns: {
+	type: "Manifest"
+	id:   "test___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "test"
+	}
+} %s
+`, testtemplates.ModuleVersion, tc.attribute)))
+			assert.NilError(t, err)
+
+			buildResult, err := NewBuilder().Build(
+				WithProjectRoot(rootDir),
+				WithPackagePath("./infra/suspend"),
+			)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, buildResult.Suspended, tc.suspended)
+		})
+	}
+}
+
+func TestBuilder_Build_GenerateName(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	rootDir := t.TempDir()
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	_, err = txtar.Create(rootDir, strings.NewReader(useGenerateNameTemplate()))
+	assert.NilError(t, err)
+
+	builder := NewBuilder()
+
+	// every reconciliation rejects the object instead of creating another one with a new generated name.
+	for range 2 {
+		_, err = builder.Build(
+			WithProjectRoot(rootDir),
+			WithPackagePath("./infra/generatename"),
+		)
+		assert.ErrorIs(t, err, ErrGenerateName)
+		assert.ErrorContains(t, err, "migration-")
+	}
+}
+
+func TestBuilder_Build_Strict(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	rootDir := t.TempDir()
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	_, err = txtar.Create(rootDir, strings.NewReader(useMisspelledFieldTemplate()))
+	assert.NilError(t, err)
+
+	builder := NewBuilder()
+
+	buildResult, err := builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/misspelled"),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(buildResult.Instances), 1)
+
+	_, err = builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/misspelled"),
+		WithStrict(true),
+	)
+	assert.ErrorIs(t, err, ErrUnknownField)
+	assert.ErrorContains(t, err, "dependancies")
+
+	_, err = txtar.Create(rootDir, strings.NewReader(useMultiComponentTemplate()))
+	assert.NilError(t, err)
+
+	buildResult, err = builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/multi"),
+		WithStrict(true),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(buildResult.Instances), 2)
+}
+
+func useMisspelledFieldTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/component/build@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/misspelled/component.cue --
+package misspelled
+
+secret: {
+	type: "Manifest"
+	id:   "secret"
+	dependencies: []
+	dependancies: ["namespace"]
+	content: {
+		apiVersion: "v1"
+		kind:       "Secret"
+		metadata: {
+			name:      "secret"
+			namespace: "test"
+		}
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
+func TestBuilder_Build_RegisteredAttribute(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	rootDir := t.TempDir()
+
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	_, err = txtar.Create(rootDir, strings.NewReader(useRegisteredAttributeTemplate()))
+	assert.NilError(t, err)
+
+	builder := NewBuilder()
+
+	_, err = builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/registeredattribute"),
+	)
+	assert.ErrorContains(t, err, ErrUnknownAttribute.Error())
+
+	var args []string
+	attributeHandlers["custom"] = func(attr cue.Attribute, meta *FieldMetadata) error {
+		for i := 0; i < attr.NumArgs(); i++ {
+			arg, err := attr.String(i)
+			if err != nil {
+				return err
+			}
+			args = append(args, arg)
+		}
+		meta.IgnoreInstr = kube.OnConflict
+		return nil
+	}
+	defer delete(attributeHandlers, "custom")
+
+	buildResult, err := builder.Build(
+		WithProjectRoot(rootDir),
+		WithPackagePath("./infra/registeredattribute"),
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, args, []string{"conflict"})
+	assert.Equal(t, len(buildResult.Instances), 1)
+
+	manifest, ok := buildResult.Instances[0].(*Manifest)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, manifest.Content.Metadata, &kube.ManifestMetadata{
+		Node: map[string]kube.ManifestMetadata{
+			"spec": {
+				Node: map[string]kube.ManifestMetadata{
+					"replicas": {
+						Field: &kube.ManifestFieldMetadata{
+							IgnoreInstr: kube.OnConflict,
+						},
+					},
+				},
+			},
+		},
+	})
+}
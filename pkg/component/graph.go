@@ -17,6 +17,10 @@ package component
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kharf/navecd/pkg/helm"
 )
 
 var (
@@ -67,9 +71,100 @@ func (graph *DependencyGraph) Get(componentID string) Instance {
 	return node
 }
 
+// GetByRef returns the manifest Component identified by its apiVersion, kind, name and namespace.
+// Namespace is empty for cluster-scoped objects.
+// It returns nil if no Node has been found.
+func (graph *DependencyGraph) GetByRef(apiVersion, kind, name, namespace string) Instance {
+	return graph.Get(ManifestID(apiVersion, kind, name, namespace))
+}
+
+// Find returns all Components matching the predicate, sorted by their id.
+func (graph *DependencyGraph) Find(predicate func(instance Instance) bool) []Instance {
+	var result []Instance
+	for _, node := range graph.set {
+		if predicate(node) {
+			result = append(result, node)
+		}
+	}
+	slices.SortFunc(result, func(a, b Instance) int {
+		return strings.Compare(a.GetID(), b.GetID())
+	})
+	return result
+}
+
+// ManifestID constructs the canonical id of a manifest Component in the format name_namespace_group_kind.
+func ManifestID(apiVersion, kind, name, namespace string) string {
+	group := ""
+	if index := strings.LastIndex(apiVersion, "/"); index != -1 {
+		group = apiVersion[:index]
+	}
+	return fmt.Sprintf("%s_%s_%s_%s", name, namespace, group, kind)
+}
+
+// Validate checks that the dependencies of all components resolve to components of the graph,
+// regardless of their types, so that Helm releases can depend on manifests and vice versa.
+// The returned error names every component with its unknown dependency.
+func (dag *DependencyGraph) Validate() error {
+	ids := make([]string, 0, len(dag.set))
+	for id := range dag.set {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var errs []error
+	for _, id := range ids {
+		node := dag.set[id]
+		for _, dependency := range node.GetDependencies() {
+			if _, found := dag.set[dependency]; !found {
+				errs = append(errs, fmt.Errorf(
+					"%w: %s %s depends on %s, which is not declared in the project",
+					ErrUnknownComponentID,
+					instanceType(node),
+					id,
+					dependency,
+				))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// instanceType returns the component type as declared in CUE.
+func instanceType(instance Instance) string {
+	switch instance.(type) {
+	case *Manifest:
+		return "Manifest"
+	case *Patch:
+		return "Patch"
+	case *helm.ReleaseComponent:
+		return "HelmRelease"
+	}
+	return fmt.Sprintf("%T", instance)
+}
+
 // TopologicalSort performs a topological sort on the component dependency graph and returns the sorted order.
 // It returns an error if a cycle is detected.
 func (dag *DependencyGraph) TopologicalSort() ([]Instance, error) {
+	ids := make([]string, 0, len(dag.set))
+	for id := range dag.set {
+		ids = append(ids, id)
+	}
+	return dag.sort(ids)
+}
+
+// Subtree returns the component with the given id and all of its transitive dependencies in topological order.
+// Components, which neither are nor provide a dependency of the component, are omitted.
+// It returns an error if the component is not part of the graph or a cycle is detected.
+func (dag *DependencyGraph) Subtree(componentID string) ([]Instance, error) {
+	if dag.Get(componentID) == nil {
+		return nil, fmt.Errorf("%w: %s not found in dependency graph", ErrUnknownComponentID, componentID)
+	}
+	return dag.sort([]string{componentID})
+}
+
+// sort walks the given components and their transitive dependencies and returns them in topological order.
+func (dag *DependencyGraph) sort(ids []string) ([]Instance, error) {
 	inProcessing := make(map[string]struct{})
 	visited := make(map[string]struct{}, len(dag.set))
 	result := make([]Instance, 0, len(dag.set))
@@ -105,8 +200,8 @@ func (dag *DependencyGraph) TopologicalSort() ([]Instance, error) {
 		return nil
 	}
 
-	for node := range dag.set {
-		if err := walk(node); err != nil {
+	for _, id := range ids {
+		if err := walk(id); err != nil {
 			return nil, err
 		}
 	}
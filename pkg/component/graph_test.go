@@ -15,10 +15,12 @@
 package component_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
 	"gotest.tools/v3/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -173,6 +175,112 @@ func TestDependencyGraph_Get(t *testing.T) {
 	}
 }
 
+func refManifest(id string, apiVersion string, kind string, name string, namespace string) *component.Manifest {
+	metadata := map[string]interface{}{
+		"name": name,
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return &component.Manifest{
+		ID: id,
+		Content: component.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":       kind,
+					"apiVersion": apiVersion,
+					"metadata":   metadata,
+				},
+			},
+		},
+	}
+}
+
+func TestDependencyGraph_GetByRef(t *testing.T) {
+	namespace := refManifest("prometheus___Namespace", "v1", "Namespace", "prometheus", "")
+	deployment := refManifest("prometheus_prometheus_apps_Deployment", "apps/v1", "Deployment", "prometheus", "prometheus")
+	clusterRole := refManifest(
+		"prometheus__rbac.authorization.k8s.io_ClusterRole",
+		"rbac.authorization.k8s.io/v1",
+		"ClusterRole",
+		"prometheus",
+		"",
+	)
+
+	graph := component.NewDependencyGraph()
+	err := graph.Insert(namespace, deployment, clusterRole)
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		name       string
+		apiVersion string
+		kind       string
+		objName    string
+		namespace  string
+		expected   component.Instance
+	}{
+		{
+			name:       "ClusterScoped",
+			apiVersion: "v1",
+			kind:       "Namespace",
+			objName:    "prometheus",
+			expected:   namespace,
+		},
+		{
+			name:       "GroupedAPIVersion",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			objName:    "prometheus",
+			namespace:  "prometheus",
+			expected:   deployment,
+		},
+		{
+			name:       "ClusterScopedGroupedAPIVersion",
+			apiVersion: "rbac.authorization.k8s.io/v1",
+			kind:       "ClusterRole",
+			objName:    "prometheus",
+			expected:   clusterRole,
+		},
+		{
+			name:       "WrongNamespace",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			objName:    "prometheus",
+			namespace:  "default",
+			expected:   nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := graph.GetByRef(tc.apiVersion, tc.kind, tc.objName, tc.namespace)
+			assert.Assert(t, cmp.Equal(node, tc.expected))
+		})
+	}
+}
+
+func TestDependencyGraph_Find(t *testing.T) {
+	graph := component.NewDependencyGraph()
+	err := graph.Insert(
+		refManifest("prometheus___Namespace", "v1", "Namespace", "prometheus", ""),
+		refManifest("linkerd___Namespace", "v1", "Namespace", "linkerd", ""),
+		refManifest("prometheus_prometheus_apps_Deployment", "apps/v1", "Deployment", "prometheus", "prometheus"),
+	)
+	assert.NilError(t, err)
+
+	namespaces := graph.Find(func(instance component.Instance) bool {
+		manifest, ok := instance.(*component.Manifest)
+		return ok && manifest.GetKind() == "Namespace"
+	})
+	assert.Equal(t, len(namespaces), 2)
+	assert.Equal(t, namespaces[0].GetID(), "linkerd___Namespace")
+	assert.Equal(t, namespaces[1].GetID(), "prometheus___Namespace")
+
+	none := graph.Find(func(instance component.Instance) bool {
+		return false
+	})
+	assert.Equal(t, len(none), 0)
+}
+
 func TestDependencyGraph_Delete(t *testing.T) {
 	graph := component.NewDependencyGraph()
 	err := graph.Insert(
@@ -535,3 +643,131 @@ func TestDependencyGraph_TopologicalSort(t *testing.T) {
 		})
 	}
 }
+
+func TestDependencyGraph_Validate(t *testing.T) {
+	certManager := &helm.ReleaseComponent{
+		ID:           "cert-manager_cert-manager_HelmRelease",
+		Dependencies: []string{},
+		Content: helm.ReleaseDeclaration{
+			Name:      "cert-manager",
+			Namespace: "cert-manager",
+		},
+	}
+	// the ClusterIssuer CRD is installed by the cert-manager release.
+	issuer := &component.Manifest{
+		ID:           "letsencrypt___cert-manager.io_ClusterIssuer",
+		Dependencies: []string{certManager.ID},
+		Content: component.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":       "ClusterIssuer",
+					"apiVersion": "cert-manager.io/v1",
+					"metadata": map[string]interface{}{
+						"name": "letsencrypt",
+					},
+				},
+			},
+		},
+	}
+	config := &component.Manifest{
+		ID:           "ingress-config_ingress__ConfigMap",
+		Dependencies: []string{},
+		Content: component.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":       "ConfigMap",
+					"apiVersion": "v1",
+					"metadata": map[string]interface{}{
+						"name":      "ingress-config",
+						"namespace": "ingress",
+					},
+				},
+			},
+		},
+	}
+	ingress := &helm.ReleaseComponent{
+		ID:           "ingress_ingress_HelmRelease",
+		Dependencies: []string{config.ID, issuer.ID},
+		Content: helm.ReleaseDeclaration{
+			Name:      "ingress",
+			Namespace: "ingress",
+		},
+	}
+
+	dag := component.NewDependencyGraph()
+	err := dag.Insert(ingress, issuer, config, certManager)
+	assert.NilError(t, err)
+	assert.NilError(t, dag.Validate())
+
+	sorted, err := dag.TopologicalSort()
+	assert.NilError(t, err)
+	position := func(id string) int {
+		return slices.IndexFunc(sorted, func(instance component.Instance) bool {
+			return instance.GetID() == id
+		})
+	}
+	assert.Assert(t, position(certManager.ID) < position(issuer.ID))
+	assert.Assert(t, position(issuer.ID) < position(ingress.ID))
+	assert.Assert(t, position(config.ID) < position(ingress.ID))
+
+	dag.Delete(config.ID)
+	err = dag.Validate()
+	assert.ErrorIs(t, err, component.ErrUnknownComponentID)
+	assert.ErrorContains(
+		t,
+		err,
+		"HelmRelease ingress_ingress_HelmRelease depends on ingress-config_ingress__ConfigMap, which is not declared in the project",
+	)
+}
+
+func TestDependencyGraph_Subtree(t *testing.T) {
+	manifest := func(id string, kind string, name string, dependencies ...string) *component.Manifest {
+		return &component.Manifest{
+			ID:           id,
+			Dependencies: dependencies,
+			Content: component.ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind":       kind,
+						"apiVersion": "v1",
+						"metadata": map[string]interface{}{
+							"name": name,
+						},
+					},
+				},
+			},
+		}
+	}
+	namespace := manifest("monitoring___Namespace", "Namespace", "monitoring")
+	prometheus := &helm.ReleaseComponent{
+		ID:           "prometheus_monitoring_HelmRelease",
+		Dependencies: []string{namespace.ID},
+		Content: helm.ReleaseDeclaration{
+			Name:      "prometheus",
+			Namespace: "monitoring",
+		},
+	}
+	dashboards := manifest("dashboards_monitoring__ConfigMap", "ConfigMap", "dashboards", namespace.ID, prometheus.ID)
+	unrelated := manifest("unrelated___Namespace", "Namespace", "unrelated")
+	unrelatedConfig := manifest("config_unrelated__ConfigMap", "ConfigMap", "config", unrelated.ID)
+
+	dag := component.NewDependencyGraph()
+	err := dag.Insert(dashboards, unrelatedConfig, prometheus, unrelated, namespace)
+	assert.NilError(t, err)
+
+	subtree, err := dag.Subtree(dashboards.ID)
+	assert.NilError(t, err)
+	ids := make([]string, 0, len(subtree))
+	for _, instance := range subtree {
+		ids = append(ids, instance.GetID())
+	}
+	assert.DeepEqual(t, ids, []string{namespace.ID, prometheus.ID, dashboards.ID})
+
+	subtree, err = dag.Subtree(namespace.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(subtree), 1)
+	assert.Equal(t, subtree[0].GetID(), namespace.ID)
+
+	_, err = dag.Subtree("missing___Namespace")
+	assert.ErrorIs(t, err, component.ErrUnknownComponentID)
+}
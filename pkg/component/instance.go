@@ -28,4 +28,5 @@ type Instance interface {
 }
 
 var _ Instance = (*kube.Manifest)(nil)
+var _ Instance = (*kube.Patch)(nil)
 var _ Instance = (*helm.ReleaseComponent)(nil)
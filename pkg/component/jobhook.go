@@ -0,0 +1,268 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cuelang.org/go/cue"
+	"github.com/kharf/navecd/pkg/kube"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	ErrInvalidHook = errors.New("Invalid hook")
+	ErrHookFailed  = errors.New("Hook failed")
+)
+
+// DefaultJobHookTimeout is used for hooks without a timeout.
+const DefaultJobHookTimeout = 5 * time.Minute
+
+// HookPhase defines whether a hook runs before or after its target.
+type HookPhase string
+
+const (
+	// PreHook runs before its target is applied. The target is not applied, if the hook fails.
+	PreHook HookPhase = "pre"
+
+	// PostHook runs after its target has been applied.
+	PostHook HookPhase = "post"
+)
+
+// JobHook is declared with the hook attribute on manifest components of kind batch/v1 Job,
+// e.g. @hook(pre, "app_app_apps_Deployment") or @hook(post, "app_app_apps_Deployment", "10m").
+// The Job is applied before or after its target and fails the component, if it does not complete within the timeout.
+// Like every manifest, the Job is tracked in the inventory and removed with its component.
+// Its completion is recorded in the inventory, so that a hook runs only once per declaration,
+// even if its Job is removed from the cluster afterwards, e.g. through ttlSecondsAfterFinished.
+// Changes to the Job replace it, so that the hook runs again. Failed Jobs are replaced on the next reconciliation.
+type JobHook struct {
+	Phase HookPhase
+
+	// Target is the id of the component the hook runs for.
+	Target string
+
+	// Timeout after which the hook fails, if the Job has not completed.
+	Timeout time.Duration
+}
+
+func decodeHook(componentValue cue.Value) (*JobHook, error) {
+	attr := componentValue.Attribute(hookAttr)
+	if attr.Err() != nil {
+		return nil, nil
+	}
+
+	if attr.NumArgs() != 2 && attr.NumArgs() != 3 {
+		return nil, fmt.Errorf("%w: expected phase, target and optional timeout", ErrInvalidHook)
+	}
+
+	phase, err := attr.String(0)
+	if err != nil {
+		return nil, buildError(err)
+	}
+	if phase != string(PreHook) && phase != string(PostHook) {
+		return nil, fmt.Errorf("%w: unknown phase %s, expected %s or %s", ErrInvalidHook, phase, PreHook, PostHook)
+	}
+
+	target, err := attr.String(1)
+	if err != nil {
+		return nil, buildError(err)
+	}
+	if target == "" {
+		return nil, fmt.Errorf("%w: empty target", ErrInvalidHook)
+	}
+
+	timeout := DefaultJobHookTimeout
+	if attr.NumArgs() == 3 {
+		timeoutArg, err := attr.String(2)
+		if err != nil {
+			return nil, buildError(err)
+		}
+		timeout, err = time.ParseDuration(timeoutArg)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("%w: invalid timeout %s", ErrInvalidHook, timeoutArg)
+		}
+	}
+
+	return &JobHook{
+		Phase:   HookPhase(phase),
+		Target:  target,
+		Timeout: timeout,
+	}, nil
+}
+
+func isJob(obj *unstructured.Unstructured) bool {
+	return obj != nil && obj.GetAPIVersion() == "batch/v1" && obj.GetKind() == "Job"
+}
+
+// hookCompleted reports whether the Job of a hook already completed with its current declaration.
+// Components, which are no hooks, never complete.
+func (reconciler *Reconciler) hookCompleted(manifest *Manifest, unstr kube.ExtendedUnstructured) (bool, error) {
+	if _, found := reconciler.Hooks[manifest.ID]; !found || reconciler.DryRun {
+		return false, nil
+	}
+
+	previous, err := reconciler.itemContent(manifestItem(manifest))
+	if err != nil {
+		// the hook has never been applied.
+		return false, nil
+	}
+
+	completed, err := reconciler.manifestContent(manifest, unstr, true)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(previous, completed), nil
+}
+
+// recordHookCompletion marks the Job of a hook as completed in the inventory.
+func (reconciler *Reconciler) recordHookCompletion(manifest *Manifest, unstr kube.ExtendedUnstructured) error {
+	if _, found := reconciler.Hooks[manifest.ID]; !found || reconciler.DryRun {
+		return nil
+	}
+
+	completed, err := reconciler.manifestContent(manifest, unstr, true)
+	if err != nil {
+		return err
+	}
+
+	_, err = reconciler.storeItem(manifestItem(manifest), completed)
+	return err
+}
+
+// replaceJob deletes the live Job of a hook, if its declaration changed since it was last applied or the Job failed,
+// because the pod template of Jobs is immutable and a changed or failed hook has to run again.
+func (reconciler *Reconciler) replaceJob(ctx context.Context, manifest *Manifest, unstr kube.ExtendedUnstructured) error {
+	hook, found := reconciler.Hooks[manifest.ID]
+	if !found || reconciler.DryRun {
+		return nil
+	}
+
+	previous, err := reconciler.itemContent(manifestItem(manifest))
+	if err != nil {
+		// the hook has never been applied.
+		return nil
+	}
+
+	current, err := reconciler.manifestContent(manifest, unstr, false)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(previous, current) {
+		live, err := reconciler.DynamicClient.Get(ctx, &unstr)
+		if err != nil || !jobCondition(live, "Failed") {
+			return nil
+		}
+	}
+
+	err = reconciler.DynamicClient.Delete(ctx, &unstr, kube.PropagationPolicy(v1.DeletePropagationBackground))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hook.Timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := reconciler.DynamicClient.Get(ctx, &unstr)
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: Job %s/%s was not deleted: %w", ErrHookFailed, unstr.GetNamespace(), unstr.GetName(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForJob polls the live Job of a hook until it completed.
+// It fails, once the Job failed or the timeout of the hook is exceeded.
+// Components, which are no hooks, return immediately.
+func (reconciler *Reconciler) waitForJob(ctx context.Context, instance Instance, obj *kube.ExtendedUnstructured) error {
+	hook, found := reconciler.Hooks[instance.GetID()]
+	if !found || reconciler.DryRun {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hook.Timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		live, err := reconciler.DynamicClient.Get(ctx, obj)
+		if err == nil {
+			if jobCondition(live, "Complete") {
+				return nil
+			}
+			if jobCondition(live, "Failed") {
+				return fmt.Errorf(
+					"%w: %s hook Job %s/%s of %s failed",
+					ErrHookFailed,
+					hook.Phase,
+					obj.GetNamespace(),
+					obj.GetName(),
+					hook.Target,
+				)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+			return fmt.Errorf(
+				"%w: %s hook Job %s/%s of %s did not complete after %s: %w",
+				ErrHookFailed,
+				hook.Phase,
+				obj.GetNamespace(),
+				obj.GetName(),
+				hook.Target,
+				hook.Timeout,
+				err,
+			)
+		case <-ticker.C:
+		}
+	}
+}
+
+// jobCondition reports whether the Job has a condition of the given type with status True.
+func jobCondition(job *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(job.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]any)
+		if !ok {
+			continue
+		}
+		if conditionMap["type"] == conditionType && conditionMap["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	ErrInvalidKindPattern = errors.New("Invalid kind pattern")
+	ErrKindNotAllowed     = errors.New("Kind not allowed")
+)
+
+// KindFilter restricts the kinds of manifests and patches, which are applied and garbage collected.
+// Patterns have the format group/Kind. Core kinds omit the group, e.g. Secret,
+// and * matches all kinds of a group, e.g. rbac.authorization.k8s.io/*.
+// Helm releases, whose chart renders objects or contains CRDs of kinds, which are not allowed, are refused.
+type KindFilter struct {
+	// Allowed patterns. All kinds are allowed, if empty.
+	Allowed []string
+
+	// Denied patterns take precedence over allowed patterns.
+	Denied []string
+}
+
+// IsZero reports whether the filter allows all kinds.
+func (filter KindFilter) IsZero() bool {
+	return len(filter.Allowed) == 0 && len(filter.Denied) == 0
+}
+
+// Validate returns an error for the first malformed pattern.
+func (filter KindFilter) Validate() error {
+	for _, pattern := range slices.Concat(filter.Allowed, filter.Denied) {
+		kind := pattern
+		if index := strings.LastIndex(pattern, "/"); index != -1 {
+			if index == 0 {
+				return fmt.Errorf("%w: %s has an empty group", ErrInvalidKindPattern, pattern)
+			}
+			kind = pattern[index+1:]
+		}
+		if kind == "" || strings.Contains(pattern[:len(pattern)-len(kind)], "*") {
+			return fmt.Errorf("%w: expected group/Kind, got %s", ErrInvalidKindPattern, pattern)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether objects of the given kind may be applied and garbage collected.
+func (filter KindFilter) Allows(groupKind schema.GroupKind) bool {
+	if slices.ContainsFunc(filter.Denied, kindMatcher(groupKind)) {
+		return false
+	}
+	return len(filter.Allowed) == 0 || slices.ContainsFunc(filter.Allowed, kindMatcher(groupKind))
+}
+
+// Check returns an error wrapping [ErrKindNotAllowed], if the filter does not allow objects of the given apiVersion and kind.
+func (filter KindFilter) Check(apiVersion string, kind string) error {
+	groupKind := schema.FromAPIVersionAndKind(apiVersion, kind).GroupKind()
+	if !filter.Allows(groupKind) {
+		return fmt.Errorf("%w: %s", ErrKindNotAllowed, groupKind)
+	}
+	return nil
+}
+
+func kindMatcher(groupKind schema.GroupKind) func(pattern string) bool {
+	return func(pattern string) bool {
+		group, kind := "", pattern
+		if index := strings.LastIndex(pattern, "/"); index != -1 {
+			group, kind = pattern[:index], pattern[index+1:]
+		}
+		return group == groupKind.Group && (kind == "*" || kind == groupKind.Kind)
+	}
+}
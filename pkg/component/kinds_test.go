@@ -0,0 +1,90 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component_test
+
+import (
+	"testing"
+
+	"github.com/kharf/navecd/pkg/component"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKindFilter_Allows(t *testing.T) {
+	clusterRole := schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}
+	secret := schema.GroupKind{Kind: "Secret"}
+	deployment := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	testCases := []struct {
+		name     string
+		filter   component.KindFilter
+		expected map[schema.GroupKind]bool
+	}{
+		{
+			name:     "Empty",
+			filter:   component.KindFilter{},
+			expected: map[schema.GroupKind]bool{clusterRole: true, secret: true, deployment: true},
+		},
+		{
+			name:     "Denied-Group",
+			filter:   component.KindFilter{Denied: []string{"rbac.authorization.k8s.io/*"}},
+			expected: map[schema.GroupKind]bool{clusterRole: false, secret: true, deployment: true},
+		},
+		{
+			name:     "Allowed",
+			filter:   component.KindFilter{Allowed: []string{"apps/*", "Secret"}},
+			expected: map[schema.GroupKind]bool{clusterRole: false, secret: true, deployment: true},
+		},
+		{
+			name: "Denied-Precedence",
+			filter: component.KindFilter{
+				Allowed: []string{"apps/*", "Secret"},
+				Denied:  []string{"Secret"},
+			},
+			expected: map[schema.GroupKind]bool{clusterRole: false, secret: false, deployment: true},
+		},
+		{
+			name:     "Core-Group",
+			filter:   component.KindFilter{Allowed: []string{"*"}},
+			expected: map[schema.GroupKind]bool{clusterRole: false, secret: true, deployment: false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NilError(t, tc.filter.Validate())
+			for groupKind, expected := range tc.expected {
+				assert.Equal(t, tc.filter.Allows(groupKind), expected, groupKind.String())
+			}
+		})
+	}
+}
+
+func TestKindFilter_Check(t *testing.T) {
+	filter := component.KindFilter{Denied: []string{"rbac.authorization.k8s.io/*"}}
+
+	err := filter.Check("rbac.authorization.k8s.io/v1", "ClusterRole")
+	assert.ErrorIs(t, err, component.ErrKindNotAllowed)
+	assert.ErrorContains(t, err, "ClusterRole.rbac.authorization.k8s.io")
+
+	assert.NilError(t, filter.Check("v1", "Secret"))
+}
+
+func TestKindFilter_Validate(t *testing.T) {
+	for _, pattern := range []string{"", "/Secret", "apps/", "*/Deployment"} {
+		err := component.KindFilter{Allowed: []string{pattern}}.Validate()
+		assert.ErrorIs(t, err, component.ErrInvalidKindPattern, pattern)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrInvalidRawManifest = errors.New("Invalid raw manifest")
+)
+
+// decodeRawManifests parses the YAML or JSON content of the raw manifest component with the given id into a manifest per object.
+// Multiple objects are separated by YAML document separators. Every manifest gets the id of its object,
+// see [ManifestID], and the dependencies of the raw manifest.
+// Only the manifest with the given id is returned, if componentName is set.
+func decodeRawManifests(id string, content string, dependencies []string, componentName string) ([]*Manifest, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(content)))
+
+	var manifests []*Manifest
+	for document := 0; ; document++ {
+		data, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: document %d: %w", ErrInvalidRawManifest, id, document, err)
+		}
+
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: document %d: %w", ErrInvalidRawManifest, id, document, err)
+		}
+		// empty documents and documents only containing comments.
+		trimmed := bytes.TrimSpace(jsonData)
+		if len(trimmed) == 0 || string(trimmed) == "null" {
+			continue
+		}
+
+		if trimmed[0] != '{' {
+			return nil, fmt.Errorf(
+				"%w: %s: document %d: expected content to be of type struct or a string of YAML or JSON objects, got %s",
+				ErrInvalidRawManifest,
+				id,
+				document,
+				trimmed,
+			)
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(jsonData); err != nil {
+			return nil, fmt.Errorf("%w: %s: document %d: %w", ErrInvalidRawManifest, id, document, err)
+		}
+
+		manifest := Manifest{
+			ID:           ManifestID(obj.GetAPIVersion(), obj.GetKind(), obj.GetName(), obj.GetNamespace()),
+			Dependencies: slices.Clone(dependencies),
+			Content: ExtendedUnstructured{
+				Unstructured: obj,
+			},
+		}
+		if err := validateManifest(manifest); err != nil {
+			return nil, fmt.Errorf("%w: %s: document %d: %w", ErrInvalidRawManifest, id, document, err)
+		}
+
+		if componentName != "" && manifest.ID != componentName {
+			continue
+		}
+		manifests = append(manifests, &manifest)
+	}
+
+	if len(manifests) == 0 && componentName == "" {
+		return nil, fmt.Errorf("%w: %s declares no objects", ErrInvalidRawManifest, id)
+	}
+
+	return manifests, nil
+}
+
+// rekey moves the value stored for the id of a raw manifest to the ids of its objects.
+func rekey[V any](values map[string]V, id string, ids []string) {
+	value, found := values[id]
+	if !found {
+		return
+	}
+
+	delete(values, id)
+	for _, objectID := range ids {
+		values[objectID] = value
+	}
+}
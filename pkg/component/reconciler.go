@@ -18,15 +18,38 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/kharf/navecd/pkg/helm"
 	"github.com/kharf/navecd/pkg/inventory"
 	"github.com/kharf/navecd/pkg/kube"
 	"golang.org/x/sync/errgroup"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+var (
+	ErrPatchTargetNotFound  = errors.New("Patch target not found")
+	ErrPodSecurityViolation = errors.New("PodSecurity violation")
+)
+
+// PausedAnnotation pauses the reconciliation of a live object, when set to "true".
+// Operators can set it on an object to temporarily change it by hand, e.g. for debugging.
+const PausedAnnotation = "navecd.io/paused"
+
+// SourceAnnotation points to the declaration of the component, which produced an applied object, in the format file:line.
+const SourceAnnotation = "navecd.io/source"
+
 // Reconciler reads Components with their desired state
 // and applies them on a Kubernetes cluster.
 // It stores objects in the inventory.
@@ -52,8 +75,86 @@ type Reconciler struct {
 
 	// Limit of concurrent reconciliations.
 	WorkerPoolSize int
+
+	// Suspended holds the ids of components, which are skipped.
+	// Their inventory items are kept, so that they are not garbage collected.
+	Suspended []string
+
+	// Keep holds the ids of manifests, whose objects are kept in the cluster, when they are removed from the project.
+	// They are recorded with [inventory.KeepAnnotation] in the inventory, but not on the live object.
+	Keep []string
+
+	// Requires maps ids of components to the APIs, which have to be served by the cluster.
+	// Components requiring unavailable APIs are skipped.
+	Requires map[string][]string
+
+	// WaitFor maps ids of components to the condition of their live object, which has to be met before dependents are applied.
+	// Components, whose condition is not met yet, are reported as waiting and their dependents are skipped.
+	// Components, whose condition is not met within its timeout, fail.
+	WaitFor map[string]WaitCondition
+
+	// Waits keeps the deadlines of unmet wait conditions across reconciliations.
+	// Without it, wait conditions never time out.
+	Waits *WaitTracker
+
+	// Hooks maps ids of Job manifests to the component they run for. Dependents of a hook wait for its Job to complete.
+	// Hooks, whose Job fails or does not complete within its timeout, fail.
+	Hooks map[string]JobHook
+
+	// ApplyTimeout bounds how long applies wait for objects to become ready, e.g. CustomResourceDefinitions to be established.
+	// Defaults to kube.DefaultApplyTimeout.
+	ApplyTimeout time.Duration
+
+	// ApplyMaxTimeout is the hard cap for slow applies, whose object makes progress. See [kube.ApplyTimeout].
+	// Zero means the deadline is never extended.
+	ApplyMaxTimeout time.Duration
+
+	// DryRun validates manifests and patches with a server-side dry run without persisting them or storing them in the inventory.
+	// Helm releases are skipped.
+	DryRun bool
+
+	// ApplyStatus applies declared status fields.
+	// By default they are stripped, so that the status set by the owning controller is not overwritten.
+	ApplyStatus bool
+
+	// ImagePullSecrets are added to the imagePullSecrets of all manifests containing a pod spec.
+	ImagePullSecrets []string
+
+	// ImageRegistryPrefix replaces the registry host of all container images of manifests containing a pod spec, if set.
+	// Only applied objects are rewritten, the inventory keeps the declared images.
+	ImageRegistryPrefix string
+
+	// Owner is set as owner of all applied manifests, if set. See [kube.SetOwner].
+	// Only applied objects carry it, the inventory keeps the declared manifests. Patches and Helm releases are not owned.
+	Owner *kube.Owner
+
+	// Sources maps ids of components to the position of their declaration, which is set as [SourceAnnotation] on applied manifests.
+	// Like the owner, only applied objects carry it.
+	Sources map[string]string
+
+	// CheckPodSecurity evaluates manifests containing a pod spec against the PodSecurity level enforced by their namespace
+	// and refuses to apply violating manifests with a description of every violation.
+	CheckPodSecurity bool
+
+	// KindFilter refuses to apply manifests and patches of kinds, which are not allowed.
+	KindFilter KindFilter
+
+	// ReportOutcome is called with the outcome of every component, if set.
+	// It may be called concurrently.
+	ReportOutcome func(instance Instance, outcome Outcome, err error)
+
+	// ReportChange is called for every applied component, whose desired state differs from the state stored in the inventory, if set.
+	// It may be called concurrently.
+	ReportChange func(instance Instance)
+
+	// ReportRelease is called with the installed release of every reconciled Helm release component, if set.
+	// It may be called concurrently.
+	ReportRelease func(instance Instance, release *helm.Release)
 }
 
+// Reconcile applies the instances layer by layer.
+// A failing component only skips its dependents, all other components are applied and tracked in the inventory right away,
+// so that a retry continues incrementally. The first error is returned.
 func (reconciler *Reconciler) Reconcile(
 	ctx context.Context,
 	instances []Instance,
@@ -62,10 +163,14 @@ func (reconciler *Reconciler) Reconcile(
 
 	var firstError error
 	var prevLayerErrComponents map[string]struct{}
+	waitingComponents := make(map[string]struct{})
+	levels := &podSecurityLevels{
+		levels: make(map[string]string),
+	}
 
 	for _, layer := range instanceLayers {
 		var err error
-		prevLayerErrComponents, err = reconciler.reconcileLayer(ctx, layer, prevLayerErrComponents)
+		prevLayerErrComponents, err = reconciler.reconcileLayer(ctx, layer, levels, prevLayerErrComponents, waitingComponents)
 		if err != nil && firstError == nil {
 			firstError = err
 		}
@@ -74,10 +179,47 @@ func (reconciler *Reconciler) Reconcile(
 	return firstError
 }
 
+// Outcome is the result of reconciling a single component.
+type Outcome string
+
+const (
+	OutcomeSuccess   Outcome = "success"
+	OutcomeFailure   Outcome = "failure"
+	OutcomeSkipped   Outcome = "skipped"
+	OutcomeSuspended Outcome = "suspended"
+
+	// OutcomePaused means that the live object is annotated with [PausedAnnotation].
+	OutcomePaused Outcome = "paused"
+
+	// OutcomeProgressing means that the component was applied, but has not converged to a ready state yet.
+	OutcomeProgressing Outcome = "progressing"
+
+	// OutcomeWaiting means that the component was applied, but its wait condition is not met yet.
+	// Its dependents are skipped until it is met on a later reconciliation.
+	OutcomeWaiting Outcome = "waiting"
+)
+
+func (reconciler *Reconciler) report(instance Instance, outcome Outcome, err error) {
+	if reconciler.ReportOutcome != nil {
+		reconciler.ReportOutcome(instance, outcome, err)
+	}
+}
+
+func (reconciler *Reconciler) reportChange(instance Instance, changed bool) {
+	if changed && reconciler.ReportChange != nil {
+		reconciler.ReportChange(instance)
+	}
+}
+
+// reconcileLayer reconciles the components of the layer concurrently and returns the ids of the components, which failed.
+// The ids of waiting components and their skipped dependents are added to waitingComponents,
+// which holds back dependents in all following layers.
 func (reconciler *Reconciler) reconcileLayer(
 	ctx context.Context,
 	layer InstanceLayer,
+	levels *podSecurityLevels,
 	prevLayerErrComponents map[string]struct{},
+	waitingComponents map[string]struct{},
 ) (map[string]struct{}, error) {
 	recEG := errgroup.Group{}
 	recEG.SetLimit(reconciler.WorkerPoolSize)
@@ -85,6 +227,9 @@ func (reconciler *Reconciler) reconcileLayer(
 	errChan := make(chan string)
 	errComponents := make(map[string]struct{}, len(layer.Components))
 
+	waitChan := make(chan string)
+	layerWaitingComponents := make(map[string]struct{})
+
 	errComponentsEG := errgroup.Group{}
 	errComponentsEG.Go(func() error {
 		for component := range errChan {
@@ -93,107 +238,615 @@ func (reconciler *Reconciler) reconcileLayer(
 
 		return nil
 	})
+	errComponentsEG.Go(func() error {
+		for component := range waitChan {
+			layerWaitingComponents[component] = struct{}{}
+		}
 
-	if len(prevLayerErrComponents) != 0 {
-		for _, instance := range layer.Components {
-			recEG.Go(func() error {
-				for _, dep := range instance.GetDependencies() {
-					if _, found := prevLayerErrComponents[dep]; found {
-						reconciler.Log.V(0).
-							Info("Errorneous dependency. Skipping component", "id", instance.GetID())
-						return nil
-					}
-				}
+		return nil
+	})
 
-				if err := reconciler.reconcile(ctx, instance); err != nil {
-					reconciler.Log.Error(err,
-						"Unable to reconcile component",
-						"id",
-						instance.GetID(),
+	for _, instance := range layer.Components {
+		recEG.Go(func() error {
+			log := reconciler.Log.WithValues(componentLogValues(instance)...)
+
+			for _, dep := range instance.GetDependencies() {
+				if _, found := prevLayerErrComponents[dep]; found {
+					log.V(0).Info(
+						"Errorneous dependency. Skipping component",
+						"dependency",
+						dep,
+						"outcome",
+						OutcomeSkipped,
 					)
-
-					errChan <- instance.GetID()
-					return err
+					reconciler.report(instance, OutcomeSkipped, nil)
+					return nil
 				}
-
-				return nil
-			})
-		}
-	} else {
-		for _, instance := range layer.Components {
-			recEG.Go(func() error {
-				if err := reconciler.reconcile(ctx, instance); err != nil {
-					reconciler.Log.Error(err,
-						"Unable to reconcile component",
-						"id",
-						instance.GetID(),
+				if _, found := waitingComponents[dep]; found {
+					log.V(0).Info(
+						"Waiting for dependency. Skipping component",
+						"dependency",
+						dep,
+						"outcome",
+						OutcomeSkipped,
 					)
+					reconciler.report(instance, OutcomeSkipped, nil)
 
-					errChan <- instance.GetID()
-					return err
+					waitChan <- instance.GetID()
+					return nil
 				}
-
+			}
+
+			if slices.Contains(reconciler.Suspended, instance.GetID()) {
+				log.V(0).Info(
+					"Suspended. Skipping component",
+					"outcome",
+					OutcomeSuspended,
+				)
+				reconciler.report(instance, OutcomeSuspended, nil)
 				return nil
-			})
-		}
+			}
+
+			unavailableAPI, err := reconciler.unavailableAPI(instance)
+			if err != nil {
+				log.Error(err,
+					"Unable to discover required APIs",
+					"outcome",
+					OutcomeFailure,
+				)
+				reconciler.report(instance, OutcomeFailure, err)
+
+				errChan <- instance.GetID()
+				return err
+			}
+			if unavailableAPI != "" {
+				log.V(0).Info(
+					"Required API not available. Skipping component",
+					"api",
+					unavailableAPI,
+					"outcome",
+					OutcomeSkipped,
+				)
+				reconciler.report(instance, OutcomeSkipped, nil)
+				return nil
+			}
+
+			paused, err := reconciler.paused(ctx, instance)
+			if err != nil {
+				log.Error(err,
+					"Unable to read live object",
+					"outcome",
+					OutcomeFailure,
+				)
+				reconciler.report(instance, OutcomeFailure, err)
+
+				errChan <- instance.GetID()
+				return err
+			}
+			if paused {
+				log.V(0).Info(
+					"Live object paused. Skipping component",
+					"annotation",
+					PausedAnnotation,
+					"outcome",
+					OutcomePaused,
+				)
+				reconciler.report(instance, OutcomePaused, nil)
+				return nil
+			}
+
+			if _, isRelease := instance.(*helm.ReleaseComponent); isRelease && reconciler.DryRun {
+				log.V(0).Info(
+					"Dry run. Skipping Helm release",
+					"outcome",
+					OutcomeSkipped,
+				)
+				reconciler.report(instance, OutcomeSkipped, nil)
+				return nil
+			}
+
+			start := time.Now()
+			outcome, err := reconciler.reconcile(ctx, instance, levels)
+			duration := time.Since(start)
+			if err != nil {
+				log.Error(err,
+					"Unable to reconcile component",
+					"duration",
+					duration,
+					"outcome",
+					OutcomeFailure,
+				)
+				reconciler.report(instance, OutcomeFailure, err)
+
+				errChan <- instance.GetID()
+				return err
+			}
+
+			if outcome == OutcomeWaiting {
+				waitChan <- instance.GetID()
+			}
+
+			log.V(1).Info(
+				"Reconciled component",
+				"duration",
+				duration,
+				"outcome",
+				outcome,
+			)
+			reconciler.report(instance, outcome, nil)
+
+			return nil
+		})
 	}
 
 	recErr := recEG.Wait()
 
 	close(errChan)
+	close(waitChan)
 
 	_ = errComponentsEG.Wait()
+	maps.Copy(waitingComponents, layerWaitingComponents)
 
 	return errComponents, recErr
 }
 
+// unavailableAPI returns the first API required by the component, which is not served by the cluster.
+func (reconciler *Reconciler) unavailableAPI(instance Instance) (string, error) {
+	for _, api := range reconciler.Requires[instance.GetID()] {
+		gvk, err := ParseRequiredAPI(api)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := reconciler.DynamicClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				return api, nil
+			}
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// paused reports whether the live object of a manifest or patch is annotated with [PausedAnnotation].
+// Only objects, which have been applied before, can be paused.
+// Components, which are not in the inventory yet, are therefore not looked up.
+func (reconciler *Reconciler) paused(ctx context.Context, instance Instance) (bool, error) {
+	var unstr kube.ExtendedUnstructured
+	var item inventory.Item
+	switch componentInstance := instance.(type) {
+	case *Manifest:
+		unstr = componentInstance.Content
+		item = manifestItem(componentInstance)
+	case *Patch:
+		unstr = componentInstance.Content
+		item = patchItem(componentInstance)
+	default:
+		return false, nil
+	}
+
+	if !reconciler.InventoryInstance.HasItem(item) {
+		return false, nil
+	}
+
+	live, err := reconciler.DynamicClient.Get(ctx, &unstr)
+	if err != nil {
+		// the API of the object may be served only after its CRD has been applied.
+		if k8sErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return live.GetAnnotations()[PausedAnnotation] == "true", nil
+}
+
+// podSecurityLevels caches the PodSecurity levels enforced by namespaces for a single reconciliation,
+// so that a namespace is read once and not for every manifest containing a pod spec.
+type podSecurityLevels struct {
+	mu     sync.Mutex
+	levels map[string]string
+}
+
+// podSecurityLevel returns the PodSecurity level enforced by the namespace and whether the namespace exists.
+// Missing namespaces are not cached, because they may be applied later in the same reconciliation.
+func (reconciler *Reconciler) podSecurityLevel(
+	ctx context.Context,
+	levels *podSecurityLevels,
+	name string,
+) (string, bool, error) {
+	levels.mu.Lock()
+	level, found := levels.levels[name]
+	levels.mu.Unlock()
+	if found {
+		return level, true, nil
+	}
+
+	namespace := &unstructured.Unstructured{}
+	namespace.SetAPIVersion("v1")
+	namespace.SetKind("Namespace")
+	namespace.SetName(name)
+	live, err := reconciler.DynamicClient.DynamicClient().Get(ctx, namespace)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	level = live.GetLabels()[kube.PodSecurityEnforceLabel]
+	levels.mu.Lock()
+	levels.levels[name] = level
+	levels.mu.Unlock()
+
+	return level, true, nil
+}
+
+// checkPodSecurity returns an error describing every violation of the PodSecurity level enforced by the namespace of obj.
+// Objects in namespaces, which do not exist yet, are not checked.
+func (reconciler *Reconciler) checkPodSecurity(
+	ctx context.Context,
+	levels *podSecurityLevels,
+	obj *unstructured.Unstructured,
+) error {
+	if obj.GetNamespace() == "" || !kube.HasPodSpec(obj.GetKind()) {
+		return nil
+	}
+
+	level, found, err := reconciler.podSecurityLevel(ctx, levels, obj.GetNamespace())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	violations, err := kube.PodSecurityViolations(obj, level)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) != 0 {
+		return fmt.Errorf(
+			"%w: %s %s/%s violates the %s level enforced by its namespace: %s",
+			ErrPodSecurityViolation,
+			obj.GetKind(),
+			obj.GetNamespace(),
+			obj.GetName(),
+			level,
+			strings.Join(violations, ", "),
+		)
+	}
+
+	return nil
+}
+
+// reconcile applies the component and returns whether it is ready, progressing or waiting for its wait condition.
 func (reconciler *Reconciler) reconcile(
 	ctx context.Context,
 	instance Instance,
-) error {
+	levels *podSecurityLevels,
+) (Outcome, error) {
 	switch componentInstance := instance.(type) {
 	case *Manifest:
-		reconciler.Log.V(1).Info(
-			"Applying manifest",
-			"namespace",
-			componentInstance.GetNamespace(),
-			"name",
-			componentInstance.GetName(),
-			"kind",
-			componentInstance.GetKind(),
+		if err := reconciler.KindFilter.Check(componentInstance.GetAPIVersion(), componentInstance.GetKind()); err != nil {
+			return OutcomeFailure, err
+		}
+		unstr := componentInstance.Content
+		unstr.Unstructured = unstr.DeepCopy()
+		kube.SetManagedBy(unstr.Unstructured, reconciler.FieldManager)
+		if err := kube.AddImagePullSecrets(unstr.Unstructured, reconciler.ImagePullSecrets); err != nil {
+			return OutcomeFailure, err
+		}
+		desired := unstr
+		source, hasSource := reconciler.Sources[componentInstance.ID]
+		if reconciler.ImageRegistryPrefix != "" || reconciler.Owner != nil || hasSource {
+			desired.Unstructured = unstr.DeepCopy()
+		}
+		if reconciler.ImageRegistryPrefix != "" {
+			if err := kube.RewriteImageRegistry(desired.Unstructured, reconciler.ImageRegistryPrefix); err != nil {
+				return OutcomeFailure, err
+			}
+		}
+		if reconciler.Owner != nil {
+			kube.SetOwner(desired.Unstructured, *reconciler.Owner)
+		}
+		if hasSource {
+			annotations := desired.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string, 1)
+			}
+			annotations[SourceAnnotation] = source
+			desired.SetAnnotations(annotations)
+		}
+		if reconciler.CheckPodSecurity {
+			if err := reconciler.checkPodSecurity(ctx, levels, desired.Unstructured); err != nil {
+				return OutcomeFailure, err
+			}
+		}
+		completed, err := reconciler.hookCompleted(componentInstance, unstr)
+		if err != nil {
+			return OutcomeFailure, err
+		}
+		if completed {
+			return OutcomeSuccess, nil
+		}
+		if err := reconciler.replaceJob(ctx, componentInstance, unstr); err != nil {
+			return OutcomeFailure, err
+		}
+		applied, err := reconciler.DynamicClient.Apply(
+			ctx,
+			&desired,
+			reconciler.FieldManager,
+			kube.ForceApply(true),
+			kube.DryRunApply(reconciler.DryRun),
+			kube.ApplyStatus(reconciler.ApplyStatus),
+			kube.ApplyTimeout(reconciler.ApplyTimeout, reconciler.ApplyMaxTimeout),
 		)
+		if reconciler.DryRun {
+			if err != nil {
+				return OutcomeFailure, err
+			}
+			return OutcomeSuccess, nil
+		}
 
-		unstr := componentInstance.Content
-		if _, err := reconciler.DynamicClient.Apply(ctx, &unstr, reconciler.FieldManager, kube.ForceApply(true)); err != nil {
-			return err
+		if err != nil {
+			// The object may exist despite the error, e.g. when waiting for it timed out.
+			// Tracking it keeps retries incremental and lets the garbage collector find it.
+			// Objects not managed by us are left untracked, so that the garbage collector never deletes them.
+			if existing, getErr := reconciler.DynamicClient.Get(ctx, &unstr); getErr == nil &&
+				kube.IsManagedBy(existing, reconciler.FieldManager) {
+				if _, trackErr := reconciler.trackManifest(componentInstance, unstr); trackErr != nil {
+					return OutcomeFailure, errors.Join(err, trackErr)
+				}
+			}
+			return OutcomeFailure, err
+		}
+
+		changed, err := reconciler.trackManifest(componentInstance, unstr)
+		if err != nil {
+			return OutcomeFailure, err
+		}
+		reconciler.reportChange(instance, changed)
+
+		met, err := reconciler.waitFor(ctx, instance, &unstr)
+		if err != nil {
+			return OutcomeFailure, err
+		}
+		if !met {
+			return OutcomeWaiting, nil
+		}
+
+		if err := reconciler.waitForJob(ctx, instance, &unstr); err != nil {
+			return OutcomeFailure, err
+		}
+		if err := reconciler.recordHookCompletion(componentInstance, unstr); err != nil {
+			return OutcomeFailure, err
 		}
 
-		invManifest := &inventory.ManifestItem{
-			ID: componentInstance.ID,
-			TypeMeta: v1.TypeMeta{
-				Kind:       componentInstance.GetKind(),
-				APIVersion: componentInstance.GetAPIVersion(),
-			},
-			Name:      componentInstance.GetName(),
-			Namespace: componentInstance.GetNamespace(),
+		if applied != nil && !kube.IsReady(applied) {
+			return OutcomeProgressing, nil
 		}
+		return OutcomeSuccess, nil
 
-		buf := &bytes.Buffer{}
-		if err := json.NewEncoder(buf).Encode(unstr.Object); err != nil {
-			return err
+	case *Patch:
+		if err := reconciler.KindFilter.Check(componentInstance.GetAPIVersion(), componentInstance.GetKind()); err != nil {
+			return OutcomeFailure, err
+		}
+		changed, err := reconciler.reconcilePatch(ctx, componentInstance)
+		if err != nil {
+			return OutcomeFailure, err
 		}
+		reconciler.reportChange(instance, changed)
 
-		if err := reconciler.InventoryInstance.StoreItem(invManifest, buf); err != nil {
-			return err
+		met, err := reconciler.waitFor(ctx, instance, &componentInstance.Content)
+		if err != nil {
+			return OutcomeFailure, err
+		}
+		if !met {
+			return OutcomeWaiting, nil
 		}
 
 	case *helm.ReleaseComponent:
-		if _, err := reconciler.ChartReconciler.Reconcile(
+		invRelease := &inventory.HelmReleaseItem{
+			Name:      componentInstance.Content.Name,
+			Namespace: componentInstance.Content.Namespace,
+			ID:        componentInstance.ID,
+		}
+		previous, _ := reconciler.itemContent(invRelease)
+
+		release, err := reconciler.ChartReconciler.Reconcile(
 			ctx,
 			componentInstance,
-		); err != nil {
-			return err
+		)
+		if err != nil {
+			return OutcomeFailure, err
+		}
+
+		invRelease.Name = release.Name
+		invRelease.Namespace = release.Namespace
+		current, err := reconciler.itemContent(invRelease)
+		if err != nil {
+			return OutcomeFailure, err
+		}
+		reconciler.reportChange(instance, !bytes.Equal(previous, current))
+		if reconciler.ReportRelease != nil {
+			reconciler.ReportRelease(instance, release)
 		}
 	}
-	return nil
+	return OutcomeSuccess, nil
+}
+
+// itemContent reads the content of the item stored in the inventory.
+func (reconciler *Reconciler) itemContent(item inventory.Item) ([]byte, error) {
+	reader, err := reconciler.InventoryInstance.GetItem(item)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// storeItem persists the item with its content in the inventory and reports whether the content differs from the stored one.
+func (reconciler *Reconciler) storeItem(item inventory.Item, content []byte) (bool, error) {
+	previous, err := reconciler.itemContent(item)
+	changed := err != nil || !bytes.Equal(previous, content)
+
+	if err := reconciler.InventoryInstance.StoreItem(item, bytes.NewReader(content)); err != nil {
+		return false, err
+	}
+
+	return changed, nil
+}
+
+// trackManifest stores the applied manifest in the inventory and reports whether it changed since it was last stored.
+func (reconciler *Reconciler) trackManifest(manifest *Manifest, unstr kube.ExtendedUnstructured) (bool, error) {
+	content, err := reconciler.manifestContent(manifest, unstr, false)
+	if err != nil {
+		return false, err
+	}
+
+	return reconciler.storeItem(manifestItem(manifest), content)
+}
+
+// manifestContent returns the content of the manifest as it is stored in the inventory.
+// Markers only relevant to Navecd, like [inventory.KeepAnnotation] and [inventory.HookCompletedAnnotation],
+// are recorded as annotations of the stored content, but never applied to the cluster.
+func (reconciler *Reconciler) manifestContent(manifest *Manifest, unstr kube.ExtendedUnstructured, hookCompleted bool) ([]byte, error) {
+	markers := make(map[string]string, 2)
+	if slices.Contains(reconciler.Keep, manifest.ID) {
+		markers[inventory.KeepAnnotation] = "true"
+	}
+	if hookCompleted {
+		markers[inventory.HookCompletedAnnotation] = "true"
+	}
+	if len(markers) == 0 {
+		return encodeManifest(unstr)
+	}
+
+	marked := unstr
+	marked.Unstructured = unstr.DeepCopy()
+	annotations := marked.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, len(markers))
+	}
+	maps.Copy(annotations, markers)
+	marked.SetAnnotations(annotations)
+	return encodeManifest(marked)
+}
+
+func manifestItem(manifest *Manifest) *inventory.ManifestItem {
+	return &inventory.ManifestItem{
+		ID: manifest.ID,
+		TypeMeta: v1.TypeMeta{
+			Kind:       manifest.GetKind(),
+			APIVersion: manifest.GetAPIVersion(),
+		},
+		Name:      manifest.GetName(),
+		Namespace: manifest.GetNamespace(),
+	}
+}
+
+// encodeManifest returns the content of the manifest as it is stored in the inventory.
+func encodeManifest(unstr kube.ExtendedUnstructured) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(unstr.Object); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// reconcilePatch merges the patch into the existing object through a Server-Side Apply,
+// so that Navecd only owns the declared fields.
+// Unlike manifests, patch targets are never created.
+// It reports whether the patch changed since it was last stored in the inventory.
+func (reconciler *Reconciler) reconcilePatch(
+	ctx context.Context,
+	patch *Patch,
+) (bool, error) {
+	unstr := patch.Content
+	if _, err := reconciler.DynamicClient.Get(ctx, &unstr); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return false, fmt.Errorf(
+				"%w: %s %s/%s",
+				ErrPatchTargetNotFound,
+				patch.GetKind(),
+				patch.GetNamespace(),
+				patch.GetName(),
+			)
+		}
+		return false, err
+	}
+
+	if _, err := reconciler.DynamicClient.Apply(
+		ctx,
+		&unstr,
+		reconciler.FieldManager,
+		kube.ForceApply(true),
+		kube.DryRunApply(reconciler.DryRun),
+		kube.ApplyStatus(reconciler.ApplyStatus),
+		kube.ApplyTimeout(reconciler.ApplyTimeout, reconciler.ApplyMaxTimeout),
+	); err != nil {
+		return false, err
+	}
+
+	if reconciler.DryRun {
+		return false, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(unstr.Object); err != nil {
+		return false, err
+	}
+
+	return reconciler.storeItem(patchItem(patch), buf.Bytes())
+}
+
+func patchItem(patch *Patch) *inventory.PatchItem {
+	return &inventory.PatchItem{
+		ID: patch.ID,
+		TypeMeta: v1.TypeMeta{
+			Kind:       patch.GetKind(),
+			APIVersion: patch.GetAPIVersion(),
+		},
+		Name:      patch.GetName(),
+		Namespace: patch.GetNamespace(),
+	}
+}
+
+// componentLogValues returns the structured logging fields identifying a component.
+func componentLogValues(instance Instance) []any {
+	values := []any{"id", instance.GetID()}
+	switch componentInstance := instance.(type) {
+	case *Manifest:
+		values = append(values,
+			"kind",
+			componentInstance.GetKind(),
+			"namespace",
+			componentInstance.GetNamespace(),
+			"name",
+			componentInstance.GetName(),
+		)
+	case *Patch:
+		values = append(values,
+			"kind",
+			componentInstance.GetKind(),
+			"namespace",
+			componentInstance.GetNamespace(),
+			"name",
+			componentInstance.GetName(),
+		)
+	case *helm.ReleaseComponent:
+		values = append(values,
+			"kind",
+			"HelmRelease",
+			"namespace",
+			componentInstance.Content.Namespace,
+			"name",
+			componentInstance.Content.Name,
+		)
+	}
+	return values
 }
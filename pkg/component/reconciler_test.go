@@ -15,9 +15,15 @@
 package component_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/kharf/navecd/internal/helmtest"
@@ -30,6 +36,11 @@ import (
 	"go.uber.org/zap/zapcore"
 	"gotest.tools/v3/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	ctrlZap "sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -234,6 +245,1072 @@ func TestReconciler_Reconcile_Error(t *testing.T) {
 
 var err error
 
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestReconciler_Reconcile_Logging(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	cacheDir := t.TempDir()
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	publicHelmEnvironment, err := helmtest.NewHelmEnvironment(
+		t,
+		helmtest.WithOCI(false),
+		helmtest.WithPrivate(false),
+	)
+	assert.NilError(t, err)
+	defer func() {
+		publicHelmEnvironment.Close()
+		kubernetes.Stop()
+	}()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	logOutput := &syncBuffer{}
+	logOpts := ctrlZap.Options{
+		Development: false,
+		Level:       zapcore.Level(-1),
+	}
+	log := ctrlZap.New(ctrlZap.UseFlagOptions(&logOpts), ctrlZap.WriteTo(logOutput))
+
+	chartReconciler := helm.ChartReconciler{
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		Client:                kubernetes.DynamicTestKubeClient,
+		FieldManager:          "manager",
+		InventoryInstance:     inventoryInstance,
+		InsecureSkipTLSVerify: true,
+		PlainHTTP:             false,
+		Log:                   log,
+		ChartCacheRoot:        cacheDir,
+	}
+
+	reconciler := component.Reconciler{
+		Log:               log,
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		ChartReconciler:   chartReconciler,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+	}
+
+	instances := []component.Instance{
+		namespace("a", nil),
+		hr("b", "b", []string{}, publicHelmEnvironment.ChartServer.URL()),
+	}
+
+	err = reconciler.Reconcile(kubernetes.Ctx, instances)
+	assert.ErrorContains(t, err, `namespaces "b" not found`)
+
+	var success, failure map[string]any
+	for _, line := range strings.Split(logOutput.String(), "\n") {
+		if line == "" {
+			continue
+		}
+
+		entry := map[string]any{}
+		assert.NilError(t, json.Unmarshal([]byte(line), &entry))
+		switch entry["outcome"] {
+		case "success":
+			success = entry
+		case "failure":
+			failure = entry
+		}
+	}
+
+	assert.Assert(t, success != nil)
+	assert.Equal(t, success["id"], "a___Namespace")
+	assert.Equal(t, success["kind"], "Namespace")
+	assert.Equal(t, success["name"], "a")
+	assert.Equal(t, success["namespace"], "")
+	assert.Assert(t, success["duration"] != nil)
+
+	assert.Assert(t, failure != nil)
+	assert.Equal(t, failure["id"], "b_b_HelmRelease")
+	assert.Equal(t, failure["kind"], "HelmRelease")
+	assert.Equal(t, failure["name"], "b")
+	assert.Equal(t, failure["namespace"], "b")
+	assert.Assert(t, failure["duration"] != nil)
+}
+
+func TestReconciler_Reconcile_Patch(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{namespace("patch", nil)})
+	assert.NilError(t, err)
+
+	operatorDeployment := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]any{
+				"name":      "operator-managed",
+				"namespace": "patch",
+			},
+			"spec": map[string]any{
+				"replicas": int64(1),
+				"selector": map[string]any{
+					"matchLabels": map[string]any{
+						"app": "operator-managed",
+					},
+				},
+				"template": map[string]any{
+					"metadata": map[string]any{
+						"labels": map[string]any{
+							"app": "operator-managed",
+						},
+					},
+					"spec": map[string]any{
+						"containers": []any{
+							map[string]any{
+								"name":  "operator-managed",
+								"image": "operator-managed:1.0.0",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	_, err = kubernetes.DynamicTestKubeClient.DynamicClient().Apply(
+		kubernetes.Ctx,
+		operatorDeployment,
+		"operator",
+	)
+	assert.NilError(t, err)
+
+	patch := &component.Patch{
+		ID: "operator-managed_patch_apps_Deployment_Patch",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      "operator-managed",
+						"namespace": "patch",
+						"labels": map[string]any{
+							"team": "navecd",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{"patch___Namespace"},
+	}
+
+	err = reconciler.Reconcile(kubernetes.Ctx, []component.Instance{patch})
+	assert.NilError(t, err)
+
+	var deployment appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "operator-managed", Namespace: "patch"},
+		&deployment,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, deployment.Labels["team"], "navecd")
+	assert.Equal(t, *deployment.Spec.Replicas, int32(1))
+	assert.Equal(t, deployment.Spec.Template.Spec.Containers[0].Image, "operator-managed:1.0.0")
+
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, storage.Items()[patch.ID], &inventory.PatchItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		Name:      "operator-managed",
+		Namespace: "patch",
+		ID:        patch.ID,
+	})
+
+	missingPatch := &component.Patch{
+		ID: "missing_patch_apps_Deployment_Patch",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      "missing",
+						"namespace": "patch",
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+
+	err = reconciler.Reconcile(kubernetes.Ctx, []component.Instance{missingPatch})
+	assert.ErrorIs(t, err, component.ErrPatchTargetNotFound)
+}
+
+func TestReconciler_Reconcile_Preserve(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+	}
+
+	deployment := &component.Manifest{
+		ID: "scaled_preserve_apps_Deployment",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      "scaled",
+						"namespace": "preserve",
+					},
+					"spec": map[string]any{
+						"replicas": int64(1),
+						"selector": map[string]any{
+							"matchLabels": map[string]any{
+								"app": "scaled",
+							},
+						},
+						"template": map[string]any{
+							"metadata": map[string]any{
+								"labels": map[string]any{
+									"app": "scaled",
+								},
+							},
+							"spec": map[string]any{
+								"containers": []any{
+									map[string]any{
+										"name":  "scaled",
+										"image": "scaled:1.0.0",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Metadata: &kube.ManifestMetadata{
+				Node: map[string]kube.ManifestMetadata{
+					"spec": {
+						Node: map[string]kube.ManifestMetadata{
+							"replicas": {
+								Field: &kube.ManifestFieldMetadata{
+									Preserve: true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{"preserve___Namespace"},
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{namespace("preserve", nil), deployment})
+	assert.NilError(t, err)
+
+	var liveDeployment appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "scaled", Namespace: "preserve"},
+		&liveDeployment,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, *liveDeployment.Spec.Replicas, int32(1))
+
+	scale := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]any{
+				"name":      "scaled",
+				"namespace": "preserve",
+			},
+			"spec": map[string]any{
+				"replicas": int64(5),
+			},
+		},
+	}
+	_, err = kubernetes.DynamicTestKubeClient.DynamicClient().Apply(
+		kubernetes.Ctx,
+		scale,
+		"autoscaler",
+		kube.ForceApply(true),
+	)
+	assert.NilError(t, err)
+
+	for range 2 {
+		err = reconciler.Reconcile(kubernetes.Ctx, []component.Instance{namespace("preserve", nil), deployment})
+		assert.NilError(t, err)
+
+		err = kubernetes.TestKubeClient.Get(
+			context.Background(),
+			types.NamespacedName{Name: "scaled", Namespace: "preserve"},
+			&liveDeployment,
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, *liveDeployment.Spec.Replicas, int32(5))
+		assert.Equal(t, liveDeployment.Spec.Template.Spec.Containers[0].Image, "scaled:1.0.0")
+	}
+}
+
+func TestReconciler_Reconcile_ImagePullSecrets(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	reconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: kubernetes.DynamicTestKubeClient,
+		InventoryInstance: &inventory.Instance{
+			Path: inventoryDir,
+		},
+		FieldManager:     "manager",
+		WorkerPoolSize:   -1,
+		ImagePullSecrets: []string{"declared", "registry"},
+	}
+
+	deployment := &component.Manifest{
+		ID: "private_pull_apps_Deployment",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      "private",
+						"namespace": "pull",
+					},
+					"spec": map[string]any{
+						"selector": map[string]any{
+							"matchLabels": map[string]any{
+								"app": "private",
+							},
+						},
+						"template": map[string]any{
+							"metadata": map[string]any{
+								"labels": map[string]any{
+									"app": "private",
+								},
+							},
+							"spec": map[string]any{
+								"imagePullSecrets": []any{
+									map[string]any{
+										"name": "declared",
+									},
+								},
+								"containers": []any{
+									map[string]any{
+										"name":  "private",
+										"image": "registry.internal/private:1.0.0",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{"pull___Namespace"},
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{namespace("pull", nil), deployment})
+	assert.NilError(t, err)
+
+	var liveDeployment appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "private", Namespace: "pull"},
+		&liveDeployment,
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, liveDeployment.Spec.Template.Spec.ImagePullSecrets, []corev1.LocalObjectReference{
+		{Name: "declared"},
+		{Name: "registry"},
+	})
+
+	declared, _, err := unstructured.NestedSlice(
+		deployment.Content.Object,
+		"spec", "template", "spec", "imagePullSecrets",
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(declared), 1)
+}
+
+func TestReconciler_Reconcile_ImageRegistryPrefix(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryInstance := &inventory.Instance{
+		Path: t.TempDir(),
+	}
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	reconciler := component.Reconciler{
+		Log:                 logr.Discard(),
+		DynamicClient:       kubernetes.DynamicTestKubeClient,
+		InventoryInstance:   inventoryInstance,
+		FieldManager:        "manager",
+		WorkerPoolSize:      -1,
+		ImageRegistryPrefix: "mirror.example.com/",
+	}
+
+	deployment := &component.Manifest{
+		ID: "mirrored_mirror_apps_Deployment",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      "mirrored",
+						"namespace": "mirror",
+					},
+					"spec": map[string]any{
+						"selector": map[string]any{
+							"matchLabels": map[string]any{
+								"app": "mirrored",
+							},
+						},
+						"template": map[string]any{
+							"metadata": map[string]any{
+								"labels": map[string]any{
+									"app": "mirrored",
+								},
+							},
+							"spec": map[string]any{
+								"initContainers": []any{
+									map[string]any{
+										"name":  "init",
+										"image": "busybox:1.36",
+									},
+								},
+								"containers": []any{
+									map[string]any{
+										"name":  "mirrored",
+										"image": "ghcr.io/stefanprodan/podinfo:6.7.0",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{"mirror___Namespace"},
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{namespace("mirror", nil), deployment})
+	assert.NilError(t, err)
+
+	var liveDeployment appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "mirrored", Namespace: "mirror"},
+		&liveDeployment,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, liveDeployment.Spec.Template.Spec.InitContainers[0].Image, "mirror.example.com/busybox:1.36")
+	assert.Equal(t, liveDeployment.Spec.Template.Spec.Containers[0].Image, "mirror.example.com/stefanprodan/podinfo:6.7.0")
+
+	// the inventory keeps the declared image, which is what image update scans target.
+	reader, err := inventoryInstance.GetItem(&inventory.ManifestItem{
+		ID:        deployment.ID,
+		Name:      "mirrored",
+		Namespace: "mirror",
+	})
+	assert.NilError(t, err)
+	defer reader.Close()
+
+	var stored unstructured.Unstructured
+	err = json.NewDecoder(reader).Decode(&stored.Object)
+	assert.NilError(t, err)
+	containers, _, err := unstructured.NestedSlice(stored.Object, "spec", "template", "spec", "containers")
+	assert.NilError(t, err)
+	assert.Equal(t, containers[0].(map[string]any)["image"], "ghcr.io/stefanprodan/podinfo:6.7.0")
+}
+
+func TestReconciler_Reconcile_Owner(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryInstance := &inventory.Instance{
+		Path: t.TempDir(),
+	}
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+		Owner: &kube.Owner{
+			APIVersion: "gitops.navecd.io/v1beta1",
+			Kind:       "GitOpsProject",
+			Name:       "project",
+			Namespace:  "owned",
+			UID:        "5d2f0a2e-6a3f-4c1b-9d1e-3b6f0c7a9e21",
+		},
+	}
+
+	configMap := &component.Manifest{
+		ID: "owned_owned__ConfigMap",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      "owned",
+						"namespace": "owned",
+					},
+					"data": map[string]any{
+						"key": "value",
+					},
+				},
+			},
+		},
+		Dependencies: []string{"owned___Namespace"},
+	}
+	instances := []component.Instance{namespace("owned", nil), configMap}
+
+	// owner references survive subsequent reconciliations without being duplicated.
+	for range 2 {
+		err := reconciler.Reconcile(kubernetes.Ctx, instances)
+		assert.NilError(t, err)
+	}
+
+	var liveConfigMap corev1.ConfigMap
+	err := kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "owned", Namespace: "owned"},
+		&liveConfigMap,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(liveConfigMap.OwnerReferences), 1)
+	assert.Equal(t, liveConfigMap.OwnerReferences[0].Kind, "GitOpsProject")
+	assert.Equal(t, liveConfigMap.OwnerReferences[0].Name, "project")
+	assert.Equal(t, liveConfigMap.OwnerReferences[0].UID, reconciler.Owner.UID)
+	assert.Equal(t, liveConfigMap.Data["key"], "value")
+
+	// cluster-scoped objects cannot reference a namespaced owner.
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "owned"},
+		&ns,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(ns.OwnerReferences), 0)
+	assert.Equal(t, ns.Labels[kube.OwnerNameLabel], "project")
+	assert.Equal(t, ns.Labels[kube.OwnerNamespaceLabel], "owned")
+
+	reader, err := inventoryInstance.GetItem(&inventory.ManifestItem{
+		ID:        configMap.ID,
+		Name:      "owned",
+		Namespace: "owned",
+	})
+	assert.NilError(t, err)
+	defer reader.Close()
+
+	var stored unstructured.Unstructured
+	err = json.NewDecoder(reader).Decode(&stored.Object)
+	assert.NilError(t, err)
+	assert.Equal(t, len(stored.GetOwnerReferences()), 0)
+}
+
+func TestReconciler_Reconcile_Sources(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryInstance := &inventory.Instance{
+		Path: t.TempDir(),
+	}
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+		Sources: map[string]string{
+			"sourced___Namespace": "infra/sourced/namespace.cue:7",
+		},
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{namespace("sourced", nil)})
+	assert.NilError(t, err)
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "sourced"},
+		&ns,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, ns.Annotations[component.SourceAnnotation], "infra/sourced/namespace.cue:7")
+
+	reader, err := inventoryInstance.GetItem(&inventory.ManifestItem{
+		ID:   "sourced___Namespace",
+		Name: "sourced",
+	})
+	assert.NilError(t, err)
+	defer reader.Close()
+
+	var stored unstructured.Unstructured
+	err = json.NewDecoder(reader).Decode(&stored.Object)
+	assert.NilError(t, err)
+	_, found := stored.GetAnnotations()[component.SourceAnnotation]
+	assert.Assert(t, !found)
+}
+
+func TestReconciler_Reconcile_Suspended(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+		Suspended:         []string{"suspended___Namespace"},
+	}
+
+	instances := []component.Instance{
+		namespace("active", nil),
+		namespace("suspended", nil),
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, instances)
+	assert.NilError(t, err)
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "active"},
+		&ns,
+	)
+	assert.NilError(t, err)
+
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "suspended"},
+		&ns,
+	)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(&inventory.ManifestItem{ID: "active___Namespace"}))
+	assert.Assert(t, !storage.HasItem(&inventory.ManifestItem{ID: "suspended___Namespace"}))
+}
+
+func TestReconciler_Reconcile_Keep(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+		Keep:              []string{"kept___Namespace"},
+	}
+
+	instances := []component.Instance{
+		namespace("kept", nil),
+		namespace("removable", nil),
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, instances)
+	assert.NilError(t, err)
+
+	for _, name := range []string{"kept", "removable"} {
+		var ns corev1.Namespace
+		err = kubernetes.TestKubeClient.Get(
+			context.Background(),
+			types.NamespacedName{Name: name},
+			&ns,
+		)
+		assert.NilError(t, err)
+		_, found := ns.GetAnnotations()[inventory.KeepAnnotation]
+		assert.Assert(t, !found)
+	}
+
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.Items()["kept___Namespace"].(*inventory.ManifestItem).Keep)
+	assert.Assert(t, !storage.Items()["removable___Namespace"].(*inventory.ManifestItem).Keep)
+}
+
+func TestReconciler_Reconcile_Paused(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	var paused []string
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+		ReportOutcome: func(instance component.Instance, outcome component.Outcome, err error) {
+			if outcome == component.OutcomePaused {
+				paused = append(paused, instance.GetID())
+			}
+		},
+	}
+
+	deployment := func(image string) *component.Manifest {
+		return &component.Manifest{
+			ID: "debug_paused_apps_Deployment",
+			Content: kube.ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata": map[string]any{
+							"name":      "debug",
+							"namespace": "paused",
+						},
+						"spec": map[string]any{
+							"selector": map[string]any{
+								"matchLabels": map[string]any{
+									"app": "debug",
+								},
+							},
+							"template": map[string]any{
+								"metadata": map[string]any{
+									"labels": map[string]any{
+										"app": "debug",
+									},
+								},
+								"spec": map[string]any{
+									"containers": []any{
+										map[string]any{
+											"name":  "debug",
+											"image": image,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{"paused___Namespace"},
+		}
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		namespace("paused", nil),
+		deployment("debug:1.0.0"),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(paused), 0)
+
+	ctx := context.Background()
+	var liveDeployment appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "debug", Namespace: "paused"},
+		&liveDeployment,
+	)
+	assert.NilError(t, err)
+
+	liveDeployment.Annotations = map[string]string{component.PausedAnnotation: "true"}
+	liveDeployment.Spec.Template.Spec.Containers[0].Image = "debug:manual"
+	err = kubernetes.TestKubeClient.Update(ctx, &liveDeployment)
+	assert.NilError(t, err)
+
+	err = reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		namespace("paused", nil),
+		deployment("debug:2.0.0"),
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, paused, []string{"debug_paused_apps_Deployment"})
+
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "debug", Namespace: "paused"},
+		&liveDeployment,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, liveDeployment.Spec.Template.Spec.Containers[0].Image, "debug:manual")
+
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(&inventory.ManifestItem{ID: "debug_paused_apps_Deployment"}))
+
+	delete(liveDeployment.Annotations, component.PausedAnnotation)
+	err = kubernetes.TestKubeClient.Update(ctx, &liveDeployment)
+	assert.NilError(t, err)
+
+	err = reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		namespace("paused", nil),
+		deployment("debug:2.0.0"),
+	})
+	assert.NilError(t, err)
+
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "debug", Namespace: "paused"},
+		&liveDeployment,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, liveDeployment.Spec.Template.Spec.Containers[0].Image, "debug:2.0.0")
+}
+
+func TestReconciler_Reconcile_ListManaged(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	reconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: kubernetes.DynamicTestKubeClient,
+		InventoryInstance: &inventory.Instance{
+			Path: inventoryDir,
+		},
+		FieldManager:   "manager",
+		WorkerPoolSize: -1,
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		namespace("a", nil),
+		namespace("b", nil),
+	})
+	assert.NilError(t, err)
+
+	err = kubernetes.TestKubeClient.Create(kubernetes.Ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "unmanaged"},
+	})
+	assert.NilError(t, err)
+
+	managed, err := kubernetes.DynamicTestKubeClient.DynamicClient().ListManaged(kubernetes.Ctx, "manager")
+	assert.NilError(t, err)
+
+	var names []string
+	for _, obj := range managed {
+		assert.Equal(t, obj.GetKind(), "Namespace")
+		assert.Equal(t, obj.GetLabels()[kube.ManagedByLabel], "manager")
+		names = append(names, obj.GetName())
+	}
+	slices.Sort(names)
+	assert.DeepEqual(t, names, []string{"a", "b"})
+
+	managed, err = kubernetes.DynamicTestKubeClient.DynamicClient().ListManaged(kubernetes.Ctx, "other")
+	assert.NilError(t, err)
+	assert.Equal(t, len(managed), 0)
+}
+
+func TestReconciler_Reconcile_PartialFailure(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+	}
+
+	failing := &component.Manifest{
+		ID: "failing_missing__ConfigMap",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      "failing",
+						"namespace": "missing",
+					},
+				},
+			},
+		},
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		namespace("a", nil),
+		failing,
+		namespace("b", nil),
+	})
+	assert.ErrorContains(t, err, `namespaces "missing" not found`)
+
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(&inventory.ManifestItem{ID: "a___Namespace"}))
+	assert.Assert(t, storage.HasItem(&inventory.ManifestItem{ID: "b___Namespace"}))
+	assert.Assert(t, !storage.HasItem(&inventory.ManifestItem{ID: "failing_missing__ConfigMap"}))
+}
+
+func TestReconciler_Reconcile_Requires(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	inventoryDir := t.TempDir()
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := &inventory.Instance{
+		Path: inventoryDir,
+	}
+
+	var skipped []string
+	reconciler := component.Reconciler{
+		Log:               logr.Discard(),
+		DynamicClient:     kubernetes.DynamicTestKubeClient,
+		InventoryInstance: inventoryInstance,
+		FieldManager:      "manager",
+		WorkerPoolSize:    -1,
+		Requires: map[string][]string{
+			"available___Namespace":   {"v1/Namespace"},
+			"unavailable___Namespace": {"v1/Namespace", "monitoring.coreos.com/v1/ServiceMonitor"},
+		},
+		ReportOutcome: func(instance component.Instance, outcome component.Outcome, err error) {
+			if outcome == component.OutcomeSkipped {
+				skipped = append(skipped, instance.GetID())
+			}
+		},
+	}
+
+	instances := []component.Instance{
+		namespace("available", nil),
+		namespace("unavailable", nil),
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, instances)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, skipped, []string{"unavailable___Namespace"})
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "available"},
+		&ns,
+	)
+	assert.NilError(t, err)
+
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "unavailable"},
+		&ns,
+	)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(&inventory.ManifestItem{ID: "available___Namespace"}))
+	assert.Assert(t, !storage.HasItem(&inventory.ManifestItem{ID: "unavailable___Namespace"}))
+}
+
 func BenchmarkReconciler_Reconcile(b *testing.B) {
 	b.ReportAllocs()
 
@@ -314,6 +1391,667 @@ func app(
 	}
 }
 
+func TestReconciler_Reconcile_WaitFor(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	ctx := kubernetes.Ctx
+	dynClient := kubernetes.DynamicTestKubeClient.DynamicClient()
+
+	crd := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]any{
+				"name": "widgets.navecd.io",
+			},
+			"spec": map[string]any{
+				"group": "navecd.io",
+				"names": map[string]any{
+					"kind":     "Widget",
+					"listKind": "WidgetList",
+					"plural":   "widgets",
+					"singular": "widget",
+				},
+				"scope": "Namespaced",
+				"versions": []any{
+					map[string]any{
+						"name":    "v1",
+						"served":  true,
+						"storage": true,
+						"schema": map[string]any{
+							"openAPIV3Schema": map[string]any{
+								"type":                                 "object",
+								"x-kubernetes-preserve-unknown-fields": true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	_, err := dynClient.Apply(ctx, crd, "manager", kube.ForceApply(true))
+	assert.NilError(t, err)
+
+	widget := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]any{
+				"apiVersion": "navecd.io/v1",
+				"kind":       "Widget",
+				"metadata": map[string]any{
+					"name":      name,
+					"namespace": "default",
+				},
+				"spec": map[string]any{
+					"size": int64(1),
+				},
+			},
+		}
+	}
+	for {
+		_, err = dynClient.Get(ctx, widget("probe"))
+		if !meta.IsNoMatchError(err) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	reconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: kubernetes.DynamicTestKubeClient,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+		FieldManager:   "manager",
+		WorkerPoolSize: -1,
+		WaitFor: map[string]component.WaitCondition{
+			"ready_default_navecd.io_Widget": {Path: "status.conditions[type=Ready].status", Value: "True", Timeout: time.Minute},
+			"stuck_default_navecd.io_Widget": {Path: "status.phase", Value: "Ready", Timeout: 2 * time.Second},
+			"slow_default_navecd.io_Widget": {
+				Path:       "status.phase",
+				Value:      "Ready",
+				Timeout:    2 * time.Second,
+				MaxTimeout: time.Minute,
+			},
+		},
+		Waits: &component.WaitTracker{},
+		ReportOutcome: func(instance component.Instance, outcome component.Outcome, err error) {
+			record(fmt.Sprintf("%s %s", instance.GetID(), outcome))
+		},
+	}
+
+	// wait conditions are checked once per reconciliation, which is why the controller reconciles projects with waiting components again.
+	reconcileUntilSettled := func(instances []component.Instance) error {
+		for {
+			mu.Lock()
+			events = nil
+			mu.Unlock()
+
+			err := reconciler.Reconcile(ctx, instances)
+			if !slices.ContainsFunc(events, func(event string) bool {
+				return strings.HasSuffix(event, string(component.OutcomeWaiting))
+			}) {
+				return err
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+
+	// the dependent is held back, while the widget waits for its condition, instead of blocking the worker.
+	err = reconciler.Reconcile(ctx, []component.Instance{
+		&component.Manifest{
+			ID:      "ready_default_navecd.io_Widget",
+			Content: kube.ExtendedUnstructured{Unstructured: widget("ready")},
+		},
+		namespace("dependent", []string{"ready_default_navecd.io_Widget"}),
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []string{
+		"ready_default_navecd.io_Widget waiting",
+		"dependent___Namespace skipped",
+	})
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: "dependent"}, &ns)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	// the operator of the widgets sets the status asynchronously.
+	status := widget("ready")
+	status.Object["status"] = map[string]any{
+		"conditions": []any{
+			map[string]any{"type": "Synced", "status": "True"},
+			map[string]any{"type": "Ready", "status": "True"},
+		},
+	}
+	_, err = dynClient.Apply(ctx, status, "operator", kube.ForceApply(true), kube.ApplyStatus(true))
+	assert.NilError(t, err)
+
+	err = reconcileUntilSettled([]component.Instance{
+		&component.Manifest{
+			ID:      "ready_default_navecd.io_Widget",
+			Content: kube.ExtendedUnstructured{Unstructured: widget("ready")},
+		},
+		namespace("dependent", []string{"ready_default_navecd.io_Widget"}),
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []string{
+		"ready_default_navecd.io_Widget success",
+		"dependent___Namespace success",
+	})
+
+	err = reconcileUntilSettled([]component.Instance{
+		&component.Manifest{
+			ID:      "stuck_default_navecd.io_Widget",
+			Content: kube.ExtendedUnstructured{Unstructured: widget("stuck")},
+		},
+		namespace("never", []string{"stuck_default_navecd.io_Widget"}),
+	})
+	assert.ErrorIs(t, err, component.ErrWaitTimeout)
+	assert.DeepEqual(t, events, []string{
+		"stuck_default_navecd.io_Widget failure",
+		"never___Namespace skipped",
+	})
+
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: "never"}, &ns)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	// the operator of slow widgets reports progress for longer than the timeout, before they become ready.
+	operatorDone := make(chan struct{})
+	go func() {
+		defer close(operatorDone)
+		for {
+			if _, err := dynClient.Get(ctx, widget("slow")); err == nil {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		for generation := range int64(5) {
+			time.Sleep(time.Second)
+			status := widget("slow")
+			status.Object["status"] = map[string]any{"phase": "Provisioning", "observedGeneration": generation}
+			if _, err := dynClient.Apply(ctx, status, "operator", kube.ForceApply(true), kube.ApplyStatus(true)); err != nil {
+				return
+			}
+		}
+
+		status := widget("slow")
+		status.Object["status"] = map[string]any{"phase": "Ready", "observedGeneration": int64(5)}
+		_, _ = dynClient.Apply(ctx, status, "operator", kube.ForceApply(true), kube.ApplyStatus(true))
+	}()
+
+	err = reconcileUntilSettled([]component.Instance{
+		&component.Manifest{
+			ID:      "slow_default_navecd.io_Widget",
+			Content: kube.ExtendedUnstructured{Unstructured: widget("slow")},
+		},
+		namespace("eventually", []string{"slow_default_navecd.io_Widget"}),
+	})
+	<-operatorDone
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []string{
+		"slow_default_navecd.io_Widget success",
+		"eventually___Namespace success",
+	})
+}
+
+func TestReconciler_Reconcile_Hooks(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	ctx := kubernetes.Ctx
+	dynClient := kubernetes.DynamicTestKubeClient.DynamicClient()
+
+	job := func(name string) *component.Manifest {
+		return &component.Manifest{
+			ID: fmt.Sprintf("%s_default_batch_Job", name),
+			Content: kube.ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "batch/v1",
+						"kind":       "Job",
+						"metadata": map[string]any{
+							"name":      name,
+							"namespace": "default",
+						},
+						"spec": map[string]any{
+							"template": map[string]any{
+								"spec": map[string]any{
+									"restartPolicy": "Never",
+									"containers": []any{
+										map[string]any{
+											"name":  "migrate",
+											"image": "migrate:1.0.0",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	deployment := func(name string, dependencies ...string) *component.Manifest {
+		return &component.Manifest{
+			ID: fmt.Sprintf("%s_default_apps_Deployment", name),
+			Content: kube.ExtendedUnstructured{
+				Unstructured: &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata": map[string]any{
+							"name":      name,
+							"namespace": "default",
+						},
+						"spec": map[string]any{
+							"selector": map[string]any{
+								"matchLabels": map[string]any{
+									"app": name,
+								},
+							},
+							"template": map[string]any{
+								"metadata": map[string]any{
+									"labels": map[string]any{
+										"app": name,
+									},
+								},
+								"spec": map[string]any{
+									"containers": []any{
+										map[string]any{
+											"name":  name,
+											"image": "app:1.0.0",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Dependencies: dependencies,
+		}
+	}
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	reconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: kubernetes.DynamicTestKubeClient,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+		FieldManager:   "manager",
+		WorkerPoolSize: -1,
+		Hooks: map[string]component.JobHook{
+			"migrate_default_batch_Job": {Phase: component.PreHook, Target: "app_default_apps_Deployment", Timeout: time.Minute},
+			"broken_default_batch_Job":  {Phase: component.PreHook, Target: "gated_default_apps_Deployment", Timeout: time.Minute},
+		},
+		ReportOutcome: func(instance component.Instance, outcome component.Outcome, err error) {
+			record(fmt.Sprintf("%s %s", instance.GetID(), outcome))
+		},
+	}
+
+	// envtest runs no Job controller, which is why the status of the Jobs is set like the controller would.
+	finishJob := func(name string, conditionType string) <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, err := dynClient.Get(ctx, job(name).Content.Unstructured); err == nil {
+					break
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			time.Sleep(time.Second)
+
+			now := time.Now().UTC().Format(time.RFC3339)
+			status := map[string]any{
+				"startTime": now,
+			}
+			switch conditionType {
+			case "Complete":
+				status["completionTime"] = now
+				status["succeeded"] = int64(1)
+				status["conditions"] = []any{
+					map[string]any{"type": "SuccessCriteriaMet", "status": "True", "lastTransitionTime": now},
+					map[string]any{"type": "Complete", "status": "True", "lastTransitionTime": now},
+				}
+			case "Failed":
+				status["failed"] = int64(1)
+				status["conditions"] = []any{
+					map[string]any{"type": "FailureTarget", "status": "True", "lastTransitionTime": now},
+					map[string]any{"type": "Failed", "status": "True", "lastTransitionTime": now},
+				}
+			}
+
+			obj := job(name).Content.Unstructured
+			obj.Object["status"] = status
+			_, err := dynClient.Apply(ctx, obj, "job-controller", kube.ForceApply(true), kube.ApplyStatus(true))
+			if err == nil {
+				record(fmt.Sprintf("job %s", conditionType))
+			}
+		}()
+		return done
+	}
+
+	jobDone := finishJob("migrate", "Complete")
+	err := reconciler.Reconcile(ctx, []component.Instance{
+		job("migrate"),
+		deployment("app", "migrate_default_batch_Job"),
+	})
+	<-jobDone
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []string{
+		"job Complete",
+		"migrate_default_batch_Job success",
+		"app_default_apps_Deployment success",
+	})
+
+	// completed hooks do not run again, even if their Job was removed through ttlSecondsAfterFinished.
+	err = dynClient.Delete(ctx, job("migrate").Content.Unstructured)
+	assert.NilError(t, err)
+	events = nil
+	err = reconciler.Reconcile(ctx, []component.Instance{
+		job("migrate"),
+		deployment("app", "migrate_default_batch_Job"),
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []string{
+		"migrate_default_batch_Job success",
+		"app_default_apps_Deployment success",
+	})
+	_, err = dynClient.Get(ctx, job("migrate").Content.Unstructured)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	events = nil
+	jobDone = finishJob("broken", "Failed")
+	err = reconciler.Reconcile(ctx, []component.Instance{
+		job("broken"),
+		deployment("gated", "broken_default_batch_Job"),
+	})
+	<-jobDone
+	assert.ErrorIs(t, err, component.ErrHookFailed)
+	assert.DeepEqual(t, events, []string{
+		"job Failed",
+		"broken_default_batch_Job failure",
+		"gated_default_apps_Deployment skipped",
+	})
+
+	var gated appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: "gated", Namespace: "default"}, &gated)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	// failed hooks are replaced and run again.
+	failed, err := dynClient.Get(ctx, job("broken").Content.Unstructured)
+	assert.NilError(t, err)
+	events = nil
+	jobDone = finishJob("broken", "Complete")
+	err = reconciler.Reconcile(ctx, []component.Instance{
+		job("broken"),
+		deployment("gated", "broken_default_batch_Job"),
+	})
+	<-jobDone
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []string{
+		"job Complete",
+		"broken_default_batch_Job success",
+		"gated_default_apps_Deployment success",
+	})
+	rerun, err := dynClient.Get(ctx, job("broken").Content.Unstructured)
+	assert.NilError(t, err)
+	assert.Assert(t, rerun.GetUID() != failed.GetUID())
+}
+
+func TestReconciler_Reconcile_PodSecurity(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	reconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: kubernetes.DynamicTestKubeClient,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+		FieldManager:     "manager",
+		WorkerPoolSize:   -1,
+		CheckPodSecurity: true,
+	}
+
+	restrictedNamespace := &component.Manifest{
+		ID: "restricted___Namespace",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Namespace",
+					"metadata": map[string]any{
+						"name": "restricted",
+						"labels": map[string]any{
+							kube.PodSecurityEnforceLabel: kube.PodSecurityRestricted,
+						},
+					},
+				},
+			},
+		},
+	}
+	privilegedPod := &component.Manifest{
+		ID: "debug_restricted__Pod",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": map[string]any{
+						"name":      "debug",
+						"namespace": "restricted",
+					},
+					"spec": map[string]any{
+						"containers": []any{
+							map[string]any{
+								"name":  "debug",
+								"image": "debug:1.0.0",
+								"securityContext": map[string]any{
+									"privileged": true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{restrictedNamespace.ID},
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		restrictedNamespace,
+		privilegedPod,
+	})
+	assert.ErrorIs(t, err, component.ErrPodSecurityViolation)
+	assert.ErrorContains(
+		t,
+		err,
+		"Pod restricted/debug violates the restricted level enforced by its namespace: container \"debug\" is privileged",
+	)
+
+	var pod corev1.Pod
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "debug", Namespace: "restricted"},
+		&pod,
+	)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	// the enforced level is read once per reconciliation,
+	// so relaxing the namespace only takes effect on the next one.
+	cachedPod := &component.Manifest{
+		ID: "cached_restricted__Pod",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: privilegedPod.Content.DeepCopy(),
+		},
+		Dependencies: []string{"config_restricted__ConfigMap"},
+	}
+	cachedPod.Content.SetName("cached")
+	config := &component.Manifest{
+		ID: "config_restricted__ConfigMap",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      "config",
+						"namespace": "restricted",
+					},
+				},
+			},
+		},
+	}
+
+	var relaxErr error
+	reconciler.ReportOutcome = func(instance component.Instance, outcome component.Outcome, err error) {
+		if instance.GetID() != privilegedPod.ID {
+			return
+		}
+		var ns corev1.Namespace
+		relaxErr = kubernetes.TestKubeClient.Get(context.Background(), types.NamespacedName{Name: "restricted"}, &ns)
+		if relaxErr != nil {
+			return
+		}
+		ns.Labels[kube.PodSecurityEnforceLabel] = kube.PodSecurityPrivileged
+		relaxErr = kubernetes.TestKubeClient.Update(context.Background(), &ns)
+	}
+
+	err = reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		privilegedPod,
+		config,
+		cachedPod,
+	})
+	assert.NilError(t, relaxErr)
+	assert.ErrorIs(t, err, component.ErrPodSecurityViolation)
+
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "cached", Namespace: "restricted"},
+		&pod,
+	)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	reconciler.ReportOutcome = nil
+	err = reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		config,
+		cachedPod,
+	})
+	assert.NilError(t, err)
+
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "cached", Namespace: "restricted"},
+		&pod,
+	)
+	assert.NilError(t, err)
+}
+
+func TestReconciler_Reconcile_KindFilter(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	reconciler := component.Reconciler{
+		Log:           logr.Discard(),
+		DynamicClient: kubernetes.DynamicTestKubeClient,
+		InventoryInstance: &inventory.Instance{
+			Path: t.TempDir(),
+		},
+		FieldManager:   "manager",
+		WorkerPoolSize: -1,
+		KindFilter: component.KindFilter{
+			Denied: []string{"rbac.authorization.k8s.io/*"},
+		},
+	}
+
+	clusterRole := &component.Manifest{
+		ID: "admin__rbac.authorization.k8s.io_ClusterRole",
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "rbac.authorization.k8s.io/v1",
+					"kind":       "ClusterRole",
+					"metadata": map[string]any{
+						"name": "admin",
+					},
+					"rules": []any{
+						map[string]any{
+							"apiGroups": []any{"*"},
+							"resources": []any{"*"},
+							"verbs":     []any{"*"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := reconciler.Reconcile(kubernetes.Ctx, []component.Instance{
+		namespace("allowed", nil),
+		clusterRole,
+	})
+	assert.ErrorIs(t, err, component.ErrKindNotAllowed)
+	assert.ErrorContains(t, err, "ClusterRole.rbac.authorization.k8s.io")
+
+	var role rbacv1.ClusterRole
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "admin"},
+		&role,
+	)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(
+		context.Background(),
+		types.NamespacedName{Name: "allowed"},
+		&ns,
+	)
+	assert.NilError(t, err)
+
+	storage, err := reconciler.InventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, !storage.HasItem(&inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{Kind: "ClusterRole", APIVersion: "rbac.authorization.k8s.io/v1"},
+		Name:     "admin",
+		ID:       clusterRole.ID,
+	}))
+}
+
 func namespace(name string, dependencies []string) component.Instance {
 	return &component.Manifest{
 		ID: fmt.Sprintf("%s___Namespace", name),
@@ -0,0 +1,361 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cuelang.org/go/cue"
+	"github.com/kharf/navecd/pkg/kube"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	ErrInvalidWaitCondition = errors.New("Invalid wait condition")
+	ErrWaitTimeout          = errors.New("Wait condition not met")
+)
+
+// DefaultWaitTimeout is used for wait conditions without a timeout.
+const DefaultWaitTimeout = 5 * time.Minute
+
+// waitPollInterval is the interval between two reads of a live object, which is waited for.
+var waitPollInterval = time.Second
+
+// WaitCondition is declared with the waitFor attribute on manifest and patch components,
+// e.g. @waitFor("status.phase", "Ready", "2m") or @waitFor("status.phase", "Ready", "2m", "30m").
+// Dependents of the component are not applied until the field of the live object has the given value.
+type WaitCondition struct {
+	// Path of the field in dot notation, e.g. status.phase.
+	// List entries are addressed by index, e.g. spec.containers[0].image,
+	// or by the value of one of their fields, e.g. status.conditions[type=Ready].status.
+	Path string
+
+	// Value the field has to be equal to.
+	Value string
+
+	// Timeout after which the component fails, if its live object makes no progress.
+	Timeout time.Duration
+
+	// MaxTimeout is the hard cap for slow components, which make progress.
+	// Every advance of the observed generation of the live object, see [kube.ObservedGeneration],
+	// extends the deadline by Timeout up to MaxTimeout. Zero means the deadline is never extended.
+	MaxTimeout time.Duration
+}
+
+// Met reports whether the field of obj has the expected value.
+// Non-string fields are compared with their formatted value, e.g. true or 1.
+func (condition WaitCondition) Met(obj *unstructured.Unstructured) bool {
+	segments, err := parsePath(condition.Path)
+	if err != nil {
+		return false
+	}
+
+	var value any = obj.Object
+	for _, segment := range segments {
+		var found bool
+		value, found = segment.lookup(value)
+		if !found {
+			return false
+		}
+	}
+	return fmt.Sprint(value) == condition.Value
+}
+
+// pathSegment addresses a field and optionally an entry of the list it holds,
+// either by index or by the value of one of the fields of the entry.
+type pathSegment struct {
+	field string
+
+	isEntry bool
+	index   int
+	key     string
+	value   string
+}
+
+func (segment pathSegment) lookup(value any) (any, bool) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	value, found := object[segment.field]
+	if !found || !segment.isEntry {
+		return value, found
+	}
+
+	entries, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	if segment.key == "" {
+		if segment.index >= len(entries) {
+			return nil, false
+		}
+		return entries[segment.index], true
+	}
+	for _, entry := range entries {
+		entryObject, ok := entry.(map[string]any)
+		if ok && fmt.Sprint(entryObject[segment.key]) == segment.value {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// parsePath splits a path in dot notation into its segments.
+// Dots within list selectors, e.g. conditions[type=navecd.io/Ready], do not separate segments.
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	var rawSegments []string
+	depth := 0
+	start := 0
+	for i, char := range path {
+		switch char {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				rawSegments = append(rawSegments, path[start:i])
+				start = i + 1
+			}
+		}
+		if depth < 0 || depth > 1 {
+			return nil, fmt.Errorf("%w: invalid path %s", ErrInvalidWaitCondition, path)
+		}
+	}
+	rawSegments = append(rawSegments, path[start:])
+
+	for _, rawSegment := range rawSegments {
+		segment, err := parsePathSegment(rawSegment)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid path %s", ErrInvalidWaitCondition, path)
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+func parsePathSegment(rawSegment string) (pathSegment, error) {
+	field, selector, isEntry := strings.Cut(rawSegment, "[")
+	if field == "" {
+		return pathSegment{}, ErrInvalidWaitCondition
+	}
+	if !isEntry {
+		return pathSegment{field: field}, nil
+	}
+
+	selector, rest, closed := strings.Cut(selector, "]")
+	if !closed || rest != "" || selector == "" {
+		return pathSegment{}, ErrInvalidWaitCondition
+	}
+
+	segment := pathSegment{field: field, isEntry: true}
+	if key, value, isKey := strings.Cut(selector, "="); isKey {
+		if key == "" {
+			return pathSegment{}, ErrInvalidWaitCondition
+		}
+		segment.key = key
+		segment.value = value
+		return segment, nil
+	}
+
+	index, err := strconv.Atoi(selector)
+	if err != nil || index < 0 {
+		return pathSegment{}, ErrInvalidWaitCondition
+	}
+	segment.index = index
+	return segment, nil
+}
+
+func decodeWaitFor(componentValue cue.Value) (*WaitCondition, error) {
+	attr := componentValue.Attribute(waitForAttr)
+	if attr.Err() != nil {
+		return nil, nil
+	}
+
+	if attr.NumArgs() < 2 || attr.NumArgs() > 4 {
+		return nil, fmt.Errorf("%w: expected path, value, optional timeout and optional max timeout", ErrInvalidWaitCondition)
+	}
+
+	path, err := attr.String(0)
+	if err != nil {
+		return nil, buildError(err)
+	}
+	if _, err := parsePath(path); err != nil {
+		return nil, err
+	}
+
+	value, err := attr.String(1)
+	if err != nil {
+		return nil, buildError(err)
+	}
+
+	timeout := DefaultWaitTimeout
+	if attr.NumArgs() >= 3 {
+		timeout, err = decodeTimeout(attr, 2)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var maxTimeout time.Duration
+	if attr.NumArgs() == 4 {
+		maxTimeout, err = decodeTimeout(attr, 3)
+		if err != nil {
+			return nil, err
+		}
+		if maxTimeout < timeout {
+			return nil, fmt.Errorf("%w: max timeout %s is shorter than timeout %s", ErrInvalidWaitCondition, maxTimeout, timeout)
+		}
+	}
+
+	return &WaitCondition{
+		Path:       path,
+		Value:      value,
+		Timeout:    timeout,
+		MaxTimeout: maxTimeout,
+	}, nil
+}
+
+func decodeTimeout(attr cue.Attribute, pos int) (time.Duration, error) {
+	timeoutArg, err := attr.String(pos)
+	if err != nil {
+		return 0, buildError(err)
+	}
+	timeout, err := time.ParseDuration(timeoutArg)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("%w: invalid timeout %s", ErrInvalidWaitCondition, timeoutArg)
+	}
+	return timeout, nil
+}
+
+// WaitTracker keeps the deadlines of wait conditions, which are not met yet, across reconciliations.
+// The zero value is ready to use.
+type WaitTracker struct {
+	mu    sync.Mutex
+	waits map[string]*pendingWait
+}
+
+type pendingWait struct {
+	start        time.Time
+	deadline     time.Time
+	hardDeadline time.Time
+	generation   int64
+	observed     bool
+}
+
+// waitKey identifies the wait for a condition of a live object.
+// Recreated objects have a new uid and start waiting anew.
+func waitKey(condition WaitCondition, live *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s=%s", live.GetUID(), condition.Path, condition.Value)
+}
+
+// pending returns the wait for key and drops waits, which are past their hard deadline, because their objects are gone.
+func (tracker *WaitTracker) pending(key string, condition WaitCondition, now time.Time) *pendingWait {
+	if tracker.waits == nil {
+		tracker.waits = make(map[string]*pendingWait)
+	}
+	for otherKey, wait := range tracker.waits {
+		if otherKey != key && now.After(wait.hardDeadline) {
+			delete(tracker.waits, otherKey)
+		}
+	}
+
+	wait, found := tracker.waits[key]
+	if !found {
+		wait = &pendingWait{
+			start:        now,
+			deadline:     now.Add(condition.Timeout),
+			hardDeadline: now.Add(max(condition.Timeout, condition.MaxTimeout)),
+		}
+		tracker.waits[key] = wait
+	}
+	return wait
+}
+
+// waitFor reads the live object once and reports whether the wait condition of the component is met.
+// Components without a wait condition are always met.
+// Unmet conditions do not block the worker. Instead, the component is reported as waiting and checked again
+// on the next reconciliation, while its deadline is kept in the [WaitTracker].
+// A component is stuck, if its live object does not make progress within the timeout,
+// while the deadline of a slow component making progress is extended up to the max timeout.
+func (reconciler *Reconciler) waitFor(ctx context.Context, instance Instance, obj *kube.ExtendedUnstructured) (bool, error) {
+	condition, found := reconciler.WaitFor[instance.GetID()]
+	if !found || reconciler.DryRun {
+		return true, nil
+	}
+
+	live, err := reconciler.DynamicClient.Get(ctx, obj)
+	if err != nil {
+		return false, err
+	}
+
+	if reconciler.Waits == nil {
+		return condition.Met(live), nil
+	}
+
+	tracker := reconciler.Waits
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	key := waitKey(condition, live)
+	if condition.Met(live) {
+		delete(tracker.waits, key)
+		return true, nil
+	}
+
+	now := time.Now()
+	wait := tracker.pending(key, condition, now)
+
+	// status fields like heartbeat timestamps change without progress, which is why only the observed generation counts.
+	if observedGeneration, found := kube.ObservedGeneration(live); found {
+		if wait.observed && observedGeneration > wait.generation {
+			wait.deadline = now.Add(condition.Timeout)
+			if wait.deadline.After(wait.hardDeadline) {
+				wait.deadline = wait.hardDeadline
+			}
+		}
+		wait.generation = observedGeneration
+		wait.observed = true
+	}
+
+	if !now.Before(wait.deadline) {
+		delete(tracker.waits, key)
+		return false, waitTimeoutError(condition, obj, now.Sub(wait.start), context.DeadlineExceeded)
+	}
+
+	return false, nil
+}
+
+func waitTimeoutError(condition WaitCondition, obj *kube.ExtendedUnstructured, elapsed time.Duration, err error) error {
+	return fmt.Errorf(
+		"%w: %s of %s %s/%s is not %s after %s: %w",
+		ErrWaitTimeout,
+		condition.Path,
+		obj.GetKind(),
+		obj.GetNamespace(),
+		obj.GetName(),
+		condition.Value,
+		elapsed.Round(time.Second),
+		err,
+	)
+}
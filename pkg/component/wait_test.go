@@ -0,0 +1,111 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package component_test
+
+import (
+	"testing"
+
+	"github.com/kharf/navecd/pkg/component"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestWaitCondition_Met(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": map[string]any{
+				"replicas": int64(2),
+				"containers": []any{
+					map[string]any{"name": "app", "image": "app:1.0.0"},
+				},
+			},
+			"status": map[string]any{
+				"phase": "Ready",
+				"conditions": []any{
+					map[string]any{"type": "Synced", "status": "False"},
+					map[string]any{"type": "navecd.io/Ready", "status": "True"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		condition component.WaitCondition
+		met       bool
+	}{
+		{
+			name:      "Field",
+			condition: component.WaitCondition{Path: "status.phase", Value: "Ready"},
+			met:       true,
+		},
+		{
+			name:      "Non-String-Field",
+			condition: component.WaitCondition{Path: "spec.replicas", Value: "2"},
+			met:       true,
+		},
+		{
+			name:      "Other-Value",
+			condition: component.WaitCondition{Path: "status.phase", Value: "Pending"},
+			met:       false,
+		},
+		{
+			name:      "Missing-Field",
+			condition: component.WaitCondition{Path: "status.ready", Value: "true"},
+			met:       false,
+		},
+		{
+			name:      "List-Index",
+			condition: component.WaitCondition{Path: "spec.containers[0].image", Value: "app:1.0.0"},
+			met:       true,
+		},
+		{
+			name:      "List-Index-Out-Of-Range",
+			condition: component.WaitCondition{Path: "spec.containers[1].image", Value: "app:1.0.0"},
+			met:       false,
+		},
+		{
+			name:      "List-Selector",
+			condition: component.WaitCondition{Path: "status.conditions[type=navecd.io/Ready].status", Value: "True"},
+			met:       true,
+		},
+		{
+			name:      "List-Selector-Other-Entry",
+			condition: component.WaitCondition{Path: "status.conditions[type=Synced].status", Value: "True"},
+			met:       false,
+		},
+		{
+			name:      "List-Selector-No-Entry",
+			condition: component.WaitCondition{Path: "status.conditions[type=Healthy].status", Value: "True"},
+			met:       false,
+		},
+		{
+			name:      "List-Selector-On-Object",
+			condition: component.WaitCondition{Path: "status[type=Ready].phase", Value: "Ready"},
+			met:       false,
+		},
+		{
+			name:      "Invalid-Path",
+			condition: component.WaitCondition{Path: "status.conditions[type=Ready.status", Value: "True"},
+			met:       false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.condition.Met(obj), tc.met)
+		})
+	}
+}
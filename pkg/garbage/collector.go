@@ -16,6 +16,11 @@ package garbage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
 	"github.com/kharf/navecd/pkg/component"
@@ -23,9 +28,25 @@ import (
 	"github.com/kharf/navecd/pkg/inventory"
 	"github.com/kharf/navecd/pkg/kube"
 	"golang.org/x/sync/errgroup"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+var (
+	ErrInvalidDeletePropagation = errors.New("Delete propagation has to be Foreground, Background or Orphan")
+)
+
+// ParseDeletePropagation parses the propagation policy used for collecting manifests.
+func ParseDeletePropagation(policy string) (metav1.DeletionPropagation, error) {
+	switch propagation := metav1.DeletionPropagation(policy); propagation {
+	case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan:
+		return propagation, nil
+	}
+	return "", fmt.Errorf("%w: got %s", ErrInvalidDeletePropagation, policy)
+}
+
 // Collector inspects the inventory for dangling manifests or helm releases,
 // which are undefined in the navecd gitops repository, and uninstalls them from
 // the Kubernetes cluster and inventory.
@@ -35,18 +56,32 @@ type Collector struct {
 	Client          *kube.DynamicClient
 	ChartReconciler helm.ChartReconciler
 
+	// FieldManager releases the fields of unreferenced patches.
+	FieldManager string
+
 	// Instance is a representation of an inventory.
 	// It can store, delete and read items.
 	// The object does not include the storage itself, it only holds a reference to the storage.
 	InventoryInstance *inventory.Instance
 
 	WorkerPoolSize int
+
+	// DeletePropagation defines how dependents of collected manifests are deleted, e.g. the Pods of a Deployment.
+	// With foreground propagation, objects are removed only after all of their dependents have been removed.
+	// Defaults to the policy of the object's API.
+	DeletePropagation metav1.DeletionPropagation
+
+	// KindFilter skips manifests and patches of kinds, which are not allowed.
+	// They are kept in the inventory, so that they are collected once their kind is allowed again.
+	KindFilter component.KindFilter
 }
 
 // Collect inspects the inventory for dangling manifests or helm releases,
 // which are undefined in the navecd gitops repository, and uninstalls them from
 // the Kubernetes cluster and inventory.
 // The DependencyGraph is a representation of the gitops repository.
+// Items are collected concurrently in tiers, see [tier]. A tier is only collected after all items of the previous tier have been collected.
+// All errors of a failing tier are returned and the following tiers are not collected.
 func (c *Collector) Collect(
 	ctx context.Context,
 	dag *component.DependencyGraph,
@@ -56,41 +91,170 @@ func (c *Collector) Collect(
 	if err != nil {
 		return err
 	}
+
+	var tiers [tierCount][]inventory.Item
+	for _, invComponent := range storage.Items() {
+		if isDangling(dag, invComponent) {
+			itemTier := tier(invComponent)
+			tiers[itemTier] = append(tiers[itemTier], invComponent)
+		}
+	}
+
+	for _, items := range tiers {
+		if err := c.collectTier(ctx, items); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const (
+	// leafTier holds all objects, which are not containers of other objects.
+	leafTier = iota
+	// containerTier holds Namespaces and CustomResourceDefinitions, whose deletion removes all contained objects.
+	// Removing them last lets leaf objects be deleted in a controlled manner, e.g. with Helm uninstall hooks.
+	containerTier
+	tierCount
+)
+
+// tier returns the collection tier of the item.
+func tier(item inventory.Item) int {
+	if manifest, ok := item.(*inventory.ManifestItem); ok {
+		switch manifest.TypeMeta.GroupVersionKind().GroupKind() {
+		case schema.GroupKind{Kind: "Namespace"},
+			schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:
+			return containerTier
+		}
+	}
+	return leafTier
+}
+
+// collectTier collects the items concurrently, bounded by the worker pool size, and joins all errors.
+func (c *Collector) collectTier(
+	ctx context.Context,
+	items []inventory.Item,
+) error {
+	var mu sync.Mutex
+	var errs []error
+
 	eg := errgroup.Group{}
 	eg.SetLimit(c.WorkerPoolSize)
-	for _, invComponent := range storage.Items() {
+	for _, item := range items {
 		eg.Go(func() error {
-			return c.collect(ctx, dag, invComponent)
+			if err := c.collect(ctx, item); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+			}
+			return nil
 		})
 	}
-	return eg.Wait()
+	_ = eg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Dangling returns all inventory items, which are undefined in the DependencyGraph and would be collected, sorted by their id.
+// Kept manifests are omitted, because their objects are not deleted.
+func (c *Collector) Dangling(dag *component.DependencyGraph) ([]inventory.Item, error) {
+	storage, err := c.InventoryInstance.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var dangling []inventory.Item
+	for _, item := range storage.Items() {
+		if manifest, ok := item.(*inventory.ManifestItem); ok && manifest.Keep {
+			continue
+		}
+		if isDangling(dag, item) {
+			dangling = append(dangling, item)
+		}
+	}
+	slices.SortFunc(dangling, func(a, b inventory.Item) int {
+		return strings.Compare(a.GetID(), b.GetID())
+	})
+	return dangling, nil
+}
+
+// Untracked returns all objects in the cluster labeled as managed by the field manager, which are missing from the inventory.
+// Objects of other projects reconciled with the same field manager are tracked in their own inventories and are reported as well.
+func (c *Collector) Untracked(ctx context.Context) ([]unstructured.Unstructured, error) {
+	storage, err := c.InventoryInstance.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	managed, err := c.Client.ListManaged(ctx, c.FieldManager)
+	if err != nil {
+		return nil, err
+	}
+
+	var untracked []unstructured.Unstructured
+	for _, obj := range managed {
+		id := component.ManifestID(obj.GetAPIVersion(), obj.GetKind(), obj.GetName(), obj.GetNamespace())
+		if _, found := storage.Items()[id]; !found {
+			untracked = append(untracked, obj)
+		}
+	}
+	return untracked, nil
+}
+
+func isDangling(dag *component.DependencyGraph, inventoryItem inventory.Item) bool {
+	instance := dag.Get(inventoryItem.GetID())
+	if instance != nil {
+		return inventoryItem.GetID() != instance.GetID()
+	}
+	return true
 }
 
 func (c *Collector) collect(
 	ctx context.Context,
-	dag *component.DependencyGraph,
 	inventoryItem inventory.Item,
 ) error {
-	collect := true
-	instance := dag.Get(inventoryItem.GetID())
-	if instance != nil {
-		collect = inventoryItem.GetID() != instance.GetID()
-	}
-	if collect {
-		switch item := inventoryItem.(type) {
-		case *inventory.HelmReleaseItem:
-			if err := c.collectHelmRelease(item); err != nil {
-				return err
-			}
-		case *inventory.ManifestItem:
-			if err := c.collectManifest(ctx, item); err != nil {
-				return err
-			}
+	switch item := inventoryItem.(type) {
+	case *inventory.HelmReleaseItem:
+		if err := c.collectHelmRelease(item); err != nil {
+			return err
+		}
+	case *inventory.ManifestItem:
+		if item.Keep {
+			return c.releaseManifest(item)
+		}
+		if c.denied(item, item.TypeMeta) {
+			return nil
+		}
+		if err := c.collectManifest(ctx, item); err != nil {
+			return err
+		}
+	case *inventory.PatchItem:
+		if c.denied(item, item.TypeMeta) {
+			return nil
+		}
+		if err := c.collectPatch(ctx, item); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// denied reports whether the kind of the item is not allowed by the KindFilter and logs it, if so.
+// Denied items are not collected, so that the remaining items and following tiers are collected.
+func (c *Collector) denied(item inventory.Item, typeMeta metav1.TypeMeta) bool {
+	if err := c.KindFilter.Check(typeMeta.APIVersion, typeMeta.Kind); err != nil {
+		c.Log.Info(
+			"Kind not allowed. Skipping unreferenced object",
+			"id",
+			item.GetID(),
+			"reason",
+			err.Error(),
+		)
+		return true
+	}
+	return false
+}
+
 func (c *Collector) collectHelmRelease(
 	invHr *inventory.HelmReleaseItem,
 ) error {
@@ -128,7 +292,9 @@ func (c *Collector) collectManifest(
 	unstr.SetNamespace(invManifest.GetNamespace())
 	unstr.SetKind(invManifest.TypeMeta.Kind)
 	unstr.SetAPIVersion(invManifest.TypeMeta.APIVersion)
-	if err := c.Client.Delete(ctx, unstr); err != nil {
+	// objects may be gone already, like Jobs of completed hooks removed through ttlSecondsAfterFinished.
+	if err := c.Client.Delete(ctx, unstr, kube.PropagationPolicy(c.DeletePropagation)); err != nil &&
+		!k8sErrors.IsNotFound(err) {
 		return err
 	}
 	if err := c.InventoryInstance.DeleteItem(invManifest); err != nil {
@@ -136,3 +302,52 @@ func (c *Collector) collectManifest(
 	}
 	return nil
 }
+
+// releaseManifest removes the manifest of a kept object from the inventory, but leaves the object in the cluster.
+func (c *Collector) releaseManifest(
+	invManifest *inventory.ManifestItem,
+) error {
+	c.Log.Info(
+		"Keeping unreferenced manifest",
+		"namespace",
+		invManifest.GetNamespace(),
+		"name",
+		invManifest.GetName(),
+		"kind",
+		invManifest.TypeMeta.Kind,
+	)
+	return c.InventoryInstance.DeleteItem(invManifest)
+}
+
+// collectPatch releases the ownership of all fields declared by the patch.
+// Fields not managed by another field manager are removed from the object, but the object itself is kept.
+func (c *Collector) collectPatch(
+	ctx context.Context,
+	invPatch *inventory.PatchItem,
+) error {
+	c.Log.Info(
+		"Collecting unreferenced patch",
+		"namespace",
+		invPatch.GetNamespace(),
+		"name",
+		invPatch.GetName(),
+		"kind",
+		invPatch.TypeMeta.Kind,
+	)
+	unstr := &unstructured.Unstructured{}
+	unstr.SetName(invPatch.GetName())
+	unstr.SetNamespace(invPatch.GetNamespace())
+	unstr.SetKind(invPatch.TypeMeta.Kind)
+	unstr.SetAPIVersion(invPatch.TypeMeta.APIVersion)
+	if _, err := c.Client.Get(ctx, unstr); err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	} else if _, err := c.Client.Apply(ctx, unstr, c.FieldManager, kube.ForceApply(true)); err != nil {
+		return err
+	}
+	if err := c.InventoryInstance.DeleteItem(invPatch); err != nil {
+		return err
+	}
+	return nil
+}
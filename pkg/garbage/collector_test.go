@@ -304,6 +304,112 @@ func TestCollector_Collect(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "Kept-PVC-Removed",
+			runCase: func(context testCaseContext) {
+				pvcA := &inventory.ManifestItem{
+					TypeMeta: metav1.TypeMeta{
+						Kind:       "PersistentVolumeClaim",
+						APIVersion: "v1",
+					},
+					Name:      "data",
+					Namespace: "a",
+					ID:        "data_a__PersistentVolumeClaim",
+					Keep:      true,
+				}
+
+				dag := component.NewDependencyGraph()
+				ctx := context.ctx
+				kubernetes := context.kubernetes
+				inventoryInstance := context.inventoryInstance
+
+				prepareManifests(
+					ctx,
+					t,
+					[]*inventory.ManifestItem{nsA, pvcA},
+					kubernetes.DynamicTestKubeClient.DynamicClient(),
+					inventoryInstance,
+					dag,
+				)
+
+				storage, err := inventoryInstance.Load()
+				assert.NilError(t, err)
+				assert.Assert(t, storage.Items()[pvcA.ID].(*inventory.ManifestItem).Keep)
+				assert.Assert(t, !storage.Items()[nsA.ID].(*inventory.ManifestItem).Keep)
+
+				dag = component.NewDependencyGraph()
+				prepareManifests(
+					ctx,
+					t,
+					[]*inventory.ManifestItem{nsA},
+					kubernetes.DynamicTestKubeClient.DynamicClient(),
+					inventoryInstance,
+					dag,
+				)
+
+				dangling, err := context.collector.Dangling(&dag)
+				assert.NilError(t, err)
+				assert.Equal(t, len(dangling), 0)
+
+				err = context.collector.Collect(ctx, &dag)
+				assert.NilError(t, err)
+
+				storage, err = inventoryInstance.Load()
+				assert.NilError(t, err)
+				assert.Assert(t, !storage.HasItem(pvcA))
+
+				obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(toObject(pvcA))
+				assert.NilError(t, err)
+				assertRunning(ctx, t, kubernetes.DynamicTestKubeClient.DynamicClient(), &unstructured.Unstructured{Object: obj})
+			},
+		},
+		{
+			name: "Many-Dangling",
+			runCase: func(context testCaseContext) {
+				manifests := manyDeployments("many", 30)
+
+				dag := component.NewDependencyGraph()
+				ctx := context.ctx
+				kubernetes := context.kubernetes
+				inventoryInstance := context.inventoryInstance
+				dynClient := kubernetes.DynamicTestKubeClient.DynamicClient()
+
+				prepareManifests(
+					ctx,
+					t,
+					manifests,
+					dynClient,
+					inventoryInstance,
+					dag,
+				)
+
+				err := context.collector.Collect(ctx, &dag)
+				assert.NilError(t, err)
+
+				storage, err := inventoryInstance.Load()
+				assert.NilError(t, err)
+				assert.Equal(t, len(storage.Items()), 0)
+
+				for _, manifest := range manifests[1:] {
+					obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(toObject(manifest))
+					assert.NilError(t, err)
+					assertNotRunning(ctx, t, dynClient, &unstructured.Unstructured{Object: obj})
+				}
+
+				// the namespace is deleted last and stays terminating, because envtest runs no namespace controller.
+				ns, err := dynClient.Get(ctx, &unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "v1",
+						"kind":       "Namespace",
+						"metadata": map[string]any{
+							"name": "many",
+						},
+					},
+				})
+				assert.NilError(t, err)
+				assert.Assert(t, ns.GetDeletionTimestamp() != nil)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -346,6 +452,168 @@ func TestCollector_Collect(t *testing.T) {
 	}
 }
 
+func TestCollector_Collect_DeletePropagation(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	ctx := context.Background()
+	dynClient := kubernetes.DynamicTestKubeClient.DynamicClient()
+	inventoryInstance := &inventory.Instance{
+		Path: filepath.Join(t.TempDir(), "inventory"),
+	}
+
+	ns := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Namespace",
+			APIVersion: "v1",
+		},
+		Name: "propagation",
+		ID:   "propagation___Namespace",
+	}
+	foreground := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		Name:      "foreground",
+		Namespace: "propagation",
+		ID:        "foreground_propagation_apps_Deployment",
+	}
+	background := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		Name:      "background",
+		Namespace: "propagation",
+		ID:        "background_propagation_apps_Deployment",
+	}
+
+	dag := component.NewDependencyGraph()
+	prepareManifests(ctx, t, []*inventory.ManifestItem{ns, background}, dynClient, inventoryInstance, dag)
+	prepareManifests(ctx, t, []*inventory.ManifestItem{foreground}, dynClient, inventoryInstance, component.NewDependencyGraph())
+
+	foregroundObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(toObject(foreground))
+	assert.NilError(t, err)
+	liveForeground, err := dynClient.Get(ctx, &unstructured.Unstructured{Object: foregroundObj})
+	assert.NilError(t, err)
+
+	// a dependent, which is usually created by the deployment controller.
+	blockOwnerDeletion := true
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foreground-pod",
+			Namespace: "propagation",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         "apps/v1",
+					Kind:               "Deployment",
+					Name:               liveForeground.GetName(),
+					UID:                liveForeground.GetUID(),
+					BlockOwnerDeletion: &blockOwnerDeletion,
+				},
+			},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  "test",
+					Image: "test",
+				},
+			},
+		},
+	}
+	err = kubernetes.TestKubeClient.Create(ctx, pod)
+	assert.NilError(t, err)
+
+	collector := garbage.Collector{
+		Log:               logr.Discard(),
+		Client:            dynClient,
+		InventoryInstance: inventoryInstance,
+		WorkerPoolSize:    goRuntime.GOMAXPROCS(0),
+		DeletePropagation: metav1.DeletePropagationForeground,
+	}
+	err = collector.Collect(ctx, &dag)
+	assert.NilError(t, err)
+
+	// envtest runs no garbage collection controller, which would remove the pod and then the deployment.
+	// The deployment is kept until then.
+	liveForeground, err = dynClient.Get(ctx, liveForeground)
+	assert.NilError(t, err)
+	assert.Assert(t, liveForeground.GetDeletionTimestamp() != nil)
+	assert.DeepEqual(t, liveForeground.GetFinalizers(), []string{metav1.FinalizerDeleteDependents})
+
+	storage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, !storage.HasItem(foreground))
+
+	dag = component.NewDependencyGraph()
+	prepareManifests(ctx, t, []*inventory.ManifestItem{ns}, dynClient, inventoryInstance, dag)
+	collector.DeletePropagation = metav1.DeletePropagationBackground
+	err = collector.Collect(ctx, &dag)
+	assert.NilError(t, err)
+
+	backgroundObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(toObject(background))
+	assert.NilError(t, err)
+	assertNotRunning(ctx, t, dynClient, &unstructured.Unstructured{Object: backgroundObj})
+}
+
+func TestParseDeletePropagation(t *testing.T) {
+	for _, policy := range []string{"Foreground", "Background", "Orphan"} {
+		propagation, err := garbage.ParseDeletePropagation(policy)
+		assert.NilError(t, err)
+		assert.Equal(t, string(propagation), policy)
+	}
+
+	_, err := garbage.ParseDeletePropagation("foreground")
+	assert.ErrorIs(t, err, garbage.ErrInvalidDeletePropagation)
+}
+
+func BenchmarkCollector_Collect(b *testing.B) {
+	kubernetes := kubetest.StartKubetestEnv(b, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	ctx := context.Background()
+	dynClient := kubernetes.DynamicTestKubeClient.DynamicClient()
+
+	for _, workers := range []int{1, goRuntime.GOMAXPROCS(0)} {
+		b.Run(fmt.Sprintf("Workers-%d", workers), func(b *testing.B) {
+			inventoryInstance := &inventory.Instance{
+				Path: filepath.Join(b.TempDir(), "inventory"),
+			}
+
+			collector := garbage.Collector{
+				Log:               logr.Discard(),
+				Client:            dynClient,
+				InventoryInstance: inventoryInstance,
+				WorkerPoolSize:    workers,
+			}
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				dag := component.NewDependencyGraph()
+				prepareManifests(
+					ctx,
+					b,
+					manyDeployments(fmt.Sprintf("bench-%d-%d", workers, i), 50),
+					dynClient,
+					inventoryInstance,
+					dag,
+				)
+				dag = component.NewDependencyGraph()
+				b.StartTimer()
+
+				err := collector.Collect(ctx, &dag)
+				assert.NilError(b, err)
+			}
+		})
+	}
+}
+
 func assertRunning(
 	ctx context.Context,
 	t *testing.T,
@@ -442,7 +710,7 @@ func prepareHelmReleases(
 
 func prepareManifests(
 	ctx context.Context,
-	t *testing.T,
+	t testing.TB,
 	invManifests []*inventory.ManifestItem,
 	client *kube.DynamicClient,
 	inventoryInstance *inventory.Instance,
@@ -453,8 +721,12 @@ func prepareManifests(
 		unstr := unstructured.Unstructured{Object: obj}
 		_, err = client.Apply(ctx, &unstr, "test")
 		assert.NilError(t, err)
+		stored := unstr.DeepCopy()
+		if im.Keep {
+			stored.SetAnnotations(map[string]string{inventory.KeepAnnotation: "true"})
+		}
 		buf := &bytes.Buffer{}
-		json.NewEncoder(buf).Encode(unstr.Object)
+		json.NewEncoder(buf).Encode(stored.Object)
 		err = inventoryInstance.StoreItem(im, buf)
 		assert.NilError(t, err)
 		dag.Insert(
@@ -467,12 +739,41 @@ func prepareManifests(
 	}
 }
 
+// manyDeployments returns a namespace followed by count deployments in it.
+func manyDeployments(namespace string, count int) []*inventory.ManifestItem {
+	manifests := []*inventory.ManifestItem{
+		{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Namespace",
+				APIVersion: "v1",
+			},
+			Name: namespace,
+			ID:   fmt.Sprintf("%s___Namespace", namespace),
+		},
+	}
+	for i := range count {
+		name := fmt.Sprintf("dep%d", i)
+		manifests = append(manifests, &inventory.ManifestItem{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Deployment",
+				APIVersion: "apps/v1",
+			},
+			Name:      name,
+			Namespace: namespace,
+			ID:        fmt.Sprintf("%s_%s_apps_Deployment", name, namespace),
+		})
+	}
+	return manifests
+}
+
 func toObject(invManifest *inventory.ManifestItem) client.Object {
 	switch invManifest.TypeMeta.Kind {
 	case "Deployment":
 		return deployment(invManifest)
 	case "Namespace":
 		return namespace(invManifest)
+	case "PersistentVolumeClaim":
+		return persistentVolumeClaim(invManifest)
 	}
 	return nil
 }
@@ -489,6 +790,27 @@ func namespace(invManifest *inventory.ManifestItem) client.Object {
 	}
 }
 
+func persistentVolumeClaim(invManifest *inventory.ManifestItem) client.Object {
+	return &v1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      invManifest.GetName(),
+			Namespace: invManifest.GetNamespace(),
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.VolumeResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceStorage: resource.MustParse("1Gi"),
+				},
+			},
+		},
+	}
+}
+
 func deployment(invManifest *inventory.ManifestItem) client.Object {
 	replicas := int32(1)
 	labels := map[string]string{
@@ -0,0 +1,137 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chartCacheLock guards the cache root, which ChartReconcilers share and run concurrently on.
+// Pulling and loading charts hold the read lock, so that evictions, which hold the write lock,
+// never remove an archive, which is being pulled or loaded.
+var chartCacheLock sync.RWMutex
+
+type cachedChart struct {
+	path       string
+	size       int64
+	accessTime time.Time
+}
+
+// touch marks the archive as recently used, so that it is evicted last.
+func touch(path string) error {
+	now := time.Now()
+	return os.Chtimes(path, now, now)
+}
+
+// evict removes cached chart archives, which have not been accessed within ChartCacheTTL,
+// and, starting with the least recently used one, archives exceeding ChartCacheMaxBytes.
+// The active archive is never evicted.
+func (c *ChartReconciler) evict(active archivePath) error {
+	if c.ChartCacheMaxBytes <= 0 && c.ChartCacheTTL <= 0 {
+		return nil
+	}
+
+	chartCacheLock.Lock()
+	defer chartCacheLock.Unlock()
+
+	charts, err := listCachedCharts(c.ChartCacheRoot)
+	if err != nil {
+		return err
+	}
+
+	// least recently used first
+	slices.SortFunc(charts, func(a, b cachedChart) int {
+		return a.accessTime.Compare(b.accessTime)
+	})
+
+	var totalBytes int64
+	for _, chart := range charts {
+		totalBytes += chart.size
+	}
+
+	now := time.Now()
+	for _, chart := range charts {
+		if chart.path == active.fullPath {
+			continue
+		}
+
+		expired := c.ChartCacheTTL > 0 && now.Sub(chart.accessTime) > c.ChartCacheTTL
+		exceeded := c.ChartCacheMaxBytes > 0 && totalBytes > c.ChartCacheMaxBytes
+		if !expired && !exceeded {
+			continue
+		}
+
+		if err := os.Remove(chart.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		totalBytes -= chart.size
+	}
+
+	return nil
+}
+
+// listCachedCharts returns all archives following the layout of [newArchivePath].
+// Other files sharing the cache root are ignored.
+func listCachedCharts(chartCacheRoot string) ([]cachedChart, error) {
+	chartDirs, err := os.ReadDir(chartCacheRoot)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var charts []cachedChart
+	for _, chartDir := range chartDirs {
+		if !chartDir.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(chartCacheRoot, chartDir.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() ||
+				!strings.HasPrefix(name, chartDir.Name()+"-") ||
+				!strings.HasSuffix(name, ".tgz") {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+
+			charts = append(charts, cachedChart{
+				path:       filepath.Join(dir, name),
+				size:       info.Size(),
+				accessTime: info.ModTime(),
+			})
+		}
+	}
+
+	return charts, nil
+}
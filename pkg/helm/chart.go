@@ -24,24 +24,30 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/inventory"
 	"github.com/kharf/navecd/pkg/kube"
+	"github.com/kharf/navecd/pkg/oci"
 	"gopkg.in/yaml.v3"
 	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/chart/loader"
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/cli"
+	"helm.sh/helm/v4/pkg/downloader"
+	"helm.sh/helm/v4/pkg/getter"
 	helmKube "helm.sh/helm/v4/pkg/kube"
 	"helm.sh/helm/v4/pkg/registry"
 	release "helm.sh/helm/v4/pkg/release"
 	"helm.sh/helm/v4/pkg/release/common"
 	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	repo "helm.sh/helm/v4/pkg/repo/v1"
 	"helm.sh/helm/v4/pkg/storage/driver"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -89,6 +95,15 @@ type ChartReconciler struct {
 	// Root directory where the charts are stored/cached.
 	ChartCacheRoot string
 
+	// Upper limit of the chart cache size in bytes.
+	// Least recently used charts are evicted first when exceeded.
+	// Zero means unlimited.
+	ChartCacheMaxBytes int64
+
+	// Cached charts, which have not been used within this duration, are evicted.
+	// Zero means charts never expire.
+	ChartCacheTTL time.Duration
+
 	// Endpoint to the microsoft azure login server.
 	// Default is usually: https://login.microsoftonline.com/.
 	AzureLoginURL string
@@ -96,6 +111,23 @@ type ChartReconciler struct {
 	// Endpoint to the google metadata server, which provides access tokens.
 	// Default is: http://metadata.google.internal.
 	GCPMetadataServerURL string
+
+	// Mirrors rewrite chart repository urls before contacting the registry.
+	// The declared url is kept in the release.
+	Mirrors oci.Mirrors
+
+	// Proxy routes all chart downloads through the given proxy.
+	// Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy *url.URL
+
+	// RegistryCredentials authenticate against chart repositories and registries of charts without declared auth.
+	RegistryCredentials oci.RegistryCredentials
+
+	// CredentialCache shares credentials fetched through workload identity, if set.
+	CredentialCache *cloud.CredentialCache
+
+	// CheckKind refuses to install or upgrade releases, which render objects or contain CRDs of a kind it returns an error for, if set.
+	CheckKind func(apiVersion string, kind string) error
 }
 
 type logKey struct{}
@@ -163,6 +195,11 @@ func (c *ChartReconciler) Reconcile(
 	if err := inventoryInstance.StoreItem(invRelease, buf); err != nil {
 		return nil, err
 	}
+
+	if err := c.evict(newArchivePath(component.Content.Chart, c.ChartCacheRoot)); err != nil {
+		logger.Error(err, "Unable to evict charts from cache")
+	}
+
 	return installedRelease, nil
 }
 
@@ -241,6 +278,10 @@ func (c *ChartReconciler) installOrUpgrade(
 		return nil, err
 	}
 
+	if err := c.checkKinds(ctx, desiredRelease, chrt); err != nil {
+		return nil, err
+	}
+
 	histClient := action.NewHistory(helmConfig)
 	histClient.Max = 2
 	releases, err := histClient.Run(desiredRelease.Name)
@@ -275,13 +316,15 @@ func (c *ChartReconciler) installOrUpgrade(
 		log.V(1).Info("No changes")
 		latestInternalRelease := releases[len(releases)-1].(*releasev1.Release)
 		return &Release{
-			Name:      latestInternalRelease.Name,
-			Namespace: latestInternalRelease.Namespace,
-			Chart:     desiredRelease.Chart,
-			Values:    desiredRelease.Values,
-			Patches:   desiredRelease.Patches,
-			CRDs:      desiredRelease.CRDs,
-			Version:   latestInternalRelease.Version,
+			Name:            latestInternalRelease.Name,
+			Namespace:       latestInternalRelease.Namespace,
+			Chart:           desiredRelease.Chart,
+			Values:          desiredRelease.Values,
+			Patches:         desiredRelease.Patches,
+			CRDs:            desiredRelease.CRDs,
+			CreateNamespace: desiredRelease.CreateNamespace,
+			Version:         latestInternalRelease.Version,
+			Notes:           releaseNotes(latestInternalRelease),
 		}, nil
 	}
 
@@ -336,13 +379,15 @@ func (c *ChartReconciler) installOrUpgrade(
 	release := releaser.(*releasev1.Release)
 
 	return &Release{
-		Name:      release.Name,
-		Namespace: release.Namespace,
-		Chart:     desiredRelease.Chart,
-		Values:    desiredRelease.Values,
-		Patches:   desiredRelease.Patches,
-		CRDs:      desiredRelease.CRDs,
-		Version:   release.Version,
+		Name:            release.Name,
+		Namespace:       release.Namespace,
+		Chart:           desiredRelease.Chart,
+		Values:          desiredRelease.Values,
+		Patches:         desiredRelease.Patches,
+		CRDs:            desiredRelease.CRDs,
+		CreateNamespace: desiredRelease.CreateNamespace,
+		Version:         release.Version,
+		Notes:           releaseNotes(release),
 	}, nil
 }
 
@@ -615,7 +660,7 @@ func (c *ChartReconciler) install(
 	install.WaitStrategy = helmKube.HookOnlyStrategy
 	install.ServerSideApply = true
 	install.ReleaseName = desiredRelease.Name
-	install.CreateNamespace = false
+	install.CreateNamespace = desiredRelease.CreateNamespace
 	install.Namespace = desiredRelease.Namespace
 	if desiredRelease.Patches != nil {
 		install.PostRenderer = &PostRenderer{
@@ -623,6 +668,12 @@ func (c *ChartReconciler) install(
 		}
 	}
 
+	if !desiredRelease.CreateNamespace {
+		if err := c.assertNamespaceExists(ctx, desiredRelease.Namespace); err != nil {
+			return nil, err
+		}
+	}
+
 	log.V(1).Info("Installing chart")
 
 	releaser, err := install.Run(loadedChart, desiredRelease.Values)
@@ -633,16 +684,49 @@ func (c *ChartReconciler) install(
 	release := releaser.(*releasev1.Release)
 
 	return &Release{
-		Name:      release.Name,
-		Namespace: release.Namespace,
-		Chart:     desiredRelease.Chart,
-		Values:    desiredRelease.Values,
-		Patches:   desiredRelease.Patches,
-		CRDs:      desiredRelease.CRDs,
-		Version:   release.Version,
+		Name:            release.Name,
+		Namespace:       release.Namespace,
+		Chart:           desiredRelease.Chart,
+		Values:          desiredRelease.Values,
+		Patches:         desiredRelease.Patches,
+		CRDs:            desiredRelease.CRDs,
+		CreateNamespace: desiredRelease.CreateNamespace,
+		Version:         release.Version,
+		Notes:           releaseNotes(release),
 	}, nil
 }
 
+// releaseNotes returns the rendered NOTES.txt of the release.
+func releaseNotes(release *releasev1.Release) string {
+	if release.Info == nil {
+		return ""
+	}
+	return strings.TrimSpace(release.Info.Notes)
+}
+
+// ErrNamespaceNotFound indicates that the namespace of a release, which is not allowed to create it, does not exist.
+var ErrNamespaceNotFound = errors.New("Release namespace not found")
+
+func (c *ChartReconciler) assertNamespaceExists(ctx context.Context, namespace string) error {
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(namespace)
+
+	if _, err := c.Client.DynamicClient().Get(ctx, ns); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return fmt.Errorf(
+				"%w: %s does not exist and createNamespace is disabled",
+				ErrNamespaceNotFound,
+				namespace,
+			)
+		}
+		return err
+	}
+
+	return nil
+}
+
 func reset(
 	ctx context.Context,
 	release *releasev1.Release,
@@ -668,6 +752,9 @@ func (c *ChartReconciler) load(
 ) (*chart.Chart, error) {
 	log := ctx.Value(logKey{}).(*logr.Logger)
 
+	chartCacheLock.RLock()
+	defer chartCacheLock.RUnlock()
+
 	var err error
 	archivePath := newArchivePath(chartRequest, c.ChartCacheRoot)
 	charter, err := loader.Load(archivePath.fullPath)
@@ -686,6 +773,11 @@ func (c *ChartReconciler) load(
 		}
 		return nil, err
 	}
+
+	if err := touch(archivePath.fullPath); err != nil {
+		return nil, err
+	}
+
 	return charter.(*chart.Chart), nil
 }
 
@@ -697,14 +789,21 @@ func (c *ChartReconciler) pull(
 ) error {
 	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
 	pull := action.NewPull(action.WithConfig(helmConfig))
+
+	mirroredRequest := *chartRequest
+	mirroredRequest.RepoURL = c.Mirrors.Rewrite(chartRequest.RepoURL)
+	chartRequest = &mirroredRequest
 	pull.DestDir = archivePath.dir
 
-	httpClient := http.DefaultClient
-	httpClient.Transport = &http.Transport{
+	transport := &http.Transport{
+		Proxy: c.proxy(),
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: c.InsecureSkipTLSVerify,
 		},
 	}
+	httpClient := &http.Client{
+		Transport: transport,
+	}
 	pull.PlainHTTP = c.PlainHTTP
 	pull.InsecureSkipTLSVerify = c.InsecureSkipTLSVerify
 
@@ -728,6 +827,7 @@ func (c *ChartReconciler) pull(
 				cloud.WithNamespace(namespace),
 				cloud.WithCustomAzureLoginURL(c.AzureLoginURL),
 				cloud.WithCustomGCPMetadataServerURL(c.GCPMetadataServerURL),
+				cloud.WithCredentialCache(c.CredentialCache),
 			)
 			if err != nil {
 				return err
@@ -735,6 +835,8 @@ func (c *ChartReconciler) pull(
 
 			pull.Username = creds.Username
 			pull.Password = creds.Password
+		} else if credential, found := c.RegistryCredentials.Lookup(chartRequest.RepoURL); found {
+			pull.Username, pull.Password = credential.BasicAuth()
 		}
 
 		pull.RepoURL = chartRequest.RepoURL
@@ -749,7 +851,11 @@ func (c *ChartReconciler) pull(
 		return err
 	}
 
-	_, err = pull.Run(chartRef)
+	if registry.IsOCI(chartRequest.RepoURL) {
+		_, err = pull.Run(chartRef)
+	} else {
+		err = downloadFromRepository(pull, chartRef, transport)
+	}
 	if err != nil {
 		return err
 	}
@@ -767,6 +873,56 @@ func (c *ChartReconciler) pull(
 	return nil
 }
 
+// proxy returns the configured proxy or falls back to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func (c *ChartReconciler) proxy() func(*http.Request) (*url.URL, error) {
+	if c.Proxy != nil {
+		return http.ProxyURL(c.Proxy)
+	}
+	return http.ProxyFromEnvironment
+}
+
+// downloadFromRepository downloads a chart from a http repository like action.Pull,
+// but sends all requests with the given transport, which action.Pull does not support.
+func downloadFromRepository(pull *action.Pull, chartName string, transport *http.Transport) error {
+	getters := getter.Providers{
+		{
+			Schemes: []string{"http", "https"},
+			New: func(options ...getter.Option) (getter.Getter, error) {
+				return getter.NewHTTPGetter(append(options, getter.WithTransport(transport))...)
+			},
+		},
+	}
+
+	chartURL, err := repo.FindChartInRepoURL(
+		pull.RepoURL,
+		chartName,
+		getters,
+		repo.WithChartVersion(pull.Version),
+		repo.WithUsernamePassword(pull.Username, pull.Password),
+		repo.WithInsecureSkipTLSVerify(pull.InsecureSkipTLSVerify),
+	)
+	if err != nil {
+		return err
+	}
+
+	chartDownloader := downloader.ChartDownloader{
+		Out:     io.Discard,
+		Verify:  downloader.VerifyNever,
+		Getters: getters,
+		Options: []getter.Option{
+			getter.WithBasicAuth(pull.Username, pull.Password),
+			getter.WithInsecureSkipVerifyTLS(pull.InsecureSkipTLSVerify),
+			getter.WithPlainHTTP(pull.PlainHTTP),
+		},
+		RepositoryConfig: pull.Settings.RepositoryConfig,
+		RepositoryCache:  pull.Settings.RepositoryCache,
+		ContentCache:     pull.Settings.ContentCache,
+	}
+
+	_, _, err = chartDownloader.DownloadTo(chartURL, pull.Version, pull.DestDir)
+	return err
+}
+
 func (c *ChartReconciler) loginToRegistry(
 	ctx context.Context,
 	chartRequest *Chart,
@@ -799,6 +955,7 @@ func (c *ChartReconciler) loginToRegistry(
 			cloud.WithNamespace(namespace),
 			cloud.WithCustomAzureLoginURL(c.AzureLoginURL),
 			cloud.WithCustomGCPMetadataServerURL(c.GCPMetadataServerURL),
+			cloud.WithCredentialCache(c.CredentialCache),
 		)
 		if err != nil {
 			return nil, err
@@ -810,6 +967,15 @@ func (c *ChartReconciler) loginToRegistry(
 		); err != nil {
 			return nil, err
 		}
+	} else if credential, found := c.RegistryCredentials.Lookup(chartRequest.RepoURL); found {
+		host, _ := strings.CutPrefix(chartRequest.RepoURL, "oci://")
+		username, password := credential.BasicAuth()
+		if err := registryClient.Login(
+			host,
+			registry.LoginOptBasicAuth(username, password),
+		); err != nil {
+			return nil, err
+		}
 	}
 	return registryClient, nil
 }
@@ -18,15 +18,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net/url"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"go.uber.org/zap/zapcore"
 	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/release/common"
 	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage/driver"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,6 +49,7 @@ import (
 	"github.com/kharf/navecd/internal/helmtest"
 	"github.com/kharf/navecd/internal/kubetest"
 	"github.com/kharf/navecd/internal/ocitest"
+	"github.com/kharf/navecd/internal/proxytest"
 	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/helm"
 	. "github.com/kharf/navecd/pkg/helm"
@@ -639,6 +646,81 @@ func TestChartReconciler_Reconcile_HTTP(t *testing.T) {
 	assert.Equal(t, hpa.Namespace, releaseDeclaration.Namespace)
 }
 
+func TestChartReconciler_Reconcile_HTTPProxy(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	cueModuleRegistry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer cueModuleRegistry.Close()
+
+	publicHelmEnvironment := newHelmEnvironment(t, false, false, "", "digest")
+	defer publicHelmEnvironment.Close()
+
+	proxy := proxytest.NewProxy()
+	defer proxy.Close()
+
+	releaseDeclaration := createReleaseDeclaration(
+		"default",
+		publicHelmEnvironment.ChartServer.URL(),
+		"1.0.0@digest",
+		nil,
+		false,
+		Values{},
+		nil,
+	)
+
+	ctx := context.Background()
+
+	logOpts := ctrlZap.Options{
+		Development: false,
+		Level:       zapcore.Level(-1),
+	}
+	log := ctrlZap.New(ctrlZap.UseFlagOptions(&logOpts))
+	kubernetes := kubetest.StartKubetestEnv(t, log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := inventory.Instance{
+		Path: filepath.Join(t.TempDir(), "inventory"),
+	}
+
+	chartReconciler := helm.ChartReconciler{
+		Log:                   log,
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		Client:                kubernetes.DynamicTestKubeClient,
+		FieldManager:          "controller",
+		InventoryInstance:     &inventoryInstance,
+		InsecureSkipTLSVerify: true,
+		ChartCacheRoot:        t.TempDir(),
+		Proxy:                 proxy.URL(),
+	}
+
+	release, err := chartReconciler.Reconcile(
+		ctx,
+		&helm.ReleaseComponent{
+			ID: fmt.Sprintf(
+				"%s_%s_%s",
+				releaseDeclaration.Name,
+				releaseDeclaration.Namespace,
+				"HelmRelease",
+			),
+			Content: releaseDeclaration,
+		},
+	)
+	assert.NilError(t, err)
+	assertChartv1(t, kubernetes, release.Name, release.Namespace, 1)
+
+	chartServerURL, err := url.Parse(publicHelmEnvironment.ChartServer.URL())
+	assert.NilError(t, err)
+
+	hosts := proxy.Hosts()
+	assert.Assert(t, len(hosts) != 0)
+	for _, host := range hosts {
+		assert.Equal(t, host, chartServerURL.Host)
+	}
+}
+
 func TestChartReconciler_Reconcile_HTTPAuthSecretNotFound(t *testing.T) {
 	dnsServer, err := dnstest.NewDNSServer()
 	assert.NilError(t, err)
@@ -1697,6 +1779,253 @@ func TestChartReconciler_Reconcile_Namespaced(t *testing.T) {
 	assert.Equal(t, string(storedBytes), desiredBuf.String())
 }
 
+func TestChartReconciler_Reconcile_KindNotAllowed(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	cueModuleRegistry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer cueModuleRegistry.Close()
+
+	publicHelmEnvironment := newHelmEnvironment(t, false, false, "", "")
+	defer publicHelmEnvironment.Close()
+
+	releaseDeclaration := createReleaseDeclaration(
+		"default",
+		publicHelmEnvironment.ChartServer.URL(),
+		"1.0.0",
+		nil,
+		false,
+		Values{},
+		nil,
+	)
+
+	ctx := context.Background()
+
+	logOpts := ctrlZap.Options{
+		Development: false,
+		Level:       zapcore.Level(-1),
+	}
+	log := ctrlZap.New(ctrlZap.UseFlagOptions(&logOpts))
+	kubernetes := kubetest.StartKubetestEnv(t, log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	errDenied := errors.New("denied")
+	chartReconciler := helm.ChartReconciler{
+		Log:          log,
+		KubeConfig:   kubernetes.ControlPlane.Config,
+		Client:       kubernetes.DynamicTestKubeClient,
+		FieldManager: "controller",
+		InventoryInstance: &inventory.Instance{
+			Path: filepath.Join(t.TempDir(), "inventory"),
+		},
+		InsecureSkipTLSVerify: true,
+		ChartCacheRoot:        t.TempDir(),
+		CheckKind: func(apiVersion string, kind string) error {
+			if kind == "Deployment" {
+				return errDenied
+			}
+			return nil
+		},
+	}
+
+	_, err = chartReconciler.Reconcile(
+		ctx,
+		&helm.ReleaseComponent{
+			ID: fmt.Sprintf(
+				"%s_%s_%s",
+				releaseDeclaration.Name,
+				releaseDeclaration.Namespace,
+				"HelmRelease",
+			),
+			Content: releaseDeclaration,
+		},
+	)
+	assert.ErrorIs(t, err, errDenied)
+
+	helmConfig, err := helmtest.ConfigureHelm(chartReconciler.KubeConfig)
+	assert.NilError(t, err)
+
+	_, err = action.NewGet(helmConfig).Run(releaseDeclaration.Name)
+	assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+}
+
+func TestChartReconciler_Reconcile_MissingNamespace(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	cueModuleRegistry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer cueModuleRegistry.Close()
+
+	publicHelmEnvironment := newHelmEnvironment(t, false, false, "", "")
+	defer publicHelmEnvironment.Close()
+
+	releaseDeclaration := createReleaseDeclaration(
+		"missing",
+		publicHelmEnvironment.ChartServer.URL(),
+		"1.0.0",
+		nil,
+		false,
+		Values{},
+		nil,
+	)
+
+	ctx := context.Background()
+
+	logOpts := ctrlZap.Options{
+		Development: false,
+		Level:       zapcore.Level(-1),
+	}
+	log := ctrlZap.New(ctrlZap.UseFlagOptions(&logOpts))
+	kubernetes := kubetest.StartKubetestEnv(t, log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := inventory.Instance{
+		Path: filepath.Join(t.TempDir(), "inventory"),
+	}
+
+	chartReconciler := helm.ChartReconciler{
+		Log:                   log,
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		Client:                kubernetes.DynamicTestKubeClient,
+		FieldManager:          "controller",
+		InventoryInstance:     &inventoryInstance,
+		InsecureSkipTLSVerify: true,
+		ChartCacheRoot:        t.TempDir(),
+	}
+
+	id := fmt.Sprintf(
+		"%s_%s_%s",
+		releaseDeclaration.Name,
+		releaseDeclaration.Namespace,
+		"HelmRelease",
+	)
+
+	_, err = chartReconciler.Reconcile(
+		ctx,
+		&helm.ReleaseComponent{
+			ID:      id,
+			Content: releaseDeclaration,
+		},
+	)
+	assert.ErrorIs(t, err, helm.ErrNamespaceNotFound)
+
+	releaseDeclaration.CreateNamespace = true
+	release, err := chartReconciler.Reconcile(
+		ctx,
+		&helm.ReleaseComponent{
+			ID:      id,
+			Content: releaseDeclaration,
+		},
+	)
+	assert.NilError(t, err)
+	assertChartv1(t, kubernetes, release.Name, release.Namespace, 1)
+	assert.Equal(t, release.Namespace, releaseDeclaration.Namespace)
+	assert.Assert(t, release.CreateNamespace)
+}
+
+func TestChartReconciler_Diff(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	cueModuleRegistry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer cueModuleRegistry.Close()
+
+	publicHelmEnvironment := newHelmEnvironment(t, false, false, "", "")
+	defer publicHelmEnvironment.Close()
+
+	releaseDeclaration := createReleaseDeclaration(
+		"default",
+		publicHelmEnvironment.ChartServer.URL(),
+		"1.0.0",
+		nil,
+		false,
+		Values{},
+		nil,
+	)
+
+	ctx := context.Background()
+
+	logOpts := ctrlZap.Options{
+		Development: false,
+		Level:       zapcore.Level(-1),
+	}
+	log := ctrlZap.New(ctrlZap.UseFlagOptions(&logOpts))
+	kubernetes := kubetest.StartKubetestEnv(t, log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := inventory.Instance{
+		Path: filepath.Join(t.TempDir(), "inventory"),
+	}
+
+	chartReconciler := helm.ChartReconciler{
+		Log:                   log,
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		Client:                kubernetes.DynamicTestKubeClient,
+		FieldManager:          "controller",
+		InventoryInstance:     &inventoryInstance,
+		InsecureSkipTLSVerify: true,
+		ChartCacheRoot:        t.TempDir(),
+	}
+
+	id := fmt.Sprintf(
+		"%s_%s_%s",
+		releaseDeclaration.Name,
+		releaseDeclaration.Namespace,
+		"HelmRelease",
+	)
+
+	release, err := chartReconciler.Reconcile(
+		ctx,
+		&helm.ReleaseComponent{
+			ID:      id,
+			Content: releaseDeclaration,
+		},
+	)
+	assert.NilError(t, err)
+	assertChartv1(t, kubernetes, release.Name, release.Namespace, 1)
+
+	differences, err := chartReconciler.Diff(
+		ctx,
+		&helm.ReleaseComponent{
+			ID:      id,
+			Content: releaseDeclaration,
+		},
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(differences), 0)
+
+	releaseDeclaration.Values = Values{
+		"replicaCount": 2,
+	}
+	differences, err = chartReconciler.Diff(
+		ctx,
+		&helm.ReleaseComponent{
+			ID:      id,
+			Content: releaseDeclaration,
+		},
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, len(differences), 1)
+	assert.Equal(t, differences[0].Object.GetKind(), "Deployment")
+	assert.Equal(t, differences[0].Object.GetName(), releaseDeclaration.Name)
+	assert.DeepEqual(t, differences[0].Changes, []kube.FieldChange{
+		{
+			Path:    "spec.replicas",
+			Current: float64(1),
+			Desired: float64(2),
+		},
+	})
+
+	// nothing is upgraded
+	assertChartv1(t, kubernetes, release.Name, release.Namespace, 1)
+}
+
 func TestChartReconciler_Reconcile_Cached(t *testing.T) {
 	dnsServer, err := dnstest.NewDNSServer()
 	assert.NilError(t, err)
@@ -1831,6 +2160,119 @@ func TestChartReconciler_Reconcile_Cached(t *testing.T) {
 	assert.Equal(t, actualRelease.Version, 2)
 }
 
+func TestChartReconciler_Reconcile_CacheEviction(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	cueModuleRegistry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer cueModuleRegistry.Close()
+
+	helmEnvironment := newHelmEnvironment(t, false, false, "", "")
+	defer helmEnvironment.Close()
+
+	releaseDeclaration := createReleaseDeclaration(
+		"default",
+		helmEnvironment.ChartServer.URL(),
+		"1.0.0",
+		nil,
+		false,
+		Values{},
+		nil,
+	)
+
+	ctx := context.Background()
+
+	logOpts := ctrlZap.Options{
+		Development: false,
+		Level:       zapcore.Level(-1),
+	}
+	log := ctrlZap.New(ctrlZap.UseFlagOptions(&logOpts))
+	kubernetes := kubetest.StartKubetestEnv(t, log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	inventoryInstance := inventory.Instance{
+		Path: filepath.Join(t.TempDir(), "inventory"),
+	}
+
+	cacheRoot := t.TempDir()
+	staleTime := time.Now().Add(-1 * time.Hour)
+	staleCharts := []string{
+		filepath.Join(cacheRoot, "other", "other-1.0.0.tgz"),
+		filepath.Join(cacheRoot, "other", "other-2.0.0.tgz"),
+		filepath.Join(cacheRoot, releaseDeclaration.Chart.Name, fmt.Sprintf("%s-0.1.0.tgz", releaseDeclaration.Chart.Name)),
+	}
+	for _, staleChart := range staleCharts {
+		err := os.MkdirAll(filepath.Dir(staleChart), 0700)
+		assert.NilError(t, err)
+		err = os.WriteFile(staleChart, bytes.Repeat([]byte("a"), 1024), 0600)
+		assert.NilError(t, err)
+		err = os.Chtimes(staleChart, staleTime, staleTime)
+		assert.NilError(t, err)
+	}
+
+	unrelatedFile := filepath.Join(cacheRoot, "navecd", "project.tgz")
+	err = os.MkdirAll(filepath.Dir(unrelatedFile), 0700)
+	assert.NilError(t, err)
+	err = os.WriteFile(unrelatedFile, bytes.Repeat([]byte("a"), 1024), 0600)
+	assert.NilError(t, err)
+
+	chartReconciler := helm.ChartReconciler{
+		Log:                   log,
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		Client:                kubernetes.DynamicTestKubeClient,
+		FieldManager:          "controller",
+		InventoryInstance:     &inventoryInstance,
+		InsecureSkipTLSVerify: true,
+		ChartCacheRoot:        cacheRoot,
+		ChartCacheMaxBytes:    1,
+	}
+
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(releaseDeclaration.Namespace)
+
+	_, err = kubernetes.DynamicTestKubeClient.DynamicClient().Apply(
+		ctx,
+		ns,
+		"controller",
+	)
+	assert.NilError(t, err)
+
+	release, err := chartReconciler.Reconcile(
+		ctx,
+		&helm.ReleaseComponent{
+			ID: fmt.Sprintf(
+				"%s_%s_%s",
+				releaseDeclaration.Name,
+				releaseDeclaration.Namespace,
+				"HelmRelease",
+			),
+			Content: releaseDeclaration,
+		},
+	)
+	assert.NilError(t, err)
+	assertChartv1(t, kubernetes, release.Name, release.Namespace, 1)
+
+	for _, staleChart := range staleCharts {
+		_, err := os.Stat(staleChart)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	}
+
+	_, err = os.Stat(unrelatedFile)
+	assert.NilError(t, err)
+
+	activeChart := filepath.Join(
+		cacheRoot,
+		releaseDeclaration.Chart.Name,
+		fmt.Sprintf("%s-%s.tgz", releaseDeclaration.Chart.Name, releaseDeclaration.Chart.Version),
+	)
+	_, err = os.Stat(activeChart)
+	assert.NilError(t, err)
+}
+
 func TestChartReconciler_Reconcile_InstallPatches(t *testing.T) {
 	dnsServer, err := dnstest.NewDNSServer()
 	assert.NilError(t, err)
@@ -2085,6 +2527,7 @@ func TestChartReconciler_Reconcile_Upgrade(t *testing.T) {
 	assert.Equal(t, release.Version, 1)
 	assert.Equal(t, release.Name, releaseDeclaration.Name)
 	assert.Equal(t, release.Namespace, releaseDeclaration.Namespace)
+	assert.Equal(t, release.Notes, fmt.Sprintf("Release %s runs chart version 1.0.0.", release.Name))
 
 	contentReader, err := inventoryInstance.GetItem(&inventory.HelmReleaseItem{
 		Name:      release.Name,
@@ -2130,6 +2573,7 @@ func TestChartReconciler_Reconcile_Upgrade(t *testing.T) {
 		actualRelease.Namespace,
 	)
 	assert.Equal(t, actualRelease.Version, 2)
+	assert.Equal(t, actualRelease.Notes, fmt.Sprintf("Release %s runs chart version 2.0.0.", actualRelease.Name))
 }
 
 func TestChartReconciler_Reconcile_UpgradeCRDs(t *testing.T) {
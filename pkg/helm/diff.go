@@ -0,0 +1,162 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/kharf/navecd/pkg/kube"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v4/pkg/action"
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	helmKube "helm.sh/helm/v4/pkg/kube"
+	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ObjectDifference holds the changed fields of a single object rendered by a Helm chart.
+type ObjectDifference struct {
+	// Object is the rendered object.
+	Object *unstructured.Unstructured
+
+	kube.Difference
+}
+
+// Diff renders the chart of the release with its values and patches and compares every rendered object with its live state.
+// Only declared fields are compared, see [kube.Differ.Diff].
+// Objects, which do not exist yet, report all their fields as added. Unchanged objects are omitted.
+// Nothing is installed or upgraded.
+func (c *ChartReconciler) Diff(
+	ctx context.Context,
+	component *ReleaseComponent,
+) ([]ObjectDifference, error) {
+	desiredRelease := component.Content
+	if desiredRelease.Name == "" {
+		desiredRelease.Name = desiredRelease.Chart.Name
+	}
+	if desiredRelease.Namespace == "" {
+		desiredRelease.Namespace = "default"
+	}
+
+	logger := c.Log.WithValues(
+		"name",
+		desiredRelease.Chart.Name,
+		"url",
+		desiredRelease.Chart.RepoURL,
+		"version",
+		desiredRelease.Chart.Version,
+		"releasename",
+		desiredRelease.Name,
+		"namespace",
+		desiredRelease.Namespace,
+	)
+	ctx = context.WithValue(ctx, logKey{}, &logger)
+
+	helmCfg, err := Init(desiredRelease, c.KubeConfig, c.Client, c.FieldManager)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, configKey{}, helmCfg)
+
+	chrt, err := c.load(ctx, desiredRelease.Chart, desiredRelease.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := c.render(ctx, desiredRelease, chrt, action.DryRunServer)
+	if err != nil {
+		return nil, err
+	}
+
+	differ := kube.Differ{}
+	dynClient := c.Client.DynamicClient()
+	var differences []ObjectDifference
+	decoder := yaml.NewDecoder(bytes.NewBufferString(rendered.Manifest))
+	for {
+		desired, err := decodeManifest(decoder)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if errors.Is(err, ErrNoManifest) {
+				continue
+			}
+			return nil, err
+		}
+
+		// manifests with no namespace are set to the release namespace on installation/upgrade.
+		if desired.GetNamespace() == "" {
+			desired.SetNamespace(desiredRelease.Namespace)
+		}
+
+		live, err := dynClient.Get(ctx, desired)
+		if err != nil {
+			if !k8sErrors.IsNotFound(err) {
+				return nil, err
+			}
+			live = nil
+		}
+
+		difference, err := differ.Diff(live, desired)
+		if err != nil {
+			return nil, err
+		}
+
+		if !difference.IsEmpty() {
+			differences = append(differences, ObjectDifference{
+				Object:     desired,
+				Difference: *difference,
+			})
+		}
+	}
+
+	return differences, nil
+}
+
+// render returns the release rendered with the declared values and patches through a dry run.
+// A server-side dry run respects the capabilities of the cluster.
+func (c *ChartReconciler) render(
+	ctx context.Context,
+	desiredRelease ReleaseDeclaration,
+	loadedChart *chart.Chart,
+	dryRun action.DryRunStrategy,
+) (*releasev1.Release, error) {
+	helmConfig := ctx.Value(configKey{}).(*action.Configuration)
+
+	install := action.NewInstall(helmConfig)
+	install.PlainHTTP = c.PlainHTTP
+	install.WaitStrategy = helmKube.HookOnlyStrategy
+	install.DryRunStrategy = dryRun
+	// existing objects of the release are no conflict
+	install.IsUpgrade = true
+	install.ReleaseName = desiredRelease.Name
+	install.Namespace = desiredRelease.Namespace
+	if desiredRelease.Patches != nil {
+		install.PostRenderer = &PostRenderer{
+			Patches: desiredRelease.Patches,
+		}
+	}
+
+	releaser, err := install.Run(loadedChart, desiredRelease.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	return releaser.(*releasev1.Release), nil
+}
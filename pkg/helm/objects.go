@@ -0,0 +1,170 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v4/pkg/action"
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	releasev1 "helm.sh/helm/v4/pkg/release/v1"
+	"helm.sh/helm/v4/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Objects renders the chart of the release with its values and patches and returns every object it installs, including hooks.
+// Objects without namespace are set to the release namespace. Nothing is installed or upgraded.
+func (c *ChartReconciler) Objects(
+	ctx context.Context,
+	component *ReleaseComponent,
+) ([]*unstructured.Unstructured, error) {
+	desiredRelease := component.Content
+	if desiredRelease.Name == "" {
+		desiredRelease.Name = desiredRelease.Chart.Name
+	}
+	if desiredRelease.Namespace == "" {
+		desiredRelease.Namespace = "default"
+	}
+
+	logger := c.Log.WithValues(
+		"name",
+		desiredRelease.Chart.Name,
+		"releasename",
+		desiredRelease.Name,
+		"namespace",
+		desiredRelease.Namespace,
+	)
+	ctx = context.WithValue(ctx, logKey{}, &logger)
+
+	helmCfg, err := Init(desiredRelease, c.KubeConfig, c.Client, c.FieldManager)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, configKey{}, helmCfg)
+
+	chrt, err := c.load(ctx, desiredRelease.Chart, desiredRelease.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := c.render(ctx, desiredRelease, chrt, action.DryRunServer)
+	if err != nil {
+		return nil, err
+	}
+
+	return releaseObjects(rendered)
+}
+
+// DeployedObjects returns every object of the deployed release, including hooks.
+// Releases, which are not installed, have no objects.
+func (c *ChartReconciler) DeployedObjects(name string, namespace string) ([]*unstructured.Unstructured, error) {
+	helmCfg, err := initDeleteConfig(namespace, c.KubeConfig, c.Client.RESTMapper())
+	if err != nil {
+		return nil, err
+	}
+
+	releaser, err := action.NewGet(helmCfg).Run(name)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return releaseObjects(releaser.(*releasev1.Release))
+}
+
+func releaseObjects(release *releasev1.Release) ([]*unstructured.Unstructured, error) {
+	manifests := []string{release.Manifest}
+	for _, hook := range release.Hooks {
+		manifests = append(manifests, hook.Manifest)
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, manifest := range manifests {
+		decoder := yaml.NewDecoder(bytes.NewBufferString(manifest))
+		for {
+			obj, err := decodeManifest(decoder)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if errors.Is(err, ErrNoManifest) {
+					continue
+				}
+				return nil, err
+			}
+
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(release.Namespace)
+			}
+			objects = append(objects, obj)
+		}
+	}
+
+	return objects, nil
+}
+
+// checkKinds refuses releases, whose chart renders objects or contains CRDs of kinds, which CheckKind returns an error for.
+// The chart is rendered client-side, because only the kinds of its objects matter.
+func (c *ChartReconciler) checkKinds(
+	ctx context.Context,
+	desiredRelease ReleaseDeclaration,
+	loadedChart *chart.Chart,
+) error {
+	if c.CheckKind == nil {
+		return nil
+	}
+
+	rendered, err := c.render(ctx, desiredRelease, loadedChart, action.DryRunClient)
+	if err != nil {
+		return err
+	}
+
+	objects, err := releaseObjects(rendered)
+	if err != nil {
+		return err
+	}
+
+	for _, crd := range loadedChart.CRDObjects() {
+		decoder := yaml.NewDecoder(bytes.NewBuffer(crd.File.Data))
+		for {
+			obj, err := decodeManifest(decoder)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if errors.Is(err, ErrNoManifest) {
+					continue
+				}
+				return err
+			}
+			objects = append(objects, obj)
+		}
+	}
+
+	for _, obj := range objects {
+		if err := c.CheckKind(obj.GetAPIVersion(), obj.GetKind()); err != nil {
+			return fmt.Errorf("%s %s of release %s: %w", obj.GetKind(), obj.GetName(), desiredRelease.Name, err)
+		}
+	}
+
+	return nil
+}
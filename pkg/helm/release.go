@@ -58,9 +58,17 @@ type ReleaseDeclaration struct {
 	// Helm CRD handling configuration.
 	CRDs CRDs `json:"crds"`
 
+	// CreateNamespace lets Helm create the namespace of the release, if it does not exist.
+	// When disabled, the namespace has to exist before the release is installed.
+	CreateNamespace bool `json:"createNamespace"`
+
 	// Version is an int which represents the revision of the release.
 	// Not declared by users.
 	Version int `json:"-"`
+
+	// Notes are the rendered NOTES.txt of the chart.
+	// Not declared by users.
+	Notes string `json:"-"`
 }
 
 // Helm CRD handling configuration.
@@ -16,6 +16,8 @@ package inventory
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,9 +25,11 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var (
@@ -34,8 +38,44 @@ var (
 	// This can only happen through an incompatible change, like editing the inventory directly.
 	ErrWrongInventoryKey     = errors.New("Inventory key is incorrect")
 	ErrManifestFieldNotFound = errors.New("Manifest field not found")
+	ErrUnknownFormat         = errors.New("Unknown inventory format")
+	ErrInventoryNotFound     = errors.New("Inventory not found")
+	ErrMigrationMismatch     = errors.New("Migrated inventory does not match the source inventory")
 )
 
+// Format describes how the content of an item is stored.
+type Format string
+
+const (
+	// FormatJSON stores the content as is.
+	FormatJSON Format = "json"
+
+	// FormatGzip stores the content gzip compressed.
+	// Releases of large charts shrink considerably, because their content includes the rendered manifests.
+	FormatGzip Format = "gzip"
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatJSON, FormatGzip:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+}
+
+// KeepAnnotation marks the stored content of manifests, whose objects are kept in the cluster, when they are removed from the project.
+// It is only part of the inventory and never applied to the cluster.
+const KeepAnnotation = "navecd.io/keep"
+
+// HookCompletedAnnotation marks the stored content of hook Jobs, which completed.
+// It is only part of the inventory and never applied to the cluster.
+const HookCompletedAnnotation = "navecd.io/hook-completed"
+
+// gzipMagic are the leading bytes of gzip compressed content, which never start a JSON document.
+var gzipMagic = []byte{0x1f, 0x8b}
+
 // Item is a small representation of a stored object.
 type Item interface {
 	GetName() string
@@ -76,6 +116,14 @@ type ManifestItem struct {
 	Name      string
 	Namespace string
 	ID        string
+
+	// Keep is set for manifests, whose stored content is annotated with [KeepAnnotation].
+	// They are not deleted, when the manifest is removed from the project.
+	Keep bool
+
+	// HookCompleted is set for hook Jobs, whose stored content is annotated with [HookCompletedAnnotation].
+	// Their Job may have been removed from the cluster already, e.g. through ttlSecondsAfterFinished.
+	HookCompleted bool
 }
 
 var _ Item = (*ManifestItem)(nil)
@@ -94,6 +142,31 @@ func (manifest *ManifestItem) GetID() string {
 	return manifest.ID
 }
 
+// PatchItem is a small inventory representation of a Patch.
+// A Patch is merged into a Kubernetes object, which is not owned by Navecd.
+type PatchItem struct {
+	TypeMeta  v1.TypeMeta
+	Name      string
+	Namespace string
+	ID        string
+}
+
+var _ Item = (*PatchItem)(nil)
+
+func (patch *PatchItem) GetName() string {
+	return patch.Name
+}
+
+func (patch *PatchItem) GetNamespace() string {
+	return patch.Namespace
+}
+
+// GetID returns the string representation of the patch.
+// This is used as an identifier in the inventory.
+func (patch *PatchItem) GetID() string {
+	return patch.ID
+}
+
 // Storage represents all stored Navecd items.
 // It is effectively the current cluster state.
 type Storage struct {
@@ -118,6 +191,34 @@ func (inv Storage) HasItem(item Item) bool {
 // The object does not include the storage itself, it only holds a reference to the storage.
 type Instance struct {
 	Path string
+
+	// HelmReleaseFormat is the format HelmRelease content is stored with.
+	// Defaults to FormatJSON.
+	// Content is read regardless of the format it was stored with, so the format can be changed at any time.
+	HelmReleaseFormat Format
+}
+
+// Migrate moves the inventory stored at legacyPath to the path of this instance.
+// Nothing is moved, if there is no inventory at legacyPath or the instance already has an inventory.
+func (instance *Instance) Migrate(legacyPath string) error {
+	if _, err := os.Stat(instance.Path); err == nil {
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if _, err := os.Stat(legacyPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(instance.Path), 0700); err != nil {
+		return err
+	}
+
+	return os.Rename(legacyPath, instance.Path)
 }
 
 // Load returns all the stored components in this inventory.
@@ -149,7 +250,8 @@ func (instance *Instance) Load() (*Storage, error) {
 					ID:        key,
 				}
 			} else {
-				if len(identifier) != 4 {
+				isPatch := len(identifier) == 5 && identifier[4] == "Patch"
+				if len(identifier) != 4 && !isPatch {
 					return fmt.Errorf("%w: key '%s' does not contain 4 identifiers", ErrWrongInventoryKey, key)
 				}
 				file, err := os.Open(path)
@@ -169,14 +271,27 @@ func (instance *Instance) Load() (*Storage, error) {
 				if !found {
 					return fmt.Errorf("%w: %s not found in inventory item %s", ErrManifestFieldNotFound, "apiVersion", key)
 				}
-				items[key] = &ManifestItem{
-					TypeMeta: v1.TypeMeta{
-						Kind:       kind,
-						APIVersion: apiVersion,
-					},
-					Name:      name,
-					Namespace: namespace,
-					ID:        key,
+				typeMeta := v1.TypeMeta{
+					Kind:       kind,
+					APIVersion: apiVersion,
+				}
+				if isPatch {
+					items[key] = &PatchItem{
+						TypeMeta:  typeMeta,
+						Name:      name,
+						Namespace: namespace,
+						ID:        key,
+					}
+				} else {
+					annotations, _, _ := unstructured.NestedStringMap(unstr, "metadata", "annotations")
+					items[key] = &ManifestItem{
+						TypeMeta:      typeMeta,
+						Name:          name,
+						Namespace:     namespace,
+						ID:            key,
+						Keep:          annotations[KeepAnnotation] == "true",
+						HookCompleted: annotations[HookCompletedAnnotation] == "true",
+					}
 				}
 			}
 		}
@@ -191,16 +306,49 @@ func (instance *Instance) Load() (*Storage, error) {
 }
 
 // GetItem opens the item file for reading.
+// Compressed content is decompressed transparently.
 // If there is an error, it will be of type *PathError.
 func (instance Instance) GetItem(item Item) (io.ReadCloser, error) {
 	itemFile, err := os.Open(filepath.Join(instance.Path, itemNs(item), item.GetID()))
 	if err != nil {
 		return nil, err
 	}
-	return itemFile, nil
+
+	reader := bufio.NewReader(itemFile)
+	magic, err := reader.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		itemFile.Close()
+		return nil, err
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return &itemReader{Reader: reader, file: itemFile}, nil
+	}
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		itemFile.Close()
+		return nil, err
+	}
+	return &itemReader{Reader: gzipReader, file: itemFile}, nil
+}
+
+type itemReader struct {
+	io.Reader
+	file *os.File
+}
+
+func (reader *itemReader) Close() error {
+	return reader.file.Close()
+}
+
+// HasItem reports whether the item is stored in the inventory without loading the whole inventory.
+func (instance Instance) HasItem(item Item) bool {
+	_, err := os.Stat(filepath.Join(instance.Path, itemNs(item), item.GetID()))
+	return err == nil
 }
 
 // StoreItem persists given item with optional content in the inventory.
+// The content is streamed into the item file.
 func (instance Instance) StoreItem(item Item, contentReader io.Reader) error {
 	dir := filepath.Join(instance.Path, itemNs(item))
 	if err := os.MkdirAll(dir, 0700); err != nil {
@@ -210,27 +358,27 @@ func (instance Instance) StoreItem(item Item, contentReader io.Reader) error {
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 	if contentReader != nil {
-		bufferedReadWriter := bufio.NewReadWriter(
-			bufio.NewReader(contentReader),
-			bufio.NewWriter(file),
-		)
-		for {
-			line, err := bufferedReadWriter.ReadString('\n')
-			if err == io.EOF {
-				break
-			}
-			_, err = bufferedReadWriter.WriteString(line)
-			if err != nil {
-				return err
-			}
-		}
-		if err = bufferedReadWriter.Flush(); err != nil {
+		if err := instance.writeContent(file, item, contentReader); err != nil {
+			file.Close()
 			return err
 		}
 	}
-	return nil
+
+	return file.Close()
+}
+
+func (instance Instance) writeContent(writer io.Writer, item Item, contentReader io.Reader) error {
+	if _, isRelease := item.(*HelmReleaseItem); !isRelease || instance.HelmReleaseFormat != FormatGzip {
+		_, err := io.Copy(writer, contentReader)
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(writer)
+	if _, err := io.Copy(gzipWriter, contentReader); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
 }
 
 // DeleteItem removes the item from the inventory.
@@ -261,3 +409,163 @@ func itemNs(item Item) string {
 	}
 	return ns
 }
+
+// ItemType names the kind of an item in a snapshot.
+type ItemType string
+
+const (
+	ManifestItemType    ItemType = "Manifest"
+	PatchItemType       ItemType = "Patch"
+	HelmReleaseItemType ItemType = "HelmRelease"
+)
+
+// SnapshotEntry is the portable representation of a stored item and its content.
+type SnapshotEntry struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Type of the item. Snapshots without types restore entries with a TypeMeta as manifests and all others as releases.
+	Type     ItemType        `json:"type,omitempty"`
+	TypeMeta *v1.TypeMeta    `json:"typeMeta,omitempty"`
+	Content  json.RawMessage `json:"content,omitempty"`
+}
+
+// Snapshot is a point-in-time copy of all items stored in an inventory.
+// It is used to backup, audit and restore the managed state of a project.
+type Snapshot struct {
+	Items []SnapshotEntry `json:"items"`
+}
+
+// Export serializes all stored items including their content into a single JSON document.
+func (instance *Instance) Export(writer io.Writer) error {
+	storage, err := instance.Load()
+	if err != nil {
+		return err
+	}
+
+	snapshot := Snapshot{
+		Items: make([]SnapshotEntry, 0, len(storage.items)),
+	}
+	for _, item := range storage.items {
+		entry := SnapshotEntry{
+			ID:        item.GetID(),
+			Name:      item.GetName(),
+			Namespace: item.GetNamespace(),
+		}
+
+		switch typedItem := item.(type) {
+		case *ManifestItem:
+			typeMeta := typedItem.TypeMeta
+			entry.Type = ManifestItemType
+			entry.TypeMeta = &typeMeta
+		case *PatchItem:
+			typeMeta := typedItem.TypeMeta
+			entry.Type = PatchItemType
+			entry.TypeMeta = &typeMeta
+		case *HelmReleaseItem:
+			entry.Type = HelmReleaseItemType
+		}
+
+		content, err := instance.readContent(item)
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(content)) != 0 {
+			entry.Content = content
+		}
+
+		snapshot.Items = append(snapshot.Items, entry)
+	}
+
+	slices.SortFunc(snapshot.Items, func(a, b SnapshotEntry) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// Import restores all items of a JSON document created by [Instance.Export] into this inventory.
+// Existing items with the same ID are overwritten.
+func (instance *Instance) Import(reader io.Reader) error {
+	var snapshot Snapshot
+	if err := json.NewDecoder(reader).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	for _, entry := range snapshot.Items {
+		item, err := entry.item()
+		if err != nil {
+			return err
+		}
+
+		var contentReader io.Reader
+		if len(entry.Content) != 0 {
+			buf := &bytes.Buffer{}
+			if err := json.NewEncoder(buf).Encode(entry.Content); err != nil {
+				return err
+			}
+			contentReader = buf
+		}
+
+		if err := instance.StoreItem(item, contentReader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// item returns the item of the type the entry was exported with.
+func (entry SnapshotEntry) item() (Item, error) {
+	itemType := entry.Type
+	if itemType == "" {
+		itemType = HelmReleaseItemType
+		if entry.TypeMeta != nil {
+			itemType = ManifestItemType
+		}
+	}
+
+	if itemType == HelmReleaseItemType {
+		return &HelmReleaseItem{
+			Name:      entry.Name,
+			Namespace: entry.Namespace,
+			ID:        entry.ID,
+		}, nil
+	}
+
+	if entry.TypeMeta == nil {
+		return nil, fmt.Errorf("%w: %s item %s has no typeMeta", ErrWrongInventoryKey, itemType, entry.ID)
+	}
+
+	switch itemType {
+	case ManifestItemType:
+		return &ManifestItem{
+			TypeMeta:  *entry.TypeMeta,
+			Name:      entry.Name,
+			Namespace: entry.Namespace,
+			ID:        entry.ID,
+		}, nil
+	case PatchItemType:
+		return &PatchItem{
+			TypeMeta:  *entry.TypeMeta,
+			Name:      entry.Name,
+			Namespace: entry.Namespace,
+			ID:        entry.ID,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown item type %s of %s", ErrWrongInventoryKey, itemType, entry.ID)
+	}
+}
+
+func (instance Instance) readContent(item Item) ([]byte, error) {
+	reader, err := instance.GetItem(item)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
@@ -17,7 +17,12 @@ package inventory_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,11 +53,30 @@ func TestManager_Load(t *testing.T) {
 					Namespace: "",
 					ID:        "a___Namespace",
 				},
+				&inventory.ManifestItem{
+					TypeMeta: metav1.TypeMeta{
+						Kind:       "Job",
+						APIVersion: "batch/v1",
+					},
+					Name:          "migrate",
+					Namespace:     "test",
+					ID:            "migrate_test_batch_Job",
+					HookCompleted: true,
+				},
 				&inventory.HelmReleaseItem{
 					Name:      "test",
 					Namespace: "test",
 					ID:        "test_test_HelmRelease",
 				},
+				&inventory.PatchItem{
+					TypeMeta: metav1.TypeMeta{
+						Kind:       "Deployment",
+						APIVersion: "apps/v1",
+					},
+					Name:      "b",
+					Namespace: "test",
+					ID:        "b_test_apps_Deployment_Patch",
+				},
 			},
 		},
 	}
@@ -67,12 +91,31 @@ func TestManager_Load(t *testing.T) {
 			for _, item := range tc.items {
 				switch item := item.(type) {
 				case *inventory.ManifestItem:
+					annotations := map[string]interface{}{}
+					if item.HookCompleted {
+						annotations[inventory.HookCompletedAnnotation] = "true"
+					}
+					unstr := map[string]interface{}{
+						"apiVersion": item.TypeMeta.APIVersion,
+						"kind":       item.TypeMeta.Kind,
+						"metadata": map[string]interface{}{
+							"name":        item.Name,
+							"Namespace":   item.Namespace,
+							"annotations": annotations,
+						},
+					}
+					buf := &bytes.Buffer{}
+					err := json.NewEncoder(buf).Encode(&unstr)
+					assert.NilError(t, err)
+					err = manager.StoreItem(item, buf)
+					assert.NilError(t, err)
+				case *inventory.PatchItem:
 					unstr := map[string]interface{}{
 						"apiVersion": item.TypeMeta.APIVersion,
 						"kind":       item.TypeMeta.Kind,
 						"metadata": map[string]interface{}{
 							"name":      item.Name,
-							"Namespace": item.Namespace,
+							"namespace": item.Namespace,
 						},
 					}
 					buf := &bytes.Buffer{}
@@ -89,6 +132,403 @@ func TestManager_Load(t *testing.T) {
 			assert.NilError(t, err)
 			for _, item := range tc.items {
 				assert.Assert(t, storage.HasItem(item))
+				assert.Assert(t, manager.HasItem(item))
+				assert.DeepEqual(t, storage.Items()[item.GetID()], item)
+			}
+			assert.Assert(t, !manager.HasItem(&inventory.HelmReleaseItem{
+				Name:      "missing",
+				Namespace: "test",
+				ID:        "missing_test_HelmRelease",
+			}))
+		})
+	}
+}
+
+func TestInstance_ExportImport(t *testing.T) {
+	defer goleak.VerifyNone(
+		t,
+	)
+
+	source := inventory.Instance{
+		Path: t.TempDir(),
+	}
+
+	namespaceItem := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Namespace",
+			APIVersion: "v1",
+		},
+		Name:      "a",
+		Namespace: "",
+		ID:        "a___Namespace",
+	}
+	namespaceContent := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]interface{}{
+			"name": "a",
+		},
+	}
+	buf := &bytes.Buffer{}
+	err := json.NewEncoder(buf).Encode(&namespaceContent)
+	assert.NilError(t, err)
+	err = source.StoreItem(namespaceItem, buf)
+	assert.NilError(t, err)
+
+	releaseItem := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+	releaseContent := map[string]interface{}{
+		"name":      "test",
+		"namespace": "test",
+		"values": map[string]interface{}{
+			"replicas": float64(2),
+		},
+	}
+	buf = &bytes.Buffer{}
+	err = json.NewEncoder(buf).Encode(&releaseContent)
+	assert.NilError(t, err)
+	err = source.StoreItem(releaseItem, buf)
+	assert.NilError(t, err)
+
+	emptyReleaseItem := &inventory.HelmReleaseItem{
+		Name:      "empty",
+		Namespace: "empty",
+		ID:        "empty_empty_HelmRelease",
+	}
+	err = source.StoreItem(emptyReleaseItem, nil)
+	assert.NilError(t, err)
+
+	patchItem := &inventory.PatchItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		Name:      "b",
+		Namespace: "test",
+		ID:        "b_test_apps_Deployment_Patch",
+	}
+	patchContent := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "b",
+			"namespace": "test",
+		},
+	}
+	buf = &bytes.Buffer{}
+	err = json.NewEncoder(buf).Encode(&patchContent)
+	assert.NilError(t, err)
+	err = source.StoreItem(patchItem, buf)
+	assert.NilError(t, err)
+
+	snapshot := &bytes.Buffer{}
+	err = source.Export(snapshot)
+	assert.NilError(t, err)
+
+	var exported inventory.Snapshot
+	err = json.Unmarshal(snapshot.Bytes(), &exported)
+	assert.NilError(t, err)
+	types := map[string]inventory.ItemType{}
+	for _, entry := range exported.Items {
+		types[entry.ID] = entry.Type
+	}
+	assert.DeepEqual(t, types, map[string]inventory.ItemType{
+		namespaceItem.ID:    inventory.ManifestItemType,
+		releaseItem.ID:      inventory.HelmReleaseItemType,
+		emptyReleaseItem.ID: inventory.HelmReleaseItemType,
+		patchItem.ID:        inventory.PatchItemType,
+	})
+
+	target := inventory.Instance{
+		Path: t.TempDir(),
+	}
+	err = target.Import(snapshot)
+	assert.NilError(t, err)
+
+	storage, err := target.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 4)
+	assert.Assert(t, storage.HasItem(namespaceItem))
+	assert.Assert(t, storage.HasItem(releaseItem))
+	assert.Assert(t, storage.HasItem(emptyReleaseItem))
+	assert.DeepEqual(t, storage.Items()[namespaceItem.ID], namespaceItem)
+	assert.DeepEqual(t, storage.Items()[patchItem.ID], patchItem)
+
+	for item, expectedContent := range map[inventory.Item]map[string]interface{}{
+		namespaceItem: namespaceContent,
+		releaseItem:   releaseContent,
+		patchItem:     patchContent,
+	} {
+		reader, err := target.GetItem(item)
+		assert.NilError(t, err)
+		content := map[string]interface{}{}
+		err = json.NewDecoder(reader).Decode(&content)
+		reader.Close()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, content, expectedContent)
+	}
+}
+
+func TestInstance_Migrate(t *testing.T) {
+	root := t.TempDir()
+	legacy := inventory.Instance{
+		Path: filepath.Join(root, "12345"),
+	}
+
+	item := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+	err := legacy.StoreItem(item, nil)
+	assert.NilError(t, err)
+
+	sharded := inventory.Instance{
+		Path: filepath.Join(root, "primary", "12345"),
+	}
+	err = sharded.Migrate(legacy.Path)
+	assert.NilError(t, err)
+
+	storage, err := sharded.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(item))
+
+	_, err = os.Stat(legacy.Path)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// migrating again is a no-op
+	err = sharded.Migrate(legacy.Path)
+	assert.NilError(t, err)
+	storage, err = sharded.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, storage.HasItem(item))
+}
+
+func TestInstance_MigrateLayout(t *testing.T) {
+	root := t.TempDir()
+	legacy := inventory.Instance{
+		Path: filepath.Join(root, "12345"),
+	}
+
+	release := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+	releaseContent := "{\"name\":\"test\",\"namespace\":\"test\"}\n"
+	err := legacy.StoreItem(release, strings.NewReader(releaseContent))
+	assert.NilError(t, err)
+
+	manifest := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Namespace",
+			APIVersion: "v1",
+		},
+		Name: "a",
+		ID:   "a___Namespace",
+	}
+	manifestContent := "{\"apiVersion\":\"v1\",\"kind\":\"Namespace\",\"metadata\":{\"name\":\"a\"}}\n"
+	err = legacy.StoreItem(manifest, strings.NewReader(manifestContent))
+	assert.NilError(t, err)
+
+	sharded := inventory.Instance{
+		Path:              filepath.Join(root, "primary", "12345"),
+		HelmReleaseFormat: inventory.FormatGzip,
+	}
+
+	result, err := sharded.MigrateLayout(legacy.Path, true)
+	assert.NilError(t, err)
+	assert.Equal(t, result.SourcePath, legacy.Path)
+	assert.Equal(t, result.Items, 2)
+	assert.Equal(t, result.BackupPath, "")
+
+	// dry runs leave everything untouched
+	_, err = os.Stat(sharded.Path)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = os.Stat(sharded.Path + "-migrating")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	storage, err := legacy.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 2)
+
+	result, err = sharded.MigrateLayout(legacy.Path, false)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Items, 2)
+
+	_, err = os.Stat(legacy.Path)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	storage, err = sharded.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 2)
+	assert.DeepEqual(t, storage.Items()[manifest.ID], manifest)
+	assert.Assert(t, storage.HasItem(release))
+
+	stored, err := os.ReadFile(filepath.Join(sharded.Path, "test", release.ID))
+	assert.NilError(t, err)
+	assert.Assert(t, string(stored) != releaseContent)
+
+	for item, expectedContent := range map[inventory.Item]string{
+		release:  releaseContent,
+		manifest: manifestContent,
+	} {
+		reader, err := sharded.GetItem(item)
+		assert.NilError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NilError(t, reader.Close())
+		assert.NilError(t, err)
+		assert.Equal(t, string(content), expectedContent)
+	}
+
+	backup := inventory.Instance{
+		Path: result.BackupPath,
+	}
+	storage, err = backup.Load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(storage.Items()), 2)
+
+	_, err = (&inventory.Instance{Path: filepath.Join(root, "primary", "67890")}).MigrateLayout(filepath.Join(root, "67890"), false)
+	assert.ErrorIs(t, err, inventory.ErrInventoryNotFound)
+}
+
+func TestInstance_StoreItem(t *testing.T) {
+	release := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+	manifest := &inventory.ManifestItem{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Namespace",
+			APIVersion: "v1",
+		},
+		Name: "a",
+		ID:   "a___Namespace",
+	}
+
+	testCases := []struct {
+		name              string
+		format            inventory.Format
+		item              inventory.Item
+		content           string
+		expectCompression bool
+	}{
+		{
+			name:    "JSON",
+			format:  inventory.FormatJSON,
+			item:    release,
+			content: "{\"name\":\"test\"}\n",
+		},
+		{
+			name:    "Default",
+			item:    release,
+			content: "{\"name\":\"test\"}\n",
+		},
+		{
+			name:    "NoTrailingNewline",
+			format:  inventory.FormatJSON,
+			item:    release,
+			content: "{\"name\":\"test\",\n\"namespace\":\"test\"}",
+		},
+		{
+			name:              "Gzip",
+			format:            inventory.FormatGzip,
+			item:              release,
+			content:           "{\"name\":\"test\"}\n",
+			expectCompression: true,
+		},
+		{
+			name:    "GzipManifest",
+			format:  inventory.FormatGzip,
+			item:    manifest,
+			content: "{\"apiVersion\":\"v1\",\"kind\":\"Namespace\"}\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := inventory.Instance{
+				Path:              t.TempDir(),
+				HelmReleaseFormat: tc.format,
+			}
+			err := instance.StoreItem(tc.item, strings.NewReader(tc.content))
+			assert.NilError(t, err)
+
+			stored, err := os.ReadFile(filepath.Join(instance.Path, tc.item.GetName(), tc.item.GetID()))
+			assert.NilError(t, err)
+			assert.Equal(t, string(stored) != tc.content, tc.expectCompression)
+
+			// reading does not depend on the configured format
+			for _, format := range []inventory.Format{inventory.FormatJSON, inventory.FormatGzip} {
+				instance.HelmReleaseFormat = format
+				reader, err := instance.GetItem(tc.item)
+				assert.NilError(t, err)
+				content, err := io.ReadAll(reader)
+				assert.NilError(t, reader.Close())
+				assert.NilError(t, err)
+				assert.Equal(t, string(content), tc.content)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := inventory.ParseFormat("gzip")
+	assert.NilError(t, err)
+	assert.Equal(t, format, inventory.FormatGzip)
+
+	_, err = inventory.ParseFormat("gob")
+	assert.ErrorIs(t, err, inventory.ErrUnknownFormat)
+}
+
+func BenchmarkInstance_StoreItem(b *testing.B) {
+	release := &inventory.HelmReleaseItem{
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_HelmRelease",
+	}
+
+	manifests := make([]map[string]interface{}, 0, 500)
+	for i := range 500 {
+		manifests = append(manifests, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      fmt.Sprintf("test-%d", i),
+				"namespace": "test",
+			},
+			"data": map[string]interface{}{
+				"key": strings.Repeat("value", 20),
+			},
+		})
+	}
+	content, err := json.Marshal(map[string]interface{}{
+		"name":      "test",
+		"namespace": "test",
+		"manifests": manifests,
+	})
+	assert.NilError(b, err)
+
+	for _, format := range []inventory.Format{inventory.FormatJSON, inventory.FormatGzip} {
+		b.Run(string(format), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(content)))
+			instance := inventory.Instance{
+				Path:              b.TempDir(),
+				HelmReleaseFormat: format,
+			}
+
+			for b.Loop() {
+				err := instance.StoreItem(release, bytes.NewReader(content))
+				assert.NilError(b, err)
+
+				reader, err := instance.GetItem(release)
+				assert.NilError(b, err)
+				_, err = io.Copy(io.Discard, reader)
+				assert.NilError(b, err)
+				assert.NilError(b, reader.Close())
 			}
 		})
 	}
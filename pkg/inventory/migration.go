@@ -0,0 +1,161 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MigrationResult summarizes the migration of an inventory to the current layout.
+type MigrationResult struct {
+	// SourcePath is the path of the migrated inventory.
+	SourcePath string
+
+	// Items is the number of migrated items.
+	Items int
+
+	// BackupPath holds the previous inventory. It is empty for dry runs.
+	BackupPath string
+}
+
+// MigrateLayout rewrites an inventory into the current layout:
+// it is stored at the path of this instance and HelmRelease content is stored with the HelmReleaseFormat of this instance.
+// The inventory is read from the path of this instance or, if it does not exist, from legacyPath.
+// Items are written into a staging dir, which is loaded and compared with the source, before it replaces the source.
+// The source is kept as backup next to it.
+// On dry runs, the staging dir is removed after the comparison and nothing is replaced.
+func (instance *Instance) MigrateLayout(legacyPath string, dryRun bool) (*MigrationResult, error) {
+	sourcePath := instance.Path
+	if _, err := os.Stat(sourcePath); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		sourcePath = legacyPath
+		if _, err := os.Stat(sourcePath); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("%w: neither %s nor %s exist", ErrInventoryNotFound, instance.Path, legacyPath)
+			}
+			return nil, err
+		}
+	}
+
+	source := &Instance{
+		Path: sourcePath,
+	}
+	sourceStorage, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(instance.Path), 0700); err != nil {
+		return nil, err
+	}
+	staging := &Instance{
+		Path:              instance.Path + "-migrating",
+		HelmReleaseFormat: instance.HelmReleaseFormat,
+	}
+	if err := os.RemoveAll(staging.Path); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging.Path)
+
+	for _, item := range sourceStorage.items {
+		if err := copyItem(source, staging, item); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := verifyMigration(source, sourceStorage, staging); err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{
+		SourcePath: sourcePath,
+		Items:      len(sourceStorage.items),
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	backupPath := fmt.Sprintf("%s-backup-%s", sourcePath, time.Now().UTC().Format("20060102150405"))
+	if err := os.Rename(sourcePath, backupPath); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(staging.Path, instance.Path); err != nil {
+		// restore the source, so that the inventory is never lost.
+		return nil, errors.Join(err, os.Rename(backupPath, sourcePath))
+	}
+	result.BackupPath = backupPath
+
+	return result, nil
+}
+
+func copyItem(source *Instance, target *Instance, item Item) error {
+	content, err := source.readContent(item)
+	if err != nil {
+		return err
+	}
+
+	var contentReader io.Reader
+	if len(content) != 0 {
+		contentReader = bytes.NewReader(content)
+	}
+
+	return target.StoreItem(item, contentReader)
+}
+
+// verifyMigration loads the migrated inventory and compares its items and their content with the source.
+func verifyMigration(source *Instance, sourceStorage *Storage, migrated *Instance) error {
+	migratedStorage, err := migrated.Load()
+	if err != nil {
+		return err
+	}
+
+	if len(migratedStorage.items) != len(sourceStorage.items) {
+		return fmt.Errorf(
+			"%w: expected %d items, got %d",
+			ErrMigrationMismatch,
+			len(sourceStorage.items),
+			len(migratedStorage.items),
+		)
+	}
+
+	for id, item := range sourceStorage.items {
+		if !migratedStorage.HasItem(item) {
+			return fmt.Errorf("%w: %s is missing", ErrMigrationMismatch, id)
+		}
+
+		sourceContent, err := source.readContent(item)
+		if err != nil {
+			return err
+		}
+		migratedContent, err := migrated.readContent(item)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(sourceContent, migratedContent) {
+			return fmt.Errorf("%w: content of %s differs", ErrMigrationMismatch, id)
+		}
+	}
+
+	return nil
+}
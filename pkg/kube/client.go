@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
 	"time"
@@ -73,10 +74,21 @@ func (c *InMemoryRESTClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfi
 }
 
 type applyOptions struct {
-	dryRun bool
-	force  bool
+	dryRun             bool
+	force              bool
+	clientSideFallback bool
+	applyStatus        bool
+	timeout            time.Duration
+	maxTimeout         time.Duration
 }
 
+// DefaultApplyTimeout is used for applies without a timeout.
+const DefaultApplyTimeout = 30 * time.Second
+
+var (
+	ErrApplyTimeout = errors.New("Applied object did not become ready")
+)
+
 // ApplyOption is a specific configuration used for applying changes to an object.
 type ApplyOption func(*applyOptions)
 
@@ -94,6 +106,47 @@ func ForceApply(value bool) ApplyOption {
 	}
 }
 
+// AllowClientSideFallback indicates that objects, which are rejected by a Server-Side Apply because they exceed a size limit,
+// should be created or updated through a client-side request instead.
+func AllowClientSideFallback(value bool) ApplyOption {
+	return func(opts *applyOptions) {
+		opts.clientSideFallback = value
+	}
+}
+
+// ApplyStatus indicates that the status field of the object should be applied.
+// By default it is stripped, so that the status set by the owning controller is not overwritten.
+func ApplyStatus(value bool) ApplyOption {
+	return func(opts *applyOptions) {
+		opts.applyStatus = value
+	}
+}
+
+// ApplyTimeout bounds how long an apply waits for the object to become ready, e.g. a CustomResourceDefinition to be established.
+// Every progress of the object, see [CRDProgress], extends the deadline by timeout up to maxTimeout,
+// so that slow objects making progress are distinguished from stuck ones.
+// Timeout defaults to DefaultApplyTimeout. A maxTimeout shorter than timeout means the deadline is never extended.
+func ApplyTimeout(timeout time.Duration, maxTimeout time.Duration) ApplyOption {
+	return func(opts *applyOptions) {
+		opts.timeout = timeout
+		opts.maxTimeout = maxTimeout
+	}
+}
+
+type clientOptions struct {
+	discoveryCache *DiscoveryCache
+}
+
+// ClientOption is a specific configuration used for constructing clients.
+type ClientOption func(*clientOptions)
+
+// WithDiscoveryCache reuses the discovery results and the RESTMapper of the cache instead of discovering the APIs of the cluster again.
+func WithDiscoveryCache(cache *DiscoveryCache) ClientOption {
+	return func(opts *clientOptions) {
+		opts.discoveryCache = cache
+	}
+}
+
 type patchOptions struct {
 	patchType types.PatchType
 }
@@ -107,6 +160,21 @@ func PatchType(value types.PatchType) PatchOption {
 	}
 }
 
+type deleteOptions struct {
+	propagationPolicy v1.DeletionPropagation
+}
+
+// DeleteOption is a specific configuration used for deleting an object.
+type DeleteOption func(*deleteOptions)
+
+// PropagationPolicy defines whether and how dependents of the object are deleted.
+// Defaults to the policy of the object's API, which is background for most APIs.
+func PropagationPolicy(value v1.DeletionPropagation) DeleteOption {
+	return func(opts *deleteOptions) {
+		opts.propagationPolicy = value
+	}
+}
+
 // Client connects to a Kubernetes cluster
 // to create, read, update and delete manifests/objects.
 type Client[T any, R any] interface {
@@ -120,7 +188,7 @@ type Client[T any, R any] interface {
 	// Get retrieves the unstructured object from a Kubernetes cluster.
 	Get(ctx context.Context, obj *T) (*R, error)
 	// Delete removes the object from the Kubernetes cluster.
-	Delete(ctx context.Context, obj *T) error
+	Delete(ctx context.Context, obj *T, opts ...DeleteOption) error
 	// Returns the [meta.RESTMapper] associated with this client.
 	RESTMapper() meta.RESTMapper
 }
@@ -128,23 +196,43 @@ type Client[T any, R any] interface {
 // DynamicClient connects to a Kubernetes cluster
 // to create, read, update and delete unstructured manifests/objects.
 type DynamicClient struct {
-	dynamicClient *dynamic.DynamicClient
-	restMapper    meta.RESTMapper
+	dynamicClient   *dynamic.DynamicClient
+	discoveryClient discovery.DiscoveryInterface
+	restMapper      meta.RESTMapper
 }
 
 var _ Client[unstructured.Unstructured, unstructured.Unstructured] = (*DynamicClient)(nil)
 
 // NewDynamicClient constructs a new DynamicClient,
 // which connects to a Kubernetes cluster to create, read, update and delete unstructured manifests/objects.
-func NewDynamicClient(config *rest.Config) (*DynamicClient, error) {
+func NewDynamicClient(config *rest.Config, opts ...ClientOption) (*DynamicClient, error) {
+	clientOpts := new(clientOptions)
+	for _, opt := range opts {
+		opt(clientOpts)
+	}
+
 	httpClient, err := rest.HTTPClientFor(config)
 	if err != nil {
 		return nil, err
 	}
 
-	restMapper, err := apiutil.NewDynamicRESTMapper(config, httpClient)
-	if err != nil {
-		return nil, err
+	var restMapper meta.RESTMapper
+	var discoveryClient discovery.DiscoveryInterface
+	if clientOpts.discoveryCache != nil {
+		discoveryClient, restMapper, err = clientOpts.discoveryCache.get()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		restMapper, err = apiutil.NewDynamicRESTMapper(config, httpClient)
+		if err != nil {
+			return nil, err
+		}
+
+		discoveryClient, err = discovery.NewDiscoveryClientForConfigAndClient(config, httpClient)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	config = dynamic.ConfigFor(config)
@@ -159,8 +247,9 @@ func NewDynamicClient(config *rest.Config) (*DynamicClient, error) {
 	dynClient := dynamic.New(restClient)
 
 	return &DynamicClient{
-		dynamicClient: dynClient,
-		restMapper:    restMapper,
+		dynamicClient:   dynClient,
+		discoveryClient: discoveryClient,
+		restMapper:      restMapper,
 	}, nil
 }
 
@@ -207,26 +296,34 @@ func (client *DynamicClient) apply(
 		applyOptions.DryRun = []string{"All"}
 	}
 
+	if _, found := obj.Object["status"]; found && !options.applyStatus {
+		obj = obj.DeepCopy()
+		unstructured.RemoveNestedField(obj.Object, "status")
+	}
+
 	runtimeObj, err := resourceInterface.Apply(ctx, obj.GetName(), obj, applyOptions)
 	if err != nil {
-		return nil, err
+		if !options.clientSideFallback || !isSizeLimitError(err) {
+			return nil, err
+		}
+
+		runtimeObj, err = client.update(ctx, obj, fieldManager, options, resourceInterface)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if !options.dryRun {
-		timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		defer cancel()
-
-		_, err = client.wait(
-			timeoutCtx,
+		if err := client.wait(
+			ctx,
 			obj.GetName(),
 			v1.TypeMeta{
 				Kind:       obj.GetKind(),
 				APIVersion: obj.GetAPIVersion(),
 			},
 			resourceInterface,
-		)
-
-		if err != nil {
+			options,
+		); err != nil {
 			return nil, err
 		}
 	}
@@ -234,6 +331,55 @@ func (client *DynamicClient) apply(
 	return runtimeObj, nil
 }
 
+// update creates or replaces the object through a client-side request.
+// It is used as a fallback for objects exceeding the Server-Side Apply size limits.
+func (client *DynamicClient) update(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	fieldManager string,
+	options *applyOptions,
+	resourceInterface dynamic.ResourceInterface,
+) (*unstructured.Unstructured, error) {
+	var dryRun []string
+	if options.dryRun {
+		dryRun = []string{"All"}
+	}
+
+	liveObj, err := resourceInterface.Get(ctx, obj.GetName(), v1.GetOptions{})
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		return resourceInterface.Create(ctx, obj, v1.CreateOptions{
+			FieldManager: fieldManager,
+			DryRun:       dryRun,
+		})
+	}
+
+	updateObj := obj.DeepCopy()
+	updateObj.SetResourceVersion(liveObj.GetResourceVersion())
+	// an update replaces the whole object of resources without status subresource.
+	if liveStatus, found := liveObj.Object["status"]; found && !options.applyStatus {
+		updateObj.Object["status"] = liveStatus
+	}
+
+	return resourceInterface.Update(ctx, updateObj, v1.UpdateOptions{
+		FieldManager: fieldManager,
+		DryRun:       dryRun,
+	})
+}
+
+func isSizeLimitError(err error) bool {
+	if k8sErrors.IsRequestEntityTooLargeError(err) {
+		return true
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "metadata.annotations: Too long") ||
+		strings.Contains(msg, "request is too large")
+}
+
 // Patch applies partial changes to an object and takes ownership of this object/field.
 func (client *DynamicClient) Patch(
 	ctx context.Context,
@@ -283,40 +429,123 @@ func (client *DynamicClient) patch(
 	return runtimeObj, nil
 }
 
+// waitPollInterval is the interval between two reads of an applied object, which is not ready yet.
+var waitPollInterval = time.Second
+
+// wait polls the applied object until it is ready.
+// Only CustomResourceDefinitions are waited for, until they are established and their API is served.
 func (client *DynamicClient) wait(
 	ctx context.Context,
 	name string,
 	typeMeta v1.TypeMeta,
 	resourceInterface dynamic.ResourceInterface,
-) (bool, error) {
+	options *applyOptions,
+) error {
 	if typeMeta.Kind != "CustomResourceDefinition" {
-		return true, nil
+		return nil
 	}
 
-	select {
-	case <-ctx.Done():
-		return false, ctx.Err()
-	default:
+	timeout := options.timeout
+	if timeout <= 0 {
+		timeout = DefaultApplyTimeout
 	}
+	start := time.Now()
+	deadline := start.Add(timeout)
+	hardDeadline := start.Add(max(timeout, options.maxTimeout))
+
+	ctx, cancel := context.WithDeadline(ctx, hardDeadline)
+	defer cancel()
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	var progress string
+	observed := false
+	for {
+		obj, err := resourceInterface.Get(ctx, name, v1.GetOptions{
+			TypeMeta: typeMeta,
+		})
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err == nil {
+			conditions := GetConditions(obj)
+			if slices.ContainsFunc(conditions, func(cond Condition) bool {
+				return cond.ConditionType == string(apiextensionsv1.Established) &&
+					cond.Status == string(apiextensionsv1.ConditionTrue)
+			}) {
+				return nil
+			}
 
-	obj, err := resourceInterface.Get(ctx, name, v1.GetOptions{
-		TypeMeta: typeMeta,
-	})
-	if err != nil {
-		return false, err
+			current := CRDProgress(obj)
+			if observed && current != progress {
+				deadline = time.Now().Add(timeout)
+				if deadline.After(hardDeadline) {
+					deadline = hardDeadline
+				}
+			}
+			progress = current
+			observed = true
+		}
+
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: %s %s is not ready after %s", ErrApplyTimeout, typeMeta.Kind, name, time.Since(start).Round(time.Second))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s %s is not ready after %s: %w", ErrApplyTimeout, typeMeta.Kind, name, time.Since(start).Round(time.Second), ctx.Err())
+		case <-ticker.C:
+		}
 	}
+}
 
-	conditions := GetConditions(obj)
-	ok := slices.ContainsFunc(conditions, func(cond Condition) bool {
-		return cond.ConditionType == string(apiextensionsv1.Established) &&
-			cond.Status == string(apiextensionsv1.ConditionTrue)
-	})
-	if ok {
-		return true, nil
+// CRDProgress returns a fingerprint of the status of a CustomResourceDefinition, which changes whenever the API server makes progress on it:
+// a condition transitions or changes its reason, or the names of its API are accepted.
+// CustomResourceDefinitions do not report an observed generation, see [ObservedGeneration].
+func CRDProgress(obj *unstructured.Unstructured) string {
+	acceptedNames, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "status", "acceptedNames")
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	progress := make([]any, 0, len(conditions)+1)
+	progress = append(progress, acceptedNames)
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]any)
+		if !ok {
+			continue
+		}
+		progress = append(progress, []any{
+			conditionMap["type"],
+			conditionMap["status"],
+			conditionMap["reason"],
+			conditionMap["lastTransitionTime"],
+		})
+	}
+	// maps are printed with sorted keys.
+	return fmt.Sprint(progress)
+}
+
+// ObservedGeneration returns the generation of the object, which was last observed by its controller.
+// It is read from status.observedGeneration or, if absent, the highest observedGeneration of the status conditions.
+func ObservedGeneration(obj *unstructured.Unstructured) (int64, bool) {
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err == nil && found {
+		return observedGeneration, true
 	}
 
-	time.Sleep(1 * time.Second)
-	return client.wait(ctx, name, typeMeta, resourceInterface)
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	found = false
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]any)
+		if !ok {
+			continue
+		}
+		generation, ok := conditionMap["observedGeneration"].(int64)
+		if ok && (!found || generation > observedGeneration) {
+			observedGeneration = generation
+			found = true
+		}
+	}
+	return observedGeneration, found
 }
 
 type Condition struct {
@@ -363,20 +592,49 @@ func GetConditions(obj *unstructured.Unstructured) []Condition {
 	return conditions
 }
 
+// IsReady reports whether the object has converged to its desired state.
+// An object is not ready, if its status lags behind its generation or if it reports a false Ready or Available condition.
+// Objects without any of these signals are considered ready.
+func IsReady(obj *unstructured.Unstructured) bool {
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err == nil && found && observedGeneration < obj.GetGeneration() {
+		return false
+	}
+
+	for _, conditionType := range []string{"Ready", "Available"} {
+		for _, condition := range GetConditions(obj) {
+			if condition.ConditionType == conditionType {
+				return condition.Status == "True"
+			}
+		}
+	}
+
+	return true
+}
+
 // Delete removes the unstructured object from a Kubernetes cluster.
 // Following fields have to be set on obj:
 // - GVK, Namespace, Name
-func (client *DynamicClient) Delete(ctx context.Context, obj *unstructured.Unstructured) error {
+func (client *DynamicClient) Delete(ctx context.Context, obj *unstructured.Unstructured, opts ...DeleteOption) error {
+	deleteOpts := &deleteOptions{}
+	for _, opt := range opts {
+		opt(deleteOpts)
+	}
+
 	resourceInterface, err := client.resourceInterface(obj.GroupVersionKind(), obj.GetNamespace())
 	if err != nil {
 		return err
 	}
-	if err := resourceInterface.Delete(ctx, obj.GetName(), v1.DeleteOptions{
+	options := v1.DeleteOptions{
 		TypeMeta: v1.TypeMeta{
 			Kind:       obj.GetKind(),
 			APIVersion: obj.GetAPIVersion(),
 		},
-	}); err != nil {
+	}
+	if deleteOpts.propagationPolicy != "" {
+		options.PropagationPolicy = &deleteOpts.propagationPolicy
+	}
+	if err := resourceInterface.Delete(ctx, obj.GetName(), options); err != nil {
 		return err
 	}
 	return nil
@@ -428,6 +686,109 @@ func (client *DynamicClient) RESTMapper() meta.RESTMapper {
 	return client.restMapper
 }
 
+// ManagedByLabel marks objects applied by Navecd. Its value is the field manager, made a valid label value by [LabelValue].
+const ManagedByLabel = "navecd.io/managed-by"
+
+// SetManagedBy labels obj as managed by the field manager, so that it can be found by [DynamicClient.ListManaged].
+func SetManagedBy(obj *unstructured.Unstructured, fieldManager string) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+	labels[ManagedByLabel] = LabelValue(fieldManager)
+	obj.SetLabels(labels)
+}
+
+// IsManagedBy reports whether obj is labeled as managed by the field manager or has fields owned by it.
+func IsManagedBy(obj *unstructured.Unstructured, fieldManager string) bool {
+	if obj.GetLabels()[ManagedByLabel] == LabelValue(fieldManager) {
+		return true
+	}
+	for _, managedField := range obj.GetManagedFields() {
+		if managedField.Manager == fieldManager {
+			return true
+		}
+	}
+	return false
+}
+
+// ListManaged returns all objects labeled as managed by the field manager
+// across all discovered namespaced and cluster-scoped resources, which can be listed.
+// API groups failing discovery are skipped.
+func (client *DynamicClient) ListManaged(
+	ctx context.Context,
+	fieldManager string,
+) ([]unstructured.Unstructured, error) {
+	return client.ListSelected(ctx, fmt.Sprintf("%s=%s", ManagedByLabel, LabelValue(fieldManager)))
+}
+
+// ListSelected returns all objects matching the label selector
+// across all discovered namespaced and cluster-scoped resources, which can be listed.
+// API groups failing discovery and resources, which the client is not allowed to list, are skipped.
+func (client *DynamicClient) ListSelected(
+	ctx context.Context,
+	labelSelector string,
+) ([]unstructured.Unstructured, error) {
+	resourceLists, err := client.discoveryClient.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	listOptions := v1.ListOptions{
+		LabelSelector: labelSelector,
+	}
+
+	var selected []unstructured.Unstructured
+	for _, resourceList := range resourceLists {
+		groupVersion, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resourceList.APIResources {
+			if strings.Contains(resource.Name, "/") || !slices.Contains(resource.Verbs, "list") {
+				continue
+			}
+
+			list, err := client.dynamicClient.Resource(groupVersion.WithResource(resource.Name)).
+				List(ctx, listOptions)
+			if err != nil {
+				if k8sErrors.IsNotFound(err) || k8sErrors.IsMethodNotSupported(err) || k8sErrors.IsForbidden(err) {
+					continue
+				}
+				return nil, err
+			}
+
+			selected = append(selected, list.Items...)
+		}
+	}
+
+	return selected, nil
+}
+
+// List returns all objects of the given kind matching the label selector.
+// An empty namespace lists namespaced objects across all namespaces.
+func (client *DynamicClient) List(
+	ctx context.Context,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	labelSelector string,
+) ([]unstructured.Unstructured, error) {
+	resourceInterface, err := client.resourceInterface(gvk, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := resourceInterface.List(ctx, v1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
 func (client *DynamicClient) resourceInterface(
 	gvk schema.GroupVersionKind,
 	namespace string,
@@ -454,8 +815,8 @@ var _ Client[ExtendedUnstructured, unstructured.Unstructured] = (*ExtendedDynami
 
 // NewExtendedDynamicClient constructs a new DynamicClient,
 // which connects to a Kubernetes cluster to create, read, update and delete unstructured manifests/objects.
-func NewExtendedDynamicClient(config *rest.Config) (*ExtendedDynamicClient, error) {
-	dynClient, err := NewDynamicClient(config)
+func NewExtendedDynamicClient(config *rest.Config, opts ...ClientOption) (*ExtendedDynamicClient, error) {
+	dynClient, err := NewDynamicClient(config, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -495,6 +856,15 @@ func (e *ExtendedDynamicClient) Apply(
 		)
 	}
 
+	if obj.Metadata != nil && hasPreservedFields(*obj.Metadata) {
+		desired := obj.DeepCopy()
+		PreserveLiveFields(desired.Object, runtimeObj.Object, *obj.Metadata)
+		obj = &ExtendedUnstructured{
+			Unstructured: desired,
+			Metadata:     obj.Metadata,
+		}
+	}
+
 	// https://github.com/kubernetes-sigs/structured-merge-diff
 	managedFieldUpdate := &unstructured.Unstructured{}
 	managedFieldUpdate.SetName(obj.GetName())
@@ -689,8 +1059,52 @@ func RemoveIgnoredFields(
 	return nil
 }
 
-func (e *ExtendedDynamicClient) Delete(ctx context.Context, obj *ExtendedUnstructured) error {
-	return e.dynamicClient.Delete(ctx, obj.Unstructured)
+// PreserveLiveFields replaces the values of desired fields flagged with preserve by their live values.
+// Preserved fields, which are not present on the live object, keep their desired values.
+func PreserveLiveFields(
+	desired map[string]any,
+	live map[string]any,
+	metadata ManifestMetadata,
+) {
+	for key, fieldMetadata := range metadata.Node {
+		liveValue, found := live[key]
+		if !found {
+			continue
+		}
+
+		if fieldMetadata.Field != nil && fieldMetadata.Field.Preserve {
+			desired[key] = runtime.DeepCopyJSONValue(liveValue)
+			continue
+		}
+
+		desiredMap, ok := desired[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		liveMap, ok := liveValue.(map[string]any)
+		if !ok {
+			continue
+		}
+		PreserveLiveFields(desiredMap, liveMap, fieldMetadata)
+	}
+}
+
+func hasPreservedFields(metadata ManifestMetadata) bool {
+	if metadata.Field != nil && metadata.Field.Preserve {
+		return true
+	}
+
+	for _, nodeMetadata := range metadata.Node {
+		if hasPreservedFields(nodeMetadata) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *ExtendedDynamicClient) Delete(ctx context.Context, obj *ExtendedUnstructured, opts ...DeleteOption) error {
+	return e.dynamicClient.Delete(ctx, obj.Unstructured, opts...)
 }
 
 func (e *ExtendedDynamicClient) Get(
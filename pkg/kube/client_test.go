@@ -16,17 +16,25 @@ package kube_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/kharf/navecd/internal/kubetest"
 	"github.com/kharf/navecd/pkg/kube"
 	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/rest"
 )
 
 type update struct {
@@ -563,3 +571,627 @@ func TestExtendedDynamicClient_Apply(t *testing.T) {
 		})
 	}
 }
+
+func TestDynamicClient_Apply_ClientSideFallback(t *testing.T) {
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	dynClient := kubernetes.DynamicTestKubeClient.DynamicClient()
+	ctx := context.Background()
+
+	// many small keys blow up the managed fields of a Server-Side Apply beyond the storage request limit.
+	data := make(map[string]any, 20000)
+	for i := range 20000 {
+		data[fmt.Sprintf("key-%d", i)] = strings.Repeat("a", 48)
+	}
+
+	configMap := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":      "oversized",
+				"namespace": "default",
+			},
+			"data": data,
+		},
+	}
+
+	_, err := dynClient.Apply(
+		ctx,
+		configMap,
+		"controller",
+		kube.ForceApply(true),
+		kube.AllowClientSideFallback(true),
+	)
+	assert.NilError(t, err)
+
+	liveConfigMap, err := dynClient.Get(ctx, configMap)
+	assert.NilError(t, err)
+	liveData, found, err := unstructured.NestedMap(liveConfigMap.Object, "data")
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	assert.Equal(t, len(liveData), len(data))
+}
+
+func TestDynamicClient_Apply_Status(t *testing.T) {
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	dynClient := kubernetes.DynamicTestKubeClient.DynamicClient()
+	ctx := context.Background()
+
+	// without status subresource, status is part of the main resource and can be clobbered by an apply.
+	crd := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]any{
+				"name": "widgets.navecd.io",
+			},
+			"spec": map[string]any{
+				"group": "navecd.io",
+				"names": map[string]any{
+					"kind":     "Widget",
+					"listKind": "WidgetList",
+					"plural":   "widgets",
+					"singular": "widget",
+				},
+				"scope": "Namespaced",
+				"versions": []any{
+					map[string]any{
+						"name":    "v1",
+						"served":  true,
+						"storage": true,
+						"schema": map[string]any{
+							"openAPIV3Schema": map[string]any{
+								"type":                                 "object",
+								"x-kubernetes-preserve-unknown-fields": true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	_, err := dynClient.Apply(ctx, crd, "controller", kube.ForceApply(true))
+	assert.NilError(t, err)
+
+	widget := func(phase string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]any{
+				"apiVersion": "navecd.io/v1",
+				"kind":       "Widget",
+				"metadata": map[string]any{
+					"name":      "test",
+					"namespace": "default",
+				},
+				"spec": map[string]any{
+					"size": int64(1),
+				},
+				"status": map[string]any{
+					"phase": phase,
+				},
+			},
+		}
+	}
+
+	for {
+		_, err = dynClient.Apply(ctx, widget("Declared"), "controller", kube.ForceApply(true))
+		if !meta.IsNoMatchError(err) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.NilError(t, err)
+
+	live, err := dynClient.Get(ctx, widget(""))
+	assert.NilError(t, err)
+	_, found := live.Object["status"]
+	assert.Assert(t, !found)
+
+	// the owning controller reports the status.
+	_, err = dynClient.Apply(ctx, widget("Ready"), "owner", kube.ApplyStatus(true))
+	assert.NilError(t, err)
+
+	_, err = dynClient.Apply(ctx, widget("Declared"), "controller", kube.ForceApply(true))
+	assert.NilError(t, err)
+
+	live, err = dynClient.Get(ctx, widget(""))
+	assert.NilError(t, err)
+	phase, _, err := unstructured.NestedString(live.Object, "status", "phase")
+	assert.NilError(t, err)
+	assert.Equal(t, phase, "Ready")
+
+	_, err = dynClient.Apply(ctx, widget("Declared"), "controller", kube.ForceApply(true), kube.ApplyStatus(true))
+	assert.NilError(t, err)
+
+	live, err = dynClient.Get(ctx, widget(""))
+	assert.NilError(t, err)
+	phase, _, err = unstructured.NestedString(live.Object, "status", "phase")
+	assert.NilError(t, err)
+	assert.Equal(t, phase, "Declared")
+}
+
+func TestPreserveLiveFields(t *testing.T) {
+	desired := map[string]any{
+		"spec": map[string]any{
+			"replicas": int64(1),
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]any{
+						"declared": "true",
+					},
+				},
+			},
+			"paused": false,
+		},
+	}
+	live := map[string]any{
+		"spec": map[string]any{
+			"replicas": int64(5),
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]any{
+						"declared": "true",
+						"injected": "true",
+					},
+				},
+			},
+			"paused": true,
+		},
+	}
+	preserve := &kube.ManifestFieldMetadata{Preserve: true}
+	metadata := kube.ManifestMetadata{
+		Node: map[string]kube.ManifestMetadata{
+			"spec": {
+				Node: map[string]kube.ManifestMetadata{
+					"replicas": {Field: preserve},
+					"template": {
+						Node: map[string]kube.ManifestMetadata{
+							"metadata": {
+								Node: map[string]kube.ManifestMetadata{
+									"annotations": {Field: preserve},
+								},
+							},
+						},
+					},
+					"minReadySeconds": {Field: preserve},
+				},
+			},
+		},
+	}
+
+	kube.PreserveLiveFields(desired, live, metadata)
+
+	assert.DeepEqual(t, desired, map[string]any{
+		"spec": map[string]any{
+			"replicas": int64(5),
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]any{
+						"declared": "true",
+						"injected": "true",
+					},
+				},
+			},
+			"paused": false,
+		},
+	})
+
+	// preserved values must not share state with the live object.
+	live["spec"].(map[string]any)["template"].(map[string]any)["metadata"].(map[string]any)["annotations"].(map[string]any)["injected"] = "false"
+	assert.Equal(
+		t,
+		desired["spec"].(map[string]any)["template"].(map[string]any)["metadata"].(map[string]any)["annotations"].(map[string]any)["injected"],
+		"true",
+	)
+}
+
+func TestIsReady(t *testing.T) {
+	testCases := []struct {
+		name   string
+		object map[string]any
+		ready  bool
+	}{
+		{
+			name: "No-Status",
+			object: map[string]any{
+				"metadata": map[string]any{"generation": int64(1)},
+			},
+			ready: true,
+		},
+		{
+			name: "Outdated-Generation",
+			object: map[string]any{
+				"metadata": map[string]any{"generation": int64(2)},
+				"status":   map[string]any{"observedGeneration": int64(1)},
+			},
+			ready: false,
+		},
+		{
+			name: "Ready-False",
+			object: map[string]any{
+				"status": map[string]any{
+					"conditions": []any{
+						map[string]any{"type": "Ready", "status": "False"},
+					},
+				},
+			},
+			ready: false,
+		},
+		{
+			name: "Available-True",
+			object: map[string]any{
+				"metadata": map[string]any{"generation": int64(2)},
+				"status": map[string]any{
+					"observedGeneration": int64(2),
+					"conditions": []any{
+						map[string]any{"type": "Progressing", "status": "True"},
+						map[string]any{"type": "Available", "status": "True"},
+					},
+				},
+			},
+			ready: true,
+		},
+		{
+			name: "Available-False",
+			object: map[string]any{
+				"status": map[string]any{
+					"conditions": []any{
+						map[string]any{"type": "Available", "status": "False"},
+					},
+				},
+			},
+			ready: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, kube.IsReady(&unstructured.Unstructured{Object: tc.object}), tc.ready)
+		})
+	}
+}
+
+// newCRDServer serves a CustomResourceDefinition, which reports the next of the statuses on every read.
+// The last status is kept.
+func newCRDServer(statuses []map[string]any) *httptest.Server {
+	var reads atomic.Int64
+	crd := func(status map[string]any) map[string]any {
+		return map[string]any{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]any{
+				"name": "widgets.navecd.io",
+			},
+			"status": status,
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &v1.APIVersions{
+			TypeMeta: v1.TypeMeta{Kind: "APIVersions"},
+			Versions: []string{"v1"},
+		})
+	})
+	mux.HandleFunc("/api/v1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &v1.APIResourceList{
+			TypeMeta:     v1.TypeMeta{Kind: "APIResourceList"},
+			GroupVersion: "v1",
+		})
+	})
+	mux.HandleFunc("/apis", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &v1.APIGroupList{
+			TypeMeta: v1.TypeMeta{Kind: "APIGroupList"},
+			Groups: []v1.APIGroup{
+				{
+					Name: "apiextensions.k8s.io",
+					Versions: []v1.GroupVersionForDiscovery{
+						{GroupVersion: "apiextensions.k8s.io/v1", Version: "v1"},
+					},
+					PreferredVersion: v1.GroupVersionForDiscovery{GroupVersion: "apiextensions.k8s.io/v1", Version: "v1"},
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/apis/apiextensions.k8s.io/v1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &v1.APIResourceList{
+			TypeMeta:     v1.TypeMeta{Kind: "APIResourceList"},
+			GroupVersion: "apiextensions.k8s.io/v1",
+			APIResources: []v1.APIResource{
+				{
+					Name:  "customresourcedefinitions",
+					Kind:  "CustomResourceDefinition",
+					Verbs: []string{"get", "patch"},
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/apis/apiextensions.k8s.io/v1/customresourcedefinitions/widgets.navecd.io", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, crd(statuses[0]))
+			return
+		}
+		read := min(int(reads.Add(1))-1, len(statuses)-1)
+		writeJSON(w, crd(statuses[read]))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestDynamicClient_Apply_CRDProgress(t *testing.T) {
+	acceptedNames := map[string]any{
+		"kind":   "Widget",
+		"plural": "widgets",
+	}
+	namesAccepted := map[string]any{
+		"type":               "NamesAccepted",
+		"status":             "True",
+		"reason":             "NoConflicts",
+		"lastTransitionTime": "2024-01-01T00:00:01Z",
+	}
+	established := map[string]any{
+		"type":               "Established",
+		"status":             "True",
+		"reason":             "InitialNamesAccepted",
+		"lastTransitionTime": "2024-01-01T00:00:02Z",
+	}
+
+	testCases := []struct {
+		name     string
+		statuses []map[string]any
+		err      error
+	}{
+		{
+			name: "Slow-But-Progressing",
+			statuses: []map[string]any{
+				{},
+				{"acceptedNames": acceptedNames},
+				{"acceptedNames": acceptedNames, "conditions": []any{namesAccepted}},
+				{"acceptedNames": acceptedNames, "conditions": []any{namesAccepted, established}},
+			},
+		},
+		{
+			name: "Stuck",
+			statuses: []map[string]any{
+				{"conditions": []any{
+					map[string]any{"type": "Established", "status": "False", "reason": "Installing"},
+				}},
+			},
+			err: kube.ErrApplyTimeout,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newCRDServer(tc.statuses)
+			defer server.Close()
+
+			client, err := kube.NewDynamicClient(&rest.Config{Host: server.URL})
+			assert.NilError(t, err)
+
+			crd := &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apiextensions.k8s.io/v1",
+					"kind":       "CustomResourceDefinition",
+					"metadata": map[string]any{
+						"name": "widgets.navecd.io",
+					},
+				},
+			}
+			// every read is one second apart, so only progress keeps the apply within the timeout.
+			_, err = client.Apply(
+				context.Background(),
+				crd,
+				"controller",
+				kube.ApplyTimeout(1500*time.Millisecond, time.Minute),
+			)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestCRDProgress(t *testing.T) {
+	installing := map[string]any{
+		"type":               "Established",
+		"status":             "False",
+		"reason":             "Installing",
+		"lastTransitionTime": "2024-01-01T00:00:00Z",
+	}
+
+	testCases := []struct {
+		name     string
+		current  map[string]any
+		previous map[string]any
+		progress bool
+	}{
+		{
+			name:     "No-Change",
+			previous: map[string]any{"conditions": []any{installing}},
+			current:  map[string]any{"conditions": []any{installing}},
+			progress: false,
+		},
+		{
+			name:     "Accepted-Names",
+			previous: map[string]any{"conditions": []any{installing}},
+			current: map[string]any{
+				"acceptedNames": map[string]any{"kind": "Widget", "plural": "widgets"},
+				"conditions":    []any{installing},
+			},
+			progress: true,
+		},
+		{
+			name:     "Reason",
+			previous: map[string]any{"conditions": []any{installing}},
+			current: map[string]any{"conditions": []any{
+				map[string]any{
+					"type":               "Established",
+					"status":             "False",
+					"reason":             "Terminating",
+					"lastTransitionTime": "2024-01-01T00:00:00Z",
+				},
+			}},
+			progress: true,
+		},
+		{
+			name:     "Transition",
+			previous: map[string]any{"conditions": []any{installing}},
+			current: map[string]any{"conditions": []any{
+				map[string]any{
+					"type":               "Established",
+					"status":             "False",
+					"reason":             "Installing",
+					"lastTransitionTime": "2024-01-01T00:00:05Z",
+				},
+			}},
+			progress: true,
+		},
+		{
+			name:     "Message-Only",
+			previous: map[string]any{"conditions": []any{installing}},
+			current: map[string]any{"conditions": []any{
+				map[string]any{
+					"type":               "Established",
+					"status":             "False",
+					"reason":             "Installing",
+					"message":            "still installing",
+					"lastTransitionTime": "2024-01-01T00:00:00Z",
+				},
+			}},
+			progress: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := kube.CRDProgress(&unstructured.Unstructured{Object: map[string]any{"status": tc.previous}})
+			current := kube.CRDProgress(&unstructured.Unstructured{Object: map[string]any{"status": tc.current}})
+			assert.Equal(t, current != previous, tc.progress)
+		})
+	}
+}
+
+func TestObservedGeneration(t *testing.T) {
+	testCases := []struct {
+		name       string
+		object     map[string]any
+		generation int64
+		found      bool
+	}{
+		{
+			name: "No-Status",
+			object: map[string]any{
+				"metadata": map[string]any{"generation": int64(1)},
+			},
+			found: false,
+		},
+		{
+			name: "Status",
+			object: map[string]any{
+				"status": map[string]any{"observedGeneration": int64(3)},
+			},
+			generation: 3,
+			found:      true,
+		},
+		{
+			name: "Conditions",
+			object: map[string]any{
+				"status": map[string]any{
+					"conditions": []any{
+						map[string]any{"type": "Ready", "status": "False", "observedGeneration": int64(2)},
+						map[string]any{"type": "Synced", "status": "True", "observedGeneration": int64(4)},
+					},
+				},
+			},
+			generation: 4,
+			found:      true,
+		},
+		{
+			name: "Heartbeat-Only",
+			object: map[string]any{
+				"status": map[string]any{
+					"lastHeartbeatTime": "2024-01-01T00:00:00Z",
+					"conditions": []any{
+						map[string]any{"type": "Ready", "status": "False"},
+					},
+				},
+			},
+			found: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generation, found := kube.ObservedGeneration(&unstructured.Unstructured{Object: tc.object})
+			assert.Equal(t, found, tc.found)
+			assert.Equal(t, generation, tc.generation)
+		})
+	}
+}
+
+func TestIsManagedBy(t *testing.T) {
+	testCases := []struct {
+		name         string
+		object       func() *unstructured.Unstructured
+		fieldManager string
+		managed      bool
+	}{
+		{
+			name: "Label",
+			object: func() *unstructured.Unstructured {
+				obj := &unstructured.Unstructured{Object: map[string]any{}}
+				kube.SetManagedBy(obj, "controller")
+				return obj
+			},
+			managed: true,
+		},
+		{
+			name: "Field-Manager",
+			object: func() *unstructured.Unstructured {
+				obj := &unstructured.Unstructured{Object: map[string]any{}}
+				obj.SetManagedFields([]v1.ManagedFieldsEntry{{Manager: "controller"}})
+				return obj
+			},
+			managed: true,
+		},
+		{
+			name: "Invalid-Label-Value",
+			object: func() *unstructured.Unstructured {
+				obj := &unstructured.Unstructured{Object: map[string]any{}}
+				kube.SetManagedBy(obj, "system:serviceaccount:navecd-system:controller")
+				return obj
+			},
+			fieldManager: "system:serviceaccount:navecd-system:controller",
+			managed:      true,
+		},
+		{
+			name: "Foreign",
+			object: func() *unstructured.Unstructured {
+				obj := &unstructured.Unstructured{Object: map[string]any{}}
+				kube.SetManagedBy(obj, "other")
+				obj.SetManagedFields([]v1.ManagedFieldsEntry{{Manager: "kubectl"}})
+				return obj
+			},
+			managed: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fieldManager := tc.fieldManager
+			if fieldManager == "" {
+				fieldManager = "controller"
+			}
+			obj := tc.object()
+			for _, value := range obj.GetLabels() {
+				assert.Assert(t, len(validation.IsValidLabelValue(value)) == 0)
+			}
+			assert.Equal(t, kube.IsManagedBy(obj, fieldManager), tc.managed)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/kharf/navecd/pkg/inventory"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RedactedValue replaces the values of Secret data in differences.
+const RedactedValue = "<redacted>"
+
+// FieldChange describes a single field, which differs between two versions of an object.
+// Current is nil, if the field has been added. Desired is nil, if the field has been removed.
+type FieldChange struct {
+	// Path of the field in dot notation, e.g. spec.template.spec.containers[0].image.
+	Path    string `json:"path"`
+	Current any    `json:"current,omitempty"`
+	Desired any    `json:"desired,omitempty"`
+}
+
+// Difference holds all changed fields of an object, sorted by their path.
+type Difference struct {
+	Changes []FieldChange `json:"changes"`
+}
+
+// IsEmpty reports whether both versions of the object are equal.
+func (d Difference) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+// Differ compares the desired state of objects with their current state.
+// Changed data and stringData values of Secrets are reported as [RedactedValue].
+type Differ struct {
+	// InventoryInstance holds the content Navecd last applied.
+	InventoryInstance *inventory.Instance
+}
+
+// DiffAgainstInventory compares the desired object with the content Navecd last stored in the inventory for the item,
+// instead of the live object.
+// This allows offline and audit diffs without access to a cluster.
+func (differ *Differ) DiffAgainstInventory(
+	item inventory.Item,
+	desired *unstructured.Unstructured,
+) (*Difference, error) {
+	reader, err := differ.InventoryInstance.GetItem(item)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	stored := map[string]any{}
+	if err := json.NewDecoder(reader).Decode(&stored); err != nil {
+		return nil, err
+	}
+	removeInventoryMarkers(stored)
+
+	desiredObject, err := normalize(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDifference(stored, desiredObject, false, isSecret(desired)), nil
+}
+
+// removeInventoryMarkers drops the annotations, which are only recorded in the inventory and never part of the desired object.
+func removeInventoryMarkers(stored map[string]any) {
+	annotations, found, _ := unstructured.NestedMap(stored, "metadata", "annotations")
+	if !found {
+		return
+	}
+
+	delete(annotations, inventory.KeepAnnotation)
+	delete(annotations, inventory.HookCompletedAnnotation)
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(stored, "metadata", "annotations")
+		return
+	}
+	_ = unstructured.SetNestedMap(stored, annotations, "metadata", "annotations")
+}
+
+// Diff compares the desired object with the live object of a cluster.
+// Only declared fields are compared, because live objects carry fields set by the API server or other controllers,
+// like the status or defaults. If live is nil, all declared fields are reported as added.
+func (differ *Differ) Diff(
+	live *unstructured.Unstructured,
+	desired *unstructured.Unstructured,
+) (*Difference, error) {
+	liveObject := map[string]any{}
+	if live != nil {
+		var err error
+		liveObject, err = normalize(live)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	desiredObject, err := normalize(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDifference(liveObject, desiredObject, true, isSecret(desired)), nil
+}
+
+// normalize converts the object to JSON types, so that for example int64 and float64 values are comparable.
+func normalize(obj *unstructured.Unstructured) (map[string]any, error) {
+	objJSON, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	normalized := map[string]any{}
+	if err := json.Unmarshal(objJSON, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// isSecret reports whether obj is a core Secret, whose data must not be revealed by differences.
+func isSecret(obj *unstructured.Unstructured) bool {
+	return obj.GetAPIVersion() == "v1" && obj.GetKind() == "Secret"
+}
+
+func newDifference(current map[string]any, desired map[string]any, declaredOnly bool, redact bool) *Difference {
+	difference := &Difference{}
+	diffValue("", current, desired, declaredOnly, difference)
+	if redact {
+		for i, change := range difference.Changes {
+			if isSecretData(change.Path) {
+				difference.Changes[i].Current = redactValue(change.Current)
+				difference.Changes[i].Desired = redactValue(change.Desired)
+			}
+		}
+	}
+	slices.SortFunc(difference.Changes, func(a, b FieldChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return difference
+}
+
+// diffValue appends all differences between current and desired to difference.
+// When declaredOnly is set, fields missing in desired are not reported as removed.
+func diffValue(path string, current any, desired any, declaredOnly bool, difference *Difference) {
+	switch currentValue := current.(type) {
+	case map[string]any:
+		desiredValue, ok := desired.(map[string]any)
+		if !ok {
+			break
+		}
+
+		for key, currentChild := range currentValue {
+			desiredChild, found := desiredValue[key]
+			if !found && declaredOnly {
+				continue
+			}
+			diffValue(joinPath(path, key), currentChild, desiredChild, declaredOnly, difference)
+		}
+		for key, desiredChild := range desiredValue {
+			if _, found := currentValue[key]; !found {
+				diffValue(joinPath(path, key), nil, desiredChild, declaredOnly, difference)
+			}
+		}
+		return
+
+	case []any:
+		desiredValue, ok := desired.([]any)
+		if !ok || len(currentValue) != len(desiredValue) {
+			break
+		}
+
+		for i := range currentValue {
+			diffValue(fmt.Sprintf("%s[%d]", path, i), currentValue[i], desiredValue[i], declaredOnly, difference)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(current, desired) {
+		difference.Changes = append(difference.Changes, FieldChange{
+			Path:    path,
+			Current: current,
+			Desired: desired,
+		})
+	}
+}
+
+// isSecretData reports whether the path points to the data or stringData field of a Secret or one of their keys.
+func isSecretData(path string) bool {
+	for _, field := range []string{"data", "stringData"} {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue masks a changed Secret value, so that the change is still visible, but not its content.
+// Keys of added or removed data maps are kept.
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key := range v {
+			redacted[key] = RedactedValue
+		}
+		return redacted
+	default:
+		return RedactedValue
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kharf/navecd/pkg/inventory"
+	"github.com/kharf/navecd/pkg/kube"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func deployment(replicas int64, image string, labels map[string]any) *unstructured.Unstructured {
+	metadata := map[string]any{
+		"name":      "test",
+		"namespace": "test",
+	}
+	if labels != nil {
+		metadata["labels"] = labels
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   metadata,
+			"spec": map[string]any{
+				"replicas": replicas,
+				"template": map[string]any{
+					"spec": map[string]any{
+						"containers": []any{
+							map[string]any{
+								"name":  "test",
+								"image": image,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiffer_DiffAgainstInventory(t *testing.T) {
+	inventoryInstance := &inventory.Instance{
+		Path: t.TempDir(),
+	}
+
+	item := &inventory.ManifestItem{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		Name:      "test",
+		Namespace: "test",
+		ID:        "test_test_apps_Deployment",
+	}
+
+	buf := &bytes.Buffer{}
+	err := json.NewEncoder(buf).Encode(deployment(1, "test:1.0.0", map[string]any{"team": "a"}).Object)
+	assert.NilError(t, err)
+	err = inventoryInstance.StoreItem(item, buf)
+	assert.NilError(t, err)
+
+	differ := kube.Differ{
+		InventoryInstance: inventoryInstance,
+	}
+
+	difference, err := differ.DiffAgainstInventory(item, deployment(1, "test:1.0.0", map[string]any{"team": "a"}))
+	assert.NilError(t, err)
+	assert.Assert(t, difference.IsEmpty())
+
+	difference, err = differ.DiffAgainstInventory(item, deployment(2, "test:1.1.0", nil))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, difference.Changes, []kube.FieldChange{
+		{
+			Path:    "metadata.labels",
+			Current: map[string]any{"team": "a"},
+			Desired: nil,
+		},
+		{
+			Path:    "spec.replicas",
+			Current: float64(1),
+			Desired: float64(2),
+		},
+		{
+			Path:    "spec.template.spec.containers[0].image",
+			Current: "test:1.0.0",
+			Desired: "test:1.1.0",
+		},
+	})
+}
+
+func TestDiffer_Diff(t *testing.T) {
+	live := deployment(1, "test:1.0.0", map[string]any{"team": "a"})
+	err := unstructured.SetNestedField(live.Object, int64(1), "status", "readyReplicas")
+	assert.NilError(t, err)
+
+	differ := kube.Differ{}
+
+	difference, err := differ.Diff(live, deployment(1, "test:1.0.0", nil))
+	assert.NilError(t, err)
+	assert.Assert(t, difference.IsEmpty())
+
+	difference, err = differ.Diff(live, deployment(2, "test:1.0.0", map[string]any{"team": "b"}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, difference.Changes, []kube.FieldChange{
+		{
+			Path:    "metadata.labels.team",
+			Current: "a",
+			Desired: "b",
+		},
+		{
+			Path:    "spec.replicas",
+			Current: float64(1),
+			Desired: float64(2),
+		},
+	})
+
+	difference, err = differ.Diff(nil, deployment(1, "test:1.0.0", nil))
+	assert.NilError(t, err)
+	assert.Equal(t, len(difference.Changes), 4)
+}
+
+func secret(data map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]any{
+				"name":      "secret",
+				"namespace": "test",
+			},
+			"data": data,
+		},
+	}
+}
+
+func TestDiffer_Diff_Secret(t *testing.T) {
+	differ := kube.Differ{}
+
+	live := secret(map[string]any{"password": "c2VjcmV0"})
+
+	difference, err := differ.Diff(live, secret(map[string]any{"password": "c2VjcmV0"}))
+	assert.NilError(t, err)
+	assert.Assert(t, difference.IsEmpty())
+
+	difference, err = differ.Diff(live, secret(map[string]any{"password": "bmV3"}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, difference.Changes, []kube.FieldChange{
+		{
+			Path:    "data.password",
+			Current: kube.RedactedValue,
+			Desired: kube.RedactedValue,
+		},
+	})
+
+	desired := secret(map[string]any{"password": "bmV3"})
+	err = unstructured.SetNestedStringMap(desired.Object, map[string]string{"token": "abcd"}, "stringData")
+	assert.NilError(t, err)
+	difference, err = differ.Diff(nil, desired)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, difference.Changes, []kube.FieldChange{
+		{
+			Path:    "apiVersion",
+			Desired: "v1",
+		},
+		{
+			Path:    "data",
+			Desired: map[string]any{"password": kube.RedactedValue},
+		},
+		{
+			Path:    "kind",
+			Desired: "Secret",
+		},
+		{
+			Path:    "metadata",
+			Desired: map[string]any{"name": "secret", "namespace": "test"},
+		},
+		{
+			Path:    "stringData",
+			Desired: map[string]any{"token": kube.RedactedValue},
+		},
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// DiscoveryCache shares discovery results and the RESTMapper built from them across clients,
+// so that not every client construction discovers all APIs of the cluster again.
+// Cached results are dropped after the TTL or once invalidated, e.g. because a CRD changed.
+// It is safe for concurrent use.
+type DiscoveryCache struct {
+	config *rest.Config
+	ttl    time.Duration
+
+	mu            sync.Mutex
+	discovery     discovery.CachedDiscoveryInterface
+	restMapper    meta.RESTMapper
+	serverVersion string
+	refreshedAt   time.Time
+}
+
+// NewDiscoveryCache constructs a [DiscoveryCache] discovering APIs with the given config.
+// Discovery is deferred until the first client uses the cache.
+func NewDiscoveryCache(config *rest.Config, ttl time.Duration) *DiscoveryCache {
+	return &DiscoveryCache{
+		config: config,
+		ttl:    ttl,
+	}
+}
+
+// Invalidate drops all cached results. The next client using the cache discovers the APIs again.
+func (cache *DiscoveryCache) Invalidate() {
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.discovery = nil
+	cache.restMapper = nil
+	cache.serverVersion = ""
+}
+
+// ServerVersion returns the git version of the Kubernetes API server.
+// It is cached and dropped together with the discovered APIs.
+func (cache *DiscoveryCache) ServerVersion() (string, error) {
+	discoveryClient, _, err := cache.get()
+	if err != nil {
+		return "", err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.serverVersion != "" {
+		return cache.serverVersion, nil
+	}
+
+	info, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	cache.serverVersion = info.GitVersion
+
+	return cache.serverVersion, nil
+}
+
+// get returns the shared discovery client and RESTMapper and rebuilds them, if they were invalidated or expired.
+// The RESTMapper learns API groups lazily and looks up unknown kinds on its own,
+// so that objects of CRDs applied within the same reconciliation are mapped.
+func (cache *DiscoveryCache) get() (discovery.CachedDiscoveryInterface, meta.RESTMapper, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.restMapper != nil && time.Since(cache.refreshedAt) < cache.ttl {
+		return cache.discovery, cache.restMapper, nil
+	}
+
+	httpClient, err := rest.HTTPClientFor(cache.config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	restMapper, err := apiutil.NewDynamicRESTMapper(cache.config, httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfigAndClient(cache.config, httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cache.discovery = memory.NewMemCacheClient(discoveryClient)
+	cache.restMapper = restMapper
+	cache.serverVersion = ""
+	cache.refreshedAt = time.Now()
+
+	return cache.discovery, cache.restMapper, nil
+}
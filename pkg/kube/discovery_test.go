@@ -0,0 +1,174 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kharf/navecd/pkg/kube"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+)
+
+// discoveryServer serves the discovery documents of the core API with ConfigMaps and counts the discoveries.
+type discoveryServer struct {
+	*httptest.Server
+	discoveries atomic.Int64
+	versions    atomic.Int64
+}
+
+func newDiscoveryServer() *discoveryServer {
+	server := &discoveryServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		server.discoveries.Add(1)
+		writeJSON(w, &v1.APIVersions{
+			TypeMeta: v1.TypeMeta{Kind: "APIVersions"},
+			Versions: []string{"v1"},
+		})
+	})
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		server.versions.Add(1)
+		writeJSON(w, &version.Info{GitVersion: "v1.33.0"})
+	})
+	mux.HandleFunc("/apis", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &v1.APIGroupList{
+			TypeMeta: v1.TypeMeta{Kind: "APIGroupList"},
+		})
+	})
+	mux.HandleFunc("/api/v1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &v1.APIResourceList{
+			TypeMeta:     v1.TypeMeta{Kind: "APIResourceList"},
+			GroupVersion: "v1",
+			APIResources: []v1.APIResource{
+				{
+					Name:       "configmaps",
+					Kind:       "ConfigMap",
+					Namespaced: true,
+					Verbs:      []string{"get", "list"},
+				},
+			},
+		})
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// mapConfigMap builds a client like every reconciliation does and maps a ConfigMap.
+func mapConfigMap(config *rest.Config, opts ...kube.ClientOption) error {
+	client, err := kube.NewDynamicClient(config, opts...)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.RESTMapper().RESTMapping(schema.GroupKind{Kind: "ConfigMap"}, "v1")
+	return err
+}
+
+func TestDiscoveryCache(t *testing.T) {
+	server := newDiscoveryServer()
+	defer server.Close()
+	config := &rest.Config{Host: server.URL}
+
+	for range 5 {
+		err := mapConfigMap(config)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, server.discoveries.Load(), int64(5))
+
+	server.discoveries.Store(0)
+	cache := kube.NewDiscoveryCache(config, time.Hour)
+	for range 5 {
+		err := mapConfigMap(config, kube.WithDiscoveryCache(cache))
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, server.discoveries.Load(), int64(1))
+
+	cache.Invalidate()
+	err := mapConfigMap(config, kube.WithDiscoveryCache(cache))
+	assert.NilError(t, err)
+	assert.Equal(t, server.discoveries.Load(), int64(2))
+
+	server.discoveries.Store(0)
+	expiringCache := kube.NewDiscoveryCache(config, time.Nanosecond)
+	for range 2 {
+		time.Sleep(time.Millisecond)
+		err := mapConfigMap(config, kube.WithDiscoveryCache(expiringCache))
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, server.discoveries.Load(), int64(2))
+}
+
+func TestDiscoveryCache_ServerVersion(t *testing.T) {
+	server := newDiscoveryServer()
+	defer server.Close()
+	config := &rest.Config{Host: server.URL}
+
+	cache := kube.NewDiscoveryCache(config, time.Hour)
+	for range 5 {
+		serverVersion, err := cache.ServerVersion()
+		assert.NilError(t, err)
+		assert.Equal(t, serverVersion, "v1.33.0")
+	}
+	assert.Equal(t, server.versions.Load(), int64(1))
+
+	cache.Invalidate()
+	_, err := cache.ServerVersion()
+	assert.NilError(t, err)
+	assert.Equal(t, server.versions.Load(), int64(2))
+}
+
+func BenchmarkDiscoveryCache(b *testing.B) {
+	server := newDiscoveryServer()
+	defer server.Close()
+	config := &rest.Config{Host: server.URL}
+
+	b.Run("Uncached", func(b *testing.B) {
+		server.discoveries.Store(0)
+		for b.Loop() {
+			if err := mapConfigMap(config); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(server.discoveries.Load())/float64(b.N), "discoveries/op")
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		server.discoveries.Store(0)
+		cache := kube.NewDiscoveryCache(config, time.Hour)
+		for b.Loop() {
+			if err := mapConfigMap(config, kube.WithDiscoveryCache(cache)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(server.discoveries.Load())/float64(b.N), "discoveries/op")
+		if discoveries := server.discoveries.Load(); discoveries != 1 {
+			b.Fatalf("expected repeated reconciliations to discover once, got %d discoveries", discoveries)
+		}
+	})
+}
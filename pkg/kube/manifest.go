@@ -39,6 +39,10 @@ type ManifestMetadata struct {
 // ManifestFieldMetadata extends unstructured fields with additional information.
 type ManifestFieldMetadata struct {
 	IgnoreInstr IgnoreInstruction
+
+	// Preserve tells Navecd to keep the live value of the field, if present, instead of the declared one.
+	// The declared value is only used on creation or when the field is missing on the live object.
+	Preserve bool
 }
 
 // ExtendedUnstructured enhances Kubernetes Unstructured struct with additional Metadata, like IgnoreAttributes.
@@ -83,3 +87,35 @@ func (m *Manifest) GetName() string {
 func (m *Manifest) GetNamespace() string {
 	return m.Content.GetNamespace()
 }
+
+// Patch represents a Navecd component, which merges its content into an existing object.
+// Unlike a [Manifest], the object is neither created nor deleted by Navecd.
+type Patch struct {
+	ID           string
+	Dependencies []string
+	Content      ExtendedUnstructured
+}
+
+func (p *Patch) GetID() string {
+	return p.ID
+}
+
+func (p *Patch) GetDependencies() []string {
+	return p.Dependencies
+}
+
+func (p *Patch) GetKind() string {
+	return p.Content.GetKind()
+}
+
+func (p *Patch) GetAPIVersion() string {
+	return p.Content.GetAPIVersion()
+}
+
+func (p *Patch) GetName() string {
+	return p.Content.GetName()
+}
+
+func (p *Patch) GetNamespace() string {
+	return p.Content.GetNamespace()
+}
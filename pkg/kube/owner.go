@@ -0,0 +1,111 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// OwnerNameLabel holds the name of the owner of objects, which cannot reference it with an owner reference.
+	OwnerNameLabel = "navecd.io/owner-name"
+
+	// OwnerNamespaceLabel holds the namespace of the owner of objects, which cannot reference it with an owner reference.
+	OwnerNamespaceLabel = "navecd.io/owner-namespace"
+)
+
+// Owner is a namespaced object, which owns the objects applied on its behalf.
+type Owner struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	UID        types.UID
+}
+
+// SetOwner adds an owner reference to obj, so that Kubernetes deletes obj together with its owner.
+// Kubernetes only resolves owner references of namespaced owners within their namespace,
+// which is why cluster-scoped objects and objects of other namespaces are labeled with
+// [OwnerNameLabel] and [OwnerNamespaceLabel] instead. They have to be cleaned up by selecting these labels, see [OwnerSelector].
+func SetOwner(obj *unstructured.Unstructured, owner Owner) {
+	if obj.GetNamespace() != owner.Namespace {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string, 2)
+		}
+		labels[OwnerNameLabel] = LabelValue(owner.Name)
+		labels[OwnerNamespaceLabel] = LabelValue(owner.Namespace)
+		obj.SetLabels(labels)
+		return
+	}
+
+	ownerReferences := obj.GetOwnerReferences()
+	for _, ownerReference := range ownerReferences {
+		if ownerReference.UID == owner.UID {
+			return
+		}
+	}
+	obj.SetOwnerReferences(append(ownerReferences, v1.OwnerReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Name:       owner.Name,
+		UID:        owner.UID,
+	}))
+}
+
+// OwnerSelector selects the objects labeled with the owner by [SetOwner].
+func OwnerSelector(owner Owner) string {
+	return fmt.Sprintf(
+		"%s=%s,%s=%s",
+		OwnerNameLabel,
+		LabelValue(owner.Name),
+		OwnerNamespaceLabel,
+		LabelValue(owner.Namespace),
+	)
+}
+
+// LabelValue returns value, if it is a valid label value.
+// Longer or otherwise invalid values are shortened to a prefix and a hash of the whole value, which is a valid label value.
+func LabelValue(value string) string {
+	if len(validation.IsValidLabelValue(value)) == 0 {
+		return value
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:])[:10]
+
+	prefix := make([]byte, 0, validation.LabelValueMaxLength)
+	for _, char := range []byte(value) {
+		if len(prefix) == validation.LabelValueMaxLength-len(hash)-1 {
+			break
+		}
+		if char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' ||
+			(len(prefix) > 0 && (char == '-' || char == '_' || char == '.')) {
+			prefix = append(prefix, char)
+		}
+	}
+	if len(prefix) == 0 {
+		return hash
+	}
+
+	return fmt.Sprintf("%s-%s", prefix, hash)
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kharf/navecd/pkg/kube"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestSetOwner(t *testing.T) {
+	owner := kube.Owner{
+		APIVersion: "gitops.navecd.io/v1beta1",
+		Kind:       "GitOpsProject",
+		Name:       "project",
+		Namespace:  "navecd-system",
+		UID:        "1234",
+	}
+
+	testCases := []struct {
+		name                    string
+		namespace               string
+		expectedOwnerReferences []v1.OwnerReference
+		expectedLabels          map[string]string
+	}{
+		{
+			name:      "Same-Namespace",
+			namespace: "navecd-system",
+			expectedOwnerReferences: []v1.OwnerReference{
+				{
+					APIVersion: "gitops.navecd.io/v1beta1",
+					Kind:       "GitOpsProject",
+					Name:       "project",
+					UID:        "1234",
+				},
+			},
+		},
+		{
+			name:      "Other-Namespace",
+			namespace: "default",
+			expectedLabels: map[string]string{
+				kube.OwnerNameLabel:      "project",
+				kube.OwnerNamespaceLabel: "navecd-system",
+			},
+		},
+		{
+			name:      "Cluster-Scoped",
+			namespace: "",
+			expectedLabels: map[string]string{
+				kube.OwnerNameLabel:      "project",
+				kube.OwnerNamespaceLabel: "navecd-system",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("ConfigMap")
+			obj.SetName("test")
+			obj.SetNamespace(tc.namespace)
+
+			kube.SetOwner(obj, owner)
+			// setting the owner again must not duplicate the reference.
+			kube.SetOwner(obj, owner)
+
+			assert.DeepEqual(t, obj.GetOwnerReferences(), tc.expectedOwnerReferences)
+			assert.DeepEqual(t, obj.GetLabels(), tc.expectedLabels)
+		})
+	}
+}
+
+func TestSetOwner_LongName(t *testing.T) {
+	owner := kube.Owner{
+		APIVersion: "gitops.navecd.io/v1beta1",
+		Kind:       "GitOpsProject",
+		Name:       strings.Repeat("project.", 10),
+		Namespace:  "navecd-system",
+		UID:        "1234",
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Namespace")
+	obj.SetName("test")
+
+	kube.SetOwner(obj, owner)
+
+	name := obj.GetLabels()[kube.OwnerNameLabel]
+	assert.Assert(t, len(validation.IsValidLabelValue(name)) == 0, name)
+	assert.Assert(t, strings.HasPrefix(name, "project.project."))
+	assert.Equal(t, kube.OwnerSelector(owner), fmt.Sprintf("%s=%s,%s=navecd-system", kube.OwnerNameLabel, name, kube.OwnerNamespaceLabel))
+}
+
+func TestLabelValue(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+	}{
+		{name: "Valid", value: "project"},
+		{name: "Too-Long", value: strings.Repeat("a", 64)},
+		{name: "Invalid-Start", value: "-project"},
+		{name: "Invalid-Chars", value: "project:name"},
+		{name: "No-Valid-Chars", value: "::"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value := kube.LabelValue(tc.value)
+			assert.Assert(t, len(validation.IsValidLabelValue(value)) == 0, value)
+			if len(validation.IsValidLabelValue(tc.value)) == 0 {
+				assert.Equal(t, value, tc.value)
+			} else {
+				assert.Assert(t, value != kube.LabelValue(tc.value+"x"))
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// podSpecPaths maps kinds of the Kubernetes core APIs to the path of the pod spec they contain.
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// HasPodSpec reports whether objects of the kind contain a pod spec.
+func HasPodSpec(kind string) bool {
+	_, found := podSpecPaths[kind]
+	return found
+}
+
+// AddImagePullSecrets appends the secrets to the imagePullSecrets of the pod spec of obj.
+// Already referenced secrets are not duplicated.
+// Objects without a pod spec are left untouched.
+func AddImagePullSecrets(obj *unstructured.Unstructured, secrets []string) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	path, found := podSpecPaths[obj.GetKind()]
+	if !found {
+		return nil
+	}
+
+	podSpec, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return err
+	}
+
+	pullSecrets, _, err := unstructured.NestedSlice(podSpec, "imagePullSecrets")
+	if err != nil {
+		return err
+	}
+
+	referenced := make(map[string]struct{}, len(pullSecrets))
+	for _, pullSecret := range pullSecrets {
+		if ref, ok := pullSecret.(map[string]any); ok {
+			if name, ok := ref["name"].(string); ok {
+				referenced[name] = struct{}{}
+			}
+		}
+	}
+
+	for _, secret := range secrets {
+		if _, found := referenced[secret]; found {
+			continue
+		}
+		referenced[secret] = struct{}{}
+		pullSecrets = append(pullSecrets, map[string]any{"name": secret})
+	}
+
+	podSpec["imagePullSecrets"] = pullSecrets
+	return unstructured.SetNestedMap(obj.Object, podSpec, path...)
+}
+
+// RewriteImageRegistry replaces the registry host of all container images in the pod spec of obj with prefix.
+// Images without a registry host, e.g. nginx:1.27, are prefixed.
+// The prefix may contain a path, e.g. mirror.example.com/dockerhub.
+// Objects without a pod spec are left untouched.
+func RewriteImageRegistry(obj *unstructured.Unstructured, prefix string) error {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return nil
+	}
+
+	path, found := podSpecPaths[obj.GetKind()]
+	if !found {
+		return nil
+	}
+
+	podSpec, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return err
+	}
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, found, err := unstructured.NestedSlice(podSpec, field)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		for _, container := range containers {
+			container, ok := container.(map[string]any)
+			if !ok {
+				continue
+			}
+			if image, ok := container["image"].(string); ok && image != "" {
+				container["image"] = prefix + "/" + withoutRegistry(image)
+			}
+		}
+		podSpec[field] = containers
+	}
+
+	return unstructured.SetNestedMap(obj.Object, podSpec, path...)
+}
+
+// withoutRegistry strips the registry host of an image reference.
+// Like container runtimes, the first path component is considered a host, if it contains a dot or a port or is localhost.
+func withoutRegistry(image string) string {
+	host, rest, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return rest
+	}
+	return image
+}
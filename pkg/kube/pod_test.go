@@ -0,0 +1,164 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/kharf/navecd/pkg/kube"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAddImagePullSecrets(t *testing.T) {
+	testCases := []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		secrets  []string
+		path     []string
+		expected []any
+	}{
+		{
+			name:    "Deployment",
+			obj:     deployment(1, "test", nil),
+			secrets: []string{"registry"},
+			path:    []string{"spec", "template", "spec", "imagePullSecrets"},
+			expected: []any{
+				map[string]any{"name": "registry"},
+			},
+		},
+		{
+			name: "Merge",
+			obj: func() *unstructured.Unstructured {
+				obj := deployment(1, "test", nil)
+				err := unstructured.SetNestedSlice(obj.Object, []any{
+					map[string]any{"name": "existing"},
+					map[string]any{"name": "registry"},
+				}, "spec", "template", "spec", "imagePullSecrets")
+				assert.NilError(t, err)
+				return obj
+			}(),
+			secrets: []string{"registry", "other", "other"},
+			path:    []string{"spec", "template", "spec", "imagePullSecrets"},
+			expected: []any{
+				map[string]any{"name": "existing"},
+				map[string]any{"name": "registry"},
+				map[string]any{"name": "other"},
+			},
+		},
+		{
+			name: "CronJob",
+			obj: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "batch/v1",
+					"kind":       "CronJob",
+					"spec": map[string]any{
+						"jobTemplate": map[string]any{
+							"spec": map[string]any{
+								"template": map[string]any{
+									"spec": map[string]any{},
+								},
+							},
+						},
+					},
+				},
+			},
+			secrets: []string{"registry"},
+			path:    []string{"spec", "jobTemplate", "spec", "template", "spec", "imagePullSecrets"},
+			expected: []any{
+				map[string]any{"name": "registry"},
+			},
+		},
+		{
+			name: "NoPodSpec",
+			obj: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+				},
+			},
+			secrets: []string{"registry"},
+			path:    []string{"spec", "imagePullSecrets"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := kube.AddImagePullSecrets(tc.obj, tc.secrets)
+			assert.NilError(t, err)
+
+			pullSecrets, _, err := unstructured.NestedSlice(tc.obj.Object, tc.path...)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, pullSecrets, tc.expected)
+		})
+	}
+}
+
+func TestRewriteImageRegistry(t *testing.T) {
+	testCases := []struct {
+		name     string
+		image    string
+		prefix   string
+		expected string
+	}{
+		{
+			name:     "DockerHub",
+			image:    "nginx:1.27",
+			prefix:   "mirror.example.com",
+			expected: "mirror.example.com/nginx:1.27",
+		},
+		{
+			name:     "DockerHubOrganization",
+			image:    "bitnami/redis:7.4",
+			prefix:   "mirror.example.com",
+			expected: "mirror.example.com/bitnami/redis:7.4",
+		},
+		{
+			name:     "Registry",
+			image:    "ghcr.io/kharf/navecd:0.30.0",
+			prefix:   "mirror.example.com/ghcr/",
+			expected: "mirror.example.com/ghcr/kharf/navecd:0.30.0",
+		},
+		{
+			name:     "RegistryWithPort",
+			image:    "registry.internal:5000/app@sha256:abc",
+			prefix:   "mirror.example.com",
+			expected: "mirror.example.com/app@sha256:abc",
+		},
+		{
+			name:     "Localhost",
+			image:    "localhost/app:1.0.0",
+			prefix:   "mirror.example.com",
+			expected: "mirror.example.com/app:1.0.0",
+		},
+		{
+			name:     "NoPrefix",
+			image:    "nginx:1.27",
+			expected: "nginx:1.27",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := deployment(1, tc.image, nil)
+			err := kube.RewriteImageRegistry(obj, tc.prefix)
+			assert.NilError(t, err)
+
+			containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+			assert.NilError(t, err)
+			assert.Equal(t, containers[0].(map[string]any)["image"], tc.expected)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PodSecurityEnforceLabel is the namespace label defining the enforced Pod Security Standard level.
+const PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// Pod Security Standard levels, see https://kubernetes.io/docs/concepts/security/pod-security-standards.
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// baselineCapabilities may be added to containers by the baseline level.
+var baselineCapabilities = []corev1.Capability{
+	"AUDIT_WRITE",
+	"CHOWN",
+	"DAC_OVERRIDE",
+	"FOWNER",
+	"FSETID",
+	"KILL",
+	"MKNOD",
+	"NET_BIND_SERVICE",
+	"SETFCAP",
+	"SETGID",
+	"SETPCAP",
+	"SETUID",
+	"SYS_CHROOT",
+}
+
+// PodSecurityViolations evaluates the pod spec of obj against the controls of the given Pod Security Standard level
+// and returns a description of every violation.
+// Objects without a pod spec and unknown levels have no violations.
+// The API server stays the authority, this evaluation only surfaces the most common violations before applying.
+func PodSecurityViolations(obj *unstructured.Unstructured, level string) ([]string, error) {
+	if level != PodSecurityBaseline && level != PodSecurityRestricted {
+		return nil, nil
+	}
+
+	path, found := podSpecPaths[obj.GetKind()]
+	if !found {
+		return nil, nil
+	}
+
+	podSpecContent, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var podSpec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpecContent, &podSpec); err != nil {
+		return nil, err
+	}
+
+	violations := baselineViolations(&podSpec)
+	if level == PodSecurityRestricted {
+		violations = append(violations, restrictedViolations(&podSpec)...)
+	}
+
+	return violations, nil
+}
+
+// podContainer is a container of any type with the name used in violations.
+type podContainer struct {
+	name            string
+	securityContext *corev1.SecurityContext
+	ports           []corev1.ContainerPort
+}
+
+func containers(podSpec *corev1.PodSpec) []podContainer {
+	var result []podContainer
+	for _, container := range podSpec.InitContainers {
+		result = append(result, podContainer{container.Name, container.SecurityContext, container.Ports})
+	}
+	for _, container := range podSpec.Containers {
+		result = append(result, podContainer{container.Name, container.SecurityContext, container.Ports})
+	}
+	for _, container := range podSpec.EphemeralContainers {
+		result = append(result, podContainer{container.Name, container.SecurityContext, container.Ports})
+	}
+	return result
+}
+
+func baselineViolations(podSpec *corev1.PodSpec) []string {
+	var violations []string
+	if podSpec.HostNetwork {
+		violations = append(violations, "hostNetwork=true")
+	}
+	if podSpec.HostPID {
+		violations = append(violations, "hostPID=true")
+	}
+	if podSpec.HostIPC {
+		violations = append(violations, "hostIPC=true")
+	}
+
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("volume %q uses hostPath", volume.Name))
+		}
+	}
+
+	if podSpec.SecurityContext != nil && isUnconfined(podSpec.SecurityContext.SeccompProfile) {
+		violations = append(violations, "pod seccompProfile.type=Unconfined")
+	}
+
+	for _, container := range containers(podSpec) {
+		for _, port := range container.ports {
+			if port.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("container %q uses hostPort %d", container.name, port.HostPort))
+			}
+		}
+
+		securityContext := container.securityContext
+		if securityContext == nil {
+			continue
+		}
+
+		if securityContext.Privileged != nil && *securityContext.Privileged {
+			violations = append(violations, fmt.Sprintf("container %q is privileged", container.name))
+		}
+
+		if securityContext.Capabilities != nil {
+			for _, capability := range securityContext.Capabilities.Add {
+				if !slices.Contains(baselineCapabilities, capability) {
+					violations = append(
+						violations,
+						fmt.Sprintf("container %q adds capability %s", container.name, capability),
+					)
+				}
+			}
+		}
+
+		if securityContext.ProcMount != nil && *securityContext.ProcMount != corev1.DefaultProcMount {
+			violations = append(violations, fmt.Sprintf("container %q uses procMount %s", container.name, *securityContext.ProcMount))
+		}
+
+		if isUnconfined(securityContext.SeccompProfile) {
+			violations = append(violations, fmt.Sprintf("container %q seccompProfile.type=Unconfined", container.name))
+		}
+	}
+
+	return violations
+}
+
+func restrictedViolations(podSpec *corev1.PodSpec) []string {
+	var violations []string
+	for _, volume := range podSpec.Volumes {
+		source := volume.VolumeSource
+		if source.ConfigMap == nil && source.CSI == nil && source.DownwardAPI == nil && source.EmptyDir == nil &&
+			source.Ephemeral == nil && source.PersistentVolumeClaim == nil && source.Projected == nil &&
+			source.Secret == nil && source.HostPath == nil {
+			violations = append(violations, fmt.Sprintf("volume %q uses a restricted volume type", volume.Name))
+		}
+	}
+
+	podContext := podSpec.SecurityContext
+	if podContext == nil {
+		podContext = &corev1.PodSecurityContext{}
+	}
+	if podContext.RunAsUser != nil && *podContext.RunAsUser == 0 {
+		violations = append(violations, "pod runAsUser=0")
+	}
+
+	for _, container := range containers(podSpec) {
+		securityContext := container.securityContext
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+
+		if securityContext.AllowPrivilegeEscalation == nil || *securityContext.AllowPrivilegeEscalation {
+			violations = append(
+				violations,
+				fmt.Sprintf("container %q must set allowPrivilegeEscalation=false", container.name),
+			)
+		}
+
+		runAsNonRoot := podContext.RunAsNonRoot
+		if securityContext.RunAsNonRoot != nil {
+			runAsNonRoot = securityContext.RunAsNonRoot
+		}
+		if runAsNonRoot == nil || !*runAsNonRoot {
+			violations = append(violations, fmt.Sprintf("container %q must set runAsNonRoot=true", container.name))
+		}
+
+		if securityContext.RunAsUser != nil && *securityContext.RunAsUser == 0 {
+			violations = append(violations, fmt.Sprintf("container %q runAsUser=0", container.name))
+		}
+
+		seccompProfile := podContext.SeccompProfile
+		if securityContext.SeccompProfile != nil {
+			seccompProfile = securityContext.SeccompProfile
+		}
+		if seccompProfile == nil ||
+			(seccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault && seccompProfile.Type != corev1.SeccompProfileTypeLocalhost) {
+			violations = append(
+				violations,
+				fmt.Sprintf("container %q must set seccompProfile.type to RuntimeDefault or Localhost", container.name),
+			)
+		}
+
+		capabilities := securityContext.Capabilities
+		if capabilities == nil || !slices.Contains(capabilities.Drop, "ALL") {
+			violations = append(violations, fmt.Sprintf("container %q must drop ALL capabilities", container.name))
+		}
+		if capabilities != nil {
+			for _, capability := range capabilities.Add {
+				if capability != "NET_BIND_SERVICE" && slices.Contains(baselineCapabilities, capability) {
+					violations = append(
+						violations,
+						fmt.Sprintf("container %q adds capability %s", container.name, capability),
+					)
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+func isUnconfined(profile *corev1.SeccompProfile) bool {
+	return profile != nil && profile.Type == corev1.SeccompProfileTypeUnconfined
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/kharf/navecd/pkg/kube"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPodSecurityViolations(t *testing.T) {
+	privilegedContainer := map[string]any{
+		"name":  "debug",
+		"image": "debug:1.0.0",
+		"securityContext": map[string]any{
+			"privileged": true,
+		},
+	}
+	restrictedContainer := map[string]any{
+		"name":  "app",
+		"image": "app:1.0.0",
+		"securityContext": map[string]any{
+			"allowPrivilegeEscalation": false,
+			"capabilities": map[string]any{
+				"drop": []any{"ALL"},
+			},
+		},
+	}
+	restrictedPodContext := map[string]any{
+		"runAsNonRoot": true,
+		"seccompProfile": map[string]any{
+			"type": "RuntimeDefault",
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		kind       string
+		podSpec    map[string]any
+		level      string
+		violations []string
+	}{
+		{
+			name:  "Privileged-Restricted",
+			kind:  "Pod",
+			level: kube.PodSecurityRestricted,
+			podSpec: map[string]any{
+				"containers": []any{privilegedContainer},
+			},
+			violations: []string{
+				"container \"debug\" is privileged",
+				"container \"debug\" must set allowPrivilegeEscalation=false",
+				"container \"debug\" must set runAsNonRoot=true",
+				"container \"debug\" must set seccompProfile.type to RuntimeDefault or Localhost",
+				"container \"debug\" must drop ALL capabilities",
+			},
+		},
+		{
+			name:  "Privileged-Baseline",
+			kind:  "Deployment",
+			level: kube.PodSecurityBaseline,
+			podSpec: map[string]any{
+				"hostNetwork": true,
+				"containers":  []any{privilegedContainer},
+				"volumes": []any{
+					map[string]any{
+						"name":     "host",
+						"hostPath": map[string]any{"path": "/var/run"},
+					},
+				},
+			},
+			violations: []string{
+				"hostNetwork=true",
+				"volume \"host\" uses hostPath",
+				"container \"debug\" is privileged",
+			},
+		},
+		{
+			name:  "Privileged-Privileged",
+			kind:  "Pod",
+			level: kube.PodSecurityPrivileged,
+			podSpec: map[string]any{
+				"containers": []any{privilegedContainer},
+			},
+		},
+		{
+			name:  "Compliant-Restricted",
+			kind:  "Deployment",
+			level: kube.PodSecurityRestricted,
+			podSpec: map[string]any{
+				"securityContext": restrictedPodContext,
+				"containers":      []any{restrictedContainer},
+			},
+		},
+		{
+			name:  "No-Pod-Spec",
+			kind:  "ConfigMap",
+			level: kube.PodSecurityRestricted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       tc.kind,
+					"metadata": map[string]any{
+						"name":      "test",
+						"namespace": "test",
+					},
+				},
+			}
+			if tc.podSpec != nil {
+				if tc.kind == "Pod" {
+					obj.Object["spec"] = tc.podSpec
+				} else {
+					obj.Object["spec"] = map[string]any{
+						"template": map[string]any{
+							"spec": tc.podSpec,
+						},
+					}
+				}
+			}
+
+			violations, err := kube.PodSecurityViolations(obj, tc.level)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, violations, tc.violations)
+		})
+	}
+}
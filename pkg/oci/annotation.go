@@ -0,0 +1,62 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"time"
+)
+
+const (
+	// CreatedAnnotation holds the time the project artifact was built in RFC 3339 format.
+	CreatedAnnotation = "org.opencontainers.image.created"
+
+	// RevisionAnnotation holds the source control revision the project artifact was built from, e.g. a commit sha.
+	RevisionAnnotation = "org.opencontainers.image.revision"
+
+	// VersionAnnotation holds the version of Navecd, which built the project artifact.
+	VersionAnnotation = "io.navecd.version"
+)
+
+// Artifact describes a loaded project artifact.
+type Artifact struct {
+	Digest string
+
+	// Annotations of the artifact manifest.
+	Annotations map[string]string
+}
+
+// Revision returns the source control revision the artifact was built from, if annotated.
+func (artifact Artifact) Revision() string {
+	return artifact.Annotations[RevisionAnnotation]
+}
+
+// Created returns the time the artifact was built, if annotated.
+func (artifact Artifact) Created() string {
+	return artifact.Annotations[CreatedAnnotation]
+}
+
+// artifactAnnotations returns the manifest annotations of a project artifact built at created.
+func artifactAnnotations(options *projectClientOptions, created time.Time) map[string]string {
+	annotations := map[string]string{
+		CreatedAnnotation: created.UTC().Format(time.RFC3339),
+		VersionAnnotation: Version,
+	}
+
+	if options.revision != "" {
+		annotations[RevisionAnnotation] = options.revision
+	}
+
+	return annotations
+}
@@ -0,0 +1,275 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	// SBOMArtifactType is the artifact type of SPDX SBOMs attached to project artifacts.
+	SBOMArtifactType = "application/spdx+json"
+
+	// ProvenanceArtifactType is the artifact type of in-toto provenance attestations attached to project artifacts.
+	ProvenanceArtifactType = "application/vnd.in-toto+json"
+
+	// SLSAProvenancePredicateType is the predicate type of the provenance attestations.
+	SLSAProvenancePredicateType = "https://slsa.dev/provenance/v1"
+
+	// ProjectBuildType describes how Navecd builds project artifacts in provenance attestations.
+	ProjectBuildType = "https://navecd.io/push/v1"
+)
+
+type spdxDocument struct {
+	SPDXVersion       string           `json:"spdxVersion"`
+	DataLicense       string           `json:"dataLicense"`
+	SPDXID            string           `json:"SPDXID"`
+	Name              string           `json:"name"`
+	DocumentNamespace string           `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo `json:"creationInfo"`
+	Packages          []spdxPackage    `json:"packages"`
+	Files             []spdxFile       `json:"files"`
+	Relationships     []spdxRelation   `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string `json:"name"`
+	SPDXID           string `json:"SPDXID"`
+	DownloadLocation string `json:"downloadLocation"`
+	FilesAnalyzed    bool   `json:"filesAnalyzed"`
+}
+
+type spdxFile struct {
+	FileName  string         `json:"fileName"`
+	SPDXID    string         `json:"SPDXID"`
+	Checksums []spdxChecksum `json:"checksums"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelation struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// generateSBOM describes all regular files of the project directory as an SPDX document.
+func generateSBOM(path string, subject string, digest string, created time.Time) ([]byte, error) {
+	document := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              subject,
+		DocumentNamespace: fmt.Sprintf("https://navecd.io/spdx/%s@%s", subject, digest),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + DefaultUserAgent()},
+		},
+		Packages: []spdxPackage{
+			{
+				Name:             subject,
+				SPDXID:           "SPDXRef-Package",
+				DownloadLocation: "NOASSERTION",
+				FilesAnalyzed:    true,
+			},
+		},
+		Relationships: []spdxRelation{
+			{
+				SPDXElementID:      "SPDXRef-DOCUMENT",
+				RelationshipType:   "DESCRIBES",
+				RelatedSPDXElement: "SPDXRef-Package",
+			},
+		},
+	}
+
+	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(path, filePath)
+		if err != nil {
+			return err
+		}
+
+		checksum, err := sha256File(filePath)
+		if err != nil {
+			return err
+		}
+
+		fileID := fmt.Sprintf("SPDXRef-File-%d", len(document.Files))
+		document.Files = append(document.Files, spdxFile{
+			FileName: "./" + filepath.ToSlash(relPath),
+			SPDXID:   fileID,
+			Checksums: []spdxChecksum{
+				{
+					Algorithm:     "SHA256",
+					ChecksumValue: checksum,
+				},
+			},
+		})
+		document.Relationships = append(document.Relationships, spdxRelation{
+			SPDXElementID:      "SPDXRef-Package",
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: fileID,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(document)
+}
+
+func sha256File(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     slsaProvenance  `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenance struct {
+	BuildDefinition slsaBuildDefinition `json:"buildDefinition"`
+	RunDetails      slsaRunDetails      `json:"runDetails"`
+}
+
+type slsaBuildDefinition struct {
+	BuildType          string         `json:"buildType"`
+	ExternalParameters map[string]any `json:"externalParameters"`
+}
+
+type slsaRunDetails struct {
+	Builder  slsaBuilder  `json:"builder"`
+	Metadata slsaMetadata `json:"metadata"`
+}
+
+type slsaBuilder struct {
+	ID string `json:"id"`
+}
+
+type slsaMetadata struct {
+	StartedOn  string `json:"startedOn"`
+	FinishedOn string `json:"finishedOn"`
+}
+
+// generateProvenance creates an in-toto statement with a SLSA provenance predicate for the pushed project artifact.
+func generateProvenance(
+	subject string,
+	tag string,
+	digest v1.Hash,
+	startedOn time.Time,
+	finishedOn time.Time,
+) ([]byte, error) {
+	statement := inTotoStatement{
+		Type: "https://in-toto.io/Statement/v1",
+		Subject: []inTotoSubject{
+			{
+				Name: subject,
+				Digest: map[string]string{
+					digest.Algorithm: digest.Hex,
+				},
+			},
+		},
+		PredicateType: SLSAProvenancePredicateType,
+		Predicate: slsaProvenance{
+			BuildDefinition: slsaBuildDefinition{
+				BuildType: ProjectBuildType,
+				ExternalParameters: map[string]any{
+					"repository": subject,
+					"tag":        tag,
+				},
+			},
+			RunDetails: slsaRunDetails{
+				Builder: slsaBuilder{
+					ID: DefaultUserAgent(),
+				},
+				Metadata: slsaMetadata{
+					StartedOn:  startedOn.UTC().Format(time.RFC3339),
+					FinishedOn: finishedOn.UTC().Format(time.RFC3339),
+				},
+			},
+		},
+	}
+
+	return json.Marshal(statement)
+}
+
+// referrerArtifact wraps content into an artifact manifest, which refers to the subject.
+// The artifact type is set as config media type, so that registries without explicit artifactType support can serve it.
+func referrerArtifact(artifactType string, content []byte, subject v1.Descriptor) (v1.Image, error) {
+	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, types.MediaType(artifactType))
+
+	img, err := mutate.Append(img, mutate.Addendum{
+		Layer: static.NewLayer(content, types.MediaType(artifactType)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	referrer, ok := mutate.Subject(img, subject).(v1.Image)
+	if !ok {
+		return nil, fmt.Errorf("unable to set subject of %s artifact", artifactType)
+	}
+
+	return referrer, nil
+}
@@ -17,20 +17,28 @@ package oci
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/kharf/navecd/internal/tgz"
@@ -66,22 +74,105 @@ func (d *RecoverableError) Unwrap() error {
 }
 
 const (
-	ContentLayerMediaType = "application/vnd.navecd.content.v1.tar+gzip"
-	ConfigMediaType       = "application/vnd.navecd.config.v1+json"
+	ContentLayerMediaType     = "application/vnd.navecd.content.v1.tar+gzip"
+	ZstdContentLayerMediaType = "application/vnd.navecd.content.v1.tar+zstd"
+	ConfigMediaType           = "application/vnd.navecd.config.v1+json"
 )
 
+// Compression is the algorithm used to compress the project content layer.
+type Compression = tgz.Compression
+
+const (
+	GzipCompression = tgz.Gzip
+	ZstdCompression = tgz.Zstd
+)
+
+// mediaTypePrefix is shared by the media types of all versions of the project artifact format.
+const mediaTypePrefix = "application/vnd.navecd."
+
+// artifactFormat describes a version of the project artifact format.
+type artifactFormat struct {
+	// contentLayerMediaTypes maps the supported content layer media types to their compression.
+	contentLayerMediaTypes map[types.MediaType]Compression
+}
+
+// artifactFormats maps the config media types of all loadable project artifact format versions to their format.
+var artifactFormats = map[types.MediaType]artifactFormat{
+	ConfigMediaType: {
+		contentLayerMediaTypes: map[types.MediaType]Compression{
+			ContentLayerMediaType:     GzipCompression,
+			ZstdContentLayerMediaType: ZstdCompression,
+		},
+	},
+}
+
 var (
 	ErrWrongMediaType = errors.New("Wrong media type")
+
+	// ErrUnsupportedArtifactVersion indicates a project artifact, which was built in a newer format than this version of Navecd can load.
+	ErrUnsupportedArtifactVersion = errors.New("Unsupported project artifact version")
+
+	// ErrArtifactTooLarge indicates a project artifact, which exceeds the size configured with [WithMaxArtifactBytes].
+	ErrArtifactTooLarge = tgz.ErrSizeLimitExceeded
+
+	// ErrIllegalArtifactPath indicates a project artifact with an entry, which would be extracted outside of the target directory.
+	ErrIllegalArtifactPath = tgz.ErrIllegalPath
 )
 
+// negotiateFormat selects the format of a project artifact by its config media type.
+// Artifacts of newer format versions are rejected before anything is extracted.
+func negotiateFormat(manifest *v1.Manifest) (*artifactFormat, error) {
+	configMediaType := manifest.Config.MediaType
+	format, found := artifactFormats[configMediaType]
+	if !found {
+		if strings.HasPrefix(string(configMediaType), mediaTypePrefix) {
+			return nil, unsupportedVersionError(configMediaType)
+		}
+		return nil, fmt.Errorf("%w: got %s, wanted %s", ErrWrongMediaType, configMediaType, ConfigMediaType)
+	}
+
+	for _, layer := range manifest.Layers {
+		_, found := format.contentLayerMediaTypes[layer.MediaType]
+		if !found && strings.HasPrefix(string(layer.MediaType), mediaTypePrefix) {
+			return nil, unsupportedVersionError(layer.MediaType)
+		}
+	}
+
+	return &format, nil
+}
+
+func unsupportedVersionError(mediaType types.MediaType) error {
+	return fmt.Errorf(
+		"%w: %s is not known to Navecd %s, upgrade Navecd to load this project artifact",
+		ErrUnsupportedArtifactVersion,
+		mediaType,
+		Version,
+	)
+}
+
 type basicAuthOpt struct {
 	user     string
 	password string
 }
 
 type options struct {
-	auth     *basicAuthOpt
-	insecure bool
+	auth      *basicAuthOpt
+	insecure  bool
+	userAgent string
+	headers   http.Header
+	ctx       context.Context
+	keychain  authn.Keychain
+	proxy     *url.URL
+	log       *logr.Logger
+}
+
+// Version of Navecd, which is part of the default user-agent.
+// It is set at build time.
+var Version = "dev"
+
+// DefaultUserAgent identifies Navecd's registry traffic, if no other user-agent is configured.
+func DefaultUserAgent() string {
+	return "navecd/" + Version
 }
 
 type Option func(opts *options)
@@ -101,13 +192,87 @@ func WithInsecure(insecure bool) Option {
 	}
 }
 
+// WithUserAgent overrides the user-agent sent to the registry.
+// Defaults to [DefaultUserAgent].
+func WithUserAgent(userAgent string) Option {
+	return func(opts *options) {
+		opts.userAgent = userAgent
+	}
+}
+
+// WithHeader adds a header to every request sent to the registry.
+// It can be used multiple times to add multiple headers.
+func WithHeader(key, value string) Option {
+	return func(opts *options) {
+		if opts.headers == nil {
+			opts.headers = http.Header{}
+		}
+		opts.headers.Add(key, value)
+	}
+}
+
+// WithKeychain resolves registry credentials from the given keychain, like the local docker config.
+// Basic auth takes precedence.
+func WithKeychain(keychain authn.Keychain) Option {
+	return func(opts *options) {
+		opts.keychain = keychain
+	}
+}
+
+// WithProxy routes all registry traffic through the given proxy,
+// overriding the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func WithProxy(proxy *url.URL) Option {
+	return func(opts *options) {
+		opts.proxy = proxy
+	}
+}
+
+// WithLogger logs every request to the registry at the debug level V(1).
+func WithLogger(log logr.Logger) Option {
+	return func(opts *options) {
+		opts.log = &log
+	}
+}
+
+// WithContext aborts requests to the registry, once ctx is done.
+func WithContext(ctx context.Context) Option {
+	return func(opts *options) {
+		opts.ctx = ctx
+	}
+}
+
 type Client interface {
+	// Name returns the fully qualified repository name.
+	Name() string
 	ListTags(opts ...Option) ([]string, error)
 	Image(tag string, opts ...Option) (v1.Image, error)
 	PushImage(img v1.Image, tag string, path string, opts ...Option) (string, error)
+
+	// PushReferrer pushes an artifact by digest, which refers to its subject via the OCI referrers API.
+	PushReferrer(artifact v1.Image, opts ...Option) (string, error)
+
+	// Referrers returns the descriptors of all artifacts referring to the given digest.
+	Referrers(digest string, opts ...Option) ([]v1.Descriptor, error)
 }
 
+var (
+	ErrInvalidRepository = errors.New("Invalid repository")
+)
+
+// OCIScheme is the optional scheme of repository urls, which is stripped before parsing.
+const OCIScheme = "oci://"
+
+// NewRepositoryClient constructs a Client for the given repository, which may start with the oci:// scheme.
 func NewRepositoryClient(repoName string, insecure bool) (Client, error) {
+	repoName = strings.TrimPrefix(repoName, OCIScheme)
+	if repoName == "" || strings.Contains(repoName, "://") {
+		return nil, fmt.Errorf(
+			"%w: expected format [oci://]registry/repository, got %s",
+			ErrInvalidRepository,
+			repoName,
+		)
+	}
+
 	var insecureOpts []name.Option
 	if insecure {
 		insecureOpts = append(insecureOpts, name.Insecure)
@@ -127,6 +292,10 @@ type repositoryClient struct {
 	repo name.Repository
 }
 
+func (d *repositoryClient) Name() string {
+	return d.repo.Name()
+}
+
 func (d *repositoryClient) Image(tag string, opts ...Option) (v1.Image, error) {
 	image, err := remote.Image(d.repo.Tag(tag), evalRemoteOpts(opts)...)
 	if err != nil {
@@ -136,8 +305,30 @@ func (d *repositoryClient) Image(tag string, opts ...Option) (v1.Image, error) {
 	return image, nil
 }
 
+// ListTags retries with the catalog scope in addition to the pull scope, if the registry denies listing tags,
+// because some registries issue pull scoped tokens, which do not permit listing tags.
 func (d *repositoryClient) ListTags(opts ...Option) ([]string, error) {
 	remoteVersions, err := remote.List(d.repo, evalRemoteOpts(opts)...)
+	if err == nil {
+		return remoteVersions, nil
+	}
+
+	var transportErr *transport.Error
+	if !errors.As(err, &transportErr) ||
+		(transportErr.StatusCode != http.StatusUnauthorized && transportErr.StatusCode != http.StatusForbidden) {
+		return nil, err
+	}
+
+	remoteOpts, err := evalScopedRemoteOpts(
+		d.repo,
+		[]string{d.repo.Scope(transport.PullScope), d.repo.Registry.Scope(transport.CatalogScope)},
+		opts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteVersions, err = remote.List(d.repo, remoteOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -158,69 +349,343 @@ func (d *repositoryClient) PushImage(img v1.Image, ref string, path string, opts
 	return digest.String(), nil
 }
 
+func (d *repositoryClient) PushReferrer(artifact v1.Image, opts ...Option) (string, error) {
+	digest, err := artifact.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	if err := remote.Write(d.repo.Digest(digest.String()), artifact, evalRemoteOpts(opts)...); err != nil {
+		return "", err
+	}
+
+	return digest.String(), nil
+}
+
+func (d *repositoryClient) Referrers(digest string, opts ...Option) ([]v1.Descriptor, error) {
+	index, err := remote.Referrers(d.repo.Digest(digest), evalRemoteOpts(opts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest.Manifests, nil
+}
+
 var _ Client = (*repositoryClient)(nil)
 
-func evalRemoteOpts(opts []Option) []remote.Option {
-	options := &options{}
+// Exists reports whether the given image or artifact reference, like registry/repository:tag, can be resolved.
+// The reference may start with the oci:// scheme.
+func Exists(ref string, insecure bool, opts ...Option) (bool, error) {
+	var nameOpts []name.Option
+	if insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+
+	reference, err := name.ParseReference(strings.TrimPrefix(ref, OCIScheme), nameOpts...)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := remote.Head(reference, evalRemoteOpts(opts)...); err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func evalOpts(opts []Option) *options {
+	options := &options{
+		userAgent: DefaultUserAgent(),
+	}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(options)
 		}
 	}
+	return options
+}
 
-	var remoteOptions []remote.Option
-	if options.auth != nil {
-		remoteOptions = append(remoteOptions, remote.WithAuth(&authn.Basic{
-			Username: options.auth.user,
-			Password: options.auth.password,
-		}))
+func evalRemoteOpts(opts []Option) []remote.Option {
+	options := evalOpts(opts)
+
+	remoteOptions := []remote.Option{
+		remote.WithUserAgent(options.userAgent),
+	}
+	if auth := evalAuth(options); auth != nil {
+		remoteOptions = append(remoteOptions, remote.WithAuth(auth))
+	} else if options.keychain != nil {
+		remoteOptions = append(remoteOptions, remote.WithAuthFromKeychain(options.keychain))
+	}
+
+	if transport := evalTransport(options); transport != nil {
+		remoteOptions = append(remoteOptions, remote.WithTransport(transport))
+	}
+
+	if options.ctx != nil {
+		remoteOptions = append(remoteOptions, remote.WithContext(options.ctx))
 	}
 
 	return remoteOptions
 }
 
-func evalCraneOpts(opts []Option) []crane.Option {
-	options := &options{}
-	for _, opt := range opts {
-		if opt != nil {
-			opt(options)
+// evalAuth returns the authenticator of explicit credentials or nil, if none are set.
+func evalAuth(options *options) authn.Authenticator {
+	if options.auth == nil {
+		return nil
+	}
+	return &authn.Basic{
+		Username: options.auth.user,
+		Password: options.auth.password,
+	}
+}
+
+// evalScopedRemoteOpts extends the options of evalRemoteOpts with a transport,
+// which authenticates against the registry of repo with the given scopes up front.
+// Remote operations request only the scope of the operation, e.g. pull, and registries do not always
+// advertise missing scopes in their challenges.
+func evalScopedRemoteOpts(repo name.Repository, scopes []string, opts []Option) ([]remote.Option, error) {
+	options := evalOpts(opts)
+	ctx := options.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	auth := evalAuth(options)
+	if auth == nil && options.keychain != nil {
+		var err error
+		auth, err = authn.Resolve(ctx, options.keychain, repo)
+		if err != nil {
+			return nil, err
 		}
 	}
+	if auth == nil {
+		auth = authn.Anonymous
+	}
+
+	inner := evalTransport(options)
+	if inner == nil {
+		inner = remote.DefaultTransport
+	}
+	inner = transport.NewUserAgent(transport.NewRetry(inner), options.userAgent)
+
+	scopedTransport, err := transport.NewWithContext(ctx, repo.Registry, auth, inner, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// the scoped transport replaces the transport of evalRemoteOpts and is used without further wrapping.
+	return append(evalRemoteOpts(opts), remote.WithTransport(scopedTransport), remote.WithContext(ctx)), nil
+}
+
+func evalCraneOpts(opts []Option) []crane.Option {
+	options := evalOpts(opts)
 
-	var craneOptions []crane.Option
+	craneOptions := []crane.Option{
+		crane.WithUserAgent(options.userAgent),
+	}
 	if options.auth != nil {
 		craneOptions = append(craneOptions, crane.WithAuth(&authn.Basic{
 			Username: options.auth.user,
 			Password: options.auth.password,
 		}))
+	} else if options.keychain != nil {
+		craneOptions = append(craneOptions, crane.WithAuthFromKeychain(options.keychain))
 	}
 
 	if options.insecure {
 		craneOptions = append(craneOptions, crane.Insecure)
 	}
 
+	if transport := evalTransport(options); transport != nil {
+		craneOptions = append(craneOptions, crane.WithTransport(transport))
+	}
+
+	if options.ctx != nil {
+		craneOptions = append(craneOptions, crane.WithContext(options.ctx))
+	}
+
 	return craneOptions
 }
 
+// evalTransport returns nil, if the default transport satisfies the options.
+func evalTransport(options *options) http.RoundTripper {
+	if len(options.headers) == 0 && options.proxy == nil && options.log == nil {
+		return nil
+	}
+
+	var transport http.RoundTripper = remote.DefaultTransport
+	if options.proxy != nil {
+		if defaultTransport, ok := remote.DefaultTransport.(*http.Transport); ok {
+			proxiedTransport := defaultTransport.Clone()
+			proxiedTransport.Proxy = http.ProxyURL(options.proxy)
+			transport = proxiedTransport
+		}
+	}
+
+	if len(options.headers) != 0 {
+		transport = &headerTransport{
+			inner:   transport,
+			headers: options.headers,
+		}
+	}
+
+	if options.log != nil {
+		transport = &logTransport{
+			inner: transport,
+			log:   *options.log,
+		}
+	}
+
+	return transport
+}
+
+// headerTransport sets additional headers on every request.
+type headerTransport struct {
+	inner   http.RoundTripper
+	headers http.Header
+}
+
+var _ http.RoundTripper = (*headerTransport)(nil)
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	return t.inner.RoundTrip(req)
+}
+
+// logTransport logs every request at the debug level.
+type logTransport struct {
+	inner http.RoundTripper
+	log   logr.Logger
+}
+
+var _ http.RoundTripper = (*logTransport)(nil)
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil {
+		t.log.V(1).Info(
+			"Registry request failed",
+			"method", req.Method,
+			"url", req.URL.Redacted(),
+			"error", err.Error(),
+		)
+		return nil, err
+	}
+
+	t.log.V(1).Info(
+		"Registry request",
+		"method", req.Method,
+		"url", req.URL.Redacted(),
+		"status", resp.StatusCode,
+		"duration", time.Since(start).String(),
+	)
+	return resp, nil
+}
+
 type projectClientOptions struct {
-	cacheDir string
-	repoOpts []Option
+	cacheDir    string
+	repoOpts    []Option
+	compression Compression
+	sbom        bool
+	provenance  bool
+	subpath     string
+	revision    string
+	maxBytes    int64
+
+	cacheRetention time.Duration
 }
 
 type ProjectClientOption func(opts *projectClientOptions)
 
+// logger returns the logger configured with [WithLogger] as repository option.
+func (opts *projectClientOptions) logger() logr.Logger {
+	if log := evalOpts(opts.repoOpts).log; log != nil {
+		return *log
+	}
+	return logr.Discard()
+}
+
 func WithCacheDir(dir string) ProjectClientOption {
 	return func(opts *projectClientOptions) {
 		opts.cacheDir = dir
 	}
 }
 
+// WithCacheRetention prunes the completion markers and the extracted artifacts of target dirs, which were not loaded within retention, on load.
+// Their artifacts are extracted again, when they are loaded the next time. Zero or less keeps them forever.
+func WithCacheRetention(retention time.Duration) ProjectClientOption {
+	return func(opts *projectClientOptions) {
+		opts.cacheRetention = retention
+	}
+}
+
 func WithRepositoryOption(option Option) ProjectClientOption {
 	return func(opts *projectClientOptions) {
 		opts.repoOpts = append(opts.repoOpts, option)
 	}
 }
 
+// WithCompression sets the algorithm to compress the project content with on push.
+// Defaults to gzip.
+func WithCompression(compression Compression) ProjectClientOption {
+	return func(opts *projectClientOptions) {
+		opts.compression = compression
+	}
+}
+
+// WithSubpath only extracts the content below subpath of the project artifact on load.
+// The subpath prefix is stripped, so that its content ends up directly in the target dir.
+func WithSubpath(subpath string) ProjectClientOption {
+	return func(opts *projectClientOptions) {
+		opts.subpath = subpath
+	}
+}
+
+// WithMaxArtifactBytes limits the size of the downloaded and of the extracted project artifact on load.
+// Larger artifacts fail with an [UnrecoverableError] wrapping [ErrArtifactTooLarge]. Zero or less means no limit.
+func WithMaxArtifactBytes(maxBytes int64) ProjectClientOption {
+	return func(opts *projectClientOptions) {
+		opts.maxBytes = maxBytes
+	}
+}
+
+// WithSBOM attaches an SPDX SBOM of the project files to the pushed artifact as a referrer.
+func WithSBOM(enabled bool) ProjectClientOption {
+	return func(opts *projectClientOptions) {
+		opts.sbom = enabled
+	}
+}
+
+// WithProvenance attaches a SLSA provenance attestation to the pushed artifact as a referrer.
+func WithProvenance(enabled bool) ProjectClientOption {
+	return func(opts *projectClientOptions) {
+		opts.provenance = enabled
+	}
+}
+
+// WithRevision annotates the pushed artifact with the source control revision it is built from, e.g. a commit sha.
+func WithRevision(revision string) ProjectClientOption {
+	return func(opts *projectClientOptions) {
+		opts.revision = revision
+	}
+}
+
 func NewProjectClient(ociClient Client) *ProjectClient {
 	return &ProjectClient{
 		Client: ociClient,
@@ -231,7 +696,11 @@ type ProjectClient struct {
 	Client
 }
 
-func (client *ProjectClient) PushImageFromPath(tag string, path string, opts ...ProjectClientOption) (string, error) {
+// PushImageFromPath archives the project at path, pushes it with the given tag and returns its digest.
+// The artifact is annotated with its build time, the Navecd version and the source revision, if set.
+// SBOMs and provenance attestations are pushed afterwards as referrers of the project artifact, if enabled.
+func (client *ProjectClient) PushImageFromPath(ctx context.Context, tag string, path string, opts ...ProjectClientOption) (string, error) {
+	startedOn := time.Now()
 	options := &projectClientOptions{}
 	for _, opt := range opts {
 		opt(options)
@@ -246,28 +715,223 @@ func (client *ProjectClient) PushImageFromPath(tag string, path string, opts ...
 		options.cacheDir = dir
 	}
 
-	archive := filepath.Join(options.cacheDir, "navecd.tgz")
-	if err := tgz.Create(path, archive); err != nil {
+	log := options.logger()
+	log.V(1).Info("Building project artifact", "path", path, "compression", options.compression)
+	img, err := buildImage(path, options, startedOn)
+	if err != nil {
 		return "", err
 	}
 
-	contentLayer, err := tarball.LayerFromFile(archive, tarball.WithMediaType(ContentLayerMediaType), tarball.WithCompressedCaching)
+	repoOpts := append(options.repoOpts, WithContext(ctx))
+	digest, err := client.PushImage(img, tag, path, repoOpts...)
 	if err != nil {
 		return "", err
 	}
+	log.V(1).Info("Pushed project artifact", "repository", client.Name(), "tag", tag, "digest", digest)
+
+	if !options.sbom && !options.provenance {
+		return digest, nil
+	}
+
+	if err := client.pushAttestations(img, tag, path, startedOn, options, repoOpts); err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// buildImage archives the project at path into the content layer of a project artifact built at created.
+// The archive is cached in the cache dir, which has to exist until the image is written.
+func buildImage(path string, options *projectClientOptions, created time.Time) (v1.Image, error) {
+	mediaType := types.MediaType(ContentLayerMediaType)
+	if options.compression == ZstdCompression {
+		mediaType = ZstdContentLayerMediaType
+	} else {
+		options.compression = GzipCompression
+	}
+
+	archive := filepath.Join(options.cacheDir, "navecd.tgz")
+	if err := tgz.CreateCompressed(path, archive, options.compression); err != nil {
+		return nil, err
+	}
+
+	contentLayer, err := tarball.LayerFromFile(archive, tarball.WithMediaType(mediaType), tarball.WithCompressedCaching)
+	if err != nil {
+		return nil, err
+	}
 
 	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
 	img = mutate.ConfigMediaType(img, ConfigMediaType)
 
 	img, err = mutate.Append(img, mutate.Addendum{Layer: contentLayer})
+	if err != nil {
+		return nil, err
+	}
+
+	annotated, ok := mutate.Annotations(img, artifactAnnotations(options, created)).(v1.Image)
+	if !ok {
+		return nil, errors.New("unable to annotate project artifact")
+	}
+
+	return annotated, nil
+}
+
+// ExportImageFromPath archives the project at path and writes the project artifact into an OCI image layout at layoutDir,
+// so that it can be transferred without registry access and pushed later with [ProjectClient.PushImageFromLayout].
+func ExportImageFromPath(path string, layoutDir string, opts ...ProjectClientOption) (string, error) {
+	options := &projectClientOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.cacheDir == "" {
+		dir, err := os.MkdirTemp("", "navecd-*")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(dir)
+		options.cacheDir = dir
+	}
+
+	img, err := buildImage(path, options, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	imageLayout, err := layout.Write(layoutDir, empty.Index)
+	if err != nil {
+		return "", err
+	}
+
+	if err := imageLayout.AppendImage(img); err != nil {
+		return "", err
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	return digest.String(), nil
+}
+
+var (
+	ErrEmptyImageLayout = errors.New("Image layout contains no project artifact")
+)
+
+// PushImageFromLayout pushes the project artifact of an OCI image layout written by [ExportImageFromPath] with the given tag.
+func (client *ProjectClient) PushImageFromLayout(ctx context.Context, tag string, layoutDir string, opts ...ProjectClientOption) (string, error) {
+	options := &projectClientOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	imageLayout, err := layout.FromPath(layoutDir)
+	if err != nil {
+		return "", err
+	}
+
+	index, err := imageLayout.ImageIndex()
 	if err != nil {
 		return "", err
 	}
 
-	return client.PushImage(img, tag, path, options.repoOpts...)
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return "", err
+	}
+
+	if len(indexManifest.Manifests) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrEmptyImageLayout, layoutDir)
+	}
+
+	img, err := index.Image(indexManifest.Manifests[0].Digest)
+	if err != nil {
+		return "", err
+	}
+
+	return client.PushImage(img, tag, layoutDir, append(options.repoOpts, WithContext(ctx))...)
+}
+
+func (client *ProjectClient) pushAttestations(
+	img v1.Image,
+	tag string,
+	path string,
+	startedOn time.Time,
+	options *projectClientOptions,
+	repoOpts []Option,
+) error {
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := img.RawManifest()
+	if err != nil {
+		return err
+	}
+
+	subject := v1.Descriptor{
+		MediaType: types.OCIManifestSchema1,
+		Size:      int64(len(manifest)),
+		Digest:    digest,
+	}
+
+	subjectName := client.Name()
+	if options.sbom {
+		content, err := generateSBOM(path, subjectName, digest.String(), startedOn)
+		if err != nil {
+			return err
+		}
+
+		if err := client.pushReferrer(SBOMArtifactType, content, subject, repoOpts); err != nil {
+			return err
+		}
+	}
+
+	if options.provenance {
+		content, err := generateProvenance(subjectName, tag, digest, startedOn, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if err := client.pushReferrer(ProvenanceArtifactType, content, subject, repoOpts); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
+func (client *ProjectClient) pushReferrer(
+	artifactType string,
+	content []byte,
+	subject v1.Descriptor,
+	repoOpts []Option,
+) error {
+	artifact, err := referrerArtifact(artifactType, content, subject)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PushReferrer(artifact, repoOpts...)
+	return err
+}
+
+// LoadImage extracts the project artifact with the given tag into targetDir and returns its digest.
+// See [ProjectClient.LoadArtifact].
 func (client *ProjectClient) LoadImage(ctx context.Context, tag string, targetDir string, opts ...ProjectClientOption) (string, error) {
+	artifact, err := client.LoadArtifact(ctx, tag, targetDir, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return artifact.Digest, nil
+}
+
+// LoadArtifact extracts the project artifact with the given tag into targetDir and returns its digest and annotations.
+// An artifact, which was already extracted, is not downloaded again.
+func (client *ProjectClient) LoadArtifact(ctx context.Context, tag string, targetDir string, opts ...ProjectClientOption) (*Artifact, error) {
 	options := &projectClientOptions{}
 	for _, opt := range opts {
 		if opt != nil {
@@ -275,85 +939,218 @@ func (client *ProjectClient) LoadImage(ctx context.Context, tag string, targetDi
 		}
 	}
 
-	image, err := client.Image(tag, options.repoOpts...)
+	image, err := client.Image(tag, append(options.repoOpts, WithContext(ctx))...)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	imgMediaType, err := image.MediaType()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if imgMediaType != types.OCIManifestSchema1 {
-		return "", fmt.Errorf("%w: got %s, wanted %s", ErrWrongMediaType, imgMediaType, types.OCIManifestSchema1)
+		return nil, fmt.Errorf("%w: got %s, wanted %s", ErrWrongMediaType, imgMediaType, types.OCIManifestSchema1)
 	}
 
 	manifest, err := image.Manifest()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if manifest.Config.MediaType != ConfigMediaType {
-		return "", fmt.Errorf("%w: got %s, wanted %s", ErrWrongMediaType, manifest.Config.MediaType, ConfigMediaType)
+	format, err := negotiateFormat(manifest)
+	if err != nil {
+		return nil, err
 	}
 
 	imageDigest, err := image.Digest()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	completionDir := filepath.Join(options.cacheDir, "completion")
+	// markers are scoped to their target dir, because target dirs of different projects may share a cache dir.
+	markerDir := filepath.Join(completionDir, fmt.Sprintf("%x", sha256.Sum256([]byte(targetDir))))
 	imageDigestStr := imageDigest.String()
-	marker := filepath.Join(completionDir, fmt.Sprintf("%s%s", imageDigestStr, ".complete"))
+	markerName := imageDigestStr
+	if options.subpath != "" {
+		// the same artifact may be extracted with different subpaths.
+		markerName = fmt.Sprintf("%s-%x", imageDigestStr, sha256.Sum256([]byte(options.subpath)))
+	}
+	marker := filepath.Join(markerDir, fmt.Sprintf("%s%s", markerName, ".complete"))
+
+	artifact := &Artifact{
+		Digest:      imageDigestStr,
+		Annotations: manifest.Annotations,
+	}
+
+	log := options.logger()
+	if options.cacheRetention > 0 {
+		if err := pruneMarkers(completionDir, markerDir, options.cacheRetention); err != nil {
+			return nil, err
+		}
+	}
 
 	if _, err := os.Stat(marker); err == nil {
-		return imageDigestStr, nil
+		log.V(1).Info("Project artifact already extracted", "digest", imageDigestStr)
+		now := time.Now()
+		if err := os.Chtimes(markerDir, now, now); err != nil {
+			return nil, err
+		}
+		return artifact, nil
 	}
+	log.V(1).Info("Extracting project artifact", "digest", imageDigestStr, "targetDir", targetDir)
 
-	err = prepareDirs(completionDir, targetDir)
+	err = prepareDirs(markerDir, targetDir)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	targetDirBkp := fmt.Sprintf("%s-bkp", targetDir)
 	err = createBackup(targetDir, targetDirBkp)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	archiveDir := filepath.Join(options.cacheDir, imageDigestStr)
-	archiveFilePath, _, err := downloadImage(image, archiveDir)
+	archiveFilePath, compression, err := downloadImage(image, format, archiveDir, options.maxBytes)
 	if err != nil {
-		return "", &RecoverableError{
+		if errors.Is(err, ErrArtifactTooLarge) {
+			return nil, &UnrecoverableError{
+				Err: err,
+			}
+		}
+		return nil, &RecoverableError{
 			Err:        err,
 			BackupPath: targetDirBkp,
 		}
 	}
 	defer os.RemoveAll(archiveDir)
 
-	err = unpack(archiveFilePath, targetDir)
+	// The artifact is extracted next to targetDir and moved into place afterwards,
+	// so that an interrupted extraction never leaves a partial project behind.
+	stagingDir := fmt.Sprintf("%s-staging", targetDir)
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(stagingDir, 0700); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	err = unpack(archiveFilePath, stagingDir, compression, options.subpath, options.maxBytes)
 	if err != nil {
-		return "", &UnrecoverableError{
+		return nil, &UnrecoverableError{
 			Err: err,
 		}
 	}
 
+	if err := replaceDir(stagingDir, targetDir); err != nil {
+		return nil, err
+	}
+
 	markerFile, err := os.Create(marker)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer markerFile.Close()
 
-	return imageDigestStr, nil
+	return artifact, nil
+}
+
+// replaceDir moves the directory src to dst, replacing the existing dst.
+// Both have to be located on the same file system.
+func replaceDir(src string, dst string) error {
+	old := fmt.Sprintf("%s-old", dst)
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+
+	if err := os.Rename(dst, old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+// pruneMarkers removes the marker dirs of all target dirs except markerDir, which were not loaded within retention,
+// together with their target dirs and backups. Markers of the former flat layout are removed as well.
+func pruneMarkers(completionDir string, markerDir string, retention time.Duration) error {
+	entries, err := os.ReadDir(completionDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(completionDir, entry.Name())
+		if path == markerDir {
+			continue
+		}
+
+		if entry.IsDir() {
+			info, err := entry.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			if time.Since(info.ModTime()) <= retention {
+				continue
+			}
+
+			if err := removeTargetDir(path); err != nil {
+				return err
+			}
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// targetFileName is the name of the file in a marker dir, which records the path of its target dir.
+const targetFileName = "target"
+
+// removeTargetDir removes the target dir recorded in markerDir and its backup.
+// Marker dirs without a recorded target dir are left as they are.
+func removeTargetDir(markerDir string) error {
+	targetDir, err := os.ReadFile(filepath.Join(markerDir, targetFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.RemoveAll(string(targetDir)); err != nil {
+		return err
+	}
+	return os.RemoveAll(fmt.Sprintf("%s-bkp", targetDir))
 }
 
-func prepareDirs(completionDir string, targetDir string) error {
-	if err := os.RemoveAll(completionDir); err != nil {
+// prepareDirs removes the markers of previously extracted artifacts of the target dir,
+// because they are replaced by the extraction, and records the target dir for pruning.
+func prepareDirs(markerDir string, targetDir string) error {
+	if err := os.RemoveAll(markerDir); err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(completionDir, 0700); err != nil {
+	if err := os.MkdirAll(markerDir, 0700); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(markerDir, targetFileName), []byte(targetDir), 0600); err != nil {
 		return err
 	}
 
@@ -363,49 +1160,78 @@ func prepareDirs(completionDir string, targetDir string) error {
 	return nil
 }
 
-func downloadImage(image v1.Image, targetDir string) (string, int64, error) {
+// downloadImage stores the compressed content layer of the image in targetDir
+// and returns its path and compression.
+// Layers larger than maxBytes are rejected, if maxBytes is positive.
+func downloadImage(image v1.Image, format *artifactFormat, targetDir string, maxBytes int64) (string, Compression, error) {
 	if err := os.MkdirAll(targetDir, 0700); err != nil {
-		return "", 0, err
+		return "", "", err
 	}
 
 	archiveFilePath := filepath.Join(targetDir, "navecd.tgz")
 	layers, err := image.Layers()
 	if err != nil {
-		return "", 0, err
+		return "", "", err
 	}
 
 	contentLayer := layers[0]
 
 	mediaType, err := contentLayer.MediaType()
 	if err != nil {
-		return "", 0, err
+		return "", "", err
 	}
 
-	if mediaType != ContentLayerMediaType {
-		return "", 0, fmt.Errorf("%w: got %s, wanted %s", ErrWrongMediaType, mediaType, ContentLayerMediaType)
+	compression, found := format.contentLayerMediaTypes[mediaType]
+	if !found {
+		return "", "", fmt.Errorf(
+			"%w: got %s, wanted %s or %s",
+			ErrWrongMediaType,
+			mediaType,
+			ContentLayerMediaType,
+			ZstdContentLayerMediaType,
+		)
+	}
+
+	if maxBytes > 0 {
+		size, err := contentLayer.Size()
+		if err != nil {
+			return "", "", err
+		}
+		if size > maxBytes {
+			return "", "", fmt.Errorf("%w: layer has %d bytes, but only %d are allowed", ErrArtifactTooLarge, size, maxBytes)
+		}
 	}
 
 	writer, err := os.Create(archiveFilePath)
 	if err != nil {
-		return "", 0, err
+		return "", "", err
 	}
 	defer writer.Close()
 
 	reader, err := contentLayer.Compressed()
 	if err != nil {
-		return "", 0, err
+		return "", "", err
 	}
 	defer reader.Close()
 
-	size, err := io.Copy(writer, bufio.NewReader(reader))
+	var layerReader io.Reader = bufio.NewReader(reader)
+	if maxBytes > 0 {
+		// the descriptor size is not trusted, one more byte than allowed reveals an exceeded limit.
+		layerReader = io.LimitReader(layerReader, maxBytes+1)
+	}
+
+	written, err := io.Copy(writer, layerReader)
 	if err != nil {
-		return "", 0, err
+		return "", "", err
+	}
+	if maxBytes > 0 && written > maxBytes {
+		return "", "", fmt.Errorf("%w: more than %d bytes", ErrArtifactTooLarge, maxBytes)
 	}
-	return archiveFilePath, size, nil
+	return archiveFilePath, compression, nil
 }
 
-func unpack(archiveFilePath string, targetDir string) error {
-	if err := tgz.Read(archiveFilePath, targetDir); err != nil {
+func unpack(archiveFilePath string, targetDir string, compression Compression, subpath string, maxBytes int64) error {
+	if err := tgz.ReadSubpath(archiveFilePath, targetDir, compression, subpath, tgz.WithMaxBytes(maxBytes)); err != nil {
 		return err
 	}
 
@@ -0,0 +1,901 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/kharf/navecd/internal/dnstest"
+	"github.com/kharf/navecd/internal/ocitest"
+	"github.com/kharf/navecd/internal/proxytest"
+	"github.com/kharf/navecd/pkg/oci"
+	"go.uber.org/zap/zapcore"
+	"gotest.tools/v3/assert"
+	ctrlZap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestNewRepositoryClient(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(projectDir, "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		name     string
+		repoName string
+		wantErr  error
+	}{
+		{
+			name:     "Without-Scheme",
+			repoName: registry.Addr() + "/withoutscheme",
+		},
+		{
+			name:     "With-OCI-Scheme",
+			repoName: "oci://" + registry.Addr() + "/withscheme",
+		},
+		{
+			name:     "Other-Scheme",
+			repoName: "https://" + registry.Addr() + "/otherscheme",
+			wantErr:  oci.ErrInvalidRepository,
+		},
+		{
+			name:     "Only-Scheme",
+			repoName: "oci://",
+			wantErr:  oci.ErrInvalidRepository,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := oci.NewRepositoryClient(tc.repoName, false)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			_, err = oci.NewProjectClient(client).PushImageFromPath(context.Background(), "latest", projectDir)
+			assert.NilError(t, err)
+
+			tags, err := client.ListTags()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tags, []string{"latest"})
+		})
+	}
+}
+
+func TestRepositoryClient_Headers(t *testing.T) {
+	testCases := []struct {
+		name              string
+		opts              []oci.Option
+		expectedUserAgent string
+		expectedToken     string
+	}{
+		{
+			name:              "Default",
+			expectedUserAgent: oci.DefaultUserAgent(),
+		},
+		{
+			name: "Custom",
+			opts: []oci.Option{
+				oci.WithUserAgent("navecd/test"),
+				oci.WithHeader("X-Gateway-Token", "token"),
+			},
+			expectedUserAgent: "navecd/test",
+			expectedToken:     "token",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var requests []http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests = append(requests, r.Header.Clone())
+				mu.Unlock()
+
+				if r.URL.Path == "/v2/headers/tags/list" {
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"name":"headers","tags":["latest"]}`))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client, err := oci.NewRepositoryClient(strings.TrimPrefix(server.URL, "http://")+"/headers", true)
+			assert.NilError(t, err)
+
+			tags, err := client.ListTags(tc.opts...)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tags, []string{"latest"})
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Assert(t, len(requests) != 0)
+			for _, header := range requests {
+				assert.Equal(t, header.Get("X-Gateway-Token"), tc.expectedToken)
+				assert.Assert(t, strings.HasPrefix(header.Get("User-Agent"), tc.expectedUserAgent))
+			}
+		})
+	}
+}
+
+// newScopedRegistry serves a registry, which issues tokens with the requested scopes and,
+// like some registries, only accepts tag listings with the catalog scope, if catalogRequired is set.
+// Its challenges do not advertise the missing scope.
+// The returned func reports the scopes of all issued tokens.
+func newScopedRegistry(t *testing.T, catalogRequired bool) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var issuedScopes []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		challenge := func() {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="scoped"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		scopes := strings.Fields(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+
+		switch r.URL.Path {
+		case "/token":
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "navecd" || password != "abcd" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			mu.Lock()
+			issuedScopes = append(issuedScopes, r.URL.Query()["scope"]...)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"token": strings.Join(r.URL.Query()["scope"], " "),
+			})
+		case "/v2/":
+			challenge()
+		case "/v2/scoped/tags/list":
+			if !slices.Contains(scopes, "repository:scoped:pull") ||
+				(catalogRequired && !slices.Contains(scopes, "registry:catalog:*")) {
+				challenge()
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name":"scoped","tags":["latest"]}`))
+		case "/v2/scoped/manifests/latest":
+			if !slices.Contains(scopes, "repository:scoped:pull") {
+				challenge()
+				return
+			}
+			manifest, err := empty.Image.RawManifest()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", string(types.DockerManifestSchema2))
+			_, _ = w.Write(manifest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(issuedScopes)
+	}
+}
+
+func TestRepositoryClient_Scopes(t *testing.T) {
+	server, issuedScopes := newScopedRegistry(t, true)
+
+	client, err := oci.NewRepositoryClient(strings.TrimPrefix(server.URL, "http://")+"/scoped", true)
+	assert.NilError(t, err)
+
+	tags, err := client.ListTags(oci.WithBasicAuth("navecd", "abcd"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tags, []string{"latest"})
+	assert.Assert(t, slices.Contains(issuedScopes(), "registry:catalog:*"))
+
+	image, err := client.Image("latest", oci.WithBasicAuth("navecd", "abcd"))
+	assert.NilError(t, err)
+	_, err = image.RawManifest()
+	assert.NilError(t, err)
+}
+
+func TestRepositoryClient_ListTags_PullScope(t *testing.T) {
+	server, issuedScopes := newScopedRegistry(t, false)
+
+	client, err := oci.NewRepositoryClient(strings.TrimPrefix(server.URL, "http://")+"/scoped", true)
+	assert.NilError(t, err)
+
+	tags, err := client.ListTags(oci.WithBasicAuth("navecd", "abcd"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tags, []string{"latest"})
+	assert.DeepEqual(t, issuedScopes(), []string{"repository:scoped:pull"})
+}
+
+func TestProjectClient_PushImageFromPath_Timeout(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-unblock:
+		}
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	projectDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(projectDir, "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	client, err := oci.NewRepositoryClient(strings.TrimPrefix(server.URL, "http://")+"/unresponsive", true)
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = oci.NewProjectClient(client).PushImageFromPath(
+		ctx,
+		"latest",
+		projectDir,
+		oci.WithRepositoryOption(oci.WithInsecure(true)),
+	)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Assert(t, time.Since(start) < 10*time.Second)
+}
+
+func TestProjectClient_RoundTrip(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectDir := t.TempDir()
+	err = os.MkdirAll(filepath.Join(projectDir, "infra"), 0700)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, "infra", "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		name              string
+		opts              []oci.ProjectClientOption
+		expectedMediaType types.MediaType
+	}{
+		{
+			name:              "Default",
+			expectedMediaType: oci.ContentLayerMediaType,
+		},
+		{
+			name:              "Zstd",
+			opts:              []oci.ProjectClientOption{oci.WithCompression(oci.ZstdCompression)},
+			expectedMediaType: oci.ZstdContentLayerMediaType,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := oci.NewRepositoryClient(registry.Addr()+"/"+strings.ToLower(tc.name), false)
+			assert.NilError(t, err)
+
+			projectClient := oci.NewProjectClient(client)
+			pushedDigest, err := projectClient.PushImageFromPath(context.Background(), "latest", projectDir, tc.opts...)
+			assert.NilError(t, err)
+
+			image, err := client.Image("latest")
+			assert.NilError(t, err)
+			layers, err := image.Layers()
+			assert.NilError(t, err)
+			assert.Equal(t, len(layers), 1)
+			mediaType, err := layers[0].MediaType()
+			assert.NilError(t, err)
+			assert.Equal(t, mediaType, tc.expectedMediaType)
+
+			targetDir := filepath.Join(t.TempDir(), "project")
+			loadedDigest, err := projectClient.LoadImage(
+				context.Background(),
+				"latest",
+				targetDir,
+				oci.WithCacheDir(t.TempDir()),
+			)
+			assert.NilError(t, err)
+			assert.Equal(t, loadedDigest, pushedDigest)
+
+			content, err := os.ReadFile(filepath.Join(targetDir, "infra", "file"))
+			assert.NilError(t, err)
+			assert.Equal(t, string(content), "content")
+		})
+	}
+}
+
+func TestProjectClient_LoadImage_MediaTypes(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	testCases := []struct {
+		name              string
+		configMediaType   types.MediaType
+		contentMediaType  types.MediaType
+		expectedErr       error
+		expectedErrString string
+	}{
+		{
+			name:              "FutureConfig",
+			configMediaType:   "application/vnd.navecd.config.v2+json",
+			contentMediaType:  "application/vnd.navecd.content.v2.tar+gzip",
+			expectedErr:       oci.ErrUnsupportedArtifactVersion,
+			expectedErrString: "upgrade Navecd to load this project artifact",
+		},
+		{
+			name:              "FutureContent",
+			configMediaType:   oci.ConfigMediaType,
+			contentMediaType:  "application/vnd.navecd.content.v2.tar+gzip",
+			expectedErr:       oci.ErrUnsupportedArtifactVersion,
+			expectedErrString: "upgrade Navecd to load this project artifact",
+		},
+		{
+			name:              "Foreign",
+			configMediaType:   types.OCIConfigJSON,
+			contentMediaType:  types.OCILayer,
+			expectedErr:       oci.ErrWrongMediaType,
+			expectedErrString: "wanted application/vnd.navecd.config.v1+json",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := oci.NewRepositoryClient(registry.Addr()+"/"+strings.ToLower(tc.name), false)
+			assert.NilError(t, err)
+
+			img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+			img = mutate.ConfigMediaType(img, tc.configMediaType)
+			img, err = mutate.Append(img, mutate.Addendum{
+				Layer: static.NewLayer([]byte("content"), tc.contentMediaType),
+			})
+			assert.NilError(t, err)
+			_, err = client.PushImage(img, "latest", "")
+			assert.NilError(t, err)
+
+			_, err = oci.NewProjectClient(client).LoadImage(
+				context.Background(),
+				"latest",
+				filepath.Join(t.TempDir(), "project"),
+				oci.WithCacheDir(t.TempDir()),
+			)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.ErrorContains(t, err, tc.expectedErrString)
+		})
+	}
+}
+
+func TestProjectClient_LoadImage_Interrupted(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(projectDir, "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	client, err := oci.NewRepositoryClient(registry.Addr()+"/interrupted", false)
+	assert.NilError(t, err)
+
+	projectClient := oci.NewProjectClient(client)
+	_, err = projectClient.PushImageFromPath(context.Background(), "latest", projectDir)
+	assert.NilError(t, err)
+
+	corrupt := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	corrupt = mutate.ConfigMediaType(corrupt, oci.ConfigMediaType)
+	corrupt, err = mutate.Append(corrupt, mutate.Addendum{
+		Layer: static.NewLayer([]byte("truncated"), oci.ContentLayerMediaType),
+	})
+	assert.NilError(t, err)
+	_, err = client.PushImage(corrupt, "corrupt", "")
+	assert.NilError(t, err)
+
+	cacheDir := t.TempDir()
+	targetDir := filepath.Join(t.TempDir(), "project")
+
+	// leftovers of an extraction interrupted before its completion marker was written
+	err = os.MkdirAll(targetDir, 0700)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(targetDir, "stale"), []byte("stale"), 0600)
+	assert.NilError(t, err)
+	err = os.MkdirAll(targetDir+"-staging", 0700)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(targetDir+"-staging", "partial"), []byte("partial"), 0600)
+	assert.NilError(t, err)
+
+	assertProject := func() {
+		entries, err := os.ReadDir(targetDir)
+		assert.NilError(t, err)
+		assert.Equal(t, len(entries), 1)
+		assert.Equal(t, entries[0].Name(), "file")
+
+		_, err = os.Stat(targetDir + "-staging")
+		assert.Assert(t, errors.Is(err, fs.ErrNotExist))
+	}
+
+	_, err = projectClient.LoadImage(context.Background(), "latest", targetDir, oci.WithCacheDir(cacheDir))
+	assert.NilError(t, err)
+	assertProject()
+
+	_, err = projectClient.LoadImage(context.Background(), "corrupt", targetDir, oci.WithCacheDir(cacheDir))
+	var unrecErr *oci.UnrecoverableError
+	assert.Assert(t, errors.As(err, &unrecErr))
+	assertProject()
+}
+
+func TestProjectClient_LoadImage_Cache(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	client, err := oci.NewRepositoryClient(registry.Addr()+"/cache", false)
+	assert.NilError(t, err)
+	projectClient := oci.NewProjectClient(client)
+
+	versions := []string{"1.0.0", "1.1.0", "1.2.0"}
+	for _, version := range versions {
+		projectDir := t.TempDir()
+		err = os.WriteFile(filepath.Join(projectDir, "version"), []byte(version), 0600)
+		assert.NilError(t, err)
+		_, err = projectClient.PushImageFromPath(context.Background(), version, projectDir)
+		assert.NilError(t, err)
+	}
+
+	cacheDir := t.TempDir()
+	completionDir := filepath.Join(cacheDir, "completion")
+	targetDir := filepath.Join(t.TempDir(), "project")
+	otherTargetDir := filepath.Join(t.TempDir(), "other")
+
+	countMarkers := func() int {
+		count := 0
+		err := filepath.WalkDir(completionDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if strings.HasSuffix(d.Name(), ".complete") {
+				count++
+			}
+			return nil
+		})
+		assert.NilError(t, err)
+		return count
+	}
+
+	// a marker of the former flat layout.
+	err = os.MkdirAll(completionDir, 0700)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(completionDir, "sha256:abc.complete"), nil, 0600)
+	assert.NilError(t, err)
+
+	_, err = projectClient.LoadImage(
+		context.Background(),
+		versions[0],
+		otherTargetDir,
+		oci.WithCacheDir(cacheDir),
+	)
+	assert.NilError(t, err)
+
+	for _, version := range versions {
+		_, err = projectClient.LoadImage(
+			context.Background(),
+			version,
+			targetDir,
+			oci.WithCacheDir(cacheDir),
+			oci.WithCacheRetention(time.Hour),
+		)
+		assert.NilError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(targetDir, "version"))
+		assert.NilError(t, err)
+		assert.Equal(t, string(content), version)
+	}
+
+	// markers of replaced versions are pruned, the other target dir is still within retention.
+	assert.Equal(t, countMarkers(), 2)
+
+	// extracting into one target dir does not invalidate the markers of others.
+	_, err = projectClient.LoadImage(
+		context.Background(),
+		versions[0],
+		otherTargetDir,
+		oci.WithCacheDir(cacheDir),
+		oci.WithCacheRetention(time.Hour),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, countMarkers(), 2)
+
+	entries, err := os.ReadDir(completionDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	expired := time.Now().Add(-2 * time.Hour)
+	for _, entry := range entries {
+		err := os.Chtimes(filepath.Join(completionDir, entry.Name()), expired, expired)
+		assert.NilError(t, err)
+	}
+
+	_, err = projectClient.LoadImage(
+		context.Background(),
+		versions[2],
+		targetDir,
+		oci.WithCacheDir(cacheDir),
+		oci.WithCacheRetention(time.Hour),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, countMarkers(), 1)
+
+	// the extracted artifact of the expired target dir is pruned together with its marker.
+	_, err = os.Stat(otherTargetDir)
+	assert.Assert(t, errors.Is(err, fs.ErrNotExist))
+	_, err = os.Stat(fmt.Sprintf("%s-bkp", otherTargetDir))
+	assert.Assert(t, errors.Is(err, fs.ErrNotExist))
+
+	content, err := os.ReadFile(filepath.Join(targetDir, "version"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), versions[2])
+}
+
+// tarGzip archives the given files in memory, preserving their names as they are.
+func tarGzip(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0600,
+			Size:     int64(len(content)),
+		})
+		assert.NilError(t, err)
+		_, err = tarWriter.Write(content)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tarWriter.Close())
+	assert.NilError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func TestProjectClient_LoadImage_Limits(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	testCases := []struct {
+		name        string
+		files       map[string][]byte
+		maxBytes    int64
+		expectedErr error
+	}{
+		{
+			name: "Oversized-Archive",
+			// zeros compress well, so that only the extracted size exceeds the limit.
+			files:       map[string][]byte{"bomb": make([]byte, 1<<20)},
+			maxBytes:    64 << 10,
+			expectedErr: oci.ErrArtifactTooLarge,
+		},
+		{
+			name:        "Oversized-Layer",
+			files:       map[string][]byte{"file": []byte("content")},
+			maxBytes:    8,
+			expectedErr: oci.ErrArtifactTooLarge,
+		},
+		{
+			name:        "Traversal",
+			files:       map[string][]byte{"file": []byte("content"), "../escape": []byte("content")},
+			maxBytes:    64 << 10,
+			expectedErr: oci.ErrIllegalArtifactPath,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := oci.NewRepositoryClient(registry.Addr()+"/"+strings.ToLower(tc.name), false)
+			assert.NilError(t, err)
+
+			img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+			img = mutate.ConfigMediaType(img, oci.ConfigMediaType)
+			img, err = mutate.Append(img, mutate.Addendum{
+				Layer: static.NewLayer(tarGzip(t, tc.files), oci.ContentLayerMediaType),
+			})
+			assert.NilError(t, err)
+			_, err = client.PushImage(img, "latest", "")
+			assert.NilError(t, err)
+
+			rootDir := t.TempDir()
+			targetDir := filepath.Join(rootDir, "project")
+			_, err = oci.NewProjectClient(client).LoadImage(
+				context.Background(),
+				"latest",
+				targetDir,
+				oci.WithCacheDir(t.TempDir()),
+				oci.WithMaxArtifactBytes(tc.maxBytes),
+			)
+			var unrecErr *oci.UnrecoverableError
+			assert.Assert(t, errors.As(err, &unrecErr))
+			assert.ErrorIs(t, err, tc.expectedErr)
+
+			entries, err := os.ReadDir(targetDir)
+			assert.NilError(t, err)
+			assert.Equal(t, len(entries), 0)
+
+			_, err = os.Stat(filepath.Join(rootDir, "escape"))
+			assert.Assert(t, errors.Is(err, fs.ErrNotExist))
+		})
+	}
+}
+
+func TestProjectClient_Annotations(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(projectDir, "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	client, err := oci.NewRepositoryClient(registry.Addr()+"/annotations", false)
+	assert.NilError(t, err)
+
+	projectClient := oci.NewProjectClient(client)
+	before := time.Now().Add(-time.Second)
+	pushedDigest, err := projectClient.PushImageFromPath(
+		context.Background(),
+		"latest",
+		projectDir,
+		oci.WithRevision("4f2c1e7"),
+	)
+	assert.NilError(t, err)
+
+	cacheDir := t.TempDir()
+	targetDir := filepath.Join(t.TempDir(), "project")
+	// the second load is served from the extracted artifact
+	for range 2 {
+		artifact, err := projectClient.LoadArtifact(
+			context.Background(),
+			"latest",
+			targetDir,
+			oci.WithCacheDir(cacheDir),
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, artifact.Digest, pushedDigest)
+		assert.Equal(t, artifact.Revision(), "4f2c1e7")
+		assert.Equal(t, artifact.Annotations[oci.VersionAnnotation], oci.Version)
+
+		created, err := time.Parse(time.RFC3339, artifact.Created())
+		assert.NilError(t, err)
+		assert.Assert(t, !created.Before(before.Truncate(time.Second)))
+	}
+}
+
+func TestProjectClient_Logger(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(projectDir, "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		name          string
+		level         int
+		expectedDebug bool
+	}{
+		{
+			name:          "Info",
+			level:         0,
+			expectedDebug: false,
+		},
+		{
+			name:          "Debug",
+			level:         1,
+			expectedDebug: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := oci.NewRepositoryClient(registry.Addr()+"/"+strings.ToLower(tc.name), false)
+			assert.NilError(t, err)
+
+			var logOutput bytes.Buffer
+			log := ctrlZap.New(
+				ctrlZap.WriteTo(&logOutput),
+				ctrlZap.Level(zapcore.Level(-tc.level)),
+			)
+
+			_, err = oci.NewProjectClient(client).PushImageFromPath(
+				context.Background(),
+				"latest",
+				projectDir,
+				oci.WithRepositoryOption(oci.WithLogger(log)),
+			)
+			assert.NilError(t, err)
+
+			output := logOutput.String()
+			assert.Equal(t, strings.Contains(output, "Registry request"), tc.expectedDebug)
+			assert.Equal(t, strings.Contains(output, "Pushed project artifact"), tc.expectedDebug)
+		})
+	}
+}
+
+func TestProjectClient_PushImageFromPath_Attestations(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectDir := t.TempDir()
+	err = os.MkdirAll(filepath.Join(projectDir, "infra"), 0700)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, "infra", "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	client, err := oci.NewRepositoryClient(registry.Addr()+"/attested", false)
+	assert.NilError(t, err)
+
+	digest, err := oci.NewProjectClient(client).PushImageFromPath(
+		context.Background(),
+		"latest",
+		projectDir,
+		oci.WithSBOM(true),
+		oci.WithProvenance(true),
+	)
+	assert.NilError(t, err)
+
+	referrers, err := client.Referrers(digest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(referrers), 2)
+
+	artifactTypes := make(map[string]string, len(referrers))
+	for _, referrer := range referrers {
+		artifactTypes[referrer.ArtifactType] = referrer.Digest.String()
+	}
+
+	sbomDigest, found := artifactTypes[oci.SBOMArtifactType]
+	assert.Assert(t, found)
+	sbom, err := crane.Pull(registry.Addr() + "/attested@" + sbomDigest)
+	assert.NilError(t, err)
+	layers, err := sbom.Layers()
+	assert.NilError(t, err)
+	assert.Equal(t, len(layers), 1)
+	reader, err := layers[0].Uncompressed()
+	assert.NilError(t, err)
+	defer reader.Close()
+	var document struct {
+		Files []struct {
+			FileName string `json:"fileName"`
+		} `json:"files"`
+	}
+	err = json.NewDecoder(reader).Decode(&document)
+	assert.NilError(t, err)
+	assert.Equal(t, len(document.Files), 1)
+	assert.Equal(t, document.Files[0].FileName, "./infra/file")
+
+	_, found = artifactTypes[oci.ProvenanceArtifactType]
+	assert.Assert(t, found)
+
+	unattested, err := oci.NewProjectClient(client).PushImageFromPath(
+		context.Background(),
+		"unattested",
+		t.TempDir(),
+	)
+	assert.NilError(t, err)
+
+	referrers, err = client.Referrers(unattested)
+	assert.NilError(t, err)
+	assert.Equal(t, len(referrers), 0)
+}
+
+func TestRepositoryClient_Proxy(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	proxy := proxytest.NewProxy()
+	defer proxy.Close()
+
+	projectDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(projectDir, "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	client, err := oci.NewRepositoryClient(registry.Addr()+"/proxied", false)
+	assert.NilError(t, err)
+
+	_, err = oci.NewProjectClient(client).PushImageFromPath(
+		context.Background(),
+		"latest",
+		projectDir,
+		oci.WithRepositoryOption(oci.WithProxy(proxy.URL())),
+	)
+	assert.NilError(t, err)
+
+	tags, err := client.ListTags(oci.WithProxy(proxy.URL()))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tags, []string{"latest"})
+
+	hosts := proxy.Hosts()
+	assert.Assert(t, len(hosts) != 0)
+	for _, host := range hosts {
+		assert.Equal(t, host, registry.Addr())
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+)
+
+var (
+	ErrInvalidRegistryCredentials = errors.New("Invalid registry credentials")
+)
+
+// RegistryCredential authenticates against a single registry either with a username and password or with a token.
+type RegistryCredential struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// Token is an access token, like a personal access token, which is sent instead of the password.
+	Token string `json:"token,omitempty"`
+}
+
+// BasicAuth returns the username and the password or token.
+func (credential RegistryCredential) BasicAuth() (string, string) {
+	if credential.Password != "" {
+		return credential.Username, credential.Password
+	}
+	return credential.Username, credential.Token
+}
+
+// RegistryCredentials map registry hosts, like ghcr.io or registry.internal:5000, to their credentials.
+// They provide static credentials for several registries at once in environments without workload identity.
+type RegistryCredentials map[string]RegistryCredential
+
+var _ authn.Keychain = RegistryCredentials(nil)
+
+// LoadRegistryCredentials reads registry credentials from a JSON file in the format:
+//
+//	{
+//		"ghcr.io": {"username": "user", "password": "secret"},
+//		"registry.internal": {"token": "token"}
+//	}
+func LoadRegistryCredentials(path string) (RegistryCredentials, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := RegistryCredentials{}
+	if err := json.Unmarshal(content, &credentials); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidRegistryCredentials, path, err)
+	}
+
+	for host, credential := range credentials {
+		if host == "" || strings.Contains(host, "/") {
+			return nil, fmt.Errorf("%w: expected a registry host, got %q", ErrInvalidRegistryCredentials, host)
+		}
+		if credential.Password == "" && credential.Token == "" {
+			return nil, fmt.Errorf("%w: %s has neither a password nor a token", ErrInvalidRegistryCredentials, host)
+		}
+	}
+
+	return credentials, nil
+}
+
+// Lookup returns the credential of the registry host of the given reference.
+// References may start with a scheme like oci:// or https:// and contain a path.
+func (credentials RegistryCredentials) Lookup(ref string) (RegistryCredential, bool) {
+	if len(credentials) == 0 {
+		return RegistryCredential{}, false
+	}
+
+	if index := strings.Index(ref, "://"); index != -1 {
+		ref = ref[index+3:]
+	}
+	host, _, _ := strings.Cut(ref, "/")
+
+	credential, found := credentials[host]
+	return credential, found
+}
+
+// Resolve implements [authn.Keychain], so that the credentials can be passed to [WithKeychain].
+// Registries without credentials are accessed anonymously.
+func (credentials RegistryCredentials) Resolve(resource authn.Resource) (authn.Authenticator, error) {
+	credential, found := credentials.Lookup(resource.RegistryStr())
+	if !found {
+		return authn.Anonymous, nil
+	}
+
+	username, password := credential.BasicAuth()
+	return authn.FromConfig(authn.AuthConfig{
+		Username: username,
+		Password: password,
+	}), nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kharf/navecd/internal/dnstest"
+	"github.com/kharf/navecd/internal/ocitest"
+	"github.com/kharf/navecd/pkg/oci"
+	"gotest.tools/v3/assert"
+)
+
+func TestLoadRegistryCredentials(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    oci.RegistryCredentials
+		expectedErr error
+	}{
+		{
+			name: "Valid",
+			content: `{
+				"ghcr.io": {"username": "navecd", "password": "abcd"},
+				"registry.internal:5000": {"token": "efgh"}
+			}`,
+			expected: oci.RegistryCredentials{
+				"ghcr.io":                {Username: "navecd", Password: "abcd"},
+				"registry.internal:5000": {Token: "efgh"},
+			},
+		},
+		{
+			name:        "Path",
+			content:     `{"ghcr.io/kharf": {"username": "navecd", "password": "abcd"}}`,
+			expectedErr: oci.ErrInvalidRegistryCredentials,
+		},
+		{
+			name:        "No-Secret",
+			content:     `{"ghcr.io": {"username": "navecd"}}`,
+			expectedErr: oci.ErrInvalidRegistryCredentials,
+		},
+		{
+			name:        "Malformed",
+			content:     `ghcr.io: navecd`,
+			expectedErr: oci.ErrInvalidRegistryCredentials,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "credentials.json")
+			err := os.WriteFile(path, []byte(tc.content), 0600)
+			assert.NilError(t, err)
+
+			credentials, err := oci.LoadRegistryCredentials(path)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, credentials, tc.expected)
+		})
+	}
+}
+
+func TestRegistryCredentials_Lookup(t *testing.T) {
+	credentials := oci.RegistryCredentials{
+		"ghcr.io":                {Username: "navecd", Password: "abcd"},
+		"registry.internal:5000": {Token: "efgh"},
+	}
+
+	credential, found := credentials.Lookup("oci://ghcr.io/kharf/charts")
+	assert.Assert(t, found)
+	assert.Equal(t, credential.Password, "abcd")
+
+	credential, found = credentials.Lookup("registry.internal:5000/project")
+	assert.Assert(t, found)
+	username, password := credential.BasicAuth()
+	assert.Equal(t, username, "")
+	assert.Equal(t, password, "efgh")
+
+	_, found = credentials.Lookup("https://registry.internal/charts")
+	assert.Assert(t, !found)
+}
+
+func TestRegistryCredentials_Resolve(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	passwordRegistry, err := ocitest.NewTLSRegistry(true, "")
+	assert.NilError(t, err)
+	defer passwordRegistry.Close()
+
+	tokenRegistry, err := ocitest.NewTLSRegistry(true, "")
+	assert.NilError(t, err)
+	defer tokenRegistry.Close()
+
+	anonymousRegistry, err := ocitest.NewTLSRegistry(true, "")
+	assert.NilError(t, err)
+	defer anonymousRegistry.Close()
+
+	credentials := oci.RegistryCredentials{
+		passwordRegistry.Addr(): {Username: "navecd", Password: "abcd"},
+		tokenRegistry.Addr():    {Username: "navecd", Token: "abcd"},
+	}
+
+	projectDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(projectDir, "file"), []byte("content"), 0600)
+	assert.NilError(t, err)
+
+	for _, registry := range []*ocitest.Registry{passwordRegistry, tokenRegistry} {
+		client, err := oci.NewRepositoryClient(registry.Addr()+"/credentials", false)
+		assert.NilError(t, err)
+
+		projectClient := oci.NewProjectClient(client)
+		pushedDigest, err := projectClient.PushImageFromPath(
+			context.Background(),
+			"latest",
+			projectDir,
+			oci.WithRepositoryOption(oci.WithKeychain(credentials)),
+		)
+		assert.NilError(t, err)
+
+		digest, err := projectClient.LoadImage(
+			context.Background(),
+			"latest",
+			filepath.Join(t.TempDir(), "project"),
+			oci.WithCacheDir(t.TempDir()),
+			oci.WithRepositoryOption(oci.WithKeychain(credentials)),
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, digest, pushedDigest)
+	}
+
+	client, err := oci.NewRepositoryClient(anonymousRegistry.Addr()+"/credentials", false)
+	assert.NilError(t, err)
+
+	_, err = oci.NewProjectClient(client).PushImageFromPath(
+		context.Background(),
+		"latest",
+		projectDir,
+		oci.WithRepositoryOption(oci.WithKeychain(credentials)),
+	)
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidMirror = errors.New("Invalid registry mirror")
+)
+
+// Mirrors are rewrite rules mapping registry host prefixes to the hosts of their mirrors.
+// They are used to route all registry traffic through internal mirrors, for example in air-gapped environments.
+// A prefix can contain a path, like docker.io/library, to only mirror parts of a registry.
+type Mirrors map[string]string
+
+// ParseMirror parses a rewrite rule in the format prefix=mirror.
+func ParseMirror(rule string) (string, string, error) {
+	prefix, mirror, found := strings.Cut(rule, "=")
+	if !found || prefix == "" || mirror == "" {
+		return "", "", fmt.Errorf("%w: expected format prefix=mirror, got %s", ErrInvalidMirror, rule)
+	}
+	return prefix, mirror, nil
+}
+
+// Rewrite replaces the longest matching prefix of the given reference with its mirror.
+// References may start with a scheme like oci:// or https://, which is preserved.
+// The reference is returned unchanged, if no prefix matches.
+func (mirrors Mirrors) Rewrite(ref string) string {
+	if len(mirrors) == 0 {
+		return ref
+	}
+
+	scheme := ""
+	withoutScheme := ref
+	if index := strings.Index(ref, "://"); index != -1 {
+		scheme = ref[:index+3]
+		withoutScheme = ref[index+3:]
+	}
+
+	var matchedPrefix string
+	for prefix := range mirrors {
+		if len(prefix) <= len(matchedPrefix) || !strings.HasPrefix(withoutScheme, prefix) {
+			continue
+		}
+
+		// only match whole host or path segments
+		rest := withoutScheme[len(prefix):]
+		if rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, ":") {
+			continue
+		}
+
+		matchedPrefix = prefix
+	}
+
+	if matchedPrefix == "" {
+		return ref
+	}
+
+	return scheme + mirrors[matchedPrefix] + withoutScheme[len(matchedPrefix):]
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci_test
+
+import (
+	"testing"
+
+	"github.com/kharf/navecd/pkg/oci"
+	"gotest.tools/v3/assert"
+)
+
+func TestMirrors_Rewrite(t *testing.T) {
+	mirrors := oci.Mirrors{
+		"docker.io":         "mirror.internal/docker",
+		"docker.io/library": "mirror.internal/library",
+		"ghcr.io":           "mirror.internal:5000/ghcr",
+	}
+
+	testCases := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{
+			name:     "Host",
+			ref:      "docker.io/kharf/navecd",
+			expected: "mirror.internal/docker/kharf/navecd",
+		},
+		{
+			name:     "Longest-Prefix",
+			ref:      "docker.io/library/nginx:1.27",
+			expected: "mirror.internal/library/nginx:1.27",
+		},
+		{
+			name:     "OCI-Scheme",
+			ref:      "oci://ghcr.io/kharf/charts",
+			expected: "oci://mirror.internal:5000/ghcr/kharf/charts",
+		},
+		{
+			name:     "HTTPS-Scheme",
+			ref:      "https://docker.io/charts",
+			expected: "https://mirror.internal/docker/charts",
+		},
+		{
+			name:     "Partial-Host",
+			ref:      "docker.iox/kharf/navecd",
+			expected: "docker.iox/kharf/navecd",
+		},
+		{
+			name:     "No-Match",
+			ref:      "quay.io/kharf/navecd",
+			expected: "quay.io/kharf/navecd",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, mirrors.Rewrite(tc.ref), tc.expected)
+		})
+	}
+}
+
+func TestParseMirror(t *testing.T) {
+	prefix, mirror, err := oci.ParseMirror("docker.io=mirror.internal/docker")
+	assert.NilError(t, err)
+	assert.Equal(t, prefix, "docker.io")
+	assert.Equal(t, mirror, "mirror.internal/docker")
+
+	_, _, err = oci.ParseMirror("docker.io")
+	assert.ErrorIs(t, err, oci.ErrInvalidMirror)
+}
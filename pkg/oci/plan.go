@@ -0,0 +1,101 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// PlanArtifactType is the artifact type of plans, which describe the changes a project would apply to a cluster.
+// Plans are pushed as standalone artifacts, so that they cannot be confused with project artifacts.
+const PlanArtifactType = "application/vnd.navecd.plan.v1+json"
+
+// PushPlan pushes the JSON encoded plan with the given tag as artifact of type [PlanArtifactType] and returns its digest.
+func (client *ProjectClient) PushPlan(ctx context.Context, tag string, plan []byte, opts ...ProjectClientOption) (string, error) {
+	options := &projectClientOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, PlanArtifactType)
+	img, err := mutate.Append(img, mutate.Addendum{
+		Layer: static.NewLayer(plan, PlanArtifactType),
+	})
+	if err != nil {
+		return "", err
+	}
+	annotated, ok := mutate.Annotations(img, map[string]string{
+		CreatedAnnotation: time.Now().UTC().Format(time.RFC3339),
+		VersionAnnotation: Version,
+	}).(v1.Image)
+	if !ok {
+		return "", errors.New("unable to annotate plan artifact")
+	}
+
+	digest, err := client.PushImage(annotated, tag, "", append(options.repoOpts, WithContext(ctx))...)
+	if err != nil {
+		return "", err
+	}
+	options.logger().V(1).Info("Pushed plan artifact", "repository", client.Name(), "tag", tag, "digest", digest)
+
+	return digest, nil
+}
+
+// LoadPlan returns the JSON encoded plan of the artifact with the given tag.
+// It fails with ErrWrongMediaType, if the artifact is not a plan.
+func (client *ProjectClient) LoadPlan(ctx context.Context, tag string, opts ...ProjectClientOption) ([]byte, error) {
+	options := &projectClientOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	image, err := client.Image(tag, append(options.repoOpts, WithContext(ctx))...)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := image.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Config.MediaType != PlanArtifactType || len(manifest.Layers) != 1 {
+		return nil, fmt.Errorf("%w: got %s, wanted %s", ErrWrongMediaType, manifest.Config.MediaType, PlanArtifactType)
+	}
+
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := layers[0].Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
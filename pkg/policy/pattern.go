@@ -0,0 +1,268 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// errConditionNotMet is returned, if a conditional anchor is not met.
+// The surrounding list entry or, at the top level, the whole resource is not validated then.
+var errConditionNotMet = errors.New("condition not met")
+
+// anchor is the kind of a Kyverno anchor wrapping a key of a pattern, e.g. =(securityContext).
+type anchor string
+
+const (
+	noAnchor          anchor = ""
+	conditionAnchor   anchor = "("
+	equalityAnchor    anchor = "=("
+	negationAnchor    anchor = "X("
+	globalAnchor      anchor = "<("
+	existenceAnchor   anchor = "^("
+	addIfAbsentAnchor anchor = "+("
+)
+
+func parseAnchor(key string) (anchor, string) {
+	if !strings.HasSuffix(key, ")") {
+		return noAnchor, key
+	}
+	for _, candidate := range []anchor{equalityAnchor, negationAnchor, globalAnchor, existenceAnchor, addIfAbsentAnchor, conditionAnchor} {
+		if strings.HasPrefix(key, string(candidate)) {
+			return candidate, key[len(candidate) : len(key)-1]
+		}
+	}
+	return noAnchor, key
+}
+
+// matchPattern validates value against the Kyverno pattern, where path is the location of value in the resource.
+func matchPattern(value any, pattern any, path string) error {
+	if err := matchValue(value, pattern, path); err != nil {
+		if errors.Is(err, errConditionNotMet) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func matchValue(value any, pattern any, path string) error {
+	switch pattern := pattern.(type) {
+	case map[string]any:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return mismatch(path, "an object", value)
+		}
+		return matchObject(object, pattern, path)
+
+	case []any:
+		list, ok := value.([]any)
+		if !ok {
+			return mismatch(path, "a list", value)
+		}
+		return matchList(list, pattern, path)
+
+	default:
+		if !matchScalar(value, pattern) {
+			return mismatch(path, pattern, value)
+		}
+		return nil
+	}
+}
+
+func matchObject(object map[string]any, pattern map[string]any, path string) error {
+	// keys are sorted to report violations deterministically.
+	keys := slices.Sorted(maps.Keys(pattern))
+
+	// conditions decide whether the object is validated at all, which is why they are evaluated first.
+	for _, key := range keys {
+		fieldPattern := pattern[key]
+		anchor, field := parseAnchor(key)
+		if anchor != conditionAnchor {
+			continue
+		}
+		fieldValue, found := object[field]
+		if !found || matchValue(fieldValue, fieldPattern, path+field+"/") != nil {
+			return errConditionNotMet
+		}
+	}
+
+	for _, key := range keys {
+		fieldPattern := pattern[key]
+		anchor, field := parseAnchor(key)
+		fieldPath := path + field + "/"
+		fieldValue, found := object[field]
+		switch anchor {
+		case conditionAnchor:
+			continue
+
+		case equalityAnchor:
+			if !found {
+				continue
+			}
+
+		case negationAnchor:
+			if found {
+				return fmt.Errorf("%w at %s: field must not be set", ErrPatternNotSatisfied, fieldPath)
+			}
+			continue
+
+		case globalAnchor, existenceAnchor, addIfAbsentAnchor:
+			return fmt.Errorf("%w: anchor %s) at %s", ErrUnsupportedPolicy, anchor, fieldPath)
+
+		default:
+			if !found {
+				return fmt.Errorf("%w at %s: field is required", ErrPatternNotSatisfied, fieldPath)
+			}
+		}
+
+		if err := matchValue(fieldValue, fieldPattern, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchList validates every entry of the list against the patterns of the list pattern.
+// Entries, which do not meet the conditions of a pattern, are not validated by it.
+func matchList(list []any, pattern []any, path string) error {
+	for i, entry := range list {
+		entryPath := fmt.Sprintf("%s%d/", path, i)
+		var entryErr error
+		for _, entryPattern := range pattern {
+			err := matchValue(entry, entryPattern, entryPath)
+			if err == nil || errors.Is(err, errConditionNotMet) {
+				entryErr = nil
+				break
+			}
+			entryErr = err
+		}
+		if entryErr != nil {
+			return entryErr
+		}
+	}
+	return nil
+}
+
+// matchScalar compares a scalar value with a pattern, which is either a literal
+// or a string of alternatives separated by |, each of which consists of operands separated by &.
+// Operands support the wildcards * and ? and the operators !, >, >=, < and <=.
+func matchScalar(value any, pattern any) bool {
+	switch pattern := pattern.(type) {
+	case nil:
+		return value == nil
+	case string:
+		for _, alternative := range strings.Split(pattern, "|") {
+			matches := true
+			for _, operand := range strings.Split(alternative, "&") {
+				if !matchOperand(value, strings.TrimSpace(operand)) {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				return true
+			}
+		}
+		return false
+	default:
+		return format(value) == format(pattern)
+	}
+}
+
+func matchOperand(value any, operand string) bool {
+	for _, operator := range []string{">=", "<=", ">", "<"} {
+		if threshold, isComparison := strings.CutPrefix(operand, operator); isComparison {
+			return compare(value, operator, threshold)
+		}
+	}
+
+	if negated, isNegation := strings.CutPrefix(operand, "!"); isNegation {
+		return !wildcardMatch(negated, format(value))
+	}
+
+	return wildcardMatch(operand, format(value))
+}
+
+func compare(value any, operator string, threshold string) bool {
+	number, err := strconv.ParseFloat(format(value), 64)
+	if err != nil {
+		return false
+	}
+	limit, err := strconv.ParseFloat(strings.TrimSpace(threshold), 64)
+	if err != nil {
+		return false
+	}
+
+	switch operator {
+	case ">=":
+		return number >= limit
+	case "<=":
+		return number <= limit
+	case ">":
+		return number > limit
+	default:
+		return number < limit
+	}
+}
+
+// format returns the string form of a scalar, so that e.g. the pattern "false" matches the value false.
+func format(value any) string {
+	if value == nil {
+		return ""
+	}
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// wildcardMatch reports whether s matches the pattern, in which * matches any sequence of characters and ? a single character.
+func wildcardMatch(pattern string, s string) bool {
+	patternRunes := []rune(pattern)
+	runes := []rune(s)
+	p, i := 0, 0
+	star, starI := -1, 0
+	for i < len(runes) {
+		switch {
+		case p < len(patternRunes) && (patternRunes[p] == '?' || patternRunes[p] == runes[i]):
+			p++
+			i++
+		case p < len(patternRunes) && patternRunes[p] == '*':
+			star, starI = p, i
+			p++
+		case star != -1:
+			p = star + 1
+			starI++
+			i = starI
+		default:
+			return false
+		}
+	}
+	for p < len(patternRunes) && patternRunes[p] == '*' {
+		p++
+	}
+	return p == len(patternRunes)
+}
+
+func mismatch(path string, expected any, got any) error {
+	return fmt.Errorf("%w at %s: expected %v, got %v", ErrPatternNotSatisfied, path, expected, got)
+}
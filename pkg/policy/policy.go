@@ -0,0 +1,361 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kharf/navecd/pkg/component"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrLoadPolicies        = errors.New("Could not load policies")
+	ErrUnsupportedPolicy   = errors.New("Unsupported policy")
+	ErrPatternNotSatisfied = errors.New("Pattern not satisfied")
+)
+
+const (
+	kyvernoGroup = "kyverno.io"
+
+	// autogenAnnotation restricts the Pod controllers, which Pod policies are applied to. none disables them.
+	autogenAnnotation = "pod-policies.kyverno.io/autogen-controllers"
+)
+
+// podTemplatePaths maps the Pod controllers to the path of their Pod template.
+// Like Kyverno, rules matching Pods are applied to the Pod templates of these controllers as well.
+var podTemplatePaths = map[string][]string{
+	"Deployment":            {"spec", "template"},
+	"StatefulSet":           {"spec", "template"},
+	"DaemonSet":             {"spec", "template"},
+	"ReplicaSet":            {"spec", "template"},
+	"ReplicationController": {"spec", "template"},
+	"Job":                   {"spec", "template"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+}
+
+// Policy is a validate rule of a Kyverno ClusterPolicy or Policy, which every matching rendered manifest has to satisfy.
+// Rules are evaluated with the pattern and anyPattern semantics of Kyverno, including anchors, wildcards and operators,
+// and are applied to the Pod templates of Pod controllers, if they match Pods.
+// Rules relying on a cluster, like preconditions, exclusions or deny conditions, are not supported.
+type Policy struct {
+	// Name of the policy and its rule, e.g. disallow-privileged-containers/privileged-containers.
+	Name    string
+	Message string
+
+	// Kinds the rule applies to in the Kyverno format, e.g. Pod or apps/v1/Deployment. An empty list matches every kind.
+	Kinds []string
+
+	// Namespace of a namespaced Kyverno Policy, which only applies to manifests of its namespace.
+	Namespace string
+
+	// Namespaces the rule applies to. An empty list matches every namespace.
+	Namespaces []string
+
+	// Autogen reports whether the rule is applied to the Pod templates of Pod controllers.
+	Autogen bool
+
+	// Patterns of the rule. A manifest satisfies the rule, if it matches any of them.
+	Patterns []any
+}
+
+// Violation describes a component not satisfying a policy.
+type Violation struct {
+	Policy      string
+	ComponentID string
+	Message     string
+	Err         error
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: policy %s violated: %s: %v", v.ComponentID, v.Policy, v.Message, v.Err)
+}
+
+type kyvernoPolicy struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Rules []kyvernoRule `json:"rules"`
+	} `json:"spec"`
+}
+
+type kyvernoRule struct {
+	Name          string           `json:"name"`
+	Match         kyvernoMatch     `json:"match"`
+	Exclude       *kyvernoMatch    `json:"exclude"`
+	Preconditions any              `json:"preconditions"`
+	Context       any              `json:"context"`
+	Validate      *kyvernoValidate `json:"validate"`
+}
+
+type kyvernoMatch struct {
+	Any       []kyvernoFilter   `json:"any"`
+	All       []kyvernoFilter   `json:"all"`
+	Resources *kyvernoResources `json:"resources"`
+}
+
+type kyvernoFilter struct {
+	Resources kyvernoResources `json:"resources"`
+}
+
+type kyvernoResources struct {
+	Kinds      []string `json:"kinds"`
+	Namespaces []string `json:"namespaces"`
+}
+
+type kyvernoValidate struct {
+	Message    string `json:"message"`
+	Pattern    any    `json:"pattern"`
+	AnyPattern []any  `json:"anyPattern"`
+}
+
+// Load reads all Kyverno ClusterPolicies and Policies declared in the YAML files of the given directory and its subdirectories.
+func Load(dir string) ([]Policy, error) {
+	var policies []Policy
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+
+		filePolicies, err := loadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		policies = append(policies, filePolicies...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLoadPolicies, err)
+	}
+
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("%w: no Kyverno policies found in %s", ErrLoadPolicies, dir)
+	}
+
+	return policies, nil
+}
+
+func loadFile(path string) ([]Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	var policies []Policy
+	for document := 0; ; document++ {
+		data, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", document, err)
+		}
+
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", document, err)
+		}
+		// empty documents and documents only containing comments.
+		trimmed := bytes.TrimSpace(jsonData)
+		if len(trimmed) == 0 || string(trimmed) == "null" {
+			continue
+		}
+
+		var kyverno kyvernoPolicy
+		if err := yaml.Unmarshal(jsonData, &kyverno); err != nil {
+			return nil, fmt.Errorf("document %d: %w", document, err)
+		}
+
+		documentPolicies, err := convert(kyverno)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", document, err)
+		}
+		policies = append(policies, documentPolicies...)
+	}
+
+	return policies, nil
+}
+
+// convert returns a policy per validate rule of the Kyverno policy.
+func convert(kyverno kyvernoPolicy) ([]Policy, error) {
+	group, _, _ := strings.Cut(kyverno.APIVersion, "/")
+	if group != kyvernoGroup || (kyverno.Kind != "ClusterPolicy" && kyverno.Kind != "Policy") {
+		return nil, fmt.Errorf(
+			"%w: expected a ClusterPolicy or Policy of %s, got %s %s",
+			ErrUnsupportedPolicy,
+			kyvernoGroup,
+			kyverno.APIVersion,
+			kyverno.Kind,
+		)
+	}
+
+	policies := make([]Policy, 0, len(kyverno.Spec.Rules))
+	for _, rule := range kyverno.Spec.Rules {
+		name := fmt.Sprintf("%s/%s", kyverno.Metadata.Name, rule.Name)
+		if err := checkRule(rule); err != nil {
+			return nil, fmt.Errorf("%w: rule %s: %w", ErrUnsupportedPolicy, name, err)
+		}
+
+		policy := Policy{
+			Name:    name,
+			Message: rule.Validate.Message,
+			Autogen: kyverno.Metadata.Annotations[autogenAnnotation] != "none",
+		}
+		if kyverno.Kind == "Policy" {
+			policy.Namespace = kyverno.Metadata.Namespace
+		}
+
+		filters := slices.Concat(rule.Match.Any, rule.Match.All)
+		if rule.Match.Resources != nil {
+			filters = append(filters, kyvernoFilter{Resources: *rule.Match.Resources})
+		}
+		for _, filter := range filters {
+			policy.Kinds = append(policy.Kinds, filter.Resources.Kinds...)
+			policy.Namespaces = append(policy.Namespaces, filter.Resources.Namespaces...)
+		}
+
+		if rule.Validate.Pattern != nil {
+			policy.Patterns = []any{rule.Validate.Pattern}
+		} else {
+			policy.Patterns = rule.Validate.AnyPattern
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// checkRule errors, if the rule cannot be evaluated against rendered manifests without a cluster.
+func checkRule(rule kyvernoRule) error {
+	switch {
+	case rule.Validate == nil:
+		return errors.New("only validate rules are supported")
+	case rule.Validate.Pattern == nil && len(rule.Validate.AnyPattern) == 0:
+		return errors.New("only validate rules with a pattern or anyPattern are supported")
+	case rule.Exclude != nil:
+		return errors.New("exclude is not supported")
+	case rule.Preconditions != nil:
+		return errors.New("preconditions are not supported")
+	case rule.Context != nil:
+		return errors.New("context is not supported")
+	case len(rule.Match.All) > 1:
+		return errors.New("match.all with more than one filter is not supported")
+	}
+	return nil
+}
+
+// Evaluate checks the rendered manifests of the given components against all policies.
+// Helm releases are not rendered without a cluster and therefore skipped.
+func Evaluate(policies []Policy, instances []component.Instance) []Violation {
+	var violations []Violation
+	for _, instance := range instances {
+		manifest, ok := instance.(*component.Manifest)
+		if !ok {
+			continue
+		}
+
+		for _, policy := range policies {
+			resource, matches := policy.resource(manifest.Content.Unstructured)
+			if !matches {
+				continue
+			}
+
+			if err := policy.validate(resource); err != nil {
+				violations = append(violations, Violation{
+					Policy:      policy.Name,
+					ComponentID: manifest.ID,
+					Message:     policy.Message,
+					Err:         err,
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// resource returns the part of obj the policy validates, which is the Pod template for Pod controllers,
+// and whether the policy applies to obj at all.
+func (policy Policy) resource(obj *unstructured.Unstructured) (map[string]any, bool) {
+	if policy.Namespace != "" && obj.GetNamespace() != policy.Namespace {
+		return nil, false
+	}
+	if len(policy.Namespaces) != 0 && !slices.ContainsFunc(policy.Namespaces, func(namespace string) bool {
+		return wildcardMatch(namespace, obj.GetNamespace())
+	}) {
+		return nil, false
+	}
+
+	if len(policy.Kinds) == 0 || slices.ContainsFunc(policy.Kinds, func(kind string) bool {
+		return kindMatches(kind, obj.GetAPIVersion(), obj.GetKind())
+	}) {
+		return obj.Object, true
+	}
+
+	templatePath, isController := podTemplatePaths[obj.GetKind()]
+	if !policy.Autogen || !isController || !slices.ContainsFunc(policy.Kinds, func(kind string) bool {
+		return kindMatches(kind, "v1", "Pod")
+	}) {
+		return nil, false
+	}
+
+	template, found, err := unstructured.NestedMap(obj.Object, templatePath...)
+	if err != nil || !found {
+		return nil, false
+	}
+	return template, true
+}
+
+// kindMatches reports whether the Kyverno kind, e.g. Pod, apps/v1/Deployment or v1/ConfigMap, matches an object.
+func kindMatches(kind string, apiVersion string, objKind string) bool {
+	index := strings.LastIndex(kind, "/")
+	if index == -1 {
+		return wildcardMatch(kind, objKind)
+	}
+	return wildcardMatch(kind[index+1:], objKind) && wildcardMatch(kind[:index], apiVersion)
+}
+
+func (policy Policy) validate(resource map[string]any) error {
+	var errs []error
+	for _, pattern := range policy.Patterns {
+		if err := matchPattern(resource, pattern, "/"); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
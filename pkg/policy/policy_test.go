@@ -0,0 +1,377 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kharf/navecd/internal/txtar"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/policy"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newPod(name string, privileged bool) *component.Manifest {
+	return &component.Manifest{
+		ID: name + "_test__Pod",
+		Content: component.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": map[string]any{
+						"name":      name,
+						"namespace": "test",
+					},
+					"spec": map[string]any{
+						"containers": []any{
+							map[string]any{
+								"name":  "test",
+								"image": "test",
+								"securityContext": map[string]any{
+									"privileged": privileged,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newDeployment(name string, privileged bool) *component.Manifest {
+	pod := newPod(name, privileged)
+	return &component.Manifest{
+		ID: name + "_test_apps_Deployment",
+		Content: component.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      name,
+						"namespace": "test",
+					},
+					"spec": map[string]any{
+						"template": map[string]any{
+							"spec": pod.Content.Object["spec"],
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// disallowPrivileged is the disallow-privileged-containers policy of the Kyverno pod security policy library.
+const disallowPrivileged = `
+-- pod-security/disallow-privileged-containers.yaml --
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: disallow-privileged-containers
+spec:
+  validationFailureAction: Enforce
+  background: true
+  rules:
+    - name: privileged-containers
+      match:
+        any:
+          - resources:
+              kinds:
+                - Pod
+      validate:
+        message: >-
+          Privileged mode is disallowed.
+        pattern:
+          spec:
+            =(ephemeralContainers):
+              - =(securityContext):
+                  =(privileged): "false"
+            =(initContainers):
+              - =(securityContext):
+                  =(privileged): "false"
+            containers:
+              - =(securityContext):
+                  =(privileged): "false"
+-- README.md --
+not a policy
+`
+
+func TestEvaluate(t *testing.T) {
+	policiesDir := t.TempDir()
+	_, err := txtar.Create(policiesDir, strings.NewReader(disallowPrivileged))
+	assert.NilError(t, err)
+
+	policies, err := policy.Load(policiesDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies), 1)
+
+	namespace := &component.Manifest{
+		ID: "test___Namespace",
+		Content: component.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Namespace",
+					"metadata": map[string]any{
+						"name": "test",
+					},
+				},
+			},
+		},
+	}
+
+	violations := policy.Evaluate(policies, []component.Instance{
+		namespace,
+		newPod("allowed", false),
+		newPod("privileged", true),
+		newDeployment("allowed", false),
+		newDeployment("privileged", true),
+	})
+	assert.Equal(t, len(violations), 2)
+	assert.Equal(t, violations[0].Policy, "disallow-privileged-containers/privileged-containers")
+	assert.Equal(t, violations[0].ComponentID, "privileged_test__Pod")
+	assert.Equal(t, violations[0].Message, "Privileged mode is disallowed.")
+	assert.ErrorIs(t, violations[0].Err, policy.ErrPatternNotSatisfied)
+	assert.ErrorContains(t, violations[0].Err, "/spec/containers/0/securityContext/privileged/")
+	// rules matching Pods apply to the Pod templates of Pod controllers.
+	assert.Equal(t, violations[1].ComponentID, "privileged_test_apps_Deployment")
+}
+
+func TestEvaluate_Patterns(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   string
+		violated bool
+	}{
+		{
+			name: "Wildcard",
+			policy: `
+      validate:
+        pattern:
+          spec:
+            containers:
+              - image: "t?s*"`,
+			violated: false,
+		},
+		{
+			name: "Negation",
+			policy: `
+      validate:
+        pattern:
+          spec:
+            containers:
+              - image: "!test"`,
+			violated: true,
+		},
+		{
+			name: "Alternatives",
+			policy: `
+      validate:
+        pattern:
+          spec:
+            containers:
+              - name: "app|test"`,
+			violated: false,
+		},
+		{
+			name: "Missing-Field",
+			policy: `
+      validate:
+        pattern:
+          spec:
+            hostNetwork: false`,
+			violated: true,
+		},
+		{
+			name: "Negation-Anchor",
+			policy: `
+      validate:
+        pattern:
+          spec:
+            X(hostNetwork): "null"`,
+			violated: false,
+		},
+		{
+			name: "Condition-Not-Met",
+			policy: `
+      validate:
+        pattern:
+          spec:
+            containers:
+              - (name): "app"
+                image: "app:*"`,
+			violated: false,
+		},
+		{
+			name: "Condition-Met",
+			policy: `
+      validate:
+        pattern:
+          spec:
+            containers:
+              - (name): "test"
+                image: "app:*"`,
+			violated: true,
+		},
+		{
+			name: "Any-Pattern",
+			policy: `
+      validate:
+        anyPattern:
+          - spec:
+              hostNetwork: false
+          - spec:
+              containers:
+                - name: test`,
+			violated: false,
+		},
+		{
+			name: "Other-Namespace",
+			policy: `
+          - resources:
+              kinds:
+                - Pod
+              namespaces:
+                - prod-*
+      validate:
+        pattern:
+          spec:
+            hostNetwork: false`,
+			violated: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			match := `
+      match:
+        any:
+          - resources:
+              kinds:
+                - Pod`
+			if strings.HasPrefix(tc.policy, "\n          - resources:") {
+				match = `
+      match:
+        any:`
+			}
+			policiesDir := t.TempDir()
+			_, err := txtar.Create(policiesDir, strings.NewReader(`
+-- policy.yaml --
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: test
+spec:
+  rules:
+    - name: test`+match+tc.policy+"\n"))
+			assert.NilError(t, err)
+
+			policies, err := policy.Load(policiesDir)
+			assert.NilError(t, err)
+
+			violations := policy.Evaluate(policies, []component.Instance{newPod("pod", false)})
+			assert.Equal(t, len(violations) != 0, tc.violated, "%v", violations)
+		})
+	}
+}
+
+func TestLoad_Unsupported(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+	}{
+		{
+			name: "Not-Kyverno",
+			policy: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test`,
+		},
+		{
+			name: "Mutate",
+			policy: `
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: test
+spec:
+  rules:
+    - name: test
+      match:
+        any:
+          - resources:
+              kinds:
+                - Pod
+      mutate:
+        patchStrategicMerge:
+          metadata:
+            labels:
+              team: navecd`,
+		},
+		{
+			name: "Deny",
+			policy: `
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: test
+spec:
+  rules:
+    - name: test
+      match:
+        any:
+          - resources:
+              kinds:
+                - Pod
+      validate:
+        deny:
+          conditions:
+            any:
+              - key: "{{request.operation}}"
+                operator: Equals
+                value: DELETE`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policiesDir := t.TempDir()
+			_, err := txtar.Create(policiesDir, strings.NewReader("-- policy.yaml --"+tc.policy+"\n"))
+			assert.NilError(t, err)
+
+			_, err = policy.Load(policiesDir)
+			assert.ErrorIs(t, err, policy.ErrLoadPolicies)
+			assert.ErrorIs(t, err, policy.ErrUnsupportedPolicy)
+		})
+	}
+}
+
+func TestLoad_NoPolicies(t *testing.T) {
+	policiesDir := t.TempDir()
+	_, err := txtar.Create(policiesDir, strings.NewReader(`
+-- pod.cue --
+package policies
+`))
+	assert.NilError(t, err)
+
+	_, err = policy.Load(policiesDir)
+	assert.ErrorIs(t, err, policy.ErrLoadPolicies)
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"sync"
+
+	"github.com/kharf/navecd/pkg/component"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// APIGroups remembers the API groups, which the components of each project used at their last reconciliation.
+// It is safe for concurrent use.
+type APIGroups struct {
+	mu     sync.RWMutex
+	groups map[types.UID]map[string]struct{}
+}
+
+func NewAPIGroups() *APIGroups {
+	return &APIGroups{
+		groups: make(map[types.UID]map[string]struct{}),
+	}
+}
+
+// Uses reports whether the project with the given UID used the API group at its last reconciliation.
+func (apiGroups *APIGroups) Uses(projectUID types.UID, group string) bool {
+	apiGroups.mu.RLock()
+	defer apiGroups.mu.RUnlock()
+
+	_, found := apiGroups.groups[projectUID][group]
+	return found
+}
+
+// record stores the non-core API groups of the manifests and patches of a project and of the APIs they require.
+func (apiGroups *APIGroups) record(projectUID types.UID, instances []component.Instance, requires map[string][]string) {
+	groups := make(map[string]struct{})
+	for _, instance := range instances {
+		var apiVersion string
+		switch componentInstance := instance.(type) {
+		case *component.Manifest:
+			apiVersion = componentInstance.GetAPIVersion()
+		case *component.Patch:
+			apiVersion = componentInstance.GetAPIVersion()
+		}
+
+		if gv, err := schema.ParseGroupVersion(apiVersion); err == nil && gv.Group != "" {
+			groups[gv.Group] = struct{}{}
+		}
+	}
+
+	for _, apis := range requires {
+		for _, api := range apis {
+			if gvk, err := component.ParseRequiredAPI(api); err == nil && gvk.Group != "" {
+				groups[gvk.Group] = struct{}{}
+			}
+		}
+	}
+
+	apiGroups.mu.Lock()
+	defer apiGroups.mu.Unlock()
+
+	apiGroups.groups[projectUID] = groups
+}
@@ -0,0 +1,250 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/garbage"
+	"github.com/kharf/navecd/pkg/helm"
+	"github.com/kharf/navecd/pkg/inventory"
+	"github.com/kharf/navecd/pkg/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+type ApplyOptions struct {
+	// Dir of the project configuration inside the project root.
+	Dir string
+
+	// InventoryDir holds the inventory of the applied components.
+	// It should be kept between runs, so that pruning can detect removed components.
+	InventoryDir string
+
+	// Prune collects inventory items, which are no longer declared in the project.
+	Prune bool
+
+	// DeletePropagation defines how dependents of pruned manifests are deleted.
+	// Defaults to the policy of the object's API.
+	DeletePropagation metav1.DeletionPropagation
+
+	// DryRun validates manifests and patches against the cluster without persisting them.
+	// Helm releases are skipped and nothing is pruned.
+	DryRun bool
+
+	// ImagePullSecrets are added to the imagePullSecrets of all manifests containing a pod spec.
+	ImagePullSecrets []string
+
+	// ImageRegistryPrefix replaces the registry host of all container images of manifests containing a pod spec, if set.
+	ImageRegistryPrefix string
+
+	// SourceAnnotations annotates applied manifests with the position of their declaration.
+	SourceAnnotations bool
+
+	// Component restricts the apply to the component with the given id.
+	// All other components are left untouched, which is why it cannot be combined with Prune.
+	Component string
+
+	// WithDependencies applies the transitive dependencies of Component as well.
+	WithDependencies bool
+
+	FieldManager string
+}
+
+var (
+	ErrPruneComponent = errors.New("Pruning is not supported when applying a single component")
+)
+
+// ComponentOutcome is the result of applying a single component.
+type ComponentOutcome struct {
+	ID      string
+	Outcome component.Outcome
+	Err     error
+}
+
+// applyWaitInterval is the interval between two reconciliations of a project with waiting components.
+var applyWaitInterval = 2 * time.Second
+
+type ApplyResult struct {
+	// Outcomes of all components, sorted by their id.
+	Outcomes []ComponentOutcome
+
+	// Pruned holds the collected inventory items or the items which would be collected on a dry run, sorted by their id.
+	Pruned []inventory.Item
+}
+
+// ApplyAction reconciles a local project once against a cluster,
+// without pushing it to a registry or involving the controller.
+type ApplyAction struct {
+	log         logr.Logger
+	kubeConfig  *rest.Config
+	projectRoot string
+}
+
+func NewApplyAction(
+	log logr.Logger,
+	kubeConfig *rest.Config,
+	projectRoot string,
+) ApplyAction {
+	return ApplyAction{
+		log:         log,
+		kubeConfig:  kubeConfig,
+		projectRoot: projectRoot,
+	}
+}
+
+// Apply loads the local project, optionally prunes dangling inventory items and applies the components in dependency order.
+// Component errors are reported in the outcomes and do not fail the apply.
+func (act ApplyAction) Apply(ctx context.Context, opts ApplyOptions) (*ApplyResult, error) {
+	if opts.Component != "" && opts.Prune {
+		return nil, ErrPruneComponent
+	}
+
+	componentBuilder := component.NewBuilder()
+	projectManager := NewManager(componentBuilder, -1)
+
+	projectInstance, err := projectManager.Load(ctx, act.projectRoot, opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	componentInstances, err := selectInstances(projectInstance.Dag, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeDynamicClient, err := kube.NewExtendedDynamicClient(act.kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	inventoryInstance := &inventory.Instance{
+		Path: opts.InventoryDir,
+	}
+
+	chartReconciler := helm.ChartReconciler{
+		KubeConfig:        act.kubeConfig,
+		Client:            kubeDynamicClient,
+		FieldManager:      opts.FieldManager,
+		InventoryInstance: inventoryInstance,
+		Log:               act.log,
+		ChartCacheRoot:    os.TempDir(),
+	}
+
+	result := &ApplyResult{}
+	if opts.Prune {
+		garbageCollector := garbage.Collector{
+			Log:               act.log,
+			Client:            kubeDynamicClient.DynamicClient(),
+			ChartReconciler:   chartReconciler,
+			FieldManager:      opts.FieldManager,
+			InventoryInstance: inventoryInstance,
+			WorkerPoolSize:    -1,
+			DeletePropagation: opts.DeletePropagation,
+		}
+
+		result.Pruned, err = garbageCollector.Dangling(projectInstance.Dag)
+		if err != nil {
+			return nil, err
+		}
+
+		if !opts.DryRun {
+			if err := garbageCollector.Collect(ctx, projectInstance.Dag); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	componentReconciler := component.Reconciler{
+		Log:                 act.log,
+		DynamicClient:       kubeDynamicClient,
+		ChartReconciler:     chartReconciler,
+		InventoryInstance:   inventoryInstance,
+		FieldManager:        opts.FieldManager,
+		WorkerPoolSize:      -1,
+		Suspended:           projectInstance.Suspended,
+		Keep:                projectInstance.Keep,
+		Requires:            projectInstance.Requires,
+		WaitFor:             projectInstance.WaitFor,
+		Waits:               &component.WaitTracker{},
+		Hooks:               projectInstance.Hooks,
+		DryRun:              opts.DryRun,
+		ImagePullSecrets:    opts.ImagePullSecrets,
+		ImageRegistryPrefix: opts.ImageRegistryPrefix,
+		ReportOutcome: func(instance component.Instance, outcome component.Outcome, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			result.Outcomes = append(result.Outcomes, ComponentOutcome{
+				ID:      instance.GetID(),
+				Outcome: outcome,
+				Err:     err,
+			})
+		},
+	}
+	if opts.SourceAnnotations {
+		componentReconciler.Sources = projectInstance.Sources
+	}
+
+	// wait conditions are checked once per reconciliation, which is why the components are reconciled again,
+	// until no component waits anymore. Components, whose condition is not met within its timeout, fail.
+	for {
+		result.Outcomes = nil
+		// component errors are part of the outcomes
+		_ = componentReconciler.Reconcile(ctx, componentInstances)
+		if !slices.ContainsFunc(result.Outcomes, func(outcome ComponentOutcome) bool {
+			return outcome.Outcome == component.OutcomeWaiting
+		}) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(applyWaitInterval):
+		}
+	}
+	slices.SortFunc(result.Outcomes, func(a, b ComponentOutcome) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return result, nil
+}
+
+// selectInstances returns the components to apply in topological order.
+func selectInstances(dag *component.DependencyGraph, opts ApplyOptions) ([]component.Instance, error) {
+	if opts.Component == "" {
+		return dag.TopologicalSort()
+	}
+
+	if opts.WithDependencies {
+		return dag.Subtree(opts.Component)
+	}
+
+	instance := dag.Get(opts.Component)
+	if instance == nil {
+		return nil, fmt.Errorf("%w: %s not found in project", component.ErrUnknownComponentID, opts.Component)
+	}
+	return []component.Instance{instance}, nil
+}
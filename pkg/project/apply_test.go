@@ -0,0 +1,225 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kharf/navecd/internal/dnstest"
+	"github.com/kharf/navecd/internal/kubetest"
+	"github.com/kharf/navecd/internal/projecttest"
+	"github.com/kharf/navecd/internal/testtemplates"
+	"github.com/kharf/navecd/internal/txtar"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/project"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func useApplyTemplate(withConfigMap bool) string {
+	configMap := ""
+	if withConfigMap {
+		configMap = `
+-- infra/apply/configmap.cue --
+package apply
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+configMap: component.#Manifest & {
+	dependencies: [ns.id]
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "test"
+			namespace: "apply"
+		}
+	}
+}
+`
+	}
+
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/projecttest/apply@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/apply/namespace.cue --
+package apply
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "apply"
+	}
+}
+%s`, testtemplates.ModuleVersion, configMap)
+}
+
+func TestApplyAction_Apply(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	projectRoot := filepath.Join(env.TestRoot, "project")
+	_, err = txtar.Create(projectRoot, strings.NewReader(useApplyTemplate(true)))
+	assert.NilError(t, err)
+
+	action := project.NewApplyAction(env.Log, kubernetes.ControlPlane.Config, projectRoot)
+	opts := project.ApplyOptions{
+		Dir:          ".",
+		InventoryDir: filepath.Join(env.TestRoot, "inventory"),
+		Prune:        true,
+		FieldManager: "navecd-cli",
+	}
+
+	result, err := action.Apply(ctx, opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result.Outcomes, []project.ComponentOutcome{
+		{ID: "apply___Namespace", Outcome: component.OutcomeSuccess},
+		{ID: "test_apply__ConfigMap", Outcome: component.OutcomeSuccess},
+	})
+	assert.Equal(t, len(result.Pruned), 0)
+
+	var configMap corev1.ConfigMap
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "test", Namespace: "apply"},
+		&configMap,
+	)
+	assert.NilError(t, err)
+
+	err = os.RemoveAll(projectRoot)
+	assert.NilError(t, err)
+	_, err = txtar.Create(projectRoot, strings.NewReader(useApplyTemplate(false)))
+	assert.NilError(t, err)
+
+	dryRunOpts := opts
+	dryRunOpts.DryRun = true
+	result, err = action.Apply(ctx, dryRunOpts)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Pruned), 1)
+	assert.Equal(t, result.Pruned[0].GetID(), "test_apply__ConfigMap")
+
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "test", Namespace: "apply"},
+		&configMap,
+	)
+	assert.NilError(t, err)
+
+	result, err = action.Apply(ctx, opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result.Outcomes, []project.ComponentOutcome{
+		{ID: "apply___Namespace", Outcome: component.OutcomeSuccess},
+	})
+	assert.Equal(t, len(result.Pruned), 1)
+	assert.Equal(t, result.Pruned[0].GetID(), "test_apply__ConfigMap")
+
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "test", Namespace: "apply"},
+		&configMap,
+	)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+}
+
+func TestApplyAction_Apply_Component(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	projectRoot := filepath.Join(env.TestRoot, "project")
+	_, err = txtar.Create(projectRoot, strings.NewReader(useApplyTemplate(true)+`
+-- infra/other/namespace.cue --
+package other
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "other"
+	}
+}
+`))
+	assert.NilError(t, err)
+
+	action := project.NewApplyAction(env.Log, kubernetes.ControlPlane.Config, projectRoot)
+	opts := project.ApplyOptions{
+		Dir:              ".",
+		InventoryDir:     filepath.Join(env.TestRoot, "inventory"),
+		Component:        "test_apply__ConfigMap",
+		WithDependencies: true,
+		FieldManager:     "navecd-cli",
+	}
+
+	result, err := action.Apply(ctx, opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result.Outcomes, []project.ComponentOutcome{
+		{ID: "apply___Namespace", Outcome: component.OutcomeSuccess},
+		{ID: "test_apply__ConfigMap", Outcome: component.OutcomeSuccess},
+	})
+
+	var namespace corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: "other"}, &namespace)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	pruneOpts := opts
+	pruneOpts.Prune = true
+	_, err = action.Apply(ctx, pruneOpts)
+	assert.ErrorIs(t, err, project.ErrPruneComponent)
+
+	unknownOpts := opts
+	unknownOpts.Component = "missing___Namespace"
+	_, err = action.Apply(ctx, unknownOpts)
+	assert.ErrorIs(t, err, component.ErrUnknownComponentID)
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
+	"github.com/kharf/navecd/pkg/oci"
+	"helm.sh/helm/v4/pkg/registry"
+	repo "helm.sh/helm/v4/pkg/repo/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ArtifactKind is the type of an artifact referenced by a component.
+type ArtifactKind string
+
+const (
+	ImageArtifact ArtifactKind = "image"
+	ChartArtifact ArtifactKind = "chart"
+)
+
+// Artifact is a container image or Helm chart referenced by a component.
+type Artifact struct {
+	ComponentID string
+	Kind        ArtifactKind
+
+	// Reference in the format registry/repository:tag for images and oci charts,
+	// or repoURL/name:version for charts hosted in http repositories.
+	Reference string
+
+	chart *helm.Chart
+}
+
+// ArtifactChecker contacts registries and chart repositories to confirm that
+// the image tags and chart versions referenced by a project exist.
+type ArtifactChecker struct {
+	// HTTPClient is used to download the index of http chart repositories.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// OCIOptions configure the requests to oci registries, like authentication.
+	OCIOptions []oci.Option
+
+	// Kinds restricts the check to artifacts of the given kinds, e.g. only to the charts of Helm releases.
+	// Defaults to all kinds.
+	Kinds []ArtifactKind
+}
+
+// CollectArtifacts returns all images of container specs in manifests and all charts of Helm releases declared in the project.
+func CollectArtifacts(instance *Instance) ([]Artifact, error) {
+	instances, err := instance.Dag.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	var artifacts []Artifact
+	for _, componentInstance := range instances {
+		switch typedInstance := componentInstance.(type) {
+		case *component.Manifest:
+			if typedInstance.Content.Unstructured == nil {
+				continue
+			}
+			for _, image := range collectImages(typedInstance.Content.Object) {
+				artifacts = append(artifacts, Artifact{
+					ComponentID: typedInstance.ID,
+					Kind:        ImageArtifact,
+					Reference:   image,
+				})
+			}
+
+		case *helm.ReleaseComponent:
+			chart := typedInstance.Content.Chart
+			if chart == nil {
+				continue
+			}
+			version, _ := helm.ParseVersion(chart.Version)
+			artifacts = append(artifacts, Artifact{
+				ComponentID: typedInstance.ID,
+				Kind:        ChartArtifact,
+				Reference: fmt.Sprintf(
+					"%s/%s:%s",
+					strings.TrimSuffix(chart.RepoURL, "/"),
+					chart.Name,
+					version,
+				),
+				chart: chart,
+			})
+		}
+	}
+
+	return artifacts, nil
+}
+
+var containerFields = []string{"containers", "initContainers", "ephemeralContainers"}
+
+func collectImages(object map[string]any) []string {
+	var images []string
+	for key, value := range object {
+		switch typedValue := value.(type) {
+		case map[string]any:
+			images = append(images, collectImages(typedValue)...)
+
+		case []any:
+			isContainerField := false
+			for _, field := range containerFields {
+				if key == field {
+					isContainerField = true
+					break
+				}
+			}
+
+			for _, element := range typedValue {
+				elementObject, ok := element.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				if image, ok := elementObject["image"].(string); isContainerField && ok && image != "" {
+					images = append(images, image)
+				}
+				images = append(images, collectImages(elementObject)...)
+			}
+		}
+	}
+	return images
+}
+
+// Check returns all artifacts of the project, which could not be found in their registries or repositories.
+// Indexes of http chart repositories are only downloaded once.
+func (checker *ArtifactChecker) Check(ctx context.Context, instance *Instance) ([]Artifact, error) {
+	artifacts, err := CollectArtifacts(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	ociOpts := append([]oci.Option{oci.WithContext(ctx)}, checker.OCIOptions...)
+	indexes := make(map[string]*repo.IndexFile)
+
+	var missing []Artifact
+	for _, artifact := range artifacts {
+		if len(checker.Kinds) != 0 && !slices.Contains(checker.Kinds, artifact.Kind) {
+			continue
+		}
+
+		var exists bool
+		switch {
+		case artifact.Kind == ImageArtifact || registry.IsOCI(artifact.chart.RepoURL):
+			exists, err = oci.Exists(artifact.Reference, false, ociOpts...)
+
+		default:
+			exists, err = checker.chartExists(ctx, artifact.chart, indexes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s referenced by %s: %w", artifact.Reference, artifact.ComponentID, err)
+		}
+
+		if !exists {
+			missing = append(missing, artifact)
+		}
+	}
+
+	return missing, nil
+}
+
+func (checker *ArtifactChecker) chartExists(
+	ctx context.Context,
+	chart *helm.Chart,
+	indexes map[string]*repo.IndexFile,
+) (bool, error) {
+	index, found := indexes[chart.RepoURL]
+	if !found {
+		var err error
+		index, err = checker.downloadIndex(ctx, chart.RepoURL)
+		if err != nil {
+			return false, err
+		}
+		indexes[chart.RepoURL] = index
+	}
+
+	if index == nil {
+		return false, nil
+	}
+
+	version, _ := helm.ParseVersion(chart.Version)
+	return index.Has(chart.Name, version), nil
+}
+
+// downloadIndex returns nil, if the repository has no index.
+func (checker *ArtifactChecker) downloadIndex(
+	ctx context.Context,
+	repoURL string,
+) (*repo.IndexFile, error) {
+	httpClient := checker.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		strings.TrimSuffix(repoURL, "/")+"/index.yaml",
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, err
+	}
+	index.SortEntries()
+
+	return index, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/kharf/navecd/internal/dnstest"
+	"github.com/kharf/navecd/internal/helmtest"
+	"github.com/kharf/navecd/internal/ocitest"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
+	"github.com/kharf/navecd/pkg/kube"
+	"github.com/kharf/navecd/pkg/project"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func deploymentManifest(id string, image string) *component.Manifest {
+	return &component.Manifest{
+		ID: id,
+		Content: kube.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      "app",
+						"namespace": "app",
+					},
+					"spec": map[string]any{
+						"template": map[string]any{
+							"spec": map[string]any{
+								"containers": []any{
+									map[string]any{
+										"name":  "app",
+										"image": image,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func releaseComponent(id string, repoURL string, version string) *helm.ReleaseComponent {
+	return &helm.ReleaseComponent{
+		ID: id,
+		Content: helm.ReleaseDeclaration{
+			Name:      "test",
+			Namespace: "test",
+			Chart: &helm.Chart{
+				Name:    "test",
+				RepoURL: repoURL,
+				Version: version,
+			},
+		},
+	}
+}
+
+func TestArtifactChecker_Check(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistry(false, "")
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	image, err := random.Image(64, 1)
+	assert.NilError(t, err)
+	existingImage := fmt.Sprintf("%s/app:1.0.0", registry.Addr())
+	err = crane.Push(image, existingImage)
+	assert.NilError(t, err)
+
+	helmEnvironment, err := helmtest.NewHelmEnvironment(
+		t,
+		helmtest.WithOCI(false),
+		helmtest.WithPrivate(false),
+	)
+	assert.NilError(t, err)
+	defer helmEnvironment.Close()
+
+	missingImage := fmt.Sprintf("%s/app:2.0.0", registry.Addr())
+	repoURL := helmEnvironment.ChartServer.URL()
+
+	dag := component.NewDependencyGraph()
+	err = dag.Insert(
+		deploymentManifest("app_app_apps_Deployment", existingImage),
+		deploymentManifest("missing_app_apps_Deployment", missingImage),
+		releaseComponent("test_test_HelmRelease", repoURL, "1.0.0"),
+		releaseComponent("missing_test_HelmRelease", repoURL, "9.9.9"),
+	)
+	assert.NilError(t, err)
+
+	checker := project.ArtifactChecker{}
+	missing, err := checker.Check(context.Background(), &project.Instance{
+		Dag: &dag,
+	})
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(missing), 2)
+	missingByComponent := make(map[string]project.Artifact, len(missing))
+	for _, artifact := range missing {
+		missingByComponent[artifact.ComponentID] = artifact
+	}
+
+	artifact, found := missingByComponent["missing_app_apps_Deployment"]
+	assert.Assert(t, found)
+	assert.Equal(t, artifact.Kind, project.ImageArtifact)
+	assert.Equal(t, artifact.Reference, missingImage)
+
+	artifact, found = missingByComponent["missing_test_HelmRelease"]
+	assert.Assert(t, found)
+	assert.Equal(t, artifact.Kind, project.ChartArtifact)
+	assert.Equal(t, artifact.Reference, fmt.Sprintf("%s/test:9.9.9", repoURL))
+}
+
+func TestArtifactChecker_Check_Charts(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	helmEnvironment, err := helmtest.NewHelmEnvironment(
+		t,
+		helmtest.WithOCI(false),
+		helmtest.WithPrivate(false),
+	)
+	assert.NilError(t, err)
+	defer helmEnvironment.Close()
+
+	repoURL := helmEnvironment.ChartServer.URL()
+
+	dag := component.NewDependencyGraph()
+	err = dag.Insert(
+		// images are not checked.
+		deploymentManifest("missing_app_apps_Deployment", "localhost:1/app:2.0.0"),
+		releaseComponent("test_test_HelmRelease", repoURL, "1.0.0"),
+		releaseComponent("bad_test_HelmRelease", repoURL, "1.0.1"),
+	)
+	assert.NilError(t, err)
+
+	checker := project.ArtifactChecker{
+		Kinds: []project.ArtifactKind{project.ChartArtifact},
+	}
+	missing, err := checker.Check(context.Background(), &project.Instance{
+		Dag: &dag,
+	})
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(missing), 1)
+	assert.Equal(t, missing[0].ComponentID, "bad_test_HelmRelease")
+	assert.Equal(t, missing[0].Kind, project.ChartArtifact)
+	assert.Equal(t, missing[0].Reference, fmt.Sprintf("%s/test:1.0.1", repoURL))
+
+	// unreachable repositories fail the check with the component referencing them.
+	dag = component.NewDependencyGraph()
+	err = dag.Insert(
+		releaseComponent("unreachable_test_HelmRelease", "http://localhost:1", "1.0.0"),
+	)
+	assert.NilError(t, err)
+
+	_, err = checker.Check(context.Background(), &project.Instance{
+		Dag: &dag,
+	})
+	assert.ErrorContains(t, err, "referenced by unreachable_test_HelmRelease")
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kharf/navecd/internal/tgz"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/oci"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	bundleMetadataFile    = "bundle.json"
+	bundleControllerFile  = "controller.yaml"
+	bundleImageLayoutDir  = "artifact"
+	bundleManifestDivider = "---\n"
+)
+
+var (
+	ErrInvalidBundle = errors.New("Invalid bundle")
+)
+
+// BundleMetadata describes the installation the bundle was created for.
+type BundleMetadata struct {
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Ref    string `json:"ref"`
+	Shard  string `json:"shard"`
+	Digest string `json:"digest"`
+}
+
+// Bundle is an extracted archive created by [BundleAction.Bundle].
+type Bundle struct {
+	Metadata BundleMetadata
+
+	// Manifests of the controller installation in dependency order.
+	Manifests []*unstructured.Unstructured
+
+	// ImageLayoutDir is the OCI image layout containing the project artifact.
+	ImageLayoutDir string
+}
+
+// BundleAction packs the controller manifests and the project artifact into a single archive,
+// which can be installed without access to the project sources or a registry reachable from the build machine.
+type BundleAction struct {
+	componentBuilder component.Builder
+	projectRoot      string
+}
+
+func NewBundleAction(projectRoot string) BundleAction {
+	return BundleAction{
+		projectRoot: projectRoot,
+	}
+}
+
+// Bundle writes the archive to output and returns the digest of the contained project artifact.
+// Url and Ref of the options refer to the registry the bundle is installed into.
+func (act BundleAction) Bundle(output string, opts InstallOptions) (string, error) {
+	if err := writeProjectFile(act.projectRoot, opts); err != nil {
+		return "", err
+	}
+
+	manifests, err := controllerManifests(act.componentBuilder, act.projectRoot, opts.Shard, opts.namespace())
+	if err != nil {
+		return "", err
+	}
+
+	bundleDir, err := os.MkdirTemp("", "navecd-bundle-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(bundleDir)
+
+	var controllerBuf bytes.Buffer
+	for _, manifest := range manifests {
+		content, err := yaml.Marshal(manifest.Object)
+		if err != nil {
+			return "", err
+		}
+		controllerBuf.WriteString(bundleManifestDivider)
+		controllerBuf.Write(content)
+	}
+
+	if err := os.WriteFile(filepath.Join(bundleDir, bundleControllerFile), controllerBuf.Bytes(), 0600); err != nil {
+		return "", err
+	}
+
+	opts.Log.V(1).Info("Exporting project artifact", "path", act.projectRoot)
+	digest, err := oci.ExportImageFromPath(act.projectRoot, filepath.Join(bundleDir, bundleImageLayoutDir))
+	if err != nil {
+		return "", err
+	}
+
+	metadata, err := json.Marshal(BundleMetadata{
+		Name:   opts.Name,
+		Url:    opts.Url,
+		Ref:    opts.Ref,
+		Shard:  opts.Shard,
+		Digest: digest,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(bundleDir, bundleMetadataFile), metadata, 0600); err != nil {
+		return "", err
+	}
+
+	if err := tgz.Create(bundleDir, output); err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// ReadBundle extracts the archive at path into targetDir.
+func ReadBundle(path string, targetDir string) (*Bundle, error) {
+	if err := tgz.Read(path, targetDir); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+
+	metadataContent, err := os.ReadFile(filepath.Join(targetDir, bundleMetadataFile))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+
+	var metadata BundleMetadata
+	if err := json.Unmarshal(metadataContent, &metadata); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+
+	controllerFile, err := os.Open(filepath.Join(targetDir, bundleControllerFile))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	defer controllerFile.Close()
+
+	var manifests []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(controllerFile, 4096)
+	for {
+		manifest := &unstructured.Unstructured{}
+		if err := decoder.Decode(&manifest.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+		}
+
+		if len(manifest.Object) == 0 {
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	return &Bundle{
+		Metadata:       metadata,
+		Manifests:      manifests,
+		ImageLayoutDir: filepath.Join(targetDir, bundleImageLayoutDir),
+	}, nil
+}
+
+// installBundle applies the controller manifests of the bundle and pushes its project artifact to the registry of the bundle.
+func (act InstallAction) installBundle(ctx context.Context, opts InstallOptions) (string, error) {
+	bundleDir, err := os.MkdirTemp("", "navecd-bundle-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(bundleDir)
+
+	bundle, err := ReadBundle(opts.Bundle, bundleDir)
+	if err != nil {
+		return "", err
+	}
+
+	manifests, registryCredentials, err := withRegistryAuthSecret(bundle.Manifests, opts.RegistryAuthFile, bundle.Metadata.Shard)
+	if err != nil {
+		return "", err
+	}
+
+	opts.Log.V(1).Info("Installing bundle", "bundle", opts.Bundle, "digest", bundle.Metadata.Digest)
+	if err := act.installManifests(ctx, manifests, bundle.Metadata.Shard); err != nil {
+		return "", err
+	}
+
+	ociClient, err := oci.NewRepositoryClient(bundle.Metadata.Url, opts.InsecureRegistry)
+	if err != nil {
+		return "", err
+	}
+	projectClient := oci.NewProjectClient(ociClient)
+
+	pushOpts := []oci.ProjectClientOption{
+		oci.WithRepositoryOption(
+			oci.WithInsecure(opts.InsecureRegistry),
+		),
+		oci.WithRepositoryOption(
+			oci.WithLogger(opts.Log),
+		),
+	}
+	if len(registryCredentials) != 0 {
+		pushOpts = append(pushOpts, oci.WithRepositoryOption(oci.WithKeychain(registryCredentials)))
+	}
+
+	return projectClient.PushImageFromLayout(
+		ctx,
+		bundle.Metadata.Ref,
+		bundle.ImageLayoutDir,
+		pushOpts...,
+	)
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kharf/navecd/internal/dnstest"
+	"github.com/kharf/navecd/internal/ocitest"
+	"github.com/kharf/navecd/pkg/oci"
+	"github.com/kharf/navecd/pkg/project"
+	"gotest.tools/v3/assert"
+)
+
+func TestBundleAction_Bundle(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectName := "bundle"
+	testProject := t.TempDir()
+	err = project.Init(
+		"github.com/owner/repo/bundle",
+		projectName,
+		"image",
+		false,
+		testProject,
+		"0.26.99",
+	)
+	assert.NilError(t, err)
+
+	url := fmt.Sprintf("%s/%s", registry.Addr(), projectName)
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tgz")
+	digest, err := project.NewBundleAction(testProject).Bundle(
+		bundlePath,
+		project.InstallOptions{
+			Name:     projectName,
+			Shard:    projectName,
+			Ref:      "v1",
+			Dir:      "dev",
+			Interval: 5,
+			Url:      url,
+		},
+	)
+	assert.NilError(t, err)
+
+	bundle, err := project.ReadBundle(bundlePath, t.TempDir())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, bundle.Metadata, project.BundleMetadata{
+		Name:   projectName,
+		Url:    url,
+		Ref:    "v1",
+		Shard:  projectName,
+		Digest: digest,
+	})
+
+	kinds := make(map[string]bool, len(bundle.Manifests))
+	for _, manifest := range bundle.Manifests {
+		assert.Equal(t, manifest.GetLabels()["navecd/shard"], projectName)
+		kinds[manifest.GetKind()] = true
+	}
+	assert.Assert(t, kinds["Namespace"])
+	assert.Assert(t, kinds["Deployment"])
+	assert.Assert(t, kinds["GitOpsProject"])
+
+	ociClient, err := oci.NewRepositoryClient(url, false)
+	assert.NilError(t, err)
+	projectClient := oci.NewProjectClient(ociClient)
+
+	pushedDigest, err := projectClient.PushImageFromLayout(context.Background(), "v1", bundle.ImageLayoutDir)
+	assert.NilError(t, err)
+	assert.Equal(t, pushedDigest, digest)
+
+	targetDir := filepath.Join(t.TempDir(), "project")
+	loadedDigest, err := projectClient.LoadImage(context.Background(), "v1", targetDir)
+	assert.NilError(t, err)
+	assert.Equal(t, loadedDigest, digest)
+
+	_, err = os.Stat(filepath.Join(targetDir, "navecd", fmt.Sprintf("%s_project.cue", projectName)))
+	assert.NilError(t, err)
+}
+
+func TestReadBundle_Invalid(t *testing.T) {
+	invalidBundle := filepath.Join(t.TempDir(), "bundle.tgz")
+	err := os.WriteFile(invalidBundle, []byte("invalid"), 0600)
+	assert.NilError(t, err)
+
+	_, err = project.ReadBundle(invalidBundle, t.TempDir())
+	assert.ErrorIs(t, err, project.ErrInvalidBundle)
+}
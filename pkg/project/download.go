@@ -3,7 +3,11 @@ package project
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/kube"
 	"github.com/kharf/navecd/pkg/oci"
@@ -27,14 +31,32 @@ func (d *RecoverableLoadError) Unwrap() error {
 	return d.Err
 }
 
+var (
+	ErrNoMatchingTag = errors.New("No tag matches the reference constraint")
+)
+
 // OCIRepositoryRef is a storage location for container images and other artifacts.
 type OCIRepositoryRef struct {
 	Name string
 	Ref  string
 }
 
-// Digest of the loaded remote project artifact.
-type Digest string
+// Digest identifies the loaded remote project artifact and where it was loaded from.
+type Digest struct {
+	// Artifact is the content digest of the artifact, e.g. sha256:3b4c....
+	Artifact string
+
+	// Tag the reference resolved to. It differs from the reference only for semver constraints.
+	Tag string
+
+	// Registry is the repository the artifact was loaded from, after mirrors were applied.
+	Registry string
+}
+
+// String returns the content digest of the artifact.
+func (digest Digest) String() string {
+	return digest.Artifact
+}
 
 // RemoteLoader loads a remote navecd project to a local path.
 type RemoteLoader interface {
@@ -65,6 +87,34 @@ type OCIRemoteLoader struct {
 	// Endpoint to the google metadata server, which provides access tokens.
 	// Default is: http://metadata.google.internal.
 	GCPMetadataServerURL string
+
+	// Mirrors rewrite the repository before contacting the registry.
+	Mirrors oci.Mirrors
+
+	// Proxy routes registry traffic through the given proxy.
+	// Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy *url.URL
+
+	// RegistryCredentials authenticate against the registry, if the project declares no auth.
+	RegistryCredentials oci.RegistryCredentials
+
+	// CredentialCache shares credentials fetched through workload identity, if set.
+	CredentialCache *cloud.CredentialCache
+
+	// Subpath restricts the extraction to a directory of the artifact, which then becomes the project root.
+	// Defaults to the whole artifact.
+	Subpath string
+
+	// MaxArtifactBytes limits the size of the downloaded and of the extracted artifact.
+	// Zero means no limit.
+	MaxArtifactBytes int64
+
+	// CacheRetention prunes the completion markers of projects, which were not loaded within this duration.
+	// Zero means markers are kept forever.
+	CacheRetention time.Duration
+
+	resolvedRef string
+	artifact    *oci.Artifact
 }
 
 var _ RemoteLoader = (*OCIRemoteLoader)(nil)
@@ -75,7 +125,8 @@ func (loader *OCIRemoteLoader) Load(
 	auth *cloud.Auth,
 ) (Digest, error) {
 	repository := loader.Repository
-	var opts []oci.ProjectClientOption
+	repository.Name = loader.Mirrors.Rewrite(repository.Name)
+	var repositoryOpts []oci.Option
 	if auth != nil {
 		creds, err := cloud.ReadCredentials(
 			ctx,
@@ -85,28 +136,49 @@ func (loader *OCIRemoteLoader) Load(
 			cloud.WithNamespace(loader.Namespace),
 			cloud.WithCustomAzureLoginURL(loader.AzureLoginURL),
 			cloud.WithCustomGCPMetadataServerURL(loader.GCPMetadataServerURL),
+			cloud.WithCredentialCache(loader.CredentialCache),
 		)
 		if err != nil {
-			return "", err
+			return Digest{}, err
 		}
-		opts = append(opts, oci.WithRepositoryOption(
-			oci.WithBasicAuth(creds.Username, creds.Password)),
-		)
+		repositoryOpts = append(repositoryOpts, oci.WithBasicAuth(creds.Username, creds.Password))
+	} else if len(loader.RegistryCredentials) != 0 {
+		repositoryOpts = append(repositoryOpts, oci.WithKeychain(loader.RegistryCredentials))
+	}
+	if loader.Proxy != nil {
+		repositoryOpts = append(repositoryOpts, oci.WithProxy(loader.Proxy))
 	}
 
-	opts = append(opts, oci.WithCacheDir(loader.CacheDir))
+	opts := []oci.ProjectClientOption{
+		oci.WithCacheDir(loader.CacheDir),
+		oci.WithSubpath(loader.Subpath),
+		oci.WithMaxArtifactBytes(loader.MaxArtifactBytes),
+		oci.WithCacheRetention(loader.CacheRetention),
+	}
+	for _, repositoryOpt := range repositoryOpts {
+		opts = append(opts, oci.WithRepositoryOption(repositoryOpt))
+	}
 
 	ociClient, err := oci.NewRepositoryClient(repository.Name, loader.InsecureSkipTLSverify)
 	if err != nil {
-		return "", err
+		return Digest{}, err
 	}
 	projectClient := oci.NewProjectClient(ociClient)
 
-	digest, err := projectClient.LoadImage(ctx, repository.Ref, targetDir, opts...)
+	ref, err := resolveRef(ociClient, repository.Ref, repositoryOpts...)
+	if err != nil {
+		return Digest{}, &RecoverableLoadError{
+			Err:        err,
+			BackupPath: targetDir,
+		}
+	}
+	loader.resolvedRef = ref
+
+	artifact, err := projectClient.LoadArtifact(ctx, ref, targetDir, opts...)
 	if err != nil {
 		var unrecErr *oci.UnrecoverableError
 		if errors.As(err, &unrecErr) {
-			return "", err
+			return Digest{}, err
 		}
 
 		backupPath := targetDir
@@ -115,11 +187,106 @@ func (loader *OCIRemoteLoader) Load(
 			backupPath = recError.BackupPath
 		}
 
-		return "", &RecoverableLoadError{
+		return Digest{}, &RecoverableLoadError{
 			Err:        err,
 			BackupPath: backupPath,
 		}
 	}
 
-	return Digest(digest), nil
+	loader.artifact = artifact
+
+	return Digest{
+		Artifact: artifact.Digest,
+		Tag:      ref,
+		Registry: repository.Name,
+	}, nil
+}
+
+// Artifact returns the project artifact loaded during the last successful load.
+func (loader *OCIRemoteLoader) Artifact() *oci.Artifact {
+	return loader.artifact
+}
+
+// ResolvedRef returns the tag the repository reference resolved to during the last load.
+func (loader *OCIRemoteLoader) ResolvedRef() string {
+	return loader.resolvedRef
+}
+
+// resolveRef returns the highest tag of the repository matching ref, if ref is a semver constraint.
+// Any other ref is returned unchanged.
+func resolveRef(client oci.Client, ref string, opts ...oci.Option) (string, error) {
+	if _, err := semver.NewVersion(ref); err == nil {
+		return ref, nil
+	}
+
+	constraint, err := semver.NewConstraint(ref)
+	if err != nil {
+		return ref, nil
+	}
+
+	tags, err := client.ListTags(opts...)
+	if err != nil {
+		return "", err
+	}
+
+	var highestTag string
+	var highestVersion *semver.Version
+	for _, tag := range tags {
+		version, err := semver.NewVersion(tag)
+		if err != nil || !constraint.Check(version) {
+			continue
+		}
+
+		if highestVersion == nil || version.GreaterThan(highestVersion) {
+			highestTag = tag
+			highestVersion = version
+		}
+	}
+
+	if highestVersion == nil {
+		return "", fmt.Errorf("%w: %s", ErrNoMatchingTag, ref)
+	}
+
+	return highestTag, nil
+}
+
+// RetryRemoteLoader retries loading a remote navecd project with exponential backoff
+// when the underlying loader reports a recoverable error.
+// Only after all retries are exhausted, the recoverable error is returned and the project falls back to its backup.
+type RetryRemoteLoader struct {
+	Loader RemoteLoader
+
+	// Retries is the number of additional load attempts after the first one failed.
+	Retries int
+
+	// Backoff is the wait duration before the first retry. It doubles with every subsequent retry.
+	Backoff time.Duration
+}
+
+var _ RemoteLoader = (*RetryRemoteLoader)(nil)
+
+func (loader *RetryRemoteLoader) Load(
+	ctx context.Context,
+	targetDir string,
+	auth *cloud.Auth,
+) (Digest, error) {
+	backoff := loader.Backoff
+	for attempt := 0; ; attempt++ {
+		digest, err := loader.Loader.Load(ctx, targetDir, auth)
+		if err == nil {
+			return digest, nil
+		}
+
+		var recErr *RecoverableLoadError
+		if !errors.As(err, &recErr) || attempt >= loader.Retries {
+			return digest, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return digest, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
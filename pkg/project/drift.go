@@ -0,0 +1,80 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/inventory"
+	"github.com/kharf/navecd/pkg/kube"
+	"golang.org/x/sync/errgroup"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// deletedManifests returns the sorted ids of tracked manifests, which are still declared, but were deleted from the cluster out of band.
+func deletedManifests(
+	ctx context.Context,
+	client *kube.DynamicClient,
+	inventoryInstance *inventory.Instance,
+	dag *component.DependencyGraph,
+	workerPoolSize int,
+) ([]string, error) {
+	storage, err := inventoryInstance.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var deleted []string
+	eg := errgroup.Group{}
+	eg.SetLimit(workerPoolSize)
+	for _, item := range storage.Items() {
+		manifest, ok := item.(*inventory.ManifestItem)
+		// Jobs of completed hooks are not run again, even if they were removed, e.g. through ttlSecondsAfterFinished.
+		if !ok || manifest.HookCompleted || dag.Get(manifest.GetID()) == nil {
+			continue
+		}
+
+		eg.Go(func() error {
+			unstr := &unstructured.Unstructured{}
+			unstr.SetName(manifest.GetName())
+			unstr.SetNamespace(manifest.GetNamespace())
+			unstr.SetKind(manifest.TypeMeta.Kind)
+			unstr.SetAPIVersion(manifest.TypeMeta.APIVersion)
+			if _, err := client.Get(ctx, unstr); err != nil {
+				// A missing kind means the object is gone as well, e.g. because its CRD was deleted.
+				if !k8sErrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+					return err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				deleted = append(deleted, manifest.GetID())
+			}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(deleted)
+	return deleted, nil
+}
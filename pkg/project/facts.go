@@ -0,0 +1,122 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"maps"
+	"slices"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/load"
+	"github.com/kharf/navecd/pkg/kube"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// ClusterNameVar injects the configured cluster name as string.
+	ClusterNameVar = "clusterName"
+
+	// ClusterLabelsVar injects the configured cluster labels as struct of strings.
+	ClusterLabelsVar = "clusterLabels"
+
+	// KubernetesVersionVar injects the version of the Kubernetes API server as string, e.g. v1.31.0.
+	KubernetesVersionVar = "kubernetesVersion"
+
+	// ControllerNamespaceVar injects the namespace of the Navecd controller as string.
+	// The namespace of the generated controller manifests is declared with it.
+	ControllerNamespaceVar = "controllerNamespace"
+)
+
+// ClusterFacts describe the cluster a project is loaded for.
+// Components reference them with a tag variable, which is unified with the field value:
+//
+//	cluster: *"unknown" | string @tag(cluster, var=clusterName)
+//	labels: {[string]: string} @tag(labels, var=clusterLabels)
+//	version: string @tag(version, var=kubernetesVersion)
+//
+// Facts which are not set are not injected, so that the field falls back to its default.
+type ClusterFacts struct {
+	Name                string
+	Labels              map[string]string
+	KubernetesVersion   string
+	ControllerNamespace string
+}
+
+func (facts ClusterFacts) tagVars() map[string]load.TagVar {
+	return map[string]load.TagVar{
+		ClusterNameVar: {
+			Func: func() (ast.Expr, error) {
+				return stringTagVar(facts.Name), nil
+			},
+			Description: "The configured name of the cluster.",
+		},
+		ClusterLabelsVar: {
+			Func: func() (ast.Expr, error) {
+				if len(facts.Labels) == 0 {
+					return nil, nil
+				}
+
+				labels := &ast.StructLit{}
+				for _, key := range slices.Sorted(maps.Keys(facts.Labels)) {
+					labels.Elts = append(labels.Elts, &ast.Field{
+						Label: ast.NewString(key),
+						Value: ast.NewString(facts.Labels[key]),
+					})
+				}
+				return labels, nil
+			},
+			Description: "The configured labels of the cluster.",
+		},
+		KubernetesVersionVar: {
+			Func: func() (ast.Expr, error) {
+				return stringTagVar(facts.KubernetesVersion), nil
+			},
+			Description: "The version of the Kubernetes API server.",
+		},
+		ControllerNamespaceVar: {
+			Func: func() (ast.Expr, error) {
+				return stringTagVar(facts.ControllerNamespace), nil
+			},
+			Description: "The namespace of the Navecd controller.",
+		},
+	}
+}
+
+func stringTagVar(value string) ast.Expr {
+	if value == "" {
+		return nil
+	}
+	return ast.NewString(value)
+}
+
+// serverVersion returns the Kubernetes version of the cluster through the discovery cache, if set.
+func serverVersion(cfg *rest.Config, cache *kube.DiscoveryCache) (string, error) {
+	if cache != nil {
+		return cache.ServerVersion()
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+
+	return info.GitVersion, nil
+}
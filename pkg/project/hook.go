@@ -0,0 +1,152 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	gitops "github.com/kharf/navecd/api/v1beta1"
+)
+
+// DefaultHookTimeout bounds hook invocations, if the Reconciler does not configure a timeout.
+const DefaultHookTimeout = 10 * time.Second
+
+var (
+	ErrWebhookStatus = errors.New("Webhook responded with unexpected status")
+)
+
+// PreReconcileHook is invoked before a GitOpsProject is reconciled, e.g. to run pre-checks.
+type PreReconcileHook func(ctx context.Context, gProject gitops.GitOpsProject) error
+
+// PostReconcileHook is invoked after a GitOpsProject was reconciled, e.g. to send notifications.
+// Result is nil, if the reconciliation failed with err.
+type PostReconcileHook func(
+	ctx context.Context,
+	gProject gitops.GitOpsProject,
+	result *ReconcileResult,
+	err error,
+) error
+
+func (reconciler *Reconciler) runHook(ctx context.Context, name string, hook func(ctx context.Context) error) {
+	timeout := reconciler.HookTimeout
+	if timeout == 0 {
+		timeout = DefaultHookTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := hook(ctx); err != nil {
+		reconciler.Log.Error(err, "Reconcile hook failed", "hook", name)
+	}
+}
+
+// WebhookNotification is the JSON payload sent by the WebhookNotifier.
+type WebhookNotification struct {
+	Name                  string   `json:"name"`
+	Namespace             string   `json:"namespace"`
+	Suspended             bool     `json:"suspended"`
+	Digest                string   `json:"digest,omitempty"`
+	Tag                   string   `json:"tag,omitempty"`
+	Error                 string   `json:"error,omitempty"`
+	DownloadError         string   `json:"downloadError,omitempty"`
+	ComponentError        string   `json:"componentError,omitempty"`
+	ProgressingComponents []string `json:"progressingComponents,omitempty"`
+	RecreatedComponents   []string `json:"recreatedComponents,omitempty"`
+}
+
+// WebhookNotifier posts the outcome of every reconciliation as WebhookNotification to an HTTP endpoint.
+// Its Notify method is a PostReconcileHook.
+type WebhookNotifier struct {
+	// URL of the endpoint receiving the notifications.
+	URL string
+
+	// Headers are added to every request, e.g. for authorization.
+	Headers map[string]string
+
+	// Client sends the requests.
+	// Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+var _ PostReconcileHook = (*WebhookNotifier)(nil).Notify
+
+// Notify posts the reconciliation outcome to the configured URL.
+// Any response status outside of 2xx is reported as ErrWebhookStatus.
+func (notifier *WebhookNotifier) Notify(
+	ctx context.Context,
+	gProject gitops.GitOpsProject,
+	result *ReconcileResult,
+	reconcileErr error,
+) error {
+	notification := WebhookNotification{
+		Name:      gProject.GetName(),
+		Namespace: gProject.GetNamespace(),
+		Error:     errorString(reconcileErr),
+	}
+	if result != nil {
+		notification.Suspended = result.Suspended
+		notification.Digest = result.Digest
+		notification.Tag = result.Tag
+		notification.DownloadError = errorString(result.DownloadError)
+		notification.ComponentError = errorString(result.ComponentError)
+		notification.ProgressingComponents = result.ProgressingComponents
+		notification.RecreatedComponents = result.RecreatedComponents
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notifier.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range notifier.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := notifier.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s", ErrWebhookStatus, resp.Status)
+	}
+
+	return nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	gitops "github.com/kharf/navecd/api/v1beta1"
+	"github.com/kharf/navecd/pkg/project"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func suspendedProject() gitops.GitOpsProject {
+	suspend := true
+	return gitops.GitOpsProject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:     "oci://localhost/test",
+			Ref:     "latest",
+			Suspend: &suspend,
+		},
+	}
+}
+
+func TestReconciler_Reconcile_Hooks(t *testing.T) {
+	var calls []string
+	var postResult *project.ReconcileResult
+	reconciler := project.Reconciler{
+		Log: logr.Discard(),
+		PreReconcile: func(ctx context.Context, gProject gitops.GitOpsProject) error {
+			calls = append(calls, "pre:"+gProject.Name)
+			return errors.New("pre-check failed")
+		},
+		PostReconcile: func(
+			ctx context.Context,
+			gProject gitops.GitOpsProject,
+			result *project.ReconcileResult,
+			err error,
+		) error {
+			calls = append(calls, "post:"+gProject.Name)
+			postResult = result
+			assert.NilError(t, err)
+			return nil
+		},
+	}
+
+	result, err := reconciler.Reconcile(t.Context(), suspendedProject())
+	assert.NilError(t, err)
+	assert.Assert(t, result.Suspended)
+	assert.DeepEqual(t, calls, []string{"pre:test", "post:test"})
+	assert.Equal(t, postResult, result)
+}
+
+func TestReconciler_Reconcile_HookTimeout(t *testing.T) {
+	var hookErr error
+	reconciler := project.Reconciler{
+		Log:         logr.Discard(),
+		HookTimeout: 10 * time.Millisecond,
+		PostReconcile: func(
+			ctx context.Context,
+			gProject gitops.GitOpsProject,
+			result *project.ReconcileResult,
+			err error,
+		) error {
+			<-ctx.Done()
+			hookErr = ctx.Err()
+			return hookErr
+		},
+	}
+
+	result, err := reconciler.Reconcile(t.Context(), suspendedProject())
+	assert.NilError(t, err)
+	assert.Assert(t, result.Suspended)
+	assert.ErrorIs(t, hookErr, context.DeadlineExceeded)
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var notification project.WebhookNotification
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		notification = project.WebhookNotification{}
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if notification.Name == "unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	notifier := &project.WebhookNotifier{
+		URL: server.URL,
+		Headers: map[string]string{
+			"Authorization": "Bearer abcd",
+		},
+	}
+
+	gProject := suspendedProject()
+	err := notifier.Notify(t.Context(), gProject, &project.ReconcileResult{
+		Digest:                "sha256:abcd",
+		Tag:                   "1.2.0",
+		ComponentError:        errors.New("apply failed"),
+		ProgressingComponents: []string{"a___Namespace"},
+	}, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, authorization, "Bearer abcd")
+	assert.DeepEqual(t, notification, project.WebhookNotification{
+		Name:                  "test",
+		Namespace:             "default",
+		Digest:                "sha256:abcd",
+		Tag:                   "1.2.0",
+		ComponentError:        "apply failed",
+		ProgressingComponents: []string{"a___Namespace"},
+	})
+
+	err = notifier.Notify(t.Context(), gProject, nil, errors.New("load failed"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, notification, project.WebhookNotification{
+		Name:      "test",
+		Namespace: "default",
+		Error:     "load failed",
+	})
+
+	gProject.Name = "unavailable"
+	err = notifier.Notify(t.Context(), gProject, nil, nil)
+	assert.ErrorIs(t, err, project.ErrWebhookStatus)
+}
@@ -23,19 +23,28 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/kharf/navecd/internal/manifest"
 	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/component"
 	"github.com/kharf/navecd/pkg/kube"
 	"github.com/kharf/navecd/pkg/oci"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var (
 	ErrHelmInstallationUnsupported = errors.New("Helm installation not supported yet")
+	ErrInstallObject               = errors.New("Unable to install object")
+)
+
+const (
+	installBackoff    = 250 * time.Millisecond
+	maxInstallBackoff = 5 * time.Second
 )
 
 type InstallOptions struct {
@@ -48,6 +57,22 @@ type InstallOptions struct {
 	Interval         int
 	Shard            string
 	InsecureRegistry bool
+
+	// Namespace the controller and the GitOpsProject are installed into.
+	// Defaults to ControllerNamespace.
+	Namespace string
+
+	// Bundle is the path to an archive created by [BundleAction.Bundle].
+	// The controller manifests and project artifact of the bundle are installed instead of the local project.
+	Bundle string
+
+	// RegistryAuthFile is the path to a JSON file mapping registry hosts to their credentials, see [oci.LoadRegistryCredentials].
+	// It is installed as secret, which the controller mounts, and used to push the project artifact.
+	RegistryAuthFile string
+
+	// Log receives debug output of the installation, like registry requests.
+	// Defaults to discarding all output.
+	Log logr.Logger
 }
 
 type InstallAction struct {
@@ -70,134 +95,282 @@ func NewInstallAction(
 }
 
 func (act InstallAction) Install(ctx context.Context, opts InstallOptions) (string, error) {
-	navecdDir := filepath.Join(act.projectRoot, "navecd")
+	if opts.Bundle != "" {
+		return act.installBundle(ctx, opts)
+	}
+
+	if err := writeProjectFile(act.projectRoot, opts); err != nil {
+		return "", err
+	}
+
+	manifests, err := controllerManifests(act.componentBuilder, act.projectRoot, opts.Shard, opts.namespace())
+	if err != nil {
+		return "", err
+	}
+
+	manifests, registryCredentials, err := withRegistryAuthSecret(manifests, opts.RegistryAuthFile, opts.Shard)
+	if err != nil {
+		return "", err
+	}
+
+	opts.Log.V(1).Info("Installing controller manifests", "shard", opts.Shard, "count", len(manifests))
+	if err := act.installManifests(ctx, manifests, opts.Shard); err != nil {
+		return "", err
+	}
+
+	ociClient, err := oci.NewRepositoryClient(opts.Url, opts.InsecureRegistry)
+	if err != nil {
+		return "", err
+	}
+	projectClient := oci.NewProjectClient(ociClient)
+
+	pushOpts := []oci.ProjectClientOption{
+		oci.WithRepositoryOption(
+			oci.WithInsecure(opts.InsecureRegistry),
+		),
+		oci.WithRepositoryOption(
+			oci.WithLogger(opts.Log),
+		),
+	}
+	if len(registryCredentials) != 0 {
+		pushOpts = append(pushOpts, oci.WithRepositoryOption(oci.WithKeychain(registryCredentials)))
+	}
+
+	digest, err := projectClient.PushImageFromPath(
+		ctx,
+		opts.Ref,
+		act.projectRoot,
+		pushOpts...,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// registryAuthSecretName returns the name of the secret holding the registry credentials of the controller of the given shard.
+func registryAuthSecretName(shard string) string {
+	return fmt.Sprintf("%s-registry-auth", getControllerName(shard))
+}
+
+// withRegistryAuthSecret inserts a secret holding the registry credentials read from path right after the namespace of the controller manifests,
+// so that the controller finds them on its start.
+// The manifests are returned unchanged, if path is empty.
+func withRegistryAuthSecret(
+	manifests []*unstructured.Unstructured,
+	path string,
+	shard string,
+) ([]*unstructured.Unstructured, oci.RegistryCredentials, error) {
+	if path == "" {
+		return manifests, nil, nil
+	}
+
+	credentials, err := oci.LoadRegistryCredentials(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nsIndex := slices.IndexFunc(manifests, func(manifest *unstructured.Unstructured) bool {
+		return manifest.GetAPIVersion() == "v1" && manifest.GetKind() == "Namespace"
+	})
+	if nsIndex == -1 {
+		return nil, nil, fmt.Errorf("%w: controller namespace not found", ErrInstallObject)
+	}
+
+	secret := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]any{
+				"name":      registryAuthSecretName(shard),
+				"namespace": manifests[nsIndex].GetName(),
+			},
+			"stringData": map[string]any{
+				"credentials.json": string(content),
+			},
+		},
+	}
+
+	return slices.Insert(slices.Clone(manifests), nsIndex+1, secret), credentials, nil
+}
+
+func (opts InstallOptions) namespace() string {
+	if opts.Namespace == "" {
+		return ControllerNamespace
+	}
+	return opts.Namespace
+}
+
+// writeProjectFile renders the GitOpsProject declaration into the navecd dir of the project, if it does not exist yet.
+func writeProjectFile(projectRoot string, opts InstallOptions) error {
+	navecdDir := filepath.Join(projectRoot, "navecd")
 	projectFileName := filepath.Join(navecdDir, fmt.Sprintf("%s_project.cue", opts.Name))
 
 	_, err := os.Stat(projectFileName)
 	if err != nil && !os.IsNotExist(err) {
-		return "", err
+		return err
 	}
 
-	if os.IsNotExist(err) {
-		var projectBuf bytes.Buffer
-		projectTmpl, err := template.New("").Parse(manifest.Project)
-		if err != nil {
-			return "", err
-		}
+	if !os.IsNotExist(err) {
+		return nil
+	}
 
-		provider := ""
-		secretRef := ""
-		if opts.WIP != "" {
-			switch opts.WIP {
-			case string(cloud.AWS):
-				provider = "AWS"
+	var projectBuf bytes.Buffer
+	projectTmpl, err := template.New("").Parse(manifest.Project)
+	if err != nil {
+		return err
+	}
 
-			case string(cloud.Azure):
-				provider = "Azure"
+	provider := ""
+	secretRef := ""
+	if opts.WIP != "" {
+		switch opts.WIP {
+		case string(cloud.AWS):
+			provider = "AWS"
 
-			case string(cloud.GCP):
-				provider = "GCP"
-			}
-		} else if opts.SecretRef != "" {
-			secretRef = opts.SecretRef
-		}
+		case string(cloud.Azure):
+			provider = "Azure"
 
-		if err := projectTmpl.Execute(&projectBuf, map[string]any{
-			"Name":                opts.Name,
-			"Namespace":           ControllerNamespace,
-			"Url":                 opts.Url,
-			"Ref":                 opts.Ref,
-			"Dir":                 opts.Dir,
-			"PullIntervalSeconds": opts.Interval,
-			"Shard":               opts.Shard,
-			"Provider":            provider,
-			"SecretRef":           secretRef,
-		}); err != nil {
-			return "", err
+		case string(cloud.GCP):
+			provider = "GCP"
 		}
+	} else if opts.SecretRef != "" {
+		secretRef = opts.SecretRef
+	}
 
-		if err := os.WriteFile(projectFileName, projectBuf.Bytes(), 0666); err != nil {
-			return "", err
-		}
+	if err := projectTmpl.Execute(&projectBuf, map[string]any{
+		"Name":                opts.Name,
+		"Namespace":           opts.namespace(),
+		"Url":                 opts.Url,
+		"Ref":                 opts.Ref,
+		"Dir":                 opts.Dir,
+		"PullIntervalSeconds": opts.Interval,
+		"Shard":               opts.Shard,
+		"Provider":            provider,
+		"SecretRef":           secretRef,
+	}); err != nil {
+		return err
 	}
 
-	buildResult, err := act.componentBuilder.Build(
+	return os.WriteFile(projectFileName, projectBuf.Bytes(), 0666)
+}
+
+// controllerManifests builds the navecd package of the project and returns the manifests of the given shard in dependency order.
+// The namespace is injected as controllerNamespace tag variable.
+func controllerManifests(
+	componentBuilder component.Builder,
+	projectRoot string,
+	shard string,
+	namespace string,
+) ([]*unstructured.Unstructured, error) {
+	buildResult, err := componentBuilder.Build(
 		component.WithPackagePath("./navecd"),
-		component.WithProjectRoot(act.projectRoot),
+		component.WithProjectRoot(projectRoot),
+		component.WithTagVars(ClusterFacts{ControllerNamespace: namespace}.tagVars()),
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	dag := component.NewDependencyGraph()
 	if err := dag.Insert(buildResult.Instances...); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	instances, err := dag.TopologicalSort()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	controllerName := getControllerName(opts.Shard)
+	var manifests []*unstructured.Unstructured
 	for _, instance := range instances {
 		manifest, ok := instance.(*component.Manifest)
 		if !ok {
-			return "", ErrHelmInstallationUnsupported
+			return nil, ErrHelmInstallationUnsupported
 		}
 
-		if opts.Shard == manifest.GetLabels()["navecd/shard"] {
-			timeoutCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
-			defer cancel()
-
-			if err := act.installObject(
-				timeoutCtx,
-				manifest.Content.Unstructured,
-				controllerName,
-			); err != nil {
-				return "", err
-			}
+		if shard == manifest.GetLabels()["navecd/shard"] {
+			manifests = append(manifests, manifest.Content.Unstructured)
 		}
 	}
 
-	ociClient, err := oci.NewRepositoryClient(opts.Url, opts.InsecureRegistry)
-	if err != nil {
-		return "", err
-	}
-	projectClient := oci.NewProjectClient(ociClient)
+	return manifests, nil
+}
 
-	digest, err := projectClient.PushImageFromPath(
-		opts.Ref,
-		act.projectRoot,
-		oci.WithRepositoryOption(
-			oci.WithInsecure(opts.InsecureRegistry),
-		),
-	)
-	if err != nil {
-		return "", err
+func (act InstallAction) installManifests(
+	ctx context.Context,
+	manifests []*unstructured.Unstructured,
+	shard string,
+) error {
+	controllerName := getControllerName(shard)
+	for _, manifest := range manifests {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+		defer cancel()
+
+		if err := act.installObject(
+			timeoutCtx,
+			manifest,
+			controllerName,
+		); err != nil {
+			return err
+		}
 	}
 
-	return digest, nil
+	return nil
 }
 
+// installObject applies the object and retries with an exponential backoff as long as its dependencies,
+// like the namespace or the CRD, are not available yet.
+// When the context is done, the error identifies the object and the last API error.
 func (act InstallAction) installObject(
 	ctx context.Context,
 	unstr *unstructured.Unstructured,
 	fieldManager string,
 ) error {
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
+	backoff := installBackoff
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		_, err := act.kubeClient.Apply(ctx, unstr, fieldManager)
+		if err == nil {
+			return nil
+		}
 
-	if _, err := act.kubeClient.Apply(ctx, unstr, fieldManager); err != nil {
-		if k8sErrors.IsNotFound(err) {
-			time.Sleep(1 * time.Second)
-			return act.installObject(ctx, unstr, fieldManager)
+		if ctx.Err() == nil {
+			if !k8sErrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+				return err
+			}
+			lastErr = err
 		}
-		return err
+
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return fmt.Errorf(
+				"%w: %s %s after %d attempts: %w",
+				ErrInstallObject,
+				unstr.GetKind(),
+				objectRef(unstr),
+				attempt,
+				lastErr,
+			)
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxInstallBackoff)
 	}
+}
 
-	return nil
+func objectRef(unstr *unstructured.Unstructured) string {
+	if unstr.GetNamespace() == "" {
+		return unstr.GetName()
+	}
+	return fmt.Sprintf("%s/%s", unstr.GetNamespace(), unstr.GetName())
 }
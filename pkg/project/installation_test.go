@@ -24,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -37,7 +38,11 @@ import (
 	"gotest.tools/v3/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func defaultAssertion(
@@ -155,6 +160,14 @@ func TestInstallAction_Install(t *testing.T) {
 			name: "Run-Twice",
 			test: runTwice,
 		},
+		{
+			name: "Bundle",
+			test: bundle,
+		},
+		{
+			name: "Namespace",
+			test: namespace,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -172,6 +185,79 @@ func TestInstallAction_Install(t *testing.T) {
 	}
 }
 
+func TestInstallAction_Install_MissingDependency(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	kubernetes := kubetest.StartKubetestEnv(t, logr.Discard(), kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	registry, err := ocitest.NewTLSRegistryWithSchema()
+	assert.NilError(t, err)
+	defer registry.Close()
+
+	projectName := "stuck"
+	testProject := t.TempDir()
+	err = project.Init(
+		"github.com/owner/repo/installation",
+		projectName,
+		"image",
+		false,
+		testProject,
+		"0.26.99",
+	)
+	assert.NilError(t, err)
+
+	// the namespace of the config map is never created
+	err = os.WriteFile(
+		filepath.Join(testProject, "navecd", "stuck.cue"),
+		[]byte(`package navecd
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+stuck: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "stuck"
+			namespace: "missing"
+			labels:    _stuckLabels
+		}
+	}
+}
+`),
+		0666,
+	)
+	assert.NilError(t, err)
+
+	action := project.NewInstallAction(
+		kubernetes.DynamicTestKubeClient.DynamicClient(),
+		http.DefaultClient,
+		testProject,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = action.Install(
+		ctx,
+		project.InstallOptions{
+			Name:     projectName,
+			Shard:    projectName,
+			Ref:      ref,
+			Dir:      dir,
+			Interval: intervalInSeconds,
+			Url:      filepath.Join(registry.Addr(), projectName),
+		},
+	)
+	assert.ErrorIs(t, err, project.ErrInstallObject)
+	assert.ErrorContains(t, err, "ConfigMap missing/stuck")
+	assert.ErrorContains(t, err, `namespaces "missing" not found`)
+}
+
 func fresh(t *testing.T, testContext testContext) {
 	projectName := "fresh"
 	kubernetes := testContext.kubernetes
@@ -184,7 +270,7 @@ func fresh(t *testing.T, testContext testContext) {
 		"image",
 		false,
 		testProject,
-		"0.0.99",
+		"0.26.99",
 	)
 	assert.NilError(t, err)
 
@@ -223,7 +309,7 @@ func multiTenancy(t *testing.T, testContext testContext) {
 		"image",
 		false,
 		testProject,
-		"0.0.99",
+		"0.26.99",
 	)
 	assert.NilError(t, err)
 
@@ -262,7 +348,7 @@ func multiTenancy(t *testing.T, testContext testContext) {
 		"image",
 		true,
 		testProject,
-		"0.0.99",
+		"0.26.99",
 	)
 	assert.NilError(t, err)
 
@@ -294,7 +380,7 @@ func runTwice(t *testing.T, testContext testContext) {
 		"image",
 		false,
 		testProject,
-		"0.0.99",
+		"0.26.99",
 	)
 	assert.NilError(t, err)
 
@@ -334,3 +420,122 @@ func runTwice(t *testing.T, testContext testContext) {
 	assert.NilError(t, err)
 	defaultAssertion(t, kubernetes, registry, projectName, testProject, digest)
 }
+
+func bundle(t *testing.T, testContext testContext) {
+	projectName := "bundled"
+	kubernetes := testContext.kubernetes
+	registry := testContext.registry
+
+	testProject := t.TempDir()
+	err := project.Init(
+		"github.com/owner/repo/installation",
+		projectName,
+		"image",
+		false,
+		testProject,
+		"0.26.99",
+	)
+	assert.NilError(t, err)
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tgz")
+	bundledDigest, err := project.NewBundleAction(testProject).Bundle(
+		bundlePath,
+		project.InstallOptions{
+			Name:     projectName,
+			Shard:    projectName,
+			Ref:      ref,
+			Dir:      dir,
+			Interval: intervalInSeconds,
+			Url:      filepath.Join(registry.Addr(), projectName),
+		},
+	)
+	assert.NilError(t, err)
+
+	// the bundle is installed without access to the project sources.
+	action := project.NewInstallAction(
+		kubernetes.DynamicTestKubeClient.DynamicClient(),
+		http.DefaultClient,
+		t.TempDir(),
+	)
+
+	digest, err := action.Install(
+		context.Background(),
+		project.InstallOptions{
+			Bundle: bundlePath,
+		},
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, bundledDigest)
+
+	defaultAssertion(t, kubernetes, registry, projectName, testProject, digest)
+}
+
+func namespace(t *testing.T, testContext testContext) {
+	projectName := "tenant"
+	controllerNamespace := "tenant-system"
+	kubernetes := testContext.kubernetes
+	registry := testContext.registry
+
+	testProject := t.TempDir()
+	err := project.Init(
+		"github.com/owner/repo/installation",
+		projectName,
+		"image",
+		false,
+		testProject,
+		"0.26.99",
+	)
+	assert.NilError(t, err)
+
+	action := project.NewInstallAction(
+		kubernetes.DynamicTestKubeClient.DynamicClient(),
+		http.DefaultClient,
+		testProject,
+	)
+
+	ctx := context.Background()
+	_, err = action.Install(
+		ctx,
+		project.InstallOptions{
+			Name:      projectName,
+			Shard:     projectName,
+			Ref:       ref,
+			Dir:       dir,
+			Interval:  intervalInSeconds,
+			Url:       filepath.Join(registry.Addr(), projectName),
+			Namespace: controllerNamespace,
+		},
+	)
+	assert.NilError(t, err)
+
+	var ns v1.Namespace
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: controllerNamespace}, &ns)
+	assert.NilError(t, err)
+
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: project.ControllerNamespace}, &ns)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+
+	controllerName := fmt.Sprintf("%s-%s", "project-controller", projectName)
+	objects := []client.Object{
+		&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: controllerName}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: controllerName}},
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: projectName}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: controllerName}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: projectName + "-leader-election"}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: projectName + "-leader-election"}},
+		&gitops.GitOpsProject{ObjectMeta: metav1.ObjectMeta{Name: projectName}},
+	}
+	for _, obj := range objects {
+		err := kubernetes.TestKubeClient.Get(
+			ctx,
+			types.NamespacedName{Name: obj.GetName(), Namespace: controllerNamespace},
+			obj,
+		)
+		assert.NilError(t, err)
+	}
+
+	var clusterRoleBinding rbacv1.ClusterRoleBinding
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: controllerName}, &clusterRoleBinding)
+	assert.NilError(t, err)
+	assert.Equal(t, clusterRoleBinding.Subjects[0].Namespace, controllerNamespace)
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kharf/navecd/pkg/component"
+)
+
+// applyJobHooks orders hooks around their targets.
+// Targets depend on their pre hooks, so that they are only applied once the Job completed,
+// while post hooks depend on their targets.
+func applyJobHooks(dag *component.DependencyGraph, hooks map[string]component.JobHook) error {
+	ids := make([]string, 0, len(hooks))
+	for id := range hooks {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		hook := hooks[id]
+		target := dag.Get(hook.Target)
+		if target == nil {
+			return fmt.Errorf(
+				"%w: %s hook %s runs for %s, which is not declared in the project",
+				component.ErrUnknownComponentID,
+				hook.Phase,
+				id,
+				hook.Target,
+			)
+		}
+
+		dependent, dependency := target, id
+		if hook.Phase == component.PostHook {
+			dependent, dependency = dag.Get(id), hook.Target
+		}
+
+		deps := dependencies(dependent)
+		if deps != nil && !slices.Contains(*deps, dependency) {
+			*deps = append(*deps, dependency)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+)
+
+var (
+	ErrLeaseHeld = errors.New("Project is reconciled by another shard")
+	ErrLeaseLost = errors.New("Project lease was lost")
+)
+
+// DefaultLeaseDuration is used for project leases without a duration.
+const DefaultLeaseDuration = time.Minute
+
+// ProjectLease coordinates controller instances reconciling the same project, e.g. two shards during a resharding.
+// It is a Kubernetes Lease keyed by the project UID, which only one holder can acquire at a time.
+// Leases of holders, which did not renew them within their duration, can be taken over.
+type ProjectLease struct {
+	Client coordinationv1client.LeasesGetter
+
+	// Namespace the leases are stored in.
+	Namespace string
+
+	// Identity of the holder, e.g. the name of the controller pod.
+	Identity string
+
+	// Duration after which a lease, which was not renewed, expires.
+	// Defaults to DefaultLeaseDuration.
+	Duration time.Duration
+}
+
+func (lease *ProjectLease) name(projectUID types.UID) string {
+	return fmt.Sprintf("navecd-project-%s", projectUID)
+}
+
+func (lease *ProjectLease) duration() time.Duration {
+	if lease.Duration <= 0 {
+		return DefaultLeaseDuration
+	}
+	return lease.Duration
+}
+
+// Acquire acquires or renews the lease of the project.
+// It fails with ErrLeaseHeld, if another holder owns an unexpired lease or acquires it concurrently.
+func (lease *ProjectLease) Acquire(ctx context.Context, projectUID types.UID) error {
+	leases := lease.Client.Leases(lease.Namespace)
+	now := metav1.NewMicroTime(time.Now())
+	identity := lease.Identity
+	durationSeconds := int32(lease.duration().Seconds())
+
+	live, err := leases.Get(ctx, lease.name(projectUID), metav1.GetOptions{})
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return err
+		}
+
+		_, err := leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      lease.name(projectUID),
+				Namespace: lease.Namespace,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		if k8sErrors.IsAlreadyExists(err) {
+			return fmt.Errorf("%w: lease %s was acquired concurrently", ErrLeaseHeld, lease.name(projectUID))
+		}
+		return err
+	}
+
+	holder := holderIdentity(live)
+	if holder != "" && holder != lease.Identity && !expired(live, now.Time) {
+		return fmt.Errorf("%w: lease %s is held by %s", ErrLeaseHeld, live.GetName(), holder)
+	}
+
+	if holder != lease.Identity {
+		live.Spec.AcquireTime = &now
+	}
+	live.Spec.HolderIdentity = &identity
+	live.Spec.LeaseDurationSeconds = &durationSeconds
+	live.Spec.RenewTime = &now
+
+	// the resource version of the read lease makes concurrent acquisitions conflict.
+	if _, err := leases.Update(ctx, live, metav1.UpdateOptions{}); err != nil {
+		if k8sErrors.IsConflict(err) {
+			return fmt.Errorf("%w: lease %s was acquired concurrently", ErrLeaseHeld, live.GetName())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Release gives up the lease of the project, so that other holders can acquire it right away.
+// Leases held by others are left untouched.
+func (lease *ProjectLease) Release(ctx context.Context, projectUID types.UID) error {
+	leases := lease.Client.Leases(lease.Namespace)
+
+	live, err := leases.Get(ctx, lease.name(projectUID), metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if holderIdentity(live) != lease.Identity {
+		return nil
+	}
+
+	live.Spec.HolderIdentity = nil
+	live.Spec.AcquireTime = nil
+	live.Spec.RenewTime = nil
+	_, err = leases.Update(ctx, live, metav1.UpdateOptions{})
+	return err
+}
+
+// Delete removes the lease of the project, once its project is deleted.
+// Leases held by others are left untouched.
+func (lease *ProjectLease) Delete(ctx context.Context, projectUID types.UID) error {
+	leases := lease.Client.Leases(lease.Namespace)
+
+	live, err := leases.Get(ctx, lease.name(projectUID), metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	holder := holderIdentity(live)
+	if holder != "" && holder != lease.Identity && !expired(live, time.Now()) {
+		return fmt.Errorf("%w: lease %s is held by %s", ErrLeaseHeld, live.GetName(), holder)
+	}
+
+	resourceVersion := live.GetResourceVersion()
+	err = leases.Delete(ctx, live.GetName(), metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			ResourceVersion: &resourceVersion,
+		},
+	})
+	if k8sErrors.IsConflict(err) {
+		return fmt.Errorf("%w: lease %s was acquired concurrently", ErrLeaseHeld, live.GetName())
+	}
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func holderIdentity(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+type heldLeaseKey struct{}
+
+// HoldLease acquires the lease of the project and renews it in the background, until the returned function releases it.
+// The returned context is derived from ctx and canceled with ErrLeaseLost, once a renewal fails,
+// because another shard may take the lease over after it expired.
+// Reconciliations with the returned context do not acquire the lease again.
+func (reconciler *Reconciler) HoldLease(ctx context.Context, projectUID types.UID) (context.Context, func(), error) {
+	lease, err := reconciler.projectLease()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := lease.Acquire(ctx, projectUID); err != nil {
+		return nil, nil, err
+	}
+
+	leaseCtx, cancel := context.WithCancelCause(context.WithValue(ctx, heldLeaseKey{}, projectUID))
+	renewCtx, stopRenewal := context.WithCancel(leaseCtx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(lease.duration() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+				if err := lease.Acquire(renewCtx, projectUID); err != nil && renewCtx.Err() == nil {
+					reconciler.Log.Error(err, "Unable to renew project lease")
+					cancel(fmt.Errorf("%w: %w", ErrLeaseLost, err))
+					return
+				}
+			}
+		}
+	}()
+
+	return leaseCtx, func() {
+		stopRenewal()
+		<-done
+		cancel(context.Canceled)
+		if err := lease.Release(context.WithoutCancel(ctx), projectUID); err != nil {
+			reconciler.Log.Error(err, "Unable to release project lease")
+		}
+	}, nil
+}
+
+// DeleteLease removes the lease of a deleted project.
+func (reconciler *Reconciler) DeleteLease(ctx context.Context, projectUID types.UID) error {
+	lease, err := reconciler.projectLease()
+	if err != nil {
+		return err
+	}
+	return lease.Delete(ctx, projectUID)
+}
+
+func (reconciler *Reconciler) projectLease() (*ProjectLease, error) {
+	client, err := coordinationv1client.NewForConfig(reconciler.controllerRESTConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProjectLease{
+		Client:    client,
+		Namespace: reconciler.Namespace,
+		Identity:  reconciler.LeaseIdentity,
+		Duration:  reconciler.LeaseDuration,
+	}, nil
+}
+
+// holdsLease reports whether ctx was returned by HoldLease for the project.
+func holdsLease(ctx context.Context, projectUID types.UID) bool {
+	uid, ok := ctx.Value(heldLeaseKey{}).(types.UID)
+	return ok && uid == projectUID
+}
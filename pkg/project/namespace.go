@@ -0,0 +1,136 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"slices"
+
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
+	"github.com/kharf/navecd/pkg/inventory"
+	"github.com/kharf/navecd/pkg/kube"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// AutoCreatedLabel marks namespaces, which were created by Navecd because components targeted them without declaring them.
+	AutoCreatedLabel = "navecd.io/auto-created"
+)
+
+// insertMissingNamespaces adds a Namespace component to the dependency graph for every namespace,
+// which is targeted by a component, but not declared in the project.
+// Targeting components depend on the inserted namespace, so that it is applied first.
+// Namespaces are only inserted, if they do not exist yet or were already created by Navecd,
+// otherwise the garbage collector would remove foreign namespaces, once they are not targeted anymore.
+func insertMissingNamespaces(
+	ctx context.Context,
+	dag *component.DependencyGraph,
+	instances []component.Instance,
+	client *kube.DynamicClient,
+	inventoryInstance *inventory.Instance,
+) error {
+	storage, err := inventoryInstance.Load()
+	if err != nil {
+		return err
+	}
+
+	namespaceIDs := make(map[string]string)
+	for _, instance := range instances {
+		var namespace string
+		switch componentInstance := instance.(type) {
+		case *component.Manifest:
+			namespace = componentInstance.Content.GetNamespace()
+		case *helm.ReleaseComponent:
+			namespace = componentInstance.Content.Namespace
+		}
+
+		if namespace == "" {
+			continue
+		}
+
+		namespaceID, found := namespaceIDs[namespace]
+		if !found {
+			namespaceID = component.ManifestID("v1", "Namespace", namespace, "")
+			if dag.Get(namespaceID) == nil {
+				create, err := shouldCreateNamespace(ctx, namespaceID, namespace, client, storage)
+				if err != nil {
+					return err
+				}
+
+				if !create {
+					namespaceID = ""
+				} else if err := dag.Insert(newAutoCreatedNamespace(namespaceID, namespace)); err != nil {
+					return err
+				}
+			}
+			namespaceIDs[namespace] = namespaceID
+		}
+
+		deps := dependencies(instance)
+		if namespaceID != "" && !slices.Contains(*deps, namespaceID) {
+			*deps = append(*deps, namespaceID)
+		}
+	}
+
+	return nil
+}
+
+func shouldCreateNamespace(
+	ctx context.Context,
+	namespaceID string,
+	namespace string,
+	client *kube.DynamicClient,
+	storage *inventory.Storage,
+) (bool, error) {
+	if storage.HasItem(&inventory.ManifestItem{ID: namespaceID}) {
+		return true, nil
+	}
+
+	unstr := &unstructured.Unstructured{}
+	unstr.SetAPIVersion("v1")
+	unstr.SetKind("Namespace")
+	unstr.SetName(namespace)
+	if _, err := client.Get(ctx, unstr); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return false, nil
+}
+
+func newAutoCreatedNamespace(id string, name string) *component.Manifest {
+	return &component.Manifest{
+		ID: id,
+		Content: component.ExtendedUnstructured{
+			Unstructured: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Namespace",
+					"metadata": map[string]any{
+						"name": name,
+						"labels": map[string]any{
+							AutoCreatedLabel: "true",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"slices"
+
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// policyKinds restrict the objects of their namespace from the moment those are created.
+var policyKinds = map[schema.GroupKind]struct{}{
+	{Kind: "ResourceQuota"}:                             {},
+	{Kind: "LimitRange"}:                                {},
+	{Group: "networking.k8s.io", Kind: "NetworkPolicy"}: {},
+}
+
+// orderPolicies lets all manifests and Helm releases of a namespace depend on the policy objects of that namespace,
+// so that quotas, limits and network policies are enforced on workloads from their creation on.
+// Dependencies are not added, if a policy object itself depends on the component, to avoid cycles.
+func orderPolicies(dag *component.DependencyGraph) error {
+	instances, err := dag.TopologicalSort()
+	if err != nil {
+		return err
+	}
+
+	policiesByNamespace := make(map[string][]string)
+	for _, instance := range instances {
+		if manifest, ok := instance.(*component.Manifest); ok && isPolicy(manifest) && manifest.GetNamespace() != "" {
+			policiesByNamespace[manifest.GetNamespace()] = append(policiesByNamespace[manifest.GetNamespace()], manifest.ID)
+		}
+	}
+
+	if len(policiesByNamespace) == 0 {
+		return nil
+	}
+
+	for _, instance := range instances {
+		var namespace string
+		switch componentInstance := instance.(type) {
+		case *component.Manifest:
+			if isPolicy(componentInstance) {
+				continue
+			}
+			namespace = componentInstance.GetNamespace()
+		case *helm.ReleaseComponent:
+			namespace = componentInstance.Content.Namespace
+		}
+
+		if namespace == "" {
+			continue
+		}
+
+		deps := dependencies(instance)
+		for _, policyID := range policiesByNamespace[namespace] {
+			if slices.Contains(*deps, policyID) || dependsOn(dag, policyID, instance.GetID()) {
+				continue
+			}
+			*deps = append(*deps, policyID)
+		}
+	}
+
+	return nil
+}
+
+func isPolicy(manifest *component.Manifest) bool {
+	gvk := schema.FromAPIVersionAndKind(manifest.GetAPIVersion(), manifest.GetKind())
+	_, found := policyKinds[gvk.GroupKind()]
+	return found
+}
+
+// dependsOn reports whether the component with the id from directly or transitively depends on the component with the id to.
+func dependsOn(dag *component.DependencyGraph, from string, to string) bool {
+	visited := make(map[string]struct{})
+	pending := []string{from}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if _, found := visited[id]; found {
+			continue
+		}
+		visited[id] = struct{}{}
+
+		instance := dag.Get(id)
+		if instance == nil {
+			continue
+		}
+
+		for _, dep := range instance.GetDependencies() {
+			if dep == to {
+				return true
+			}
+			pending = append(pending, dep)
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+
+	gitops "github.com/kharf/navecd/api/v1beta1"
+	"github.com/kharf/navecd/pkg/kube"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// projectOwner returns the owner set on the manifests of the project.
+func projectOwner(gProject gitops.GitOpsProject) kube.Owner {
+	return kube.Owner{
+		APIVersion: gitops.GroupVersion.String(),
+		Kind:       "GitOpsProject",
+		Name:       gProject.GetName(),
+		Namespace:  gProject.GetNamespace(),
+		UID:        gProject.GetUID(),
+	}
+}
+
+// DeleteOwnedObjects deletes the objects of a deleted project, which Kubernetes does not delete together with it,
+// because they are cluster-scoped or in another namespace and cannot reference it with an owner reference.
+// They are selected by their owner labels, see [kube.SetOwner], with the impersonated service account of the project.
+func (reconciler *Reconciler) DeleteOwnedObjects(ctx context.Context, gProject gitops.GitOpsProject) error {
+	client, err := kube.NewDynamicClient(reconciler.RESTConfig(gProject), kube.WithDiscoveryCache(reconciler.DiscoveryCache))
+	if err != nil {
+		return err
+	}
+
+	objs, err := client.ListSelected(ctx, kube.OwnerSelector(projectOwner(gProject)))
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objs {
+		var opts []kube.DeleteOption
+		if reconciler.DeletePropagation != "" {
+			opts = append(opts, kube.PropagationPolicy(reconciler.DeletePropagation))
+		}
+		if err := client.Delete(ctx, &obj, opts...); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		reconciler.Log.V(1).Info("Deleted owned object", "kind", obj.GetKind(), "namespace", obj.GetNamespace(), "name", obj.GetName())
+	}
+
+	return nil
+}
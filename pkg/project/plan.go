@@ -0,0 +1,167 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"context"
+	"os"
+	"slices"
+
+	"github.com/go-logr/logr"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
+	"github.com/kharf/navecd/pkg/kube"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+)
+
+type PlanOptions struct {
+	// Dir of the project configuration inside the project root.
+	Dir string
+
+	FieldManager string
+}
+
+// Plan describes the changes applying a project would make to a cluster.
+type Plan struct {
+	// Objects holds every object, which would be changed, in dependency order of their components.
+	Objects []PlannedObject `json:"objects"`
+}
+
+// PlannedObject is a single object, which would be created or updated.
+type PlannedObject struct {
+	// ID of the component declaring the object. Objects rendered by a Helm chart share the id of their release.
+	ID         string `json:"id"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+
+	// Create reports whether the object does not exist yet. It is not reported for objects rendered by Helm charts.
+	Create bool `json:"create,omitempty"`
+
+	// Difference holds the changed declared fields. All declared fields are reported for objects, which would be created.
+	Difference kube.Difference `json:"difference"`
+}
+
+// PlanAction computes the changes of a local project against a cluster without applying them.
+type PlanAction struct {
+	log         logr.Logger
+	kubeConfig  *rest.Config
+	projectRoot string
+}
+
+func NewPlanAction(
+	log logr.Logger,
+	kubeConfig *rest.Config,
+	projectRoot string,
+) PlanAction {
+	return PlanAction{
+		log:         log,
+		kubeConfig:  kubeConfig,
+		projectRoot: projectRoot,
+	}
+}
+
+// Plan loads the local project and compares the declared fields of all manifests, patches and objects rendered by Helm charts
+// with their live objects. Unchanged objects and suspended components are omitted.
+// Nothing is applied or installed.
+func (act PlanAction) Plan(ctx context.Context, opts PlanOptions) (*Plan, error) {
+	componentBuilder := component.NewBuilder()
+	projectManager := NewManager(componentBuilder, -1)
+
+	projectInstance, err := projectManager.Load(ctx, act.projectRoot, opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	componentInstances, err := projectInstance.Dag.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	kubeDynamicClient, err := kube.NewExtendedDynamicClient(act.kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	chartReconciler := helm.ChartReconciler{
+		KubeConfig:     act.kubeConfig,
+		Client:         kubeDynamicClient,
+		FieldManager:   opts.FieldManager,
+		Log:            act.log,
+		ChartCacheRoot: os.TempDir(),
+	}
+
+	differ := kube.Differ{}
+	dynClient := kubeDynamicClient.DynamicClient()
+	plan := &Plan{
+		Objects: []PlannedObject{},
+	}
+	for _, instance := range componentInstances {
+		if slices.Contains(projectInstance.Suspended, instance.GetID()) {
+			continue
+		}
+
+		var desired *unstructured.Unstructured
+		switch componentInstance := instance.(type) {
+		case *component.Manifest:
+			desired = componentInstance.Content.Unstructured
+		case *component.Patch:
+			desired = componentInstance.Content.Unstructured
+		case *helm.ReleaseComponent:
+			differences, err := chartReconciler.Diff(ctx, componentInstance)
+			if err != nil {
+				return nil, err
+			}
+			for _, difference := range differences {
+				plan.Objects = append(plan.Objects, plannedObject(instance.GetID(), difference.Object, false, difference.Difference))
+			}
+			continue
+		}
+
+		live, err := dynClient.Get(ctx, desired)
+		if err != nil {
+			if !k8sErrors.IsNotFound(err) {
+				return nil, err
+			}
+			live = nil
+		}
+
+		difference, err := differ.Diff(live, desired)
+		if err != nil {
+			return nil, err
+		}
+
+		if !difference.IsEmpty() {
+			plan.Objects = append(plan.Objects, plannedObject(instance.GetID(), desired, live == nil, *difference))
+		}
+	}
+
+	return plan, nil
+}
+
+func plannedObject(id string, obj *unstructured.Unstructured, create bool, difference kube.Difference) PlannedObject {
+	return PlannedObject{
+		ID:         id,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		Create:     create,
+		Difference: difference,
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kharf/navecd/internal/dnstest"
+	"github.com/kharf/navecd/internal/projecttest"
+	"github.com/kharf/navecd/pkg/kube"
+	"github.com/kharf/navecd/pkg/oci"
+	"github.com/kharf/navecd/pkg/project"
+	"gotest.tools/v3/assert"
+)
+
+func TestPlan_RoundTrip(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	plan := project.Plan{
+		Objects: []project.PlannedObject{
+			{
+				ID:         "prometheus___Namespace",
+				APIVersion: "v1",
+				Kind:       "Namespace",
+				Name:       "prometheus",
+				Create:     true,
+				Difference: kube.Difference{
+					Changes: []kube.FieldChange{
+						{Path: "metadata.name", Desired: "prometheus"},
+					},
+				},
+			},
+			{
+				ID:         "prometheus_prometheus_apps_Deployment",
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "prometheus",
+				Namespace:  "prometheus",
+				Difference: kube.Difference{
+					Changes: []kube.FieldChange{
+						{
+							Path:    "spec.template.spec.containers[0].image",
+							Current: "prometheus:1.0.0",
+							Desired: "prometheus:1.1.0",
+						},
+					},
+				},
+			},
+		},
+	}
+	planJSON, err := json.Marshal(plan)
+	assert.NilError(t, err)
+
+	client, err := oci.NewRepositoryClient(env.OCIRegistry.Addr()+"/plans", false)
+	assert.NilError(t, err)
+	projectClient := oci.NewProjectClient(client)
+
+	_, err = projectClient.PushPlan(t.Context(), "review", planJSON)
+	assert.NilError(t, err)
+
+	image, err := client.Image("review")
+	assert.NilError(t, err)
+	manifest, err := image.Manifest()
+	assert.NilError(t, err)
+	assert.Equal(t, string(manifest.Config.MediaType), oci.PlanArtifactType)
+
+	loadedJSON, err := projectClient.LoadPlan(t.Context(), "review")
+	assert.NilError(t, err)
+
+	var loadedPlan project.Plan
+	err = json.Unmarshal(loadedJSON, &loadedPlan)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, loadedPlan, plan)
+
+	repository := env.PushProject(t, "planned", "latest", []byte(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/planned@v0"
+language: version: "v0.9.0"
+`))
+	projectRepositoryClient, err := oci.NewRepositoryClient(repository.Name, false)
+	assert.NilError(t, err)
+	_, err = oci.NewProjectClient(projectRepositoryClient).LoadPlan(t.Context(), "latest")
+	assert.ErrorIs(t, err, oci.ErrWrongMediaType)
+}
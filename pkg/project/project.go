@@ -19,10 +19,15 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/mod/modconfig"
+	internalCue "github.com/kharf/navecd/internal/cue"
 	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/component"
 	"golang.org/x/sync/errgroup"
@@ -33,6 +38,14 @@ type options struct {
 
 	// optional auth used when loader is not nil
 	auth *cloud.Auth
+
+	facts *ClusterFacts
+
+	cueRegistry *CUERegistryConfig
+
+	orderPolicies bool
+
+	strict bool
 }
 
 type Option func(opts *options)
@@ -49,8 +62,56 @@ func WithAuth(auth *cloud.Auth) Option {
 	}
 }
 
+// WithClusterFacts injects the facts into fields declaring a matching tag variable.
+// See [ClusterFacts] for the available variables.
+func WithClusterFacts(facts ClusterFacts) Option {
+	return func(opts *options) {
+		opts.facts = &facts
+	}
+}
+
+// CUERegistryConfig configures the registries CUE module dependencies, like the Navecd schema, are fetched from.
+type CUERegistryConfig struct {
+	// Registry has the format of the CUE_REGISTRY environment variable, e.g. registry.example.com/cue-modules.
+	// Defaults to the CUE_REGISTRY environment variable.
+	Registry string
+
+	// Credentials authenticate at the registry hosts they are keyed with, e.g. registry.example.com.
+	Credentials map[string]cloud.Credentials
+}
+
+// WithCUERegistry fetches CUE module dependencies from the configured registries instead of the ones of the environment.
+func WithCUERegistry(config CUERegistryConfig) Option {
+	return func(opts *options) {
+		opts.cueRegistry = &config
+	}
+}
+
+// WithPolicyOrdering applies ResourceQuotas, LimitRanges and NetworkPolicies before all other components of their namespace
+// without declaring dependencies.
+func WithPolicyOrdering(enabled bool) Option {
+	return func(opts *options) {
+		opts.orderPolicies = enabled
+	}
+}
+
+// WithStrictComponents fails loading on component fields, which are not defined by the schema, see [component.WithStrict].
+func WithStrictComponents(enabled bool) Option {
+	return func(opts *options) {
+		opts.strict = enabled
+	}
+}
+
 var (
 	ErrLoadProject = errors.New("Could not load project")
+
+	// ErrNoComponents is returned, when the config directory contains no CUE packages,
+	// so that a wrong directory does not lead to pruning all components.
+	ErrNoComponents = errors.New("No CUE packages found")
+
+	// ErrDirNotFound is returned, when the project was loaded, but does not contain the requested config directory.
+	// It distinguishes a wrong directory from an artifact, which failed to load.
+	ErrDirNotFound = errors.New("Config directory not found in project")
 )
 
 // Manager loads a navecd project and resolves the component dependency graph.
@@ -66,15 +127,46 @@ func NewManager(componentBuilder component.Builder, workerPoolSize int) Manager
 	}
 }
 
+// WorkerPoolSize returns the concurrency level used for loading projects.
+func (manager *Manager) WorkerPoolSize() int {
+	return manager.workerPoolSize
+}
+
 // Instance represents the loaded project.
 type Instance struct {
 	Digest    Digest
 	Path      string
 	LoadError error
 	Dag       *component.DependencyGraph
+
+	// Warnings are non-fatal findings of building the project packages.
+	Warnings []string
+
+	// Suspended holds the sorted ids of components flagged with the suspend attribute.
+	Suspended []string
+
+	// Keep holds the ids of manifests flagged with the keep attribute.
+	Keep []string
+
+	// Requires maps ids of components to the APIs declared with the requires attribute.
+	Requires map[string][]string
+
+	// WaitFor maps ids of components to the conditions declared with the waitFor attribute.
+	WaitFor map[string]component.WaitCondition
+
+	// Hooks maps ids of Job manifests to the hooks declared with the hook attribute.
+	Hooks map[string]component.JobHook
+
+	// Sources maps ids of components to the position of their declaration in the format file:line.
+	Sources map[string]string
+
+	// SchemaVersion is the navecd schema version pinned by the project, if any.
+	SchemaVersion string
 }
 
 // Load uses a given path to a project and returns the components as a directed acyclic dependency graph.
+// It fails with ErrNoComponents, if the config directory contains no CUE packages,
+// and with ErrDirNotFound, if the project does not contain the config directory.
 func (manager *Manager) Load(
 	ctx context.Context,
 	projectPath string,
@@ -117,6 +209,11 @@ func (manager *Manager) Load(
 	}
 
 	if _, err := os.Stat(configPath); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w: %s", ErrLoadProject, ErrDirNotFound, dir)
+	}
+
+	schemaVersion, err := checkSchemaVersion(projectPath)
+	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
 	}
 
@@ -124,22 +221,96 @@ func (manager *Manager) Load(
 	producerEg.SetLimit(manager.workerPoolSize)
 
 	resultChan := make(chan *component.DependencyGraph, 1)
+	var warnings []string
+	waves := make(map[string]int)
+	var suspended []string
+	var keep []string
+	requires := make(map[string][]string)
+	waitFor := make(map[string]component.WaitCondition)
+	hooks := make(map[string]component.JobHook)
+	sources := make(map[string]string)
 	packageChan := make(chan string, 250)
 
+	var tagVars map[string]load.TagVar
+	if options.facts != nil {
+		tagVars = options.facts.tagVars()
+	}
+
+	var registry modconfig.Registry
+	if options.cueRegistry != nil {
+		credentials := make(map[string]internalCue.RegistryCredentials, len(options.cueRegistry.Credentials))
+		for host, creds := range options.cueRegistry.Credentials {
+			credentials[host] = internalCue.RegistryCredentials{
+				Username: creds.Username,
+				Password: creds.Password,
+			}
+		}
+
+		var err error
+		registry, err = internalCue.NewRegistry(options.cueRegistry.Registry, credentials, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+		}
+	}
+
+	packages := 0
 	consumerEg := &errgroup.Group{}
 	consumerEg.Go(func() error {
 		dag := component.NewDependencyGraph()
+		var buildErr error
 		for packagePath := range packageChan {
+			packages++
+			// Keep draining the channel after an error, otherwise producers block on a full buffer forever.
+			if buildErr != nil {
+				continue
+			}
+
 			buildResult, err := manager.componentBuilder.Build(
 				component.WithProjectRoot(projectPath),
 				component.WithPackagePath(packagePath),
+				component.WithTagVars(tagVars),
+				component.WithRegistry(registry),
+				component.WithStrict(options.strict),
 			)
 			if err != nil {
-				return fmt.Errorf("%w: %w", ErrLoadProject, err)
+				buildErr = err
+				continue
 			}
 
 			if err := dag.Insert(buildResult.Instances...); err != nil {
-				return fmt.Errorf("%w: %w", ErrLoadProject, err)
+				buildErr = err
+				continue
+			}
+
+			warnings = append(warnings, buildResult.Warnings...)
+			maps.Copy(waves, buildResult.Waves)
+			suspended = append(suspended, buildResult.Suspended...)
+			keep = append(keep, buildResult.Keep...)
+			maps.Copy(requires, buildResult.Requires)
+			maps.Copy(waitFor, buildResult.WaitFor)
+			maps.Copy(hooks, buildResult.Hooks)
+			maps.Copy(sources, buildResult.Sources)
+		}
+
+		if buildErr != nil {
+			return buildErr
+		}
+
+		if err := applyJobHooks(&dag, hooks); err != nil {
+			return err
+		}
+
+		if err := dag.Validate(); err != nil {
+			return err
+		}
+
+		if err := applyWaves(&dag, waves); err != nil {
+			return err
+		}
+
+		if options.orderPolicies {
+			if err := orderPolicies(&dag); err != nil {
+				return err
 			}
 		}
 
@@ -147,26 +318,37 @@ func (manager *Manager) Load(
 		return nil
 	})
 
-	if err := walkDir(projectPath, configPath, producerEg, packageChan); err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
-	}
+	// The channel must always be closed after all producers are done, so that the consumer terminates.
+	walkErr := walkDir(projectPath, configPath, producerEg, packageChan)
+	producerErr := producerEg.Wait()
+	close(packageChan)
+	consumerErr := consumerEg.Wait()
 
-	if err := producerEg.Wait(); err != nil {
+	if err := errors.Join(walkErr, producerErr, consumerErr); err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
 	}
-	close(packageChan)
 
-	if err := consumerEg.Wait(); err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrLoadProject, err)
+	if packages == 0 {
+		return nil, fmt.Errorf("%w: %w in %s", ErrLoadProject, ErrNoComponents, configPath)
 	}
 
 	dag := <-resultChan
+	slices.Sort(suspended)
 
 	return &Instance{
 		Digest:    digest,
 		Path:      configPath,
 		LoadError: downloadErr,
 		Dag:       dag,
+		Warnings:  warnings,
+		Suspended: suspended,
+		Keep:      keep,
+		Requires:  requires,
+		WaitFor:   waitFor,
+		Hooks:     hooks,
+		Sources:   sources,
+
+		SchemaVersion: schemaVersion,
 	}, nil
 }
 
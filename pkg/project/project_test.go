@@ -15,18 +15,23 @@
 package project_test
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/kharf/navecd/internal/dnstest"
 	"github.com/kharf/navecd/internal/ocitest"
 	"github.com/kharf/navecd/internal/projecttest"
 	"github.com/kharf/navecd/internal/testtemplates"
 	"github.com/kharf/navecd/internal/txtar"
+	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/component"
 	"github.com/kharf/navecd/pkg/oci"
 	"github.com/kharf/navecd/pkg/project"
@@ -57,7 +62,7 @@ module: "github.com/kharf/navecd/internal/controller/projectone@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -123,7 +128,7 @@ module: "github.com/kharf/navecd/internal/controller/projectone@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -209,54 +214,1175 @@ ns: component.#Manifest & {
 			dag := instance.Dag
 
 			for _, expectedManifest := range tc.expectedManifests {
-				apiVersionSplit := strings.Split(expectedManifest.apiVersion, "/")
-				group := ""
-				if len(apiVersionSplit) == 2 {
-					group = apiVersionSplit[0]
-				}
-				manifest := dag.Get(
-					fmt.Sprintf(
-						"%s_%s_%s_%s",
-						expectedManifest.name,
-						expectedManifest.namespace,
-						group,
-						expectedManifest.kind,
-					),
+				manifest := dag.GetByRef(
+					expectedManifest.apiVersion,
+					expectedManifest.kind,
+					expectedManifest.name,
+					expectedManifest.namespace,
+				)
+
+				assert.Assert(t, manifest != nil)
+				compManifest, ok := manifest.(*component.Manifest)
+				assert.Assert(t, ok)
+				assert.Equal(t, compManifest.GetAPIVersion(), expectedManifest.apiVersion)
+				assert.Equal(t, compManifest.GetKind(), expectedManifest.kind)
+				assert.Equal(t, compManifest.GetName(), expectedManifest.name)
+				assert.Equal(t, compManifest.GetNamespace(), expectedManifest.namespace)
+			}
+
+			for _, unexpectedManifest := range tc.unexpectedManifests {
+				manifest := dag.GetByRef(
+					unexpectedManifest.apiVersion,
+					unexpectedManifest.kind,
+					unexpectedManifest.name,
+					unexpectedManifest.namespace,
+				)
+
+				assert.Assert(t, manifest == nil)
+			}
+		})
+	}
+}
+
+func TestManager_Load_UnrecoverableError(t *testing.T) {
+	var err error
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	_, err = pm.Load(
+		t.Context(),
+		filepath.Join(env.TestRoot, "project"),
+		".",
+		project.WithRemoteLoader(&projecttest.FakeRemoteLoader{
+			Err: &oci.UnrecoverableError{},
+		}),
+	)
+	assert.ErrorIs(t, err, project.ErrLoadProject)
+}
+
+func TestManager_Load_Retry(t *testing.T) {
+	var err error
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	projectPath := filepath.Join(env.TestRoot, "project")
+	template := fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/projectone@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- dev/infra/toola/namespace.cue --
+package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "toola"
+	}
+}
+`, testtemplates.ModuleVersion)
+	_, err = txtar.Create(projectPath, strings.NewReader(template))
+	assert.NilError(t, err)
+
+	loadErr := &project.RecoverableLoadError{
+		BackupPath: projectPath,
+		Err:        errors.New("connection refused"),
+	}
+
+	flakyLoader := &projecttest.FlakyRemoteLoader{
+		Err:      loadErr,
+		Failures: 2,
+		Digest:   "sha256:abc",
+	}
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+		project.WithRemoteLoader(&project.RetryRemoteLoader{
+			Loader:  flakyLoader,
+			Retries: 2,
+			Backoff: time.Millisecond,
+		}),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, instance.LoadError)
+	assert.Equal(t, instance.Digest.Artifact, "sha256:abc")
+	assert.Equal(t, flakyLoader.Calls, 3)
+
+	flakyLoader = &projecttest.FlakyRemoteLoader{
+		Err:      loadErr,
+		Failures: 3,
+		Digest:   "sha256:abc",
+	}
+	instance, err = pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+		project.WithRemoteLoader(&project.RetryRemoteLoader{
+			Loader:  flakyLoader,
+			Retries: 2,
+			Backoff: time.Millisecond,
+		}),
+	)
+	assert.NilError(t, err)
+	assert.ErrorIs(t, instance.LoadError, loadErr)
+	assert.Equal(t, flakyLoader.Calls, 3)
+
+	flakyLoader = &projecttest.FlakyRemoteLoader{
+		Err:      &oci.UnrecoverableError{},
+		Failures: 1,
+	}
+	_, err = pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+		project.WithRemoteLoader(&project.RetryRemoteLoader{
+			Loader:  flakyLoader,
+			Retries: 2,
+			Backoff: time.Millisecond,
+		}),
+	)
+	assert.ErrorIs(t, err, project.ErrLoadProject)
+	assert.Equal(t, flakyLoader.Calls, 1)
+}
+
+func createPackages(t *testing.T, projectPath string, count int, broken bool) {
+	var builder strings.Builder
+	builder.WriteString(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/manypackages@v0"
+language: version: "v0.9.0"
+`)
+
+	for i := range count {
+		fmt.Fprintf(&builder, `
+-- infra/pkg%[1]d/namespace.cue --
+package pkg%[1]d
+
+ns: {
+	type: "Manifest"
+	id:   "ns%[1]d___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "ns%[1]d"
+	}
+}
+`, i)
+	}
+
+	if broken {
+		builder.WriteString(`
+-- infra/aaa/broken.cue --
+package aaa
+
+ns: {
+	type: "Manifest"
+`)
+	}
+
+	_, err := txtar.Create(projectPath, strings.NewReader(builder.String()))
+	assert.NilError(t, err)
+}
+
+func TestManager_Load_ManyPackages(t *testing.T) {
+	projectPath := t.TempDir()
+	createPackages(t, projectPath, 300, false)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+
+	for i := range 300 {
+		assert.Assert(t, instance.Dag.GetByRef("v1", "Namespace", fmt.Sprintf("ns%d", i), "") != nil)
+	}
+}
+
+func TestManager_Load_ManyPackages_BuildError(t *testing.T) {
+	projectPath := t.TempDir()
+	createPackages(t, projectPath, 300, true)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := pm.Load(
+			t.Context(),
+			projectPath,
+			".",
+		)
+		errChan <- err
+	}()
+
+	select {
+	case err := <-errChan:
+		assert.ErrorIs(t, err, project.ErrLoadProject)
+	case <-time.After(time.Minute):
+		t.Fatal("project load deadlocked")
+	}
+}
+
+func TestManager_Load_IncompatibleSchemaVersion(t *testing.T) {
+	testCases := []struct {
+		name       string
+		modulePath string
+		version    string
+	}{
+		{
+			name:       "Too-Old",
+			modulePath: "github.com/kharf/navecd/schema@v0",
+			version:    "v0.0.1",
+		},
+		{
+			name:       "Previous-Minor",
+			modulePath: "github.com/kharf/navecd/schema@v0",
+			version:    "v0.25.9",
+		},
+		{
+			name:       "Other-Major",
+			modulePath: "github.com/kharf/navecd/schema@v1",
+			version:    "v1.0.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			projectPath := t.TempDir()
+			_, err := txtar.Create(projectPath, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/schemaversion@v0"
+language: version: "%s"
+deps: {
+	"%s": {
+		v: "%s"
+	}
+}
+
+-- infra/toola/namespace.cue --
+package toola
+
+ns: {}
+`, testtemplates.ModuleVersion, tc.modulePath, tc.version)))
+			assert.NilError(t, err)
+
+			pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+			_, err = pm.Load(
+				t.Context(),
+				projectPath,
+				".",
+			)
+			assert.ErrorIs(t, err, project.ErrLoadProject)
+			assert.ErrorIs(t, err, project.ErrIncompatibleSchemaVersion)
+			assert.ErrorContains(t, err, "Please pin github.com/kharf/navecd/schema@v0 to a compatible version")
+		})
+	}
+}
+
+func TestManager_Load_NoComponents(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/nocomponents@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/toola/README.md --
+no cue files
+`, testtemplates.ModuleVersion)))
+	assert.NilError(t, err)
+
+	err = os.MkdirAll(filepath.Join(projectPath, "empty"), 0700)
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	for _, dir := range []string{"empty", "infra"} {
+		_, err = pm.Load(
+			t.Context(),
+			projectPath,
+			dir,
+		)
+		assert.ErrorIs(t, err, project.ErrLoadProject)
+		assert.ErrorIs(t, err, project.ErrNoComponents)
+	}
+}
+
+func TestManager_Load_CUERegistry(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	cueModuleRegistry, err := ocitest.NewTLSRegistryWithSchema(ocitest.WithPrivate(true))
+	assert.NilError(t, err)
+	defer cueModuleRegistry.Close()
+
+	// only the configured registry knows the schema.
+	t.Setenv("CUE_REGISTRY", "none")
+
+	projectPath := t.TempDir()
+	_, err = txtar.Create(projectPath, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/cueregistry@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/toola/namespace.cue --
+package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "toola"
+	}
+}
+`, testtemplates.ModuleVersion)))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	// a fresh cache forces the module to be fetched from the registry.
+	t.Setenv("CUE_CACHE_DIR", t.TempDir())
+	_, err = pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+		project.WithCUERegistry(project.CUERegistryConfig{
+			Registry: cueModuleRegistry.Addr(),
+			Credentials: map[string]cloud.Credentials{
+				cueModuleRegistry.Addr(): {
+					Username: "navecd",
+					Password: "wrong",
+				},
+			},
+		}),
+	)
+	assert.ErrorIs(t, err, component.ErrCUEBuildError)
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+		project.WithCUERegistry(project.CUERegistryConfig{
+			Registry: cueModuleRegistry.Addr(),
+			Credentials: map[string]cloud.Credentials{
+				cueModuleRegistry.Addr(): {
+					Username: "navecd",
+					Password: "abcd",
+				},
+			},
+		}),
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, instance.Dag.GetByRef("v1", "Namespace", "toola", "") != nil)
+}
+
+func TestManager_Load_SchemaVersions(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	versions := []string{"v0.26.99", "v0.27.0"}
+	cueModuleRegistry, err := ocitest.NewTLSRegistryWithSchema(ocitest.WithSchemaVersions(versions...))
+	assert.NilError(t, err)
+	defer cueModuleRegistry.Close()
+
+	// only the configured registry knows the schema.
+	t.Setenv("CUE_REGISTRY", "none")
+	// a fresh cache forces every pinned version to be fetched from the registry.
+	t.Setenv("CUE_CACHE_DIR", t.TempDir())
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	projectPaths := make([]string, 0, len(versions))
+	for i, version := range versions {
+		projectPath := t.TempDir()
+		_, err := txtar.Create(projectPath, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/schemaversions%d@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "%s"
+	}
+}
+
+-- infra/toola/namespace.cue --
+package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "toola%d"
+	}
+}
+`, i, testtemplates.ModuleVersion, version, i)))
+		assert.NilError(t, err)
+		projectPaths = append(projectPaths, projectPath)
+	}
+
+	// projects pinned to different versions are loaded side by side, like during a rolling upgrade.
+	var wg sync.WaitGroup
+	instances := make([]*project.Instance, len(versions))
+	errs := make([]error, len(versions))
+	for i, projectPath := range projectPaths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instances[i], errs[i] = pm.Load(
+				t.Context(),
+				projectPath,
+				".",
+				project.WithCUERegistry(project.CUERegistryConfig{
+					Registry: cueModuleRegistry.Addr(),
+				}),
+			)
+		}()
+	}
+	wg.Wait()
+
+	for i, version := range versions {
+		assert.NilError(t, errs[i])
+		assert.Equal(t, instances[i].SchemaVersion, version)
+		assert.Assert(t, instances[i].Dag.GetByRef("v1", "Namespace", fmt.Sprintf("toola%d", i), "") != nil)
+	}
+}
+
+func TestManager_Load_Waves(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/waves@v0"
+language: version: "v0.9.0"
+
+-- infra/waves/components.cue --
+package waves
+
+_namespace: {
+	_name: string
+	type:  "Manifest"
+	id:    "\(_name)___Namespace"
+	dependencies: [...string]
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: _name
+	}
+}
+
+crds: _namespace & {_name: "crds"}
+operator: _namespace & {
+	_name: "operator"
+	dependencies: [crds.id]
+}
+app: _namespace & {_name: "app"} @wave(1)
+monitoring: _namespace & {_name: "monitoring"} @wave(1)
+dashboards: _namespace & {_name: "dashboards"} @wave(2)
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+
+	instances, err := instance.Dag.TopologicalSort()
+	assert.NilError(t, err)
+
+	layerNumbers := make(map[string]int)
+	for layerNumber, layer := range component.Layer(instances) {
+		for _, instance := range layer.Components {
+			layerNumbers[instance.GetID()] = layerNumber
+		}
+	}
+
+	waves := [][]string{
+		{"crds___Namespace", "operator___Namespace"},
+		{"app___Namespace", "monitoring___Namespace"},
+		{"dashboards___Namespace"},
+	}
+	for i := 1; i < len(waves); i++ {
+		for _, previous := range waves[i-1] {
+			for _, current := range waves[i] {
+				assert.Assert(
+					t,
+					layerNumbers[previous] < layerNumbers[current],
+					"%s of wave %d has to be applied before %s of wave %d",
+					previous,
+					i-1,
+					current,
+					i,
 				)
+			}
+		}
+	}
+	assert.Assert(t, layerNumbers["crds___Namespace"] < layerNumbers["operator___Namespace"])
+}
+
+func TestManager_Load_PolicyOrdering(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/policyordering@v0"
+language: version: "v0.9.0"
+
+-- infra/policyordering/components.cue --
+package policyordering
+
+_manifest: {
+	_apiVersion: string
+	_kind:       string
+	_name:       string
+	_namespace:  string
+	type:        "Manifest"
+	id:          "\(_name)_\(_namespace)__\(_kind)"
+	dependencies: [...string]
+	content: {
+		apiVersion: _apiVersion
+		kind:       _kind
+		metadata: {
+			name:      _name
+			namespace: _namespace
+		}
+	}
+}
+
+quota: _manifest & {
+	_apiVersion: "v1"
+	_kind:       "ResourceQuota"
+	_name:       "quota"
+	_namespace:  "app"
+}
+limits: _manifest & {
+	_apiVersion: "v1"
+	_kind:       "LimitRange"
+	_name:       "limits"
+	_namespace:  "app"
+	dependencies: [deployment.id]
+}
+deployment: _manifest & {
+	_apiVersion: "apps/v1"
+	_kind:       "Deployment"
+	_name:       "app"
+	_namespace:  "app"
+}
+otherDeployment: _manifest & {
+	_apiVersion: "apps/v1"
+	_kind:       "Deployment"
+	_name:       "other"
+	_namespace:  "other"
+}
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	testCases := []struct {
+		name            string
+		opts            []project.Option
+		expectedOrdered bool
+	}{
+		{
+			name:            "Enabled",
+			opts:            []project.Option{project.WithPolicyOrdering(true)},
+			expectedOrdered: true,
+		},
+		{
+			name:            "Disabled",
+			expectedOrdered: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance, err := pm.Load(
+				t.Context(),
+				projectPath,
+				".",
+				tc.opts...,
+			)
+			assert.NilError(t, err)
+
+			instances, err := instance.Dag.TopologicalSort()
+			assert.NilError(t, err)
+
+			layerNumbers := make(map[string]int)
+			for layerNumber, layer := range component.Layer(instances) {
+				for _, instance := range layer.Components {
+					layerNumbers[instance.GetID()] = layerNumber
+				}
+			}
+
+			assert.Equal(
+				t,
+				layerNumbers["quota_app__ResourceQuota"] < layerNumbers["app_app__Deployment"],
+				tc.expectedOrdered,
+			)
+			// the limit range explicitly depends on the deployment
+			assert.Assert(t, layerNumbers["app_app__Deployment"] < layerNumbers["limits_app__LimitRange"])
+			assert.DeepEqual(t, instance.Dag.Get("other_other__Deployment").GetDependencies(), []string{})
+		})
+	}
+}
+
+func TestManager_Load_Suspended(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/suspended@v0"
+language: version: "v0.9.0"
+
+-- infra/suspended/components.cue --
+package suspended
+
+_namespace: {
+	_name: string
+	type:  "Manifest"
+	id:    "\(_name)___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: _name
+	}
+}
+
+active: _namespace & {_name: "active"}
+suspended: _namespace & {_name: "suspended"} @suspend()
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, instance.Suspended, []string{"suspended___Namespace"})
+	assert.Assert(t, instance.Dag.GetByRef("v1", "Namespace", "suspended", "") != nil)
+	assert.Assert(t, instance.Dag.GetByRef("v1", "Namespace", "active", "") != nil)
+}
+
+func TestManager_Load_Keep(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/keep@v0"
+language: version: "v0.9.0"
+
+-- infra/keep/components.cue --
+package keep
+
+_namespace: {
+	_name: string
+	type:  "Manifest"
+	id:    "\(_name)___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: _name
+	}
+}
+
+removable: _namespace & {_name: "removable"}
+kept: _namespace & {_name: "kept"} @keep()
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, instance.Keep, []string{"kept___Namespace"})
+
+	kept := instance.Dag.GetByRef("v1", "Namespace", "kept", "").(*component.Manifest)
+	assert.Assert(t, kept.Content.GetAnnotations() == nil)
+
+	removable := instance.Dag.GetByRef("v1", "Namespace", "removable", "").(*component.Manifest)
+	assert.Assert(t, removable.Content.GetAnnotations() == nil)
+}
+
+func TestManager_Load_ClusterFacts(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/facts@v0"
+language: version: "v0.9.0"
+
+-- infra/facts/namespace.cue --
+package facts
+
+_cluster: *"unknown" | string @tag(cluster, var=clusterName)
+_labels: {[string]: string} @tag(labels, var=clusterLabels)
+_version: *"" | string @tag(version, var=kubernetesVersion)
+
+ns: {
+	type: "Manifest"
+	id:   "\(_cluster)___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: {
+			name:   _cluster
+			labels: _labels
+			annotations: version: _version
+		}
+	}
+}
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+		project.WithClusterFacts(project.ClusterFacts{
+			Name: "prod-eu",
+			Labels: map[string]string{
+				"topology.kubernetes.io/region": "eu-central-1",
+			},
+			KubernetesVersion: "v1.31.0",
+		}),
+	)
+	assert.NilError(t, err)
+
+	manifest, ok := instance.Dag.GetByRef("v1", "Namespace", "prod-eu", "").(*component.Manifest)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, manifest.Content.GetLabels(), map[string]string{
+		"topology.kubernetes.io/region": "eu-central-1",
+	})
+	assert.Equal(t, manifest.Content.GetAnnotations()["version"], "v1.31.0")
+
+	instance, err = pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, instance.Dag.GetByRef("v1", "Namespace", "unknown", "") != nil)
+}
+
+func TestManager_Load_Requires(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/requires@v0"
+language: version: "v0.9.0"
+
+-- infra/requires/components.cue --
+package requires
+
+_namespace: {
+	_name: string
+	type:  "Manifest"
+	id:    "\(_name)___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: _name
+	}
+}
+
+plain: _namespace & {_name: "plain"}
+monitoring: _namespace & {_name: "monitoring"} @requires("monitoring.coreos.com/v1/ServiceMonitor", "v1/ConfigMap")
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, instance.Requires, map[string][]string{
+		"monitoring___Namespace": {"monitoring.coreos.com/v1/ServiceMonitor", "v1/ConfigMap"},
+	})
+}
+
+func TestManager_Load_InvalidRequires(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/requires@v0"
+language: version: "v0.9.0"
+
+-- infra/requires/components.cue --
+package requires
+
+monitoring: {
+	type: "Manifest"
+	id:   "monitoring___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "monitoring"
+	}
+} @requires("ServiceMonitor")
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	_, err = pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.ErrorIs(t, err, component.ErrInvalidRequiredAPI)
+}
+
+func TestManager_Load_WaitFor(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/waitfor@v0"
+language: version: "v0.9.0"
+
+-- infra/waitfor/components.cue --
+package waitfor
+
+_namespace: {
+	_name: string
+	type:  "Manifest"
+	id:    "\(_name)___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: _name
+	}
+}
+
+plain: _namespace & {_name: "plain"}
+active: _namespace & {_name: "active"} @waitFor("status.phase", "Active")
+terminating: _namespace & {_name: "terminating"} @waitFor("status.phase", "Terminating", "30s")
+slow: _namespace & {_name: "slow"} @waitFor("status.phase", "Active", "30s", "10m")
+`))
+	assert.NilError(t, err)
 
-				assert.Assert(t, manifest != nil)
-				compManifest, ok := manifest.(*component.Manifest)
-				assert.Assert(t, ok)
-				assert.Equal(t, compManifest.GetAPIVersion(), expectedManifest.apiVersion)
-				assert.Equal(t, compManifest.GetKind(), expectedManifest.kind)
-				assert.Equal(t, compManifest.GetName(), expectedManifest.name)
-				assert.Equal(t, compManifest.GetNamespace(), expectedManifest.namespace)
-			}
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
 
-			for _, unexpectedManifest := range tc.unexpectedManifests {
-				apiVersionSplit := strings.Split(unexpectedManifest.apiVersion, "/")
-				group := ""
-				if len(apiVersionSplit) == 2 {
-					group = apiVersionSplit[0]
-				}
-				manifest := dag.Get(
-					fmt.Sprintf(
-						"%s_%s_%s_%s",
-						unexpectedManifest.name,
-						unexpectedManifest.namespace,
-						group,
-						unexpectedManifest.kind,
-					),
-				)
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, instance.WaitFor, map[string]component.WaitCondition{
+		"active___Namespace": {
+			Path:    "status.phase",
+			Value:   "Active",
+			Timeout: component.DefaultWaitTimeout,
+		},
+		"terminating___Namespace": {
+			Path:    "status.phase",
+			Value:   "Terminating",
+			Timeout: 30 * time.Second,
+		},
+		"slow___Namespace": {
+			Path:       "status.phase",
+			Value:      "Active",
+			Timeout:    30 * time.Second,
+			MaxTimeout: 10 * time.Minute,
+		},
+	})
+}
 
-				assert.Assert(t, manifest == nil)
-			}
+func TestManager_Load_Hooks(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := txtar.Create(projectPath, strings.NewReader(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/hooks@v0"
+language: version: "v0.9.0"
+
+-- infra/hooks/components.cue --
+package hooks
+
+_job: {
+	_name: string
+	type:  "Manifest"
+	id:    "\(_name)_app_batch_Job"
+	dependencies: []
+	content: {
+		apiVersion: "batch/v1"
+		kind:       "Job"
+		metadata: {
+			name:      _name
+			namespace: "app"
+		}
+		spec: template: spec: {
+			restartPolicy: "Never"
+			containers: [{name: _name, image: "\(_name):1.0.0"}]
+		}
+	}
+}
+
+app: {
+	type: "Manifest"
+	id:   "app_app_apps_Deployment"
+	dependencies: []
+	content: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+		metadata: {
+			name:      "app"
+			namespace: "app"
+		}
+	}
+}
+
+migrate: _job & {_name: "migrate"} @hook(pre, "app_app_apps_Deployment")
+smoke: _job & {_name: "smoke"} @hook(post, "app_app_apps_Deployment", "30s")
+`))
+	assert.NilError(t, err)
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, instance.Hooks, map[string]component.JobHook{
+		"migrate_app_batch_Job": {
+			Phase:   component.PreHook,
+			Target:  "app_app_apps_Deployment",
+			Timeout: component.DefaultJobHookTimeout,
+		},
+		"smoke_app_batch_Job": {
+			Phase:   component.PostHook,
+			Target:  "app_app_apps_Deployment",
+			Timeout: 30 * time.Second,
+		},
+	})
+
+	instances, err := instance.Dag.TopologicalSort()
+	assert.NilError(t, err)
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.GetID())
+	}
+	assert.DeepEqual(t, ids, []string{"migrate_app_batch_Job", "app_app_apps_Deployment", "smoke_app_batch_Job"})
+}
+
+func TestManager_Load_InvalidHooks(t *testing.T) {
+	testCases := []struct {
+		name        string
+		component   string
+		expectedErr error
+	}{
+		{
+			name: "NoJob",
+			component: `{
+	type: "Manifest"
+	id:   "monitoring___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "monitoring"
+	}
+} @hook(pre, "app___Namespace")`,
+			expectedErr: component.ErrInvalidHook,
+		},
+		{
+			name:        "UnknownPhase",
+			component:   `_job @hook(during, "app___Namespace")`,
+			expectedErr: component.ErrInvalidHook,
+		},
+		{
+			name:        "UnknownTarget",
+			component:   `_job @hook(pre, "unknown___Namespace")`,
+			expectedErr: component.ErrUnknownComponentID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			projectPath := t.TempDir()
+			_, err := txtar.Create(projectPath, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/hooks@v0"
+language: version: "v0.9.0"
+
+-- infra/hooks/components.cue --
+package hooks
+
+_job: {
+	type: "Manifest"
+	id:   "migrate_app_batch_Job"
+	dependencies: []
+	content: {
+		apiVersion: "batch/v1"
+		kind:       "Job"
+		metadata: {
+			name:      "migrate"
+			namespace: "app"
+		}
+	}
+}
+
+app: {
+	type: "Manifest"
+	id:   "app___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "app"
+	}
+}
+
+hook: %s
+`, tc.component)))
+			assert.NilError(t, err)
+
+			pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+			_, err = pm.Load(
+				t.Context(),
+				projectPath,
+				".",
+			)
+			assert.ErrorIs(t, err, tc.expectedErr)
 		})
 	}
 }
 
-func TestManager_Load_UnrecoverableError(t *testing.T) {
-	var err error
+func TestManager_Load_InvalidWaitFor(t *testing.T) {
+	testCases := []struct {
+		name      string
+		attribute string
+	}{
+		{
+			name:      "MissingValue",
+			attribute: `@waitFor("status.phase")`,
+		},
+		{
+			name:      "EmptyPathElement",
+			attribute: `@waitFor("status..phase", "Ready")`,
+		},
+		{
+			name:      "UnclosedListSelector",
+			attribute: `@waitFor("status.conditions[type=Ready.status", "True")`,
+		},
+		{
+			name:      "InvalidListIndex",
+			attribute: `@waitFor("status.conditions[-1].status", "True")`,
+		},
+		{
+			name:      "InvalidTimeout",
+			attribute: `@waitFor("status.phase", "Ready", "soon")`,
+		},
+		{
+			name:      "MaxTimeoutShorterThanTimeout",
+			attribute: `@waitFor("status.phase", "Ready", "2m", "1m")`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			projectPath := t.TempDir()
+			_, err := txtar.Create(projectPath, strings.NewReader(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/waitfor@v0"
+language: version: "v0.9.0"
+
+-- infra/waitfor/components.cue --
+package waitfor
+
+monitoring: {
+	type: "Manifest"
+	id:   "monitoring___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "monitoring"
+	}
+} %s
+`, tc.attribute)))
+			assert.NilError(t, err)
+
+			pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+			_, err = pm.Load(
+				t.Context(),
+				projectPath,
+				".",
+			)
+			assert.ErrorIs(t, err, component.ErrInvalidWaitCondition)
+		})
+	}
+}
+
+func TestManager_Load_RegistryMirror(t *testing.T) {
 	dnsServer, err := dnstest.NewDNSServer()
 	assert.NilError(t, err)
 	defer dnsServer.Close()
@@ -264,17 +1390,240 @@ func TestManager_Load_UnrecoverableError(t *testing.T) {
 	env := projecttest.InitTestEnvironment(t)
 	defer env.Close()
 
+	env.PushProject(t, "mirrored", "latest", []byte(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/mirrored@v0"
+language: version: "v0.9.0"
+
+-- infra/mirrored/namespace.cue --
+package mirrored
+
+ns: {
+	type: "Manifest"
+	id:   "mirrored___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "mirrored"
+	}
+}
+`))
+
 	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
 
-	_, err = pm.Load(
+	instance, err := pm.Load(
 		t.Context(),
 		filepath.Join(env.TestRoot, "project"),
 		".",
-		project.WithRemoteLoader(&projecttest.FakeRemoteLoader{
-			Err: &oci.UnrecoverableError{},
+		project.WithRemoteLoader(&project.OCIRemoteLoader{
+			Repository: project.OCIRepositoryRef{
+				Name: "docker.io/mirrored",
+				Ref:  "latest",
+			},
+			CacheDir: t.TempDir(),
+			Mirrors: oci.Mirrors{
+				"docker.io": env.OCIRegistry.Addr(),
+			},
+		}),
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, instance.Digest.Artifact != "")
+	assert.Equal(t, instance.Digest.Registry, env.OCIRegistry.Addr()+"/mirrored")
+	assert.Assert(t, instance.Dag.GetByRef("v1", "Namespace", "mirrored", "") != nil)
+}
+
+func TestManager_Load_Subpath(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	repository := env.PushProject(t, "monorepo", "latest", []byte(`
+-- docs/README.md --
+# Monorepo
+
+-- services/api/main.go --
+package main
+
+-- deploy/cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/monorepo@v0"
+language: version: "v0.9.0"
+
+-- deploy/infra/monorepo/namespace.cue --
+package monorepo
+
+ns: {
+	type: "Manifest"
+	id:   "monorepo___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "monorepo"
+	}
+}
+`))
+
+	projectPath := filepath.Join(env.TestRoot, "project")
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	instance, err := pm.Load(
+		t.Context(),
+		projectPath,
+		".",
+		project.WithRemoteLoader(&project.OCIRemoteLoader{
+			Repository: repository,
+			CacheDir:   t.TempDir(),
+			Subpath:    "deploy",
+		}),
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, instance.Digest.Artifact != "")
+	assert.Assert(t, instance.Dag.GetByRef("v1", "Namespace", "monorepo", "") != nil)
+
+	_, err = os.Stat(filepath.Join(projectPath, "cue.mod", "module.cue"))
+	assert.NilError(t, err)
+	_, err = os.Stat(filepath.Join(projectPath, "docs"))
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(projectPath, "services"))
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(projectPath, "deploy"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestManager_Load_DirNotFound(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	repository := env.PushProject(t, "dirnotfound", "latest", []byte(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/dirnotfound@v0"
+language: version: "v0.9.0"
+
+-- infra/dirnotfound/namespace.cue --
+package dirnotfound
+
+ns: {
+	type: "Manifest"
+	id:   "dirnotfound___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "dirnotfound"
+	}
+}
+`))
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	_, err = pm.Load(
+		t.Context(),
+		filepath.Join(env.TestRoot, "project"),
+		"missing",
+		project.WithRemoteLoader(&project.OCIRemoteLoader{
+			Repository: repository,
+			CacheDir:   t.TempDir(),
+		}),
+	)
+	assert.ErrorIs(t, err, project.ErrLoadProject)
+	assert.ErrorIs(t, err, project.ErrDirNotFound)
+	assert.ErrorContains(t, err, "missing")
+
+	// an artifact, which fails to load, is not reported as wrong directory.
+	_, err = pm.Load(
+		t.Context(),
+		filepath.Join(env.TestRoot, "unavailable"),
+		"missing",
+		project.WithRemoteLoader(&project.OCIRemoteLoader{
+			Repository: project.OCIRepositoryRef{
+				Name: repository.Name,
+				Ref:  "unavailable",
+			},
+			CacheDir: t.TempDir(),
 		}),
 	)
 	assert.ErrorIs(t, err, project.ErrLoadProject)
+	assert.Assert(t, !errors.Is(err, project.ErrDirNotFound))
+}
+
+func TestManager_Load_SemverRef(t *testing.T) {
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(t)
+	defer env.Close()
+
+	var repository project.OCIRepositoryRef
+	for _, tag := range []string{"0.9.0", "1.0.0", "1.2.0", "1.10.0-rc.1", "2.0.0", "latest"} {
+		repository = env.PushProject(t, "semver", tag, []byte(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/controller/semver@v0"
+language: version: "v0.9.0"
+
+-- infra/semver/namespace.cue --
+package semver
+
+ns: {
+	type: "Manifest"
+	id:   "semver___Namespace"
+	dependencies: []
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "semver"
+		metadata: labels: version: %q
+	}
+}
+`, tag)))
+	}
+
+	pm := project.NewManager(component.NewBuilder(), runtime.GOMAXPROCS(0))
+
+	loader := &project.OCIRemoteLoader{
+		Repository: project.OCIRepositoryRef{
+			Name: repository.Name,
+			Ref:  ">=1.0, <2.0",
+		},
+		CacheDir: t.TempDir(),
+	}
+	instance, err := pm.Load(
+		t.Context(),
+		filepath.Join(env.TestRoot, "project"),
+		".",
+		project.WithRemoteLoader(loader),
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, instance.Digest.Artifact != "")
+	assert.Equal(t, instance.Digest.Tag, "1.2.0")
+	assert.Equal(t, instance.Digest.Registry, repository.Name)
+	assert.Equal(t, loader.ResolvedRef(), "1.2.0")
+
+	manifest, ok := instance.Dag.GetByRef("v1", "Namespace", "semver", "").(*component.Manifest)
+	assert.Assert(t, ok)
+	assert.Equal(t, manifest.Content.GetLabels()["version"], "1.2.0")
+
+	_, err = pm.Load(
+		t.Context(),
+		filepath.Join(t.TempDir(), "project"),
+		".",
+		project.WithRemoteLoader(&project.OCIRemoteLoader{
+			Repository: project.OCIRepositoryRef{
+				Name: repository.Name,
+				Ref:  ">=3.0",
+			},
+			CacheDir: t.TempDir(),
+		}),
+	)
+	assert.ErrorIs(t, err, project.ErrNoMatchingTag)
 }
 
 func TestManager_Load_LoadError(t *testing.T) {
@@ -316,7 +1665,7 @@ module: "github.com/kharf/navecd/internal/controller/projectone@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -355,13 +1704,7 @@ ns: component.#Manifest & {
 	assert.NilError(t, err)
 	assert.Equal(t, instance.Path, projectPath)
 
-	manifestID := fmt.Sprintf(
-		"%s_%s_%s_%s",
-		"toola",
-		"",
-		"",
-		"Namespace",
-	)
+	manifestID := component.ManifestID("v1", "Namespace", "toola", "")
 	manifest := instance.Dag.Get(manifestID)
 	assert.Assert(t, manifest != nil)
 
@@ -396,7 +1739,7 @@ module: "github.com/kharf/navecd/internal/controller/projectone@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -434,13 +1777,7 @@ ns: component.#Manifest & {
 	assert.NilError(t, err)
 	assert.Equal(t, instance.Path, projectPath)
 
-	manifestID := fmt.Sprintf(
-		"%s_%s_%s_%s",
-		"toola",
-		"",
-		"",
-		"Namespace",
-	)
+	manifestID := component.ManifestID("v1", "Namespace", "toola", "")
 	manifest := instance.Dag.Get(manifestID)
 	assert.Assert(t, manifest != nil)
 
@@ -450,7 +1787,7 @@ module: "github.com/kharf/navecd/internal/controller/projectone@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -487,13 +1824,7 @@ ns: component.#Manifest & {
 	manifest = instance.Dag.Get(manifestID)
 	assert.Assert(t, manifest == nil)
 
-	manifestID = fmt.Sprintf(
-		"%s_%s_%s_%s",
-		"toolc",
-		"",
-		"",
-		"Namespace",
-	)
+	manifestID = component.ManifestID("v1", "Namespace", "toolc", "")
 
 	manifest = instance.Dag.Get(manifestID)
 	assert.Assert(t, manifest != nil)
@@ -594,7 +1925,7 @@ module: "github.com/kharf/navecd/internal/controller/projectbench@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 `, testtemplates.ModuleVersion)
@@ -0,0 +1,335 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	gitops "github.com/kharf/navecd/api/v1beta1"
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
+	"github.com/kharf/navecd/pkg/inventory"
+	"github.com/kharf/navecd/pkg/kube"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// ProvisionedRBACLabel marks RBAC objects, which were provisioned by Navecd for the impersonated service account of a project.
+	// Its value is the UID of the project.
+	ProvisionedRBACLabel = "navecd.io/rbac-for"
+)
+
+var (
+	manifestVerbs = []string{"create", "delete", "get", "list", "patch", "update", "watch"}
+	patchVerbs    = []string{"get", "patch"}
+
+	// escalatingVerbs are never granted, so that the service account can only create RBAC objects,
+	// which grant permissions it holds itself.
+	escalatingVerbs = []string{"*", "bind", "escalate", "impersonate"}
+)
+
+// releaseStorage holds the state of Helm releases in their namespace.
+var releaseStorage = schema.GroupResource{Resource: "secrets"}
+
+var (
+	roleGVK               = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}
+	roleBindingGVK        = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}
+	clusterRoleGVK        = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	clusterRoleBindingGVK = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}
+)
+
+// grants maps namespaces to the verbs allowed per resource.
+// The empty namespace holds grants of cluster-scoped resources.
+type grants map[string]map[schema.GroupResource]map[string]struct{}
+
+func (g grants) add(namespace string, resource schema.GroupResource, verbs []string) {
+	resources, found := g[namespace]
+	if !found {
+		resources = make(map[schema.GroupResource]map[string]struct{})
+		g[namespace] = resources
+	}
+
+	resourceVerbs, found := resources[resource]
+	if !found {
+		resourceVerbs = make(map[string]struct{}, len(verbs))
+		resources[resource] = resourceVerbs
+	}
+
+	for _, verb := range verbs {
+		if slices.Contains(escalatingVerbs, verb) {
+			continue
+		}
+		resourceVerbs[verb] = struct{}{}
+	}
+}
+
+// rules converts the grants of a namespace to policy rules sorted by group and resource.
+func (g grants) rules(namespace string) []any {
+	resources := slices.SortedFunc(maps.Keys(g[namespace]), func(a, b schema.GroupResource) int {
+		return cmp.Or(cmp.Compare(a.Group, b.Group), cmp.Compare(a.Resource, b.Resource))
+	})
+
+	rules := make([]any, 0, len(resources))
+	for _, resource := range resources {
+		verbs := slices.Sorted(maps.Keys(g[namespace][resource]))
+		rules = append(rules, map[string]any{
+			"apiGroups": []any{resource.Group},
+			"resources": []any{resource.Resource},
+			"verbs":     toAnySlice(verbs),
+		})
+	}
+
+	return rules
+}
+
+// provisionRBAC grants the impersonated service account of the project access to the resources declared by its components
+// and tracked in its inventory, so that removed components can still be collected.
+// Namespaced resources are granted with a Role per namespace, cluster-scoped resources with a ClusterRole.
+// Helm releases are granted the resources rendered by their chart and the resources of their deployed revision, which an upgrade may delete,
+// as well as the Secrets of their namespace storing the release.
+// Wildcards and verbs allowing privilege escalation, like bind and escalate, are never granted.
+// Kinds unknown to the cluster, e.g. of CRDs declared in the same project, and namespaces, which do not exist yet,
+// are granted on the next reconciliation.
+// Provisioned RBAC objects, which are not needed anymore, are deleted.
+func provisionRBAC(
+	ctx context.Context,
+	client *kube.DynamicClient,
+	chartReconciler helm.ChartReconciler,
+	gProject gitops.GitOpsProject,
+	serviceAccountName string,
+	instances []component.Instance,
+	inventoryInstance *inventory.Instance,
+	fieldManager string,
+) error {
+	projectGrants := make(grants)
+
+	addObject := func(apiVersion string, kind string, namespace string, verbs []string) error {
+		gvk := schema.FromAPIVersionAndKind(apiVersion, kind)
+		mapping, err := client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				return nil
+			}
+			return err
+		}
+
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			namespace = ""
+		} else if namespace == "" {
+			namespace = "default"
+		}
+
+		projectGrants.add(namespace, mapping.Resource.GroupResource(), verbs)
+		return nil
+	}
+
+	addObjects := func(namespace string, objects []*unstructured.Unstructured) error {
+		projectGrants.add(namespace, releaseStorage, manifestVerbs)
+		for _, obj := range objects {
+			if err := addObject(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), manifestVerbs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	addDeployedRelease := func(name string, namespace string) error {
+		objects, err := chartReconciler.DeployedObjects(name, namespace)
+		if err != nil {
+			return err
+		}
+		return addObjects(namespace, objects)
+	}
+
+	addRelease := func(release *helm.ReleaseComponent) error {
+		objects, err := chartReconciler.Objects(ctx, release)
+		if err != nil {
+			return err
+		}
+		name, namespace := release.Content.Name, release.Content.Namespace
+		if name == "" {
+			name = release.Content.Chart.Name
+		}
+		if namespace == "" {
+			namespace = "default"
+		}
+		if err := addObjects(namespace, objects); err != nil {
+			return err
+		}
+		return addDeployedRelease(name, namespace)
+	}
+
+	for _, instance := range instances {
+		var err error
+		switch componentInstance := instance.(type) {
+		case *component.Manifest:
+			err = addObject(
+				componentInstance.GetAPIVersion(),
+				componentInstance.GetKind(),
+				componentInstance.GetNamespace(),
+				manifestVerbs,
+			)
+		case *component.Patch:
+			err = addObject(
+				componentInstance.GetAPIVersion(),
+				componentInstance.GetKind(),
+				componentInstance.GetNamespace(),
+				patchVerbs,
+			)
+		case *helm.ReleaseComponent:
+			err = addRelease(componentInstance)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	storage, err := inventoryInstance.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range storage.Items() {
+		switch invItem := item.(type) {
+		case *inventory.ManifestItem:
+			if err := addObject(
+				invItem.TypeMeta.APIVersion,
+				invItem.TypeMeta.Kind,
+				invItem.GetNamespace(),
+				manifestVerbs,
+			); err != nil {
+				return err
+			}
+		case *inventory.HelmReleaseItem:
+			if err := addDeployedRelease(invItem.Name, invItem.GetNamespace()); err != nil {
+				return err
+			}
+		}
+	}
+
+	// missing namespaces are looked up and created by the impersonated service account
+	if gProject.Spec.CreateNamespaces && len(projectGrants) > 0 {
+		projectGrants.add("", schema.GroupResource{Resource: "namespaces"}, manifestVerbs)
+	}
+
+	name := provisionedRBACName(gProject)
+	labels := map[string]any{
+		ProvisionedRBACLabel: string(gProject.GetUID()),
+	}
+	subject := map[string]any{
+		"kind":      "ServiceAccount",
+		"name":      serviceAccountName,
+		"namespace": gProject.GetNamespace(),
+	}
+
+	for _, namespace := range slices.Sorted(maps.Keys(projectGrants)) {
+		roleGVK, bindingGVK := roleGVK, roleBindingGVK
+		if namespace == "" {
+			roleGVK, bindingGVK = clusterRoleGVK, clusterRoleBindingGVK
+		}
+
+		metadata := func() map[string]any {
+			metadata := map[string]any{
+				"name":   name,
+				"labels": maps.Clone(labels),
+			}
+			if namespace != "" {
+				metadata["namespace"] = namespace
+			}
+			return metadata
+		}
+
+		role := &unstructured.Unstructured{
+			Object: map[string]any{
+				"metadata": metadata(),
+				"rules":    projectGrants.rules(namespace),
+			},
+		}
+		role.SetGroupVersionKind(roleGVK)
+
+		binding := &unstructured.Unstructured{
+			Object: map[string]any{
+				"metadata": metadata(),
+				"subjects": []any{subject},
+				"roleRef": map[string]any{
+					"apiGroup": roleGVK.Group,
+					"kind":     roleGVK.Kind,
+					"name":     name,
+				},
+			},
+		}
+		binding.SetGroupVersionKind(bindingGVK)
+
+		for _, obj := range []*unstructured.Unstructured{role, binding} {
+			if _, err := client.Apply(ctx, obj, fieldManager, kube.ForceApply(true)); err != nil {
+				// the namespace is created by the project itself
+				if k8sErrors.IsNotFound(err) && namespace != "" {
+					break
+				}
+				return err
+			}
+		}
+	}
+
+	return deleteStaleRBAC(ctx, client, gProject, projectGrants)
+}
+
+// deleteStaleRBAC removes provisioned RBAC objects of namespaces, which are not granted anymore.
+func deleteStaleRBAC(
+	ctx context.Context,
+	client *kube.DynamicClient,
+	gProject gitops.GitOpsProject,
+	projectGrants grants,
+) error {
+	selector := fmt.Sprintf("%s=%s", ProvisionedRBACLabel, gProject.GetUID())
+	for _, gvk := range []schema.GroupVersionKind{roleBindingGVK, roleGVK, clusterRoleBindingGVK, clusterRoleGVK} {
+		objs, err := client.List(ctx, gvk, "", selector)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objs {
+			if _, found := projectGrants[obj.GetNamespace()]; found {
+				continue
+			}
+
+			obj.SetGroupVersionKind(gvk)
+			if err := client.Delete(ctx, &obj); err != nil && !k8sErrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func provisionedRBACName(gProject gitops.GitOpsProject) string {
+	return fmt.Sprintf("navecd:%s:%s", gProject.GetNamespace(), gProject.GetName())
+}
+
+func toAnySlice(values []string) []any {
+	result := make([]any, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
@@ -16,16 +16,25 @@ package project
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	gitops "github.com/kharf/navecd/api/v1beta1"
+	"github.com/kharf/navecd/pkg/cloud"
 	"github.com/kharf/navecd/pkg/component"
 	"github.com/kharf/navecd/pkg/garbage"
 	"github.com/kharf/navecd/pkg/helm"
 	"github.com/kharf/navecd/pkg/inventory"
 	"github.com/kharf/navecd/pkg/kube"
+	"github.com/kharf/navecd/pkg/oci"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 )
 
@@ -59,12 +68,55 @@ type Reconciler struct {
 	// Directory used to cache repositories or helm charts.
 	CacheDir string
 
+	// Upper limit of the helm chart cache size in bytes.
+	// Zero means unlimited.
+	ChartCacheMaxBytes int64
+
+	// Cached helm charts, which have not been used within this duration, are evicted.
+	// Zero means charts never expire.
+	ChartCacheTTL time.Duration
+
+	// Cached project artifacts, which have not been loaded within this duration, are removed and extracted again on their next load.
+	// Zero means they never expire.
+	CacheRetention time.Duration
+
+	// ApplyTimeout bounds how long applies wait for objects to become ready, e.g. CRDs to be established.
+	// Defaults to kube.DefaultApplyTimeout.
+	ApplyTimeout time.Duration
+
+	// ApplyMaxTimeout is the hard cap for applies, whose object makes progress. See [kube.ApplyTimeout].
+	// Zero means the deadline is never extended.
+	ApplyMaxTimeout time.Duration
+
+	// Waits keeps the deadlines of unmet wait conditions of all projects across reconciliations.
+	// Without it, wait conditions never time out.
+	Waits *component.WaitTracker
+
 	// Directory used to save the inventory of component references for all managed navecd projects.
 	InventoryRootDir string
 
+	// Shard of the controller instance.
+	// Inventories are stored per shard to avoid confusing them across shards.
+	Shard string
+
 	// Namespace the controller runs in.
 	Namespace string
 
+	// Number of additional attempts to download the navecd project artifact on recoverable errors,
+	// before falling back to the previously downloaded project.
+	LoadRetries int
+
+	// Wait duration before the first download retry. It doubles with every subsequent retry.
+	LoadRetryBackoff time.Duration
+
+	// Maximum queries per second to the Kubernetes API server.
+	// Zero defaults to DefaultClientQPS.
+	ClientQPS float32
+
+	// Maximum burst of queries to the Kubernetes API server.
+	// Zero defaults to DefaultClientBurst.
+	ClientBurst int
+
 	// Endpoint to the microsoft azure login server.
 	// Default is usually: https://login.microsoftonline.com/.
 	AzureLoginURL string
@@ -72,8 +124,85 @@ type Reconciler struct {
 	// Endpoint to the google metadata server, which provides access tokens.
 	// Default is: http://metadata.google.internal.
 	GCPMetadataServerURL string
+
+	// RegistryMirrors rewrite project and chart references before contacting their registries.
+	// Declared references are kept, so that the inventory is independent of the mirrors.
+	RegistryMirrors oci.Mirrors
+
+	// Proxy routes all registry and chart repository traffic through the given proxy.
+	// Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy *url.URL
+
+	// RegistryCredentials authenticate against the registries and chart repositories of projects and charts without declared auth.
+	RegistryCredentials oci.RegistryCredentials
+
+	// DiscoveryCache shares discovered APIs across reconciliations, if set.
+	DiscoveryCache *kube.DiscoveryCache
+
+	// CredentialCache shares credentials fetched through workload identity across reconciliations, chart pulls and projects, if set.
+	CredentialCache *cloud.CredentialCache
+
+	// MaxArtifactBytes limits the size of project artifacts. Zero means no limit.
+	MaxArtifactBytes int64
+
+	// KindFilter restricts the kinds of manifests and patches, which are applied and garbage collected.
+	KindFilter component.KindFilter
+
+	// DeletePropagation defines how dependents of garbage collected manifests are deleted.
+	// Defaults to the policy of the object's API.
+	DeletePropagation metav1.DeletionPropagation
+
+	// ClusterName is injected into projects as cluster fact.
+	ClusterName string
+
+	// ClusterLabels are injected into projects as cluster fact.
+	ClusterLabels map[string]string
+
+	// InventoryHelmReleaseFormat is the format HelmRelease content is stored with in the inventory.
+	// Defaults to inventory.FormatJSON.
+	InventoryHelmReleaseFormat inventory.Format
+
+	// PreReconcile is invoked before every reconciliation.
+	// Errors are logged and do not halt the reconciliation.
+	PreReconcile PreReconcileHook
+
+	// PostReconcile is invoked after every reconciliation, including failed ones.
+	// Errors are logged and do not change the reconciliation result.
+	PostReconcile PostReconcileHook
+
+	// HookTimeout bounds the duration of each hook invocation.
+	// Zero defaults to DefaultHookTimeout.
+	HookTimeout time.Duration
+
+	// CUERegistry configures the registries CUE module dependencies are fetched from.
+	// Defaults to the CUE_REGISTRY environment variable.
+	CUERegistry *CUERegistryConfig
+
+	// ProvisionRBAC grants impersonated service accounts access to the resources declared by their project
+	// through Roles and ClusterRoles managed by the controller.
+	ProvisionRBAC bool
+
+	// APIGroups records the API groups used by the components of every reconciled project, if set.
+	APIGroups *APIGroups
+
+	// LeaseIdentity enables project leases, if set, and identifies this reconciler as their holder, e.g. the shard.
+	// A project is only reconciled while holding its lease in Namespace, contending reconciliations fail with ErrLeaseHeld.
+	// Reconciliations are canceled with ErrLeaseLost, once the lease cannot be renewed.
+	LeaseIdentity string
+
+	// LeaseDuration after which leases of holders, which stopped renewing them, expire.
+	// Defaults to DefaultLeaseDuration.
+	LeaseDuration time.Duration
 }
 
+const (
+	// DefaultClientQPS is higher than the client-go default of 5,
+	// because a reconciliation applies many objects in a short time.
+	DefaultClientQPS float32 = 50
+	// DefaultClientBurst is higher than the client-go default of 10.
+	DefaultClientBurst int = 100
+)
+
 // ReconcileResult reports the outcome and metadata of a reconciliation.
 type ReconcileResult struct {
 	// Reports whether the GitOpsProject was flagged as suspended.
@@ -82,6 +211,18 @@ type ReconcileResult struct {
 	// The digest of the reconciled navecd project artifact.
 	Digest string
 
+	// Tag the reference of the reconciled navecd project artifact resolved to.
+	Tag string
+
+	// Registry is the repository the reconciled navecd project artifact was loaded from, after mirrors were applied.
+	Registry string
+
+	// SourceRevision is the source control revision the reconciled navecd project artifact was built from, if annotated.
+	SourceRevision string
+
+	// BuildTime is the time the reconciled navecd project artifact was built, if annotated.
+	BuildTime string
+
 	// DownloadError reports any error occured while trying to load the navecd project artifact.
 	// It is a soft error, which does not halt the reconciliation process, but has to be reported.
 	DownloadError error
@@ -89,34 +230,96 @@ type ReconcileResult struct {
 	// ComponentError reports the first occured component reconciliation error.
 	// It is a soft error, which does not halt the reconciliation process, but has to be reported.
 	ComponentError error
+
+	// SuspendedComponents holds the ids of components, which were skipped because of the suspend attribute.
+	SuspendedComponents []string
+
+	// ProgressingComponents holds the sorted ids of components, which were applied, but are not ready yet.
+	ProgressingComponents []string
+
+	// WaitingComponents holds the sorted ids of progressing components, whose wait condition is not met yet.
+	// Their dependents were skipped.
+	WaitingComponents []string
+
+	// RecreatedComponents holds the sorted ids of tracked manifests, which were deleted out of band and recreated.
+	RecreatedComponents []string
+
+	// TagMutated reports that the tag pointed to a different digest at the last reconciliation.
+	TagMutated bool
+
+	// ChangedObjects is the number of components, whose desired state changed since the last reconciliation or which were recreated.
+	ChangedObjects int
+
+	// Releases holds the state of all reconciled Helm releases, sorted by their component id.
+	Releases []ReleaseStatus
 }
 
+// ReleaseStatus is the state of a Helm release after it was reconciled.
+type ReleaseStatus struct {
+	// ID of the Helm release component.
+	ID        string
+	Name      string
+	Namespace string
+
+	// Revision of the release. It is incremented by every upgrade.
+	Revision int
+
+	// Notes are the rendered NOTES.txt of the chart.
+	Notes string
+}
+
+var (
+	ErrTagMutated = errors.New("Tag points to a different digest than at the last reconciliation")
+)
+
 // Reconcile clones, pulls and loads a GitOps Git repository containing the desired cluster state,
 // translates cue definitions to either Kubernetes unstructurd objects or Helm Releases and applies/installs them on a Kubernetes cluster.
 // It stores objects in the inventory and collects dangling objects.
+// The configured hooks are invoked around the reconciliation.
 func (reconciler *Reconciler) Reconcile(
 	ctx context.Context,
 	gProject gitops.GitOpsProject,
+) (*ReconcileResult, error) {
+	if reconciler.LeaseIdentity != "" && !holdsLease(ctx, gProject.GetUID()) {
+		leaseCtx, release, err := reconciler.HoldLease(ctx, gProject.GetUID())
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		ctx = leaseCtx
+	}
+
+	if reconciler.PreReconcile != nil {
+		reconciler.runHook(ctx, "PreReconcile", func(ctx context.Context) error {
+			return reconciler.PreReconcile(ctx, gProject)
+		})
+	}
+
+	result, err := reconciler.reconcile(ctx, gProject)
+	if cause := context.Cause(ctx); err != nil && errors.Is(cause, ErrLeaseLost) {
+		err = fmt.Errorf("%w: %w", cause, err)
+	}
+
+	if reconciler.PostReconcile != nil {
+		reconciler.runHook(ctx, "PostReconcile", func(ctx context.Context) error {
+			return reconciler.PostReconcile(ctx, gProject, result, err)
+		})
+	}
+
+	return result, err
+}
+
+func (reconciler *Reconciler) reconcile(
+	ctx context.Context,
+	gProject gitops.GitOpsProject,
 ) (*ReconcileResult, error) {
 	if *gProject.Spec.Suspend {
 		return &ReconcileResult{Suspended: true}, nil
 	}
 	log := reconciler.Log
 
-	var cfg *rest.Config
-	if gProject.Spec.ServiceAccountName != "" {
-		impCfg := *reconciler.KubeConfig
-		impCfg.Impersonate = rest.ImpersonationConfig{
-			UserName: fmt.Sprintf(
-				"system:serviceaccount:%s:%s",
-				gProject.Namespace,
-				gProject.Spec.ServiceAccountName,
-			),
-		}
-		cfg = &impCfg
-	} else {
-		cfg = reconciler.KubeConfig
-	}
+	serviceAccountName := impersonatedServiceAccount(gProject)
+	cfg := reconciler.RESTConfig(gProject)
 
 	log = log.WithValues(
 		"project",
@@ -124,10 +327,10 @@ func (reconciler *Reconciler) Reconcile(
 		"repository",
 		gProject.Spec.URL,
 		"impersonated",
-		gProject.Spec.ServiceAccountName,
+		serviceAccountName,
 	)
 
-	kubeDynamicClient, err := kube.NewExtendedDynamicClient(cfg)
+	kubeDynamicClient, err := kube.NewExtendedDynamicClient(cfg, kube.WithDiscoveryCache(reconciler.DiscoveryCache))
 	if err != nil {
 		log.Error(
 			err,
@@ -140,7 +343,18 @@ func (reconciler *Reconciler) Reconcile(
 	repositoryDir := filepath.Join(reconciler.CacheDir, "navecd", projectUID)
 
 	inventoryInstance := &inventory.Instance{
-		Path: filepath.Join(reconciler.InventoryRootDir, projectUID),
+		Path:              reconciler.InventoryPath(projectUID),
+		HelmReleaseFormat: reconciler.InventoryHelmReleaseFormat,
+	}
+
+	if reconciler.Shard != "" {
+		if err := inventoryInstance.Migrate(filepath.Join(reconciler.InventoryRootDir, projectUID)); err != nil {
+			log.Error(
+				err,
+				"Unable to migrate inventory",
+			)
+			return nil, err
+		}
 	}
 
 	chartReconciler := helm.ChartReconciler{
@@ -152,42 +366,109 @@ func (reconciler *Reconciler) Reconcile(
 		PlainHTTP:             reconciler.PlainHTTP,
 		Log:                   log,
 		ChartCacheRoot:        reconciler.CacheDir,
+		ChartCacheMaxBytes:    reconciler.ChartCacheMaxBytes,
+		ChartCacheTTL:         reconciler.ChartCacheTTL,
+		Mirrors:               reconciler.RegistryMirrors,
+		Proxy:                 reconciler.Proxy,
+		RegistryCredentials:   reconciler.RegistryCredentials,
+		CredentialCache:       reconciler.CredentialCache,
+	}
+	if !reconciler.KindFilter.IsZero() {
+		chartReconciler.CheckKind = reconciler.KindFilter.Check
 	}
 
 	garbageCollector := garbage.Collector{
 		Log:               log,
 		Client:            kubeDynamicClient.DynamicClient(),
 		ChartReconciler:   chartReconciler,
+		FieldManager:      reconciler.FieldManager,
 		InventoryInstance: inventoryInstance,
 		WorkerPoolSize:    reconciler.WorkerPoolSize,
+		DeletePropagation: reconciler.DeletePropagation,
+		KindFilter:        reconciler.KindFilter,
 	}
 
 	componentReconciler := component.Reconciler{
-		Log:               log,
-		DynamicClient:     kubeDynamicClient,
-		ChartReconciler:   chartReconciler,
-		InventoryInstance: inventoryInstance,
-		FieldManager:      reconciler.FieldManager,
-		WorkerPoolSize:    reconciler.WorkerPoolSize,
+		Log:                 log,
+		DynamicClient:       kubeDynamicClient,
+		ChartReconciler:     chartReconciler,
+		InventoryInstance:   inventoryInstance,
+		FieldManager:        reconciler.FieldManager,
+		WorkerPoolSize:      reconciler.WorkerPoolSize,
+		ImagePullSecrets:    gProject.Spec.ImagePullSecrets,
+		ImageRegistryPrefix: gProject.Spec.ImageRegistryPrefix,
+		CheckPodSecurity:    gProject.Spec.CheckPodSecurity,
+		KindFilter:          reconciler.KindFilter,
+		ApplyTimeout:        reconciler.ApplyTimeout,
+		ApplyMaxTimeout:     reconciler.ApplyMaxTimeout,
+		Waits:               reconciler.Waits,
+	}
+	if gProject.Spec.OwnerReferences {
+		owner := projectOwner(gProject)
+		componentReconciler.Owner = &owner
+	}
+
+	ociRemoteLoader := &OCIRemoteLoader{
+		Repository: OCIRepositoryRef{
+			Name: gProject.Spec.URL,
+			Ref:  gProject.Spec.Ref,
+		},
+		KubeClient:            kubeDynamicClient.DynamicClient(),
+		CacheDir:              reconciler.CacheDir,
+		Namespace:             reconciler.Namespace,
+		InsecureSkipTLSverify: reconciler.InsecureSkipTLSverify,
+		AzureLoginURL:         reconciler.AzureLoginURL,
+		GCPMetadataServerURL:  reconciler.GCPMetadataServerURL,
+		Mirrors:               reconciler.RegistryMirrors,
+		Proxy:                 reconciler.Proxy,
+		RegistryCredentials:   reconciler.RegistryCredentials,
+		CredentialCache:       reconciler.CredentialCache,
+		MaxArtifactBytes:      reconciler.MaxArtifactBytes,
+		CacheRetention:        reconciler.CacheRetention,
+	}
+	var remoteLoader RemoteLoader = ociRemoteLoader
+	if reconciler.LoadRetries > 0 {
+		remoteLoader = &RetryRemoteLoader{
+			Loader:  remoteLoader,
+			Retries: reconciler.LoadRetries,
+			Backoff: reconciler.LoadRetryBackoff,
+		}
+	}
+
+	kubernetesVersion, err := serverVersion(cfg, reconciler.DiscoveryCache)
+	if err != nil {
+		log.Error(
+			err,
+			"Unable to discover Kubernetes version",
+		)
+		return nil, err
+	}
+
+	loadOpts := []Option{
+		WithRemoteLoader(remoteLoader),
+		WithAuth(gProject.Spec.Auth),
+		WithClusterFacts(ClusterFacts{
+			Name:                reconciler.ClusterName,
+			Labels:              reconciler.ClusterLabels,
+			KubernetesVersion:   kubernetesVersion,
+			ControllerNamespace: reconciler.Namespace,
+		}),
+	}
+	if reconciler.CUERegistry != nil {
+		loadOpts = append(loadOpts, WithCUERegistry(*reconciler.CUERegistry))
+	}
+	if gProject.Spec.OrderPolicies {
+		loadOpts = append(loadOpts, WithPolicyOrdering(true))
+	}
+	if gProject.Spec.StrictComponents {
+		loadOpts = append(loadOpts, WithStrictComponents(true))
 	}
 
 	projectInstance, err := reconciler.ProjectManager.Load(
 		ctx,
 		repositoryDir,
 		gProject.Spec.Dir,
-		WithRemoteLoader(&OCIRemoteLoader{
-			Repository: OCIRepositoryRef{
-				Name: gProject.Spec.URL,
-				Ref:  gProject.Spec.Ref,
-			},
-			KubeClient:            kubeDynamicClient.DynamicClient(),
-			CacheDir:              reconciler.CacheDir,
-			Namespace:             reconciler.Namespace,
-			InsecureSkipTLSverify: reconciler.InsecureSkipTLSverify,
-			AzureLoginURL:         reconciler.AzureLoginURL,
-			GCPMetadataServerURL:  reconciler.GCPMetadataServerURL,
-		}),
-		WithAuth(gProject.Spec.Auth),
+		loadOpts...,
 	)
 	if err != nil {
 		log.Error(
@@ -197,6 +478,28 @@ func (reconciler *Reconciler) Reconcile(
 		return nil, err
 	}
 
+	tagMutated := false
+	if revision := gProject.Status.Revision; projectInstance.Digest.Artifact != "" &&
+		revision.Tag != "" && revision.Tag == projectInstance.Digest.Tag &&
+		revision.Digest != "" && revision.Digest != projectInstance.Digest.Artifact {
+		tagMutated = true
+		log.Info(
+			"Tag points to a different digest than at the last reconciliation",
+			"tag", revision.Tag,
+			"previousDigest", revision.Digest,
+			"digest", projectInstance.Digest,
+		)
+		if gProject.Spec.RequireImmutableTags {
+			return nil, fmt.Errorf(
+				"%w: %s changed from %s to %s",
+				ErrTagMutated,
+				revision.Tag,
+				revision.Digest,
+				projectInstance.Digest,
+			)
+		}
+	}
+
 	componentInstances, err := projectInstance.Dag.TopologicalSort()
 	if err != nil {
 		log.Error(
@@ -206,21 +509,243 @@ func (reconciler *Reconciler) Reconcile(
 		return nil, err
 	}
 
+	if reconciler.APIGroups != nil {
+		reconciler.APIGroups.record(gProject.GetUID(), componentInstances, projectInstance.Requires)
+	}
+
+	if len(projectInstance.Suspended) != 0 {
+		log.Info("Skipping suspended components", "components", projectInstance.Suspended)
+	}
+	componentReconciler.Suspended = projectInstance.Suspended
+	componentReconciler.Keep = projectInstance.Keep
+	componentReconciler.Requires = projectInstance.Requires
+	componentReconciler.WaitFor = projectInstance.WaitFor
+	componentReconciler.Hooks = projectInstance.Hooks
+	if gProject.Spec.SourceAnnotations {
+		componentReconciler.Sources = projectInstance.Sources
+	}
+
+	if reconciler.ProvisionRBAC && serviceAccountName != "" {
+		controllerCfg := reconciler.controllerRESTConfig()
+		controllerClient, err := kube.NewExtendedDynamicClient(controllerCfg, kube.WithDiscoveryCache(reconciler.DiscoveryCache))
+		if err != nil {
+			log.Error(
+				err,
+				"Unable to create Kubernetes Client",
+			)
+			return nil, err
+		}
+
+		// charts are rendered by the controller, because the service account is not granted access yet.
+		chartRenderer := chartReconciler
+		chartRenderer.KubeConfig = controllerCfg
+		chartRenderer.Client = controllerClient
+
+		if err := provisionRBAC(
+			ctx,
+			controllerClient.DynamicClient(),
+			chartRenderer,
+			gProject,
+			serviceAccountName,
+			componentInstances,
+			inventoryInstance,
+			reconciler.FieldManager,
+		); err != nil {
+			log.Error(
+				err,
+				"Unable to provision RBAC",
+			)
+			return nil, err
+		}
+	}
+
+	if gProject.Spec.CreateNamespaces {
+		if err := insertMissingNamespaces(
+			ctx,
+			projectInstance.Dag,
+			componentInstances,
+			kubeDynamicClient.DynamicClient(),
+			inventoryInstance,
+		); err != nil {
+			log.Error(
+				err,
+				"Unable to create missing namespaces",
+			)
+			return nil, err
+		}
+
+		componentInstances, err = projectInstance.Dag.TopologicalSort()
+		if err != nil {
+			log.Error(
+				err,
+				"Unable to resolve dependencies",
+			)
+			return nil, err
+		}
+	}
+
 	if err := garbageCollector.Collect(ctx, projectInstance.Dag); err != nil {
 		return nil, err
 	}
 
 	var digest string
-	if projectInstance.Digest == "" {
+	var tag string
+	var registry string
+	var sourceRevision string
+	var buildTime string
+	if projectInstance.Digest.Artifact == "" {
 		digest = gProject.Status.Revision.Digest
+		tag = gProject.Status.Revision.Tag
+		registry = gProject.Status.Revision.Registry
+		sourceRevision = gProject.Status.Revision.SourceRevision
+		buildTime = gProject.Status.Revision.BuildTime
 	} else {
-		digest = string(projectInstance.Digest)
+		digest = projectInstance.Digest.Artifact
+		tag = projectInstance.Digest.Tag
+		registry = projectInstance.Digest.Registry
+		if artifact := ociRemoteLoader.Artifact(); artifact != nil {
+			sourceRevision = artifact.Revision()
+			buildTime = artifact.Created()
+		}
+	}
+
+	deleted, err := deletedManifests(
+		ctx,
+		kubeDynamicClient.DynamicClient(),
+		inventoryInstance,
+		projectInstance.Dag,
+		reconciler.WorkerPoolSize,
+	)
+	if err != nil {
+		log.Error(
+			err,
+			"Unable to detect deleted manifests",
+		)
+		return nil, err
+	}
+	if len(deleted) != 0 {
+		log.Info("Recreating manifests deleted out of band", "components", deleted)
+	}
+
+	var mu sync.Mutex
+	var progressing []string
+	var waiting []string
+	outcomes := make(map[string]component.Outcome, len(componentInstances))
+	componentReconciler.ReportOutcome = func(instance component.Instance, outcome component.Outcome, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		outcomes[instance.GetID()] = outcome
+		switch outcome {
+		case component.OutcomeProgressing:
+			progressing = append(progressing, instance.GetID())
+		case component.OutcomeWaiting:
+			progressing = append(progressing, instance.GetID())
+			waiting = append(waiting, instance.GetID())
+		}
+	}
+
+	changed := make(map[string]struct{})
+	componentReconciler.ReportChange = func(instance component.Instance) {
+		mu.Lock()
+		defer mu.Unlock()
+		changed[instance.GetID()] = struct{}{}
+	}
+
+	var releases []ReleaseStatus
+	componentReconciler.ReportRelease = func(instance component.Instance, release *helm.Release) {
+		mu.Lock()
+		defer mu.Unlock()
+		releases = append(releases, ReleaseStatus{
+			ID:        instance.GetID(),
+			Name:      release.Name,
+			Namespace: release.Namespace,
+			Revision:  release.Version,
+			Notes:     release.Notes,
+		})
+	}
+
+	componentErr := componentReconciler.Reconcile(ctx, componentInstances)
+	slices.Sort(progressing)
+	slices.Sort(waiting)
+	slices.SortFunc(releases, func(a, b ReleaseStatus) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	recreated := slices.DeleteFunc(deleted, func(id string) bool {
+		outcome := outcomes[id]
+		return outcome != component.OutcomeSuccess && outcome != component.OutcomeProgressing && outcome != component.OutcomeWaiting
+	})
+	for _, id := range recreated {
+		changed[id] = struct{}{}
 	}
 
 	return &ReconcileResult{
-		Suspended:      false,
-		Digest:         digest,
-		DownloadError:  projectInstance.LoadError,
-		ComponentError: componentReconciler.Reconcile(ctx, componentInstances),
+		Suspended:             false,
+		Digest:                digest,
+		Tag:                   tag,
+		Registry:              registry,
+		SourceRevision:        sourceRevision,
+		BuildTime:             buildTime,
+		DownloadError:         projectInstance.LoadError,
+		ComponentError:        componentErr,
+		SuspendedComponents:   projectInstance.Suspended,
+		ProgressingComponents: progressing,
+		WaitingComponents:     waiting,
+		RecreatedComponents:   recreated,
+		TagMutated:            tagMutated,
+		ChangedObjects:        len(changed),
+		Releases:              releases,
 	}, nil
 }
+
+// InventoryPath returns the directory of the inventory of the project with the given UID.
+func (reconciler *Reconciler) InventoryPath(projectUID string) string {
+	return filepath.Join(reconciler.InventoryRootDir, reconciler.Shard, projectUID)
+}
+
+// RESTConfig returns the Kubernetes client configuration used to reconcile the given GitOpsProject.
+// It impersonates the configured service account and applies the client rate limits.
+func (reconciler *Reconciler) RESTConfig(gProject gitops.GitOpsProject) *rest.Config {
+	cfg := reconciler.controllerRESTConfig()
+
+	if serviceAccountName := impersonatedServiceAccount(gProject); serviceAccountName != "" {
+		cfg.Impersonate = rest.ImpersonationConfig{
+			UserName: fmt.Sprintf(
+				"system:serviceaccount:%s:%s",
+				gProject.Namespace,
+				serviceAccountName,
+			),
+		}
+		if impersonation := gProject.Spec.Impersonation; impersonation != nil {
+			cfg.Impersonate.Groups = impersonation.Groups
+			cfg.Impersonate.Extra = impersonation.Extra
+		}
+	}
+
+	return cfg
+}
+
+// controllerRESTConfig returns the Kubernetes client configuration of the controller itself with the client rate limits applied.
+func (reconciler *Reconciler) controllerRESTConfig() *rest.Config {
+	cfg := rest.CopyConfig(reconciler.KubeConfig)
+
+	cfg.QPS = reconciler.ClientQPS
+	if cfg.QPS == 0 {
+		cfg.QPS = DefaultClientQPS
+	}
+
+	cfg.Burst = reconciler.ClientBurst
+	if cfg.Burst == 0 {
+		cfg.Burst = DefaultClientBurst
+	}
+
+	return cfg
+}
+
+func impersonatedServiceAccount(gProject gitops.GitOpsProject) string {
+	impersonation := gProject.Spec.Impersonation
+	if impersonation != nil && impersonation.ServiceAccountName != "" {
+		return impersonation.ServiceAccountName
+	}
+	return gProject.Spec.ServiceAccountName
+}
@@ -39,11 +39,14 @@ import (
 	"github.com/kharf/navecd/pkg/project"
 	"gotest.tools/v3/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 )
 
 type broadProjectTemplate struct {
@@ -75,7 +78,7 @@ module: "github.com/kharf/navecd/internal/projecttest/broad@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -587,7 +590,7 @@ module: "github.com/kharf/navecd/internal/projecttest/mini@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -617,7 +620,7 @@ module: "github.com/kharf/navecd/internal/projecttest/mini@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -827,6 +830,295 @@ func TestReconciler_Reconcile_Impersonation(t *testing.T) {
 	assert.Assert(t, inventoryStorage.HasItem(nsManifest))
 }
 
+func TestReconciler_Reconcile_ImpersonationGroups(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(
+		t,
+	)
+	defer env.Close()
+
+	repository := env.PushProject(t, "test", "latest", []byte(useMiniTemplate()))
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	projectManager := project.NewManager(component.NewBuilder(), -1)
+
+	reconciler := project.Reconciler{
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		ComponentBuilder:      component.NewBuilder(),
+		ProjectManager:        projectManager,
+		Log:                   env.Log,
+		FieldManager:          "controller",
+		WorkerPoolSize:        -1,
+		InsecureSkipTLSverify: true,
+		CacheDir:              env.TestRoot,
+		InventoryRootDir:      filepath.Join(env.TestRoot, "inventory"),
+	}
+
+	suspend := false
+	gProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "gitops.navecd.io/v1",
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant",
+			UID:       types.UID("12345"),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 repository.Name,
+			Ref:                 repository.Ref,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+			Impersonation: &gitops.GitOpsProjectImpersonation{
+				ServiceAccountName: "mysa",
+			},
+		},
+	}
+
+	role := rbacv1.ClusterRole{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "ClusterRole",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name: "deployers",
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:     []string{"*"},
+				Resources: []string{"*"},
+				APIGroups: []string{"*"},
+			},
+		},
+	}
+
+	err = kubernetes.TestKubeClient.Create(ctx, &role)
+	assert.NilError(t, err)
+
+	roleBinding := rbacv1.ClusterRoleBinding{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "ClusterRoleBinding",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name: "deployers",
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:     "Group",
+				Name:     "navecd:deployers",
+				APIGroup: "rbac.authorization.k8s.io",
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     "deployers",
+		},
+	}
+
+	err = kubernetes.TestKubeClient.Create(ctx, &roleBinding)
+	assert.NilError(t, err)
+
+	// the service account itself is not allowed to do anything
+	result, err := reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.DownloadError)
+	assert.ErrorContains(
+		t,
+		result.ComponentError,
+		`is forbidden: User "system:serviceaccount:tenant:mysa" cannot get resource`,
+	)
+
+	gProject.Spec.Impersonation.Groups = []string{"navecd:deployers"}
+	gProject.Spec.Impersonation.Extra = map[string][]string{
+		"scopes": {"deploy"},
+	}
+	result, err = reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.DownloadError)
+	assert.NilError(t, result.ComponentError)
+
+	nsName := "toola"
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: nsName},
+		&ns,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, ns.Name, nsName)
+}
+
+func TestReconciler_Reconcile_ProvisionRBAC(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(
+		t,
+	)
+	defer env.Close()
+
+	repository := env.PushProject(t, "test", "latest", []byte(fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/projecttest/mini@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/toola/namespace.cue --
+package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "toola"
+	}
+}
+
+config: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "config"
+			namespace: "tenant"
+		}
+		data: key: "value"
+	}
+}
+`, testtemplates.ModuleVersion)))
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	projectManager := project.NewManager(component.NewBuilder(), -1)
+
+	err = kubernetes.TestKubeClient.Create(ctx, &corev1.Namespace{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "tenant",
+		},
+	})
+	assert.NilError(t, err)
+
+	reconciler := project.Reconciler{
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		ComponentBuilder:      component.NewBuilder(),
+		ProjectManager:        projectManager,
+		Log:                   env.Log,
+		FieldManager:          "controller",
+		WorkerPoolSize:        -1,
+		InsecureSkipTLSverify: true,
+		CacheDir:              env.TestRoot,
+		InventoryRootDir:      filepath.Join(env.TestRoot, "inventory"),
+	}
+
+	suspend := false
+	gProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "gitops.navecd.io/v1",
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant",
+			UID:       types.UID("12345"),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			ServiceAccountName:  "mysa",
+			URL:                 repository.Name,
+			Ref:                 repository.Ref,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+		},
+	}
+
+	// without provisioning the service account is not allowed to do anything
+	result, err := reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.ErrorContains(
+		t,
+		result.ComponentError,
+		`is forbidden: User "system:serviceaccount:tenant:mysa" cannot get resource`,
+	)
+
+	reconciler.ProvisionRBAC = true
+	result, err = reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.DownloadError)
+	assert.NilError(t, result.ComponentError)
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: "toola"}, &ns)
+	assert.NilError(t, err)
+
+	var configMap corev1.ConfigMap
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "config", Namespace: "tenant"},
+		&configMap,
+	)
+	assert.NilError(t, err)
+
+	var clusterRole rbacv1.ClusterRole
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: "navecd:tenant:test"}, &clusterRole)
+	assert.NilError(t, err)
+	assert.Equal(t, clusterRole.Labels[project.ProvisionedRBACLabel], "12345")
+	assert.DeepEqual(t, clusterRole.Rules, []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"namespaces"},
+			Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
+		},
+	})
+
+	var role rbacv1.Role
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "navecd:tenant:test", Namespace: "tenant"},
+		&role,
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, role.Rules, []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
+		},
+	})
+
+	var roleBinding rbacv1.RoleBinding
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "navecd:tenant:test", Namespace: "tenant"},
+		&roleBinding,
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, roleBinding.Subjects, []rbacv1.Subject{
+		{
+			Kind:      "ServiceAccount",
+			Name:      "mysa",
+			Namespace: "tenant",
+		},
+	})
+}
+
 func TestReconciler_Reconcile_ComponentError(t *testing.T) {
 	ctx := context.Background()
 	dnsServer, err := dnstest.NewDNSServer()
@@ -903,7 +1195,7 @@ module: "github.com/kharf/navecd/internal/projecttest/mini@v0"
 language: version: "%s"
 deps: {
 	"github.com/kharf/navecd/schema@v0": {
-		v: "v0.0.99"
+		v: "v0.26.99"
 	}
 }
 
@@ -1109,28 +1401,248 @@ func TestReconciler_Reconcile_WorkloadIdentity(t *testing.T) {
 		&dep,
 	)
 	assert.NilError(t, err)
-	assert.Equal(t, dep.Name, "deployment")
-	assert.Equal(t, dep.Namespace, toolaNs)
+	assert.Equal(t, dep.Name, "deployment")
+	assert.Equal(t, dep.Namespace, toolaNs)
+
+	var svcAcc corev1.ServiceAccount
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "test", Namespace: toolaNs},
+		&svcAcc,
+	)
+	assert.NilError(t, err)
+
+	inventoryStorage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+
+	invComponents := inventoryStorage.Items()
+	assert.Assert(t, len(invComponents) == 3, "got %v", len(invComponents))
+	testHR := &inventory.HelmReleaseItem{
+		Name:      testDep.Name,
+		Namespace: testDep.Namespace,
+		ID:        fmt.Sprintf("%s_%s_HelmRelease", testDep.Name, testDep.Namespace),
+	}
+	assert.Assert(t, inventoryStorage.HasItem(testHR))
+}
+
+func useUndeclaredNamespaceTemplate(withUndeclared bool) string {
+	undeclared := ""
+	if withUndeclared {
+		undeclared = `
+-- infra/undeclared/configmap.cue --
+package undeclared
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+configMap: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "test"
+			namespace: "undeclared"
+		}
+	}
+}
+`
+	}
+
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/projecttest/undeclared@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/existing/configmap.cue --
+package existing
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+configMap: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: {
+			name:      "test"
+			namespace: "default"
+		}
+	}
+}
+%s`, testtemplates.ModuleVersion, undeclared)
+}
+
+func TestReconciler_Reconcile_CreateNamespaces(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(
+		t,
+	)
+	defer env.Close()
+
+	repository := env.PushProject(t, "test", "latest", []byte(useUndeclaredNamespaceTemplate(true)))
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	projectManager := project.NewManager(component.NewBuilder(), -1)
+
+	reconciler := project.Reconciler{
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		ComponentBuilder:      component.NewBuilder(),
+		ProjectManager:        projectManager,
+		Log:                   env.Log,
+		FieldManager:          "controller",
+		WorkerPoolSize:        -1,
+		InsecureSkipTLSverify: true,
+		CacheDir:              env.TestRoot,
+		InventoryRootDir:      filepath.Join(env.TestRoot, "inventory"),
+	}
+
+	suspend := false
+	gProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "gitops.navecd.io/v1",
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       types.UID("12345"),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 repository.Name,
+			Ref:                 repository.Ref,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+			CreateNamespaces:    true,
+		},
+	}
+
+	result, err := reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.ComponentError)
+
+	var ns corev1.Namespace
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "undeclared"},
+		&ns,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, ns.Labels[project.AutoCreatedLabel], "true")
+
+	var configMap corev1.ConfigMap
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "test", Namespace: "undeclared"},
+		&configMap,
+	)
+	assert.NilError(t, err)
+
+	inventoryInstance := &inventory.Instance{
+		Path: filepath.Join(reconciler.InventoryRootDir, string(gProject.GetUID())),
+	}
+	inventoryStorage, err := inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, inventoryStorage.HasItem(&inventory.ManifestItem{ID: "undeclared___Namespace"}))
+	// pre-existing namespaces are never taken over
+	assert.Assert(t, !inventoryStorage.HasItem(&inventory.ManifestItem{ID: "default___Namespace"}))
+
+	env.PushProject(t, "test", "latest", []byte(useUndeclaredNamespaceTemplate(false)))
+
+	result, err = reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.ComponentError)
+
+	inventoryStorage, err = inventoryInstance.Load()
+	assert.NilError(t, err)
+	assert.Assert(t, !inventoryStorage.HasItem(&inventory.ManifestItem{ID: "undeclared___Namespace"}))
+
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "default"},
+		&ns,
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, ns.DeletionTimestamp == nil)
+}
+
+func TestReconciler_Reconcile_FieldManager(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(
+		t,
+	)
+	defer env.Close()
+
+	repository := env.PushProject(t, "test", "latest", []byte(useUndeclaredNamespaceTemplate(false)))
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	projectManager := project.NewManager(component.NewBuilder(), -1)
+
+	reconciler := project.Reconciler{
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		ComponentBuilder:      component.NewBuilder(),
+		ProjectManager:        projectManager,
+		Log:                   env.Log,
+		FieldManager:          "navecd-secondary",
+		WorkerPoolSize:        -1,
+		InsecureSkipTLSverify: true,
+		CacheDir:              env.TestRoot,
+		InventoryRootDir:      filepath.Join(env.TestRoot, "inventory"),
+	}
+
+	suspend := false
+	gProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "gitops.navecd.io/v1",
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       types.UID("12345"),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 repository.Name,
+			Ref:                 repository.Ref,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+		},
+	}
+
+	result, err := reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.ComponentError)
 
-	var svcAcc corev1.ServiceAccount
+	var configMap corev1.ConfigMap
 	err = kubernetes.TestKubeClient.Get(
 		ctx,
-		types.NamespacedName{Name: "test", Namespace: toolaNs},
-		&svcAcc,
+		types.NamespacedName{Name: "test", Namespace: "default"},
+		&configMap,
 	)
 	assert.NilError(t, err)
 
-	inventoryStorage, err := inventoryInstance.Load()
-	assert.NilError(t, err)
-
-	invComponents := inventoryStorage.Items()
-	assert.Assert(t, len(invComponents) == 3, "got %v", len(invComponents))
-	testHR := &inventory.HelmReleaseItem{
-		Name:      testDep.Name,
-		Namespace: testDep.Namespace,
-		ID:        fmt.Sprintf("%s_%s_HelmRelease", testDep.Name, testDep.Namespace),
+	managers := make([]string, 0, len(configMap.ManagedFields))
+	for _, managedField := range configMap.ManagedFields {
+		managers = append(managers, managedField.Manager)
 	}
-	assert.Assert(t, inventoryStorage.HasItem(testHR))
+	assert.Assert(t, slices.Contains(managers, "navecd-secondary"))
+	assert.Assert(t, !slices.Contains(managers, "controller"))
 }
 
 func TestReconciler_Reconcile_Suspend(t *testing.T) {
@@ -1217,6 +1729,99 @@ func TestReconciler_Reconcile_Suspend(t *testing.T) {
 	assert.Error(t, err, "deployments.apps \"test\" not found")
 }
 
+func TestReconciler_Reconcile_Lease(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(
+		t,
+	)
+	defer env.Close()
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+
+	newReconciler := func(shard string) project.Reconciler {
+		return project.Reconciler{
+			KubeConfig:       kubernetes.ControlPlane.Config,
+			ComponentBuilder: component.NewBuilder(),
+			ProjectManager:   project.NewManager(component.NewBuilder(), -1),
+			Log:              env.Log,
+			FieldManager:     "controller",
+			WorkerPoolSize:   -1,
+			CacheDir:         env.TestRoot,
+			InventoryRootDir: filepath.Join(env.TestRoot, shard),
+			Shard:            shard,
+			Namespace:        "test",
+			LeaseIdentity:    shard,
+		}
+	}
+
+	suspend := true
+	gProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "gitops.navecd.io/v1",
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       types.UID("12345"),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 "oci://localhost/test",
+			Ref:                 "latest",
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+		},
+	}
+
+	// the first shard holds the lease until the second one contended for it.
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	first := newReconciler("primary")
+	first.PreReconcile = func(ctx context.Context, gProject gitops.GitOpsProject) error {
+		close(started)
+		<-proceed
+		return nil
+	}
+	second := newReconciler("secondary")
+
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := first.Reconcile(ctx, gProject)
+		firstErr <- err
+	}()
+	<-started
+
+	_, err = second.Reconcile(ctx, gProject)
+	assert.ErrorIs(t, err, project.ErrLeaseHeld)
+	assert.ErrorIs(t, second.DeleteLease(ctx, gProject.GetUID()), project.ErrLeaseHeld)
+
+	close(proceed)
+	assert.NilError(t, <-firstErr)
+
+	// the lease is released on completion.
+	result, err := second.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Suspended, true)
+
+	// reconciliations with a held lease do not contend for it again.
+	leaseCtx, release, err := first.HoldLease(ctx, gProject.GetUID())
+	assert.NilError(t, err)
+	result, err = first.Reconcile(leaseCtx, gProject)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Suspended, true)
+	release()
+
+	assert.NilError(t, first.DeleteLease(ctx, gProject.GetUID()))
+	var lease coordinationv1.Lease
+	err = kubernetes.TestKubeClient.Get(ctx, types.NamespacedName{Name: "navecd-project-12345", Namespace: "test"}, &lease)
+	assert.Assert(t, k8sErrors.IsNotFound(err))
+}
+
 func TestReconciler_Reconcile_Conflict(t *testing.T) {
 	ctx := context.Background()
 	var err error
@@ -1604,3 +2209,272 @@ func TestReconciler_Reconcile_Stage(t *testing.T) {
 	}
 	assert.Assert(t, inventoryStorage.HasItem(nsManifest))
 }
+
+func useDeploymentTemplate() string {
+	return fmt.Sprintf(`
+-- cue.mod/module.cue --
+module: "github.com/kharf/navecd/internal/projecttest/deployment@v0"
+language: version: "%s"
+deps: {
+	"github.com/kharf/navecd/schema@v0": {
+		v: "v0.26.99"
+	}
+}
+
+-- infra/toola/components.cue --
+package toola
+
+import (
+	"github.com/kharf/navecd/schema/component"
+)
+
+ns: component.#Manifest & {
+	content: {
+		apiVersion: "v1"
+		kind:       "Namespace"
+		metadata: name: "toola"
+	}
+}
+
+deployment: component.#Manifest & {
+	dependencies: [ns.id]
+	content: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+		metadata: {
+			name:      "toola"
+			namespace: ns.content.metadata.name
+		}
+		spec: {
+			replicas: 1
+			selector: matchLabels: app: "toola"
+			template: {
+				metadata: labels: app: "toola"
+				spec: containers: [{
+					name:  "toola"
+					image: "toola:1.0.0"
+				}]
+			}
+		}
+	}
+}
+`, testtemplates.ModuleVersion)
+}
+
+func TestReconciler_Reconcile_OutOfBandDeletion(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(
+		t,
+	)
+	defer env.Close()
+
+	repository := env.PushProject(t, "test", "latest", []byte(useDeploymentTemplate()))
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	projectManager := project.NewManager(component.NewBuilder(), -1)
+
+	reconciler := project.Reconciler{
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		ComponentBuilder:      component.NewBuilder(),
+		ProjectManager:        projectManager,
+		Log:                   env.Log,
+		FieldManager:          "controller",
+		WorkerPoolSize:        -1,
+		InsecureSkipTLSverify: true,
+		CacheDir:              env.TestRoot,
+		InventoryRootDir:      filepath.Join(env.TestRoot, "inventory"),
+	}
+
+	suspend := false
+	gProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "gitops.navecd.io/v1",
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       types.UID("12345"),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 repository.Name,
+			Ref:                 repository.Ref,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+		},
+	}
+
+	result, err := reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.ComponentError)
+	assert.Equal(t, len(result.RecreatedComponents), 0)
+
+	var deployment appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "toola", Namespace: "toola"},
+		&deployment,
+	)
+	assert.NilError(t, err)
+
+	err = kubernetes.TestKubeClient.Delete(ctx, &deployment)
+	assert.NilError(t, err)
+
+	result, err = reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.NilError(t, result.ComponentError)
+	assert.DeepEqual(t, result.RecreatedComponents, []string{"toola_toola_apps_Deployment"})
+
+	var recreated appsv1.Deployment
+	err = kubernetes.TestKubeClient.Get(
+		ctx,
+		types.NamespacedName{Name: "toola", Namespace: "toola"},
+		&recreated,
+	)
+	assert.NilError(t, err)
+	assert.Assert(t, recreated.UID != deployment.UID)
+
+	result, err = reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.RecreatedComponents), 0)
+}
+
+func TestReconciler_Reconcile_TagMutated(t *testing.T) {
+	ctx := context.Background()
+	dnsServer, err := dnstest.NewDNSServer()
+	assert.NilError(t, err)
+	defer dnsServer.Close()
+
+	env := projecttest.InitTestEnvironment(
+		t,
+	)
+	defer env.Close()
+
+	repository := env.PushProject(t, "test", "latest", []byte(useDeploymentTemplate()))
+
+	kubernetes := kubetest.StartKubetestEnv(t, env.Log, kubetest.WithEnabled(true))
+	defer kubernetes.Stop()
+	projectManager := project.NewManager(component.NewBuilder(), -1)
+
+	reconciler := project.Reconciler{
+		KubeConfig:            kubernetes.ControlPlane.Config,
+		ComponentBuilder:      component.NewBuilder(),
+		ProjectManager:        projectManager,
+		Log:                   env.Log,
+		FieldManager:          "controller",
+		WorkerPoolSize:        -1,
+		InsecureSkipTLSverify: true,
+		CacheDir:              env.TestRoot,
+		InventoryRootDir:      filepath.Join(env.TestRoot, "inventory"),
+	}
+
+	suspend := false
+	gProject := gitops.GitOpsProject{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "gitops.navecd.io/v1",
+			Kind:       "GitOpsProject",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       types.UID("12345"),
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			URL:                 repository.Name,
+			Ref:                 repository.Ref,
+			PullIntervalSeconds: 5,
+			Suspend:             &suspend,
+		},
+	}
+
+	result, err := reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Tag, "latest")
+	assert.Assert(t, !result.TagMutated)
+	gProject.Status.Revision = gitops.GitOpsProjectRevision{
+		Digest: result.Digest,
+		Tag:    result.Tag,
+	}
+
+	result, err = reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.Assert(t, !result.TagMutated)
+
+	env.PushProject(t, "test", "latest", []byte(useDeploymentTemplate()+`
+-- README.md --
+mutated
+`))
+
+	result, err = reconciler.Reconcile(ctx, gProject)
+	assert.NilError(t, err)
+	assert.Assert(t, result.TagMutated)
+	assert.Assert(t, result.Digest != gProject.Status.Revision.Digest)
+
+	gProject.Spec.RequireImmutableTags = true
+	_, err = reconciler.Reconcile(ctx, gProject)
+	assert.ErrorIs(t, err, project.ErrTagMutated)
+}
+
+func TestReconciler_RESTConfig(t *testing.T) {
+	suspend := false
+	gProject := gitops.GitOpsProject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant",
+		},
+		Spec: gitops.GitOpsProjectSpec{
+			Suspend: &suspend,
+			Impersonation: &gitops.GitOpsProjectImpersonation{
+				ServiceAccountName: "mysa",
+				Groups:             []string{"navecd:deployers"},
+			},
+		},
+	}
+
+	kubeConfig := &rest.Config{
+		Host: "https://localhost:6443",
+	}
+
+	reconciler := project.Reconciler{
+		KubeConfig: kubeConfig,
+	}
+
+	cfg := reconciler.RESTConfig(gProject)
+	assert.Equal(t, cfg.QPS, project.DefaultClientQPS)
+	assert.Equal(t, cfg.Burst, project.DefaultClientBurst)
+	assert.Equal(t, cfg.Impersonate.UserName, "system:serviceaccount:tenant:mysa")
+	assert.DeepEqual(t, cfg.Impersonate.Groups, []string{"navecd:deployers"})
+
+	reconciler.ClientQPS = 200
+	reconciler.ClientBurst = 400
+	cfg = reconciler.RESTConfig(gProject)
+	assert.Equal(t, cfg.QPS, float32(200))
+	assert.Equal(t, cfg.Burst, 400)
+
+	// the shared config must not be mutated
+	assert.Equal(t, kubeConfig.QPS, float32(0))
+	assert.Equal(t, kubeConfig.Impersonate.UserName, "")
+}
+
+func TestReconciler_InventoryPath(t *testing.T) {
+	inventoryRootDir := t.TempDir()
+	primary := project.Reconciler{
+		InventoryRootDir: inventoryRootDir,
+		Shard:            "primary",
+	}
+	secondary := project.Reconciler{
+		InventoryRootDir: inventoryRootDir,
+		Shard:            "secondary",
+	}
+
+	primaryPath := primary.InventoryPath("12345")
+	secondaryPath := secondary.InventoryPath("12345")
+	assert.Assert(t, primaryPath != secondaryPath)
+	assert.Equal(t, primaryPath, filepath.Join(inventoryRootDir, "primary", "12345"))
+	assert.Equal(t, secondaryPath, filepath.Join(inventoryRootDir, "secondary", "12345"))
+}
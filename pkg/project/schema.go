@@ -0,0 +1,126 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// SchemaModule is the cue module path of the navecd schema without its major version suffix.
+	SchemaModule = "github.com/kharf/navecd/schema"
+
+	// SchemaMajorVersion is the major version of the navecd schema supported by this navecd version.
+	SchemaMajorVersion = "v0"
+
+	// MinSchemaVersion is the oldest navecd schema version supported by this navecd version.
+	MinSchemaVersion = "v0.26.0"
+)
+
+var (
+	ErrIncompatibleSchemaVersion = errors.New("Incompatible navecd schema version")
+)
+
+// SchemaVersionRange is a range of navecd schema versions within a single major version.
+type SchemaVersionRange struct {
+	// Min is the oldest supported version.
+	Min string
+
+	// Max is the newest supported version. All versions newer than Min are supported, if empty.
+	Max string
+}
+
+// Contains reports whether version is within the range.
+func (versionRange SchemaVersionRange) Contains(version string) bool {
+	if semver.Compare(version, versionRange.Min) < 0 {
+		return false
+	}
+	return versionRange.Max == "" || semver.Compare(version, versionRange.Max) <= 0
+}
+
+func (versionRange SchemaVersionRange) String() string {
+	if versionRange.Max == "" {
+		return ">= " + versionRange.Min
+	}
+	return versionRange.Min + " - " + versionRange.Max
+}
+
+// SupportedSchemaVersions is the compatibility matrix of this navecd version.
+// It maps major versions of the navecd schema to the range of versions projects may pin.
+// Every project resolves its pinned schema version from the CUE registry,
+// so that projects pinned to different supported versions are reconciled side by side, e.g. during a rolling upgrade.
+var SupportedSchemaVersions = map[string]SchemaVersionRange{
+	SchemaMajorVersion: {Min: MinSchemaVersion},
+}
+
+// checkSchemaVersion reads the navecd schema dependency of the cue module located at projectPath
+// and errors, if it is not supported by this navecd version according to [SupportedSchemaVersions].
+// It returns the pinned version.
+// Projects without a module file or without a schema dependency are not checked.
+func checkSchemaVersion(projectPath string) (string, error) {
+	moduleFilePath := filepath.Join(projectPath, "cue.mod", "module.cue")
+	content, err := os.ReadFile(moduleFilePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	moduleFile, err := modfile.Parse(content, moduleFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	for modulePath, dep := range moduleFile.Deps {
+		path, major, found := strings.Cut(modulePath, "@")
+		if path != SchemaModule || !found {
+			continue
+		}
+
+		versionRange, supported := SupportedSchemaVersions[major]
+		if !supported {
+			versionRange = SupportedSchemaVersions[SchemaMajorVersion]
+			major = SchemaMajorVersion
+		}
+
+		if !supported ||
+			!semver.IsValid(dep.Version) ||
+			semver.Major(dep.Version) != major ||
+			!versionRange.Contains(dep.Version) {
+			return "", fmt.Errorf(
+				"%w: project depends on %s %s, but only versions %s of major version %s are supported. Please pin %s to a compatible version",
+				ErrIncompatibleSchemaVersion,
+				modulePath,
+				dep.Version,
+				versionRange,
+				major,
+				SchemaModule+"@"+major,
+			)
+		}
+
+		return dep.Version, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 kharf
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"slices"
+
+	"github.com/kharf/navecd/pkg/component"
+	"github.com/kharf/navecd/pkg/helm"
+)
+
+// applyWaves orders the components of the dependency graph by their sync waves.
+// Every component depends on all components of the previous wave,
+// so that a wave is only applied, once the previous wave has been fully applied.
+// The order within a wave is still determined by the declared dependencies.
+// Components without a declared wave belong to wave 0.
+func applyWaves(dag *component.DependencyGraph, waves map[string]int) error {
+	if len(waves) == 0 {
+		return nil
+	}
+
+	instances, err := dag.TopologicalSort()
+	if err != nil {
+		return err
+	}
+
+	instancesByWave := make(map[int][]component.Instance)
+	for _, instance := range instances {
+		wave := waves[instance.GetID()]
+		instancesByWave[wave] = append(instancesByWave[wave], instance)
+	}
+
+	waveNumbers := make([]int, 0, len(instancesByWave))
+	for wave := range instancesByWave {
+		waveNumbers = append(waveNumbers, wave)
+	}
+	slices.Sort(waveNumbers)
+
+	for i := 1; i < len(waveNumbers); i++ {
+		previousWave := instancesByWave[waveNumbers[i-1]]
+		for _, instance := range instancesByWave[waveNumbers[i]] {
+			deps := dependencies(instance)
+			if deps == nil {
+				continue
+			}
+
+			for _, previous := range previousWave {
+				if !slices.Contains(*deps, previous.GetID()) {
+					*deps = append(*deps, previous.GetID())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// dependencies returns a reference to the dependencies of the given component,
+// which allows to add implicit dependencies.
+func dependencies(instance component.Instance) *[]string {
+	switch componentInstance := instance.(type) {
+	case *component.Manifest:
+		return &componentInstance.Dependencies
+	case *component.Patch:
+		return &componentInstance.Dependencies
+	case *helm.ReleaseComponent:
+		return &componentInstance.Dependencies
+	}
+	return nil
+}
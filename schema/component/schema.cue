@@ -28,6 +28,37 @@ _manifestMetadata: {
 	}
 }
 
+// RawManifest embeds existing Kubernetes Objects verbatim as a YAML or JSON string.
+// Multiple Objects are separated by YAML document separators.
+// Every Object becomes a Manifest with an id derived from the Object like the id of #Manifest,
+// which other components can depend on. Build attributes apply to all Objects.
+#RawManifest: {
+	type: "Manifest"
+
+	// Id only names the raw manifest in errors.
+	id!: string & strings.MinRunes(1)
+	dependencies: [...string]
+	content!: string & strings.MinRunes(1)
+}
+
+// Patch represents a partial Kubernetes Object, which is merged into an existing object in the cluster.
+// It allows to modify objects, which are created by other controllers or operators.
+// Navecd only owns the declared fields and releases them, when the patch is removed.
+#Patch: {
+	type:          "Patch"
+	_groupVersion: strings.Split(content.apiVersion, "/")
+	_group:        string | *""
+	if len(_groupVersion) >= 2 {
+		_group: _groupVersion[0]
+	}
+	id: "\(content.metadata.name)_\(*content.metadata.namespace | "")_\(_group)_\(content.kind)_\(type)"
+	dependencies: [...string]
+	content: {
+		_manifestMetadata
+		...
+	}
+}
+
 // HelmRelease is a running instance of a Chart and the current state in a Kubernetes Cluster.
 #HelmRelease: {
 	type: "HelmRelease"
@@ -56,6 +87,10 @@ _manifestMetadata: {
 	}]
 
 	crds: #CRDs
+
+	// CreateNamespace lets Helm create the namespace of the release, if it does not exist.
+	// When disabled, the namespace has to exist before the release is installed.
+	createNamespace: bool | *false
 }
 
 // Helm CRD handling configuration.